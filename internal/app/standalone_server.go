@@ -6,9 +6,12 @@ import (
 	"GoLoad/internal/handler/grpc"
 	"GoLoad/internal/handler/http"
 	"GoLoad/internal/handler/jobs"
+	"GoLoad/internal/logic"
 	"GoLoad/internal/utils"
 	"context"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"go.uber.org/zap"
@@ -20,7 +23,13 @@ type StandaloneServer struct {
 	rootConsumer                                             consumers.Root
 	executeAllPendingDownloadTaskJob                         jobs.ExecuteAllPendingDownloadTask
 	updateDownloadingAndFailedDownloadTaskStatusToPendingJob jobs.UpdateDownloadingAndFailedDownloadTaskStatusToPending
+	deleteExpiredDownloadTaskJob                             jobs.DeleteExpiredDownloadTask
+	updateDownloadTaskCountMetricsJob                        jobs.UpdateDownloadTaskCountMetrics
+	rotateTokenSigningKeyJob                                 jobs.RotateTokenSigningKey
 	cronConfig                                               configs.Cron
+	metricsConfig                                            configs.Metrics
+	healthConfig                                             configs.Health
+	healthLogic                                              logic.Health
 	logger                                                   *zap.Logger
 }
 
@@ -30,7 +39,13 @@ func NewStandaloneServer(
 	rootConsumer consumers.Root,
 	executeAllPendingDownloadTaskJob jobs.ExecuteAllPendingDownloadTask,
 	updateDownloadingAndFailedDownloadTaskStatusToPendingJob jobs.UpdateDownloadingAndFailedDownloadTaskStatusToPending,
+	deleteExpiredDownloadTaskJob jobs.DeleteExpiredDownloadTask,
+	updateDownloadTaskCountMetricsJob jobs.UpdateDownloadTaskCountMetrics,
+	rotateTokenSigningKeyJob jobs.RotateTokenSigningKey,
 	cronConfig configs.Cron,
+	metricsConfig configs.Metrics,
+	healthConfig configs.Health,
+	healthLogic logic.Health,
 	logger *zap.Logger,
 ) *StandaloneServer {
 	return &StandaloneServer{
@@ -39,15 +54,26 @@ func NewStandaloneServer(
 		rootConsumer:                     rootConsumer,
 		executeAllPendingDownloadTaskJob: executeAllPendingDownloadTaskJob,
 		updateDownloadingAndFailedDownloadTaskStatusToPendingJob: updateDownloadingAndFailedDownloadTaskStatusToPendingJob,
-		cronConfig: cronConfig,
-		logger:     logger,
+		deleteExpiredDownloadTaskJob:                             deleteExpiredDownloadTaskJob,
+		updateDownloadTaskCountMetricsJob:                        updateDownloadTaskCountMetricsJob,
+		rotateTokenSigningKeyJob:                                 rotateTokenSigningKeyJob,
+		cronConfig:                                               cronConfig,
+		metricsConfig:                                            metricsConfig,
+		healthConfig:                                             healthConfig,
+		healthLogic:                                              healthLogic,
+		logger:                                                   logger,
 	}
 }
+
+// newJobContext gives every job run its own request id, so that the logs of a run can be told apart.
+func newJobContext() context.Context {
+	return utils.ContextWithRequestID(context.Background(), utils.NewRequestID())
+}
 func (s StandaloneServer) scheduleCronJobs(scheduler gocron.Scheduler) error {
 	if _, err := scheduler.NewJob(
 		gocron.CronJob(s.cronConfig.ExecuteAllPendingDownloadTask.Schedule, true),
 		gocron.NewTask(func() {
-			if err := s.executeAllPendingDownloadTaskJob.Run(context.Background()); err != nil {
+			if err := s.executeAllPendingDownloadTaskJob.Run(newJobContext()); err != nil {
 				s.logger.With(zap.Error(err)).Error("failed to run execute all pending download task job")
 			}
 		}),
@@ -58,7 +84,7 @@ func (s StandaloneServer) scheduleCronJobs(scheduler gocron.Scheduler) error {
 	if _, err := scheduler.NewJob(
 		gocron.CronJob(s.cronConfig.UpdateDownloadingAndFailedDownloadTaskStatusToPending.Schedule, true),
 		gocron.NewTask(func() {
-			if err := s.executeAllPendingDownloadTaskJob.Run(context.Background()); err != nil {
+			if err := s.updateDownloadingAndFailedDownloadTaskStatusToPendingJob.Run(newJobContext()); err != nil {
 				s.logger.With(zap.Error(err)).
 					Error("failed to run update downloading and failed download task status to pending job")
 			}
@@ -68,10 +94,63 @@ func (s StandaloneServer) scheduleCronJobs(scheduler gocron.Scheduler) error {
 			Error("failed to schedule update downloading and failed download task status to pending job")
 		return err
 	}
+	if _, err := scheduler.NewJob(
+		gocron.CronJob(s.cronConfig.DeleteExpiredDownloadTask.Schedule, true),
+		gocron.NewTask(func() {
+			if err := s.deleteExpiredDownloadTaskJob.Run(newJobContext()); err != nil {
+				s.logger.With(zap.Error(err)).Error("failed to run delete expired download task job")
+			}
+		}),
+	); err != nil {
+		s.logger.With(zap.Error(err)).Error("failed to schedule delete expired download task job")
+		return err
+	}
+	if _, err := scheduler.NewJob(
+		gocron.CronJob(s.cronConfig.RotateTokenSigningKey.Schedule, true),
+		gocron.NewTask(func() {
+			if err := s.rotateTokenSigningKeyJob.Run(newJobContext()); err != nil {
+				s.logger.With(zap.Error(err)).Error("failed to run rotate token signing key job")
+			}
+		}),
+	); err != nil {
+		s.logger.With(zap.Error(err)).Error("failed to schedule rotate token signing key job")
+		return err
+	}
+	if s.metricsConfig.Enabled {
+		if _, err := scheduler.NewJob(
+			gocron.CronJob(s.metricsConfig.UpdateDownloadTaskCountSchedule, true),
+			gocron.NewTask(func() {
+				if err := s.updateDownloadTaskCountMetricsJob.Run(newJobContext()); err != nil {
+					s.logger.With(zap.Error(err)).Error("failed to run update download task count metrics job")
+				}
+			}),
+		); err != nil {
+			s.logger.With(zap.Error(err)).Error("failed to schedule update download task count metrics job")
+			return err
+		}
+	}
 	return nil
 }
+
+// shutdown reports the server as not ready, waits for the configured delay so that load balancers stop sending it
+// new requests, then stops the servers and waits for their in-flight requests to finish.
+func (s StandaloneServer) shutdown(shutdownDelay time.Duration, cancel context.CancelFunc, serverWaitGroup *sync.WaitGroup) {
+	s.logger.With(zap.Duration("shutdown_delay", shutdownDelay)).Info("shutting down")
+	s.healthLogic.StartShutdown()
+	time.Sleep(shutdownDelay)
+	cancel()
+	serverWaitGroup.Wait()
+}
 func (s StandaloneServer) Start() error {
-	if err := s.updateDownloadingAndFailedDownloadTaskStatusToPendingJob.Run(context.Background()); err != nil {
+	shutdownDelay, err := s.healthConfig.GetShutdownDelayDuration()
+	if err != nil {
+		s.logger.With(zap.Error(err)).Error("failed to parse shutdown delay")
+		return err
+	}
+	if err := s.updateDownloadingAndFailedDownloadTaskStatusToPendingJob.Run(newJobContext()); err != nil {
+		return err
+	}
+	if err := s.rotateTokenSigningKeyJob.Run(newJobContext()); err != nil {
 		return err
 	}
 	scheduler, err := gocron.NewScheduler()
@@ -88,12 +167,18 @@ func (s StandaloneServer) Start() error {
 	if err != nil {
 		return err
 	}
+	serverCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverWaitGroup := sync.WaitGroup{}
+	serverWaitGroup.Add(2)
 	go func() {
-		grpcStartErr := s.grpcServer.Start(context.Background())
+		defer serverWaitGroup.Done()
+		grpcStartErr := s.grpcServer.Start(serverCtx)
 		s.logger.With(zap.Error(grpcStartErr)).Info("grpc server stopped")
 	}()
 	go func() {
-		httpStartErr := s.httpServer.Start(context.Background())
+		defer serverWaitGroup.Done()
+		httpStartErr := s.httpServer.Start(serverCtx)
 		s.logger.With(zap.Error(httpStartErr)).Info("http server stopped")
 	}()
 	go func() {
@@ -102,5 +187,6 @@ func (s StandaloneServer) Start() error {
 	}()
 
 	utils.BlockUntilSignal(syscall.SIGINT, syscall.SIGTERM)
+	s.shutdown(shutdownDelay, cancel, &serverWaitGroup)
 	return nil
 }
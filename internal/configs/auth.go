@@ -2,12 +2,45 @@ package configs
 
 import "time"
 
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+// Argon2id are the parameters of argon2id hashes. Memory is in KiB.
+type Argon2id struct {
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"salt_length"`
+	KeyLength   uint32 `yaml:"key_length"`
+}
+
+// Hash configures how new passwords are hashed. Hashes made with another algorithm or other parameters are still
+// checked, and replaced on the next successful login.
 type Hash struct {
-	Cost int `yaml:"cost"`
+	// Algorithm is one of argon2id and bcrypt.
+	Algorithm string `yaml:"algorithm"`
+	// Cost is the cost of bcrypt hashes.
+	Cost     int      `yaml:"cost"`
+	Argon2id Argon2id `yaml:"argon2id"`
 }
 type Token struct {
 	ExpiresIn                   string `yaml:"expires_in"`
 	RegenerateTokenBeforeExpiry string `yaml:"regenerate_token_before_expiry"`
+	// Algorithm is the signing algorithm of new signing keys, one of RS256, RS512, ES256 and EdDSA.
+	Algorithm string `yaml:"algorithm"`
+	// SigningKeyEncryptionKey is the base64 encoded 32 byte key the private signing keys are encrypted with in the
+	// database. Replicas must share it to share signing keys.
+	SigningKeyEncryptionKey string `yaml:"signing_key_encryption_key"`
+	// GenerateSigningKeyEncryptionKey generates a random encryption key on startup if SigningKeyEncryptionKey is not
+	// set, for local development only: signing keys are then lost on restart and not shared across replicas.
+	GenerateSigningKeyEncryptionKey bool `yaml:"generate_signing_key_encryption_key"`
+	// RotationInterval is how long a signing key is used to sign new tokens.
+	RotationInterval string `yaml:"rotation_interval"`
+	// RotationOverlap is how long before it starts signing a new signing key is published, so that verifiers caching
+	// the JWKS have time to pick it up.
+	RotationOverlap string `yaml:"rotation_overlap"`
 }
 
 func (t Token) GetExpiresInDuration() (time.Duration, error) {
@@ -16,8 +49,75 @@ func (t Token) GetExpiresInDuration() (time.Duration, error) {
 func (t Token) GetRegenerateTokenBeforeExpiryDuration() (time.Duration, error) {
 	return time.ParseDuration(t.RegenerateTokenBeforeExpiry)
 }
+func (t Token) GetRotationIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(t.RotationInterval)
+}
+func (t Token) GetRotationOverlapDuration() (time.Duration, error) {
+	return time.ParseDuration(t.RotationOverlap)
+}
+
+// OIDC configures the single sign-on login with an OpenID Connect identity provider.
+type OIDC struct {
+	Enabled      bool   `yaml:"enabled"`
+	IssuerURL    string `yaml:"issuer_url"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL is the URL of the callback endpoint of the HTTP server, as registered with the identity provider.
+	RedirectURL string   `yaml:"redirect_url"`
+	Scopes      []string `yaml:"scopes"`
+	// AllowAccountCreation creates an account on the first login of an identity that is not linked to any account,
+	// named after its verified email.
+	AllowAccountCreation bool `yaml:"allow_account_creation"`
+	// PostLoginRedirectURL is where the browser is sent once logged in.
+	PostLoginRedirectURL string `yaml:"post_login_redirect_url"`
+	// LoginExpiresIn is how long the user has to log in with the identity provider.
+	LoginExpiresIn string `yaml:"login_expires_in"`
+}
+
+func (o OIDC) GetLoginExpiresInDuration() (time.Duration, error) {
+	return time.ParseDuration(o.LoginExpiresIn)
+}
+
+// PasswordPolicy is checked whenever a password is set. It does not apply to existing passwords.
+type PasswordPolicy struct {
+	// MinLength and MaxLength count characters. MaxLength should not exceed the 72 bytes bcrypt can hash.
+	MinLength           int  `yaml:"min_length"`
+	MaxLength           int  `yaml:"max_length"`
+	RequireUppercase    bool `yaml:"require_uppercase"`
+	RequireLowercase    bool `yaml:"require_lowercase"`
+	RequireDigit        bool `yaml:"require_digit"`
+	RequireSymbol       bool `yaml:"require_symbol"`
+	DisallowAccountName bool `yaml:"disallow_account_name"`
+}
+
+// LoginLockout locks out logins after too many failed attempts, for an account name or for a client IP. Every
+// failed attempt past the limit doubles the lockout, up to MaxLockoutDuration.
+type LoginLockout struct {
+	// MaxFailedAttemptsPerAccount and MaxFailedAttemptsPerIP disable their lockout when 0.
+	MaxFailedAttemptsPerAccount uint64 `yaml:"max_failed_attempts_per_account"`
+	MaxFailedAttemptsPerIP      uint64 `yaml:"max_failed_attempts_per_ip"`
+	// FailedAttemptWindow is how long a failed attempt is counted after the last one.
+	FailedAttemptWindow string `yaml:"failed_attempt_window"`
+	LockoutDuration     string `yaml:"lockout_duration"`
+	MaxLockoutDuration  string `yaml:"max_lockout_duration"`
+}
+
+func (l LoginLockout) GetFailedAttemptWindowDuration() (time.Duration, error) {
+	return time.ParseDuration(l.FailedAttemptWindow)
+}
+func (l LoginLockout) GetLockoutDuration() (time.Duration, error) {
+	return time.ParseDuration(l.LockoutDuration)
+}
+func (l LoginLockout) GetMaxLockoutDuration() (time.Duration, error) {
+	return time.ParseDuration(l.MaxLockoutDuration)
+}
 
 type Auth struct {
-	Hash  Hash
-	Token Token
+	Hash           Hash
+	Token          Token
+	OIDC           OIDC           `yaml:"oidc"`
+	PasswordPolicy PasswordPolicy `yaml:"password_policy"`
+	LoginLockout   LoginLockout   `yaml:"login_lockout"`
+	// AdminAccountNameList lists the account names that are given the admin role when they are created.
+	AdminAccountNameList []string `yaml:"admin_account_name_list"`
 }
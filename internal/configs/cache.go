@@ -12,4 +12,5 @@ type Cache struct {
 	Address  string    `yaml:"address"`
 	Username string    `yaml:"username"`
 	Password string    `yaml:"password"`
+	TLS      ClientTLS `yaml:"tls"`
 }
@@ -13,15 +13,19 @@ type ConfigFilePath string
 
 type Config struct {
 	// Log      Log      `yaml:"log"`
-	GRPC     GRPC     `yaml:"grpc"`
-	HTTP     HTTP     `yaml:"http"`
-	Log      Log      `yaml:"log"`
-	Auth     Auth     `yaml:"auth"`
-	Database Database `yaml:"database"`
-	Cache    Cache    `yaml:"cache"`
-	MQ       MQ       `yaml:"mq"`
-	Cron     Cron     `yaml:"cron"`
-	Download Download `yaml:"download"`
+	GRPC      GRPC      `yaml:"grpc"`
+	HTTP      HTTP      `yaml:"http"`
+	Log       Log       `yaml:"log"`
+	Auth      Auth      `yaml:"auth"`
+	Database  Database  `yaml:"database"`
+	Cache     Cache     `yaml:"cache"`
+	MQ        MQ        `yaml:"mq"`
+	Cron      Cron      `yaml:"cron"`
+	Download  Download  `yaml:"download"`
+	ShareLink ShareLink `yaml:"share_link"`
+	Metrics   Metrics   `yaml:"metrics"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Health    Health    `yaml:"health"`
 }
 
 func NewConfig(filePath ConfigFilePath) (Config, error) {
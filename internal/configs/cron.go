@@ -1,5 +1,7 @@
 package configs
 
+import "time"
+
 type ExecuteAllPendingDownloadTask struct {
 	Schedule         string `yaml:"schedule"`
 	ConcurrencyLimit int    `yaml:"concurrency_limit"`
@@ -7,9 +9,25 @@ type ExecuteAllPendingDownloadTask struct {
 type UpdateDownloadingAndFailedDownloadTaskStatusToPending struct {
 	Schedule string `yaml:"schedule"`
 }
+type DeleteExpiredDownloadTask struct {
+	Schedule string `yaml:"schedule"`
+	// RetentionPeriod is how long a download task and its files are kept after the task finishes.
+	RetentionPeriod string `yaml:"retention_period"`
+	BatchSize       uint64 `yaml:"batch_size"`
+}
+
+type RotateTokenSigningKey struct {
+	Schedule string `yaml:"schedule"`
+}
+
+func (d DeleteExpiredDownloadTask) GetRetentionPeriodDuration() (time.Duration, error) {
+	return time.ParseDuration(d.RetentionPeriod)
+}
 
 //nolint:lll // Long field names
 type Cron struct {
 	ExecuteAllPendingDownloadTask                         ExecuteAllPendingDownloadTask                         `yaml:"execute_all_pending_download_task"`
 	UpdateDownloadingAndFailedDownloadTaskStatusToPending UpdateDownloadingAndFailedDownloadTaskStatusToPending `yaml:"update_downloading_and_failed_download_task_status_to_pending"`
+	DeleteExpiredDownloadTask                             DeleteExpiredDownloadTask                             `yaml:"delete_expired_download_task"`
+	RotateTokenSigningKey                                 RotateTokenSigningKey                                 `yaml:"rotate_token_signing_key"`
 }
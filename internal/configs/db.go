@@ -13,4 +13,5 @@ type Database struct {
 	Username string       `yaml:"username"`
 	Password string       `yaml:"password"`
 	Database string       `yaml:"database"`
+	TLS      ClientTLS    `yaml:"tls"`
 }
@@ -1,5 +1,11 @@
 package configs
 
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
 type DownloadMode string
 
 const (
@@ -7,11 +13,163 @@ const (
 	DownloadModeS3    DownloadMode = "s3"
 )
 
+type PostProcessing struct {
+	MaxExtractedSize      string `yaml:"max_extracted_size"`
+	MaxExtractedFileCount int    `yaml:"max_extracted_file_count"`
+	MaxDecompressedSize   string `yaml:"max_decompressed_size"`
+	MaxSignatureSize      string `yaml:"max_signature_size"`
+}
+
+func (p PostProcessing) GetMaxExtractedSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(p.MaxExtractedSize)
+}
+func (p PostProcessing) GetMaxDecompressedSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(p.MaxDecompressedSize)
+}
+func (p PostProcessing) GetMaxSignatureSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(p.MaxSignatureSize)
+}
+
+type Batch struct {
+	MaxDownloadTaskCount int    `yaml:"max_download_task_count"`
+	MaxManifestSize      string `yaml:"max_manifest_size"`
+}
+
+func (b Batch) GetMaxManifestSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(b.MaxManifestSize)
+}
+
+type Metalink struct {
+	MaxMetalinkSize       string `yaml:"max_metalink_size"`
+	MaxConcurrentSegments int    `yaml:"max_concurrent_segments"`
+	MinSegmentSize        string `yaml:"min_segment_size"`
+}
+
+func (m Metalink) GetMaxMetalinkSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(m.MaxMetalinkSize)
+}
+func (m Metalink) GetMinSegmentSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(m.MinSegmentSize)
+}
+
+// URLPolicy decides which urls download tasks can download from. It is checked when a download task is created and
+// again whenever the downloader connects, including on redirects.
+type URLPolicy struct {
+	AllowedSchemes []string `yaml:"allowed_schemes"`
+	// AllowedHosts only allows the urls of these hosts if it is not empty. Hosts are matched by exact name or ip, by
+	// domain suffix if they start with ".", e.g. ".example.com", or by cidr. The same goes for DeniedHosts.
+	AllowedHosts []string `yaml:"allowed_hosts"`
+	DeniedHosts  []string `yaml:"denied_hosts"`
+	// AllowPrivateNetworks allows connecting to the private, loopback, link-local and other non-public addresses
+	// that are blocked by default.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+	// AllowedNetworks are cidrs allowed even if they are not public, e.g. an internal mirror. DeniedNetworks are
+	// blocked in addition to the non-public addresses, and take precedence over AllowedNetworks.
+	AllowedNetworks []string `yaml:"allowed_networks"`
+	DeniedNetworks  []string `yaml:"denied_networks"`
+	// AllowedProxySchemes are the schemes of the proxies download tasks can be downloaded through. Task proxies are
+	// not allowed if it is empty.
+	AllowedProxySchemes []string `yaml:"allowed_proxy_schemes"`
+}
+
+// HTTPClient tunes the client download tasks are downloaded with.
+type HTTPClient struct {
+	ConnectTimeout        string `yaml:"connect_timeout"`
+	TLSHandshakeTimeout   string `yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout string `yaml:"response_header_timeout"`
+	// ReadTimeout fails a download once nothing has been received for this long.
+	ReadTimeout         string `yaml:"read_timeout"`
+	IdleConnTimeout     string `yaml:"idle_conn_timeout"`
+	MaxIdleConnsPerHost int    `yaml:"max_idle_conns_per_host"`
+	MaxRedirects        int    `yaml:"max_redirects"`
+}
+
+func (h HTTPClient) GetConnectTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.ConnectTimeout)
+}
+func (h HTTPClient) GetTLSHandshakeTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.TLSHandshakeTimeout)
+}
+func (h HTTPClient) GetResponseHeaderTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.ResponseHeaderTimeout)
+}
+func (h HTTPClient) GetReadTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.ReadTimeout)
+}
+func (h HTTPClient) GetIdleConnTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.IdleConnTimeout)
+}
+
+type KMSType string
+
+const (
+	KMSTypeLocal KMSType = "local"
+)
+
+// Encryption encrypts the stored files, each with a data key of its own that is wrapped by a master key of the KMS.
+type Encryption struct {
+	Enabled   bool    `yaml:"enabled"`
+	ChunkSize string  `yaml:"chunk_size"`
+	KMS       KMSType `yaml:"kms"`
+	// CurrentMasterKeyID is the master key new data keys are wrapped with. The other master keys are kept to read
+	// the files stored before the master key was rotated, even once Enabled is turned off.
+	CurrentMasterKeyID string `yaml:"current_master_key_id"`
+	// MasterKeys are the base64 encoded 32 byte master keys of the local KMS, by id.
+	MasterKeys map[string]string `yaml:"master_keys"`
+}
+
+func (e Encryption) GetChunkSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(e.ChunkSize)
+}
+
+type MalwareScannerType string
+
+const (
+	MalwareScannerTypeNoop  MalwareScannerType = "noop"
+	MalwareScannerTypeClamd MalwareScannerType = "clamd"
+)
+
+// MalwareScan scans the downloaded files before their download tasks succeed.
+type MalwareScan struct {
+	Scanner MalwareScannerType `yaml:"scanner"`
+	// Network and Address are those of clamd, e.g. "tcp" and "127.0.0.1:3310", or "unix" and
+	// "/run/clamav/clamd.ctl".
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	// Timeout is the time a scan can take, including streaming the file. Files larger than the StreamMaxLength of
+	// clamd cannot be scanned, and fail their download tasks.
+	Timeout string `yaml:"timeout"`
+	// StreamChunkSize is the size of the chunks the file is streamed to clamd in.
+	StreamChunkSize string `yaml:"stream_chunk_size"`
+}
+
+func (m MalwareScan) GetTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(m.Timeout)
+}
+func (m MalwareScan) GetStreamChunkSizeInBytes() (uint64, error) {
+	return humanize.ParseBytes(m.StreamChunkSize)
+}
+
 type Download struct {
-	Mode              DownloadMode `yaml:"mode"`
-	DownloadDirectory string       `yaml:"download_directory"`
-	Bucket            string       `yaml:"bucket"`
-	Address           string       `yaml:"address"`
-	Username          string       `yaml:"username"`
-	Password          string       `yaml:"password"`
+	Mode              DownloadMode   `yaml:"mode"`
+	DownloadDirectory string         `yaml:"download_directory"`
+	Bucket            string         `yaml:"bucket"`
+	Address           string         `yaml:"address"`
+	Username          string         `yaml:"username"`
+	Password          string         `yaml:"password"`
+	TLS               ClientTLS      `yaml:"tls"`
+	PostProcessing    PostProcessing `yaml:"post_processing"`
+	Batch             Batch          `yaml:"batch"`
+	Metalink          Metalink       `yaml:"metalink"`
+	URLPolicy         URLPolicy      `yaml:"url_policy"`
+	HTTPClient        HTTPClient     `yaml:"http_client"`
+	Encryption        Encryption     `yaml:"encryption"`
+	MalwareScan       MalwareScan    `yaml:"malware_scan"`
+	// HTTPRequestOptionsEncryptionKey is the base64 encoded 32 byte key the http request options of download tasks
+	// are encrypted with in the database. Replicas must share it to execute each other's download tasks.
+	HTTPRequestOptionsEncryptionKey string `yaml:"http_request_options_encryption_key"`
+	// GenerateHTTPRequestOptionsEncryptionKey generates a random encryption key on startup if
+	// HTTPRequestOptionsEncryptionKey is not set, for local development only: the http request options of download
+	// tasks then cannot be decrypted after a restart or by other replicas.
+	GenerateHTTPRequestOptionsEncryptionKey bool `yaml:"generate_http_request_options_encryption_key"`
 }
@@ -1,6 +1,8 @@
 package configs
 
 import (
+	"time"
+
 	"github.com/dustin/go-humanize"
 )
 
@@ -15,4 +17,26 @@ func (g GetDownloadTaskFile) GetResponseBufferSizeInBytes() (uint64, error) {
 type GRPC struct {
 	Address             string              `yaml:"address"`
 	GetDownloadTaskFile GetDownloadTaskFile `yaml:"get_download_task_file"`
+	RateLimit           RateLimit           `yaml:"rate_limit"`
+	TLS                 TLS                 `yaml:"tls"`
+}
+
+// RateLimitRule allows Limit requests per Window. A zero Limit does not limit.
+type RateLimitRule struct {
+	Limit  uint64 `yaml:"limit"`
+	Window string `yaml:"window"`
+}
+
+func (r RateLimitRule) GetWindowDuration() (time.Duration, error) {
+	return time.ParseDuration(r.Window)
+}
+
+// RateLimit limits the requests of each account, API key or, for anonymous requests, client IP to every method.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled"`
+	// Default applies to the methods without a rule of their own.
+	Default RateLimitRule `yaml:"default"`
+	// Methods maps full method names, such as /go_load.GoLoadService/CreateSession, and the patterns of the handlers
+	// the HTTP server serves outside of the gateway, such as "GET /share/{share_link_token}", to their rule.
+	Methods map[string]RateLimitRule `yaml:"methods"`
 }
@@ -0,0 +1,23 @@
+package configs
+
+import "time"
+
+type Health struct {
+	// CheckTimeout bounds each dependency check of a readiness check.
+	CheckTimeout string `yaml:"check_timeout"`
+	// CheckInterval is how often the gRPC health service refreshes its serving status.
+	CheckInterval string `yaml:"check_interval"`
+	// ShutdownDelay is how long the server keeps serving while reporting not ready before shutting down, so that
+	// load balancers have time to stop sending it new requests.
+	ShutdownDelay string `yaml:"shutdown_delay"`
+}
+
+func (h Health) GetCheckTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(h.CheckTimeout)
+}
+func (h Health) GetCheckIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(h.CheckInterval)
+}
+func (h Health) GetShutdownDelayDuration() (time.Duration, error) {
+	return time.ParseDuration(h.ShutdownDelay)
+}
@@ -2,4 +2,8 @@ package configs
 
 type HTTP struct {
 	Address string `yaml:"address"`
+	TLS     TLS    `yaml:"tls"`
+	// GRPCClientTLS secures the connection of the gateway to the gRPC server. ServerName usually has to be set, as
+	// the gateway connects to the address the gRPC server listens on.
+	GRPCClientTLS ClientTLS `yaml:"grpc_client_tls"`
 }
@@ -0,0 +1,12 @@
+package configs
+
+type Metrics struct {
+	Enabled   bool   `yaml:"enabled"`
+	Path      string `yaml:"path"`
+	Namespace string `yaml:"namespace"`
+	// DurationBuckets are the histogram buckets, in seconds, of the api, storage and download duration metrics.
+	DurationBuckets []float64 `yaml:"duration_buckets"`
+	// ThroughputBuckets are the histogram buckets, in bytes per second, of the download throughput metric.
+	ThroughputBuckets               []float64 `yaml:"throughput_buckets"`
+	UpdateDownloadTaskCountSchedule string    `yaml:"update_download_task_count_schedule"`
+}
@@ -1,6 +1,15 @@
 package configs
 
+// SASL authenticates to the brokers with SASL/PLAIN, which sends the password as is and should be used along with TLS.
+type SASL struct {
+	Enabled  bool   `yaml:"enabled"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
 type MQ struct {
-	Addresses []string `yaml:"addresses"`
-	ClientID  string   `yaml:"client_id"`
+	Addresses []string  `yaml:"addresses"`
+	ClientID  string    `yaml:"client_id"`
+	TLS       ClientTLS `yaml:"tls"`
+	SASL      SASL      `yaml:"sasl"`
 }
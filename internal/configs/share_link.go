@@ -0,0 +1,25 @@
+package configs
+
+import "time"
+
+type ShareLink struct {
+	BaseURL    string `yaml:"base_url"`
+	SigningKey string `yaml:"signing_key"`
+	// GenerateSigningKey generates a random signing key on startup if SigningKey is not set, for local development
+	// only: share links then stop working after a restart and are not accepted by other replicas.
+	GenerateSigningKey     bool   `yaml:"generate_signing_key"`
+	DefaultExpiresIn       string `yaml:"default_expires_in"`
+	MaxExpiresIn           string `yaml:"max_expires_in"`
+	RedirectToPresignedURL bool   `yaml:"redirect_to_presigned_url"`
+	PresignedURLExpiresIn  string `yaml:"presigned_url_expires_in"`
+}
+
+func (s ShareLink) GetDefaultExpiresInDuration() (time.Duration, error) {
+	return time.ParseDuration(s.DefaultExpiresIn)
+}
+func (s ShareLink) GetMaxExpiresInDuration() (time.Duration, error) {
+	return time.ParseDuration(s.MaxExpiresIn)
+}
+func (s ShareLink) GetPresignedURLExpiresInDuration() (time.Duration, error) {
+	return time.ParseDuration(s.PresignedURLExpiresIn)
+}
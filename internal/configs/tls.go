@@ -0,0 +1,46 @@
+package configs
+
+import "time"
+
+type TLSVersion string
+
+const (
+	TLSVersion12 TLSVersion = "1.2"
+	TLSVersion13 TLSVersion = "1.3"
+)
+
+// TLS configures a server. The certificate and key files are checked for changes every ReloadInterval, so that they
+// can be rotated without a restart.
+type TLS struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables mutual TLS, clients must then present a certificate signed by one of the CAs in the file.
+	ClientCAFile   string     `yaml:"client_ca_file"`
+	MinVersion     TLSVersion `yaml:"min_version"`
+	ReloadInterval string     `yaml:"reload_interval"`
+}
+
+func (t TLS) GetReloadIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(t.ReloadInterval)
+}
+
+// ClientTLS configures a connection to a server.
+type ClientTLS struct {
+	Enabled bool `yaml:"enabled"`
+	// CAFile verifies the server against the CAs in the file instead of the ones of the system.
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile are the certificate presented to servers that require mutual TLS. They are reloaded the
+	// same way as the ones of TLS.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ServerName overrides the host name the certificate of the server is verified against.
+	ServerName         string     `yaml:"server_name"`
+	InsecureSkipVerify bool       `yaml:"insecure_skip_verify"`
+	MinVersion         TLSVersion `yaml:"min_version"`
+	ReloadInterval     string     `yaml:"reload_interval"`
+}
+
+func (c ClientTLS) GetReloadIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(c.ReloadInterval)
+}
@@ -0,0 +1,21 @@
+package configs
+
+type TracingExporter string
+
+const (
+	TracingExporterOTLPGRPC TracingExporter = "otlp_grpc"
+	TracingExporterOTLPHTTP TracingExporter = "otlp_http"
+	TracingExporterStdout   TracingExporter = "stdout"
+)
+
+type Tracing struct {
+	Enabled     bool            `yaml:"enabled"`
+	ServiceName string          `yaml:"service_name"`
+	Exporter    TracingExporter `yaml:"exporter"`
+	// Endpoint is the host:port of the OTLP collector. It is not used by the stdout exporter.
+	Endpoint string            `yaml:"endpoint"`
+	Insecure bool              `yaml:"insecure"`
+	Headers  map[string]string `yaml:"headers"`
+	// SampleRatio is the ratio of new traces that are sampled. Traces started by a sampled parent are always sampled.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
@@ -14,4 +14,8 @@ var WireSet = wire.NewSet(
 	wire.FieldsOf(new(Config), "MQ"),
 	wire.FieldsOf(new(Config), "Cron"),
 	wire.FieldsOf(new(Config), "Download"),
+	wire.FieldsOf(new(Config), "ShareLink"),
+	wire.FieldsOf(new(Config), "Metrics"),
+	wire.FieldsOf(new(Config), "Tracing"),
+	wire.FieldsOf(new(Config), "Health"),
 )
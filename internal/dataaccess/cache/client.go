@@ -22,8 +22,15 @@ var (
 type Client interface {
 	Set(ctx context.Context, key string, data any, ttl time.Duration) error
 	Get(ctx context.Context, key string) (any, error)
+	// GetAndDelete returns the data of the key and deletes it at once, so that only one caller gets it.
+	GetAndDelete(ctx context.Context, key string) (any, error)
+	// Increment adds one to the integer of the key, starting from 0, and returns the result. The ttl of the key is
+	// reset to the given one.
+	Increment(ctx context.Context, key string, ttl time.Duration) (uint64, error)
+	Delete(ctx context.Context, key string) error
 	AddToSet(ctx context.Context, key string, data ...any) error
 	IsDataInSet(ctx context.Context, key string, data any) (bool, error)
+	Ping(ctx context.Context) error
 }
 
 func NewClient(cacheConfig configs.Cache, logger *zap.Logger) (Client, error) {
@@ -31,7 +38,7 @@ func NewClient(cacheConfig configs.Cache, logger *zap.Logger) (Client, error) {
 	case configs.CacheTypeInMemory:
 		return NewInMemoryClient(logger), nil
 	case configs.CacheTypeRedis:
-		return NewRedisClient(cacheConfig, logger), nil
+		return NewRedisClient(cacheConfig, logger)
 	default:
 		return nil, fmt.Errorf("unsupported cache type: %s", cacheConfig.Type)
 	}
@@ -42,15 +49,21 @@ type redisClient struct {
 	logger      *zap.Logger
 }
 
-func NewRedisClient(cacheConfig configs.Cache, logger *zap.Logger) Client {
+func NewRedisClient(cacheConfig configs.Cache, logger *zap.Logger) (Client, error) {
+	tlsConfig, err := utils.NewClientTLSConfig(cacheConfig.TLS, logger)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create redis tls config")
+		return nil, err
+	}
 	return &redisClient{
 		redisClient: redis.NewClient(&redis.Options{
-			Addr:     cacheConfig.Address,
-			Username: cacheConfig.Username,
-			Password: cacheConfig.Password,
+			Addr:      cacheConfig.Address,
+			Username:  cacheConfig.Username,
+			Password:  cacheConfig.Password,
+			TLSConfig: tlsConfig,
 		}),
 		logger: logger,
-	}
+	}, nil
 }
 func (c redisClient) Set(ctx context.Context, key string, data any, ttl time.Duration) error {
 	logger := utils.LoggerWithContext(ctx, c.logger).
@@ -78,6 +91,44 @@ func (c redisClient) Get(ctx context.Context, key string) (any, error) {
 	}
 	return data, nil
 }
+func (c redisClient) GetAndDelete(ctx context.Context, key string) (any, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).
+		With(zap.String("key", key))
+
+	data, err := c.redisClient.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheMiss
+		}
+		logger.With(zap.Error(err)).Error("failed to get and delete data from cache")
+		return nil, status.Error(codes.Internal, "failed to get and delete data from cache")
+	}
+	return data, nil
+}
+func (c redisClient) Increment(ctx context.Context, key string, ttl time.Duration) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).
+		With(zap.String("key", key)).
+		With(zap.Duration("ttl", ttl))
+
+	pipeline := c.redisClient.TxPipeline()
+	incrCmd := pipeline.Incr(ctx, key)
+	pipeline.Expire(ctx, key, ttl)
+	if _, err := pipeline.Exec(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to increment data inside cache")
+		return 0, status.Error(codes.Internal, "failed to increment data inside cache")
+	}
+	return uint64(incrCmd.Val()), nil
+}
+func (c redisClient) Delete(ctx context.Context, key string) error {
+	logger := utils.LoggerWithContext(ctx, c.logger).
+		With(zap.String("key", key))
+
+	if err := c.redisClient.Del(ctx, key).Err(); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete data from cache")
+		return status.Error(codes.Internal, "failed to delete data from cache")
+	}
+	return nil
+}
 func (c redisClient) AddToSet(ctx context.Context, key string, data ...any) error {
 	logger := utils.LoggerWithContext(ctx, c.logger).
 		With(zap.String("key", key)).
@@ -102,36 +153,98 @@ func (c redisClient) IsDataInSet(ctx context.Context, key string, data any) (boo
 	return result, nil
 }
 
+func (c redisClient) Ping(ctx context.Context) error {
+	return c.redisClient.Ping(ctx).Err()
+}
+
+// inMemoryEntry expires at expiresAt, unless it is zero.
+type inMemoryEntry struct {
+	data      any
+	expiresAt time.Time
+}
 type inMemoryClient struct {
-	cache      map[string]any
+	cache      map[string]inMemoryEntry
 	cacheMutex *sync.Mutex
 	logger     *zap.Logger
 }
 
 func NewInMemoryClient(logger *zap.Logger) Client {
 	return &inMemoryClient{
-		cache:      make(map[string]any),
+		cache:      make(map[string]inMemoryEntry),
 		cacheMutex: new(sync.Mutex),
 		logger:     logger,
 	}
 }
-func (c inMemoryClient) Set(_ context.Context, key string, data any, _ time.Duration) error {
-	c.cache[key] = data
+
+// getEntry must be called with cacheMutex held. It deletes the entry of the key if it expired.
+func (c inMemoryClient) getEntry(key string) (inMemoryEntry, bool) {
+	entry, ok := c.cache[key]
+	if !ok {
+		return inMemoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(c.cache, key)
+		return inMemoryEntry{}, false
+	}
+	return entry, true
+}
+
+// setEntry must be called with cacheMutex held. A ttl of 0 never expires, like in redis.
+func (c inMemoryClient) setEntry(key string, data any, ttl time.Duration) {
+	entry := inMemoryEntry{data: data}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.cache[key] = entry
+}
+func (c inMemoryClient) Set(_ context.Context, key string, data any, ttl time.Duration) error {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	c.setEntry(key, data, ttl)
 	return nil
 }
 func (c inMemoryClient) Get(_ context.Context, key string) (any, error) {
-	data, ok := c.cache[key]
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	entry, ok := c.getEntry(key)
 	if !ok {
 		return nil, ErrCacheMiss
 	}
-	return data, nil
+	return entry.data, nil
+}
+func (c inMemoryClient) GetAndDelete(_ context.Context, key string) (any, error) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	entry, ok := c.getEntry(key)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	delete(c.cache, key)
+	return entry.data, nil
+}
+func (c inMemoryClient) Increment(_ context.Context, key string, ttl time.Duration) (uint64, error) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	var count uint64
+	if entry, ok := c.getEntry(key); ok {
+		count, _ = entry.data.(uint64)
+	}
+	count++
+	c.setEntry(key, count, ttl)
+	return count, nil
+}
+func (c inMemoryClient) Delete(_ context.Context, key string) error {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	delete(c.cache, key)
+	return nil
 }
 func (c inMemoryClient) AddToSet(_ context.Context, key string, data ...any) error {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	set := c.getSet(key)
 	set = append(set, data...)
-	c.cache[key] = set
+	c.cache[key] = inMemoryEntry{data: set}
 	return nil
 }
 func (c inMemoryClient) IsDataInSet(_ context.Context, key string, data any) (bool, error) {
@@ -145,12 +258,15 @@ func (c inMemoryClient) IsDataInSet(_ context.Context, key string, data any) (bo
 	}
 	return false, nil
 }
+func (c inMemoryClient) Ping(context.Context) error {
+	return nil
+}
 func (c inMemoryClient) getSet(key string) []any {
-	setValue, ok := c.cache[key]
+	entry, ok := c.getEntry(key)
 	if !ok {
 		return make([]any, 0)
 	}
-	set, ok := setValue.([]any)
+	set, ok := entry.data.([]any)
 	if !ok {
 		return make([]any, 0)
 	}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LoginAttempt counts the failed logins of a subject, such as an account name or a client IP, and holds the
+// subjects that are locked out.
+type LoginAttempt interface {
+	// IncreaseFailedAttemptCount returns the number of failed attempts of the subject, counting this one, since its
+	// last failed attempt older than ttl.
+	IncreaseFailedAttemptCount(ctx context.Context, subject string, ttl time.Duration) (uint64, error)
+	ResetFailedAttemptCount(ctx context.Context, subject string) error
+	Lock(ctx context.Context, subject string, duration time.Duration) error
+	IsLocked(ctx context.Context, subject string) (bool, error)
+}
+type loginAttempt struct {
+	client  Client
+	metrics utils.Metrics
+	logger  *zap.Logger
+}
+
+func NewLoginAttempt(client Client, metrics utils.Metrics, logger *zap.Logger) LoginAttempt {
+	return &loginAttempt{
+		client:  client,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+func (c loginAttempt) getFailedAttemptCountCacheKey(subject string) string {
+	return fmt.Sprintf("login_attempt:failed_count:%s", subject)
+}
+func (c loginAttempt) getLockCacheKey(subject string) string {
+	return fmt.Sprintf("login_attempt:lock:%s", subject)
+}
+func (c loginAttempt) IncreaseFailedAttemptCount(ctx context.Context, subject string, ttl time.Duration) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("subject", subject))
+
+	count, err := c.client.Increment(ctx, c.getFailedAttemptCountCacheKey(subject), ttl)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to increase failed login attempt count in cache")
+		return 0, err
+	}
+	return count, nil
+}
+func (c loginAttempt) ResetFailedAttemptCount(ctx context.Context, subject string) error {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("subject", subject))
+
+	if err := c.client.Delete(ctx, c.getFailedAttemptCountCacheKey(subject)); err != nil {
+		logger.With(zap.Error(err)).Error("failed to reset failed login attempt count in cache")
+		return err
+	}
+	return nil
+}
+func (c loginAttempt) Lock(ctx context.Context, subject string, duration time.Duration) error {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("subject", subject))
+
+	if err := c.client.Set(ctx, c.getLockCacheKey(subject), true, duration); err != nil {
+		logger.With(zap.Error(err)).Error("failed to insert login lock into cache")
+		return err
+	}
+	return nil
+}
+func (c loginAttempt) IsLocked(ctx context.Context, subject string) (bool, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("subject", subject))
+
+	_, err := c.client.Get(ctx, c.getLockCacheKey(subject))
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			c.metrics.IncCacheRequestCount(utils.CacheNameLoginAttempt, false)
+			return false, nil
+		}
+		logger.With(zap.Error(err)).Error("failed to get login lock from cache")
+		return false, err
+	}
+	c.metrics.IncCacheRequestCount(utils.CacheNameLoginAttempt, true)
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OIDCLoginStateData is what the server needs to remember between sending the browser to the identity provider and the
+// browser coming back with the authorization code.
+type OIDCLoginStateData struct {
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	// LinkAccountID is the account to link the identity to, or zero to log in with it.
+	LinkAccountID uint64 `json:"link_account_id"`
+}
+
+// OIDCLoginState holds the state of the OIDC logins in progress. Each state can only be taken once.
+type OIDCLoginState interface {
+	Set(ctx context.Context, state string, loginState OIDCLoginStateData, ttl time.Duration) error
+	Take(ctx context.Context, state string) (OIDCLoginStateData, error)
+}
+type oidcLoginState struct {
+	client  Client
+	metrics utils.Metrics
+	logger  *zap.Logger
+}
+
+func NewOIDCLoginState(client Client, metrics utils.Metrics, logger *zap.Logger) OIDCLoginState {
+	return &oidcLoginState{
+		client:  client,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+func (c oidcLoginState) getOIDCLoginStateCacheKey(state string) string {
+	return fmt.Sprintf("oidc_login_state:%s", state)
+}
+func (c oidcLoginState) Set(ctx context.Context, state string, loginState OIDCLoginStateData, ttl time.Duration) error {
+	logger := utils.LoggerWithContext(ctx, c.logger)
+
+	loginStateBytes, err := json.Marshal(loginState)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to marshal oidc login state")
+		return err
+	}
+	if err := c.client.Set(ctx, c.getOIDCLoginStateCacheKey(state), string(loginStateBytes), ttl); err != nil {
+		logger.With(zap.Error(err)).Error("failed to insert oidc login state into cache")
+		return err
+	}
+	return nil
+}
+func (c oidcLoginState) Take(ctx context.Context, state string) (OIDCLoginStateData, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger)
+
+	cacheEntry, err := c.client.GetAndDelete(ctx, c.getOIDCLoginStateCacheKey(state))
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			c.metrics.IncCacheRequestCount(utils.CacheNameOIDCLoginState, false)
+			return OIDCLoginStateData{}, err
+		}
+		logger.With(zap.Error(err)).Error("failed to get oidc login state from cache")
+		return OIDCLoginStateData{}, err
+	}
+	c.metrics.IncCacheRequestCount(utils.CacheNameOIDCLoginState, true)
+	loginStateString, ok := cacheEntry.(string)
+	if !ok {
+		logger.Error("cache entry is not of type string")
+		return OIDCLoginStateData{}, ErrCacheMiss
+	}
+	loginState := OIDCLoginStateData{}
+	if err := json.Unmarshal([]byte(loginStateString), &loginState); err != nil {
+		logger.With(zap.Error(err)).Error("failed to unmarshal oidc login state")
+		return OIDCLoginStateData{}, err
+	}
+	return loginState, nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RateLimit counts the requests of a subject to a method within a window, shared by every replica.
+type RateLimit interface {
+	// IncreaseRequestCount returns the number of requests of the subject to the method in the window starting at
+	// windowStart, counting this one.
+	IncreaseRequestCount(ctx context.Context, subject string, method string, windowStart time.Time, window time.Duration) (uint64, error)
+}
+type rateLimit struct {
+	client Client
+	logger *zap.Logger
+}
+
+func NewRateLimit(client Client, logger *zap.Logger) RateLimit {
+	return &rateLimit{
+		client: client,
+		logger: logger,
+	}
+}
+func (c rateLimit) getRequestCountCacheKey(subject string, method string, windowStart time.Time) string {
+	return fmt.Sprintf("rate_limit:%s:%s:%d", method, subject, windowStart.UnixNano())
+}
+func (c rateLimit) IncreaseRequestCount(
+	ctx context.Context,
+	subject string,
+	method string,
+	windowStart time.Time,
+	window time.Duration,
+) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).
+		With(zap.String("subject", subject)).
+		With(zap.String("method", method))
+
+	// The count only needs to live until the end of its window.
+	count, err := c.client.Increment(ctx, c.getRequestCountCacheKey(subject, method, windowStart), window)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to increase request count in cache")
+		return 0, err
+	}
+	return count, nil
+}
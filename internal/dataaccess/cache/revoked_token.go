@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RevokedToken holds the ids of the session tokens revoked before they expire. Entries only need to live until the
+// token would have expired anyway.
+type RevokedToken interface {
+	Add(ctx context.Context, tokenID string, ttl time.Duration) error
+	Has(ctx context.Context, tokenID string) (bool, error)
+}
+type revokedToken struct {
+	client  Client
+	metrics utils.Metrics
+	logger  *zap.Logger
+}
+
+func NewRevokedToken(client Client, metrics utils.Metrics, logger *zap.Logger) RevokedToken {
+	return &revokedToken{
+		client:  client,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+func (c revokedToken) getRevokedTokenCacheKey(tokenID string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenID)
+}
+func (c revokedToken) Add(ctx context.Context, tokenID string, ttl time.Duration) error {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("token_id", tokenID))
+
+	if err := c.client.Set(ctx, c.getRevokedTokenCacheKey(tokenID), true, ttl); err != nil {
+		logger.With(zap.Error(err)).Error("failed to insert revoked token into cache")
+		return err
+	}
+	return nil
+}
+func (c revokedToken) Has(ctx context.Context, tokenID string) (bool, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("token_id", tokenID))
+
+	_, err := c.client.Get(ctx, c.getRevokedTokenCacheKey(tokenID))
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			c.metrics.IncCacheRequestCount(utils.CacheNameRevokedToken, false)
+			return false, nil
+		}
+		logger.With(zap.Error(err)).Error("failed to get revoked token from cache")
+		return false, err
+	}
+	c.metrics.IncCacheRequestCount(utils.CacheNameRevokedToken, true)
+	return true, nil
+}
@@ -16,14 +16,16 @@ type TakenAccountName interface {
 	Has(ctx context.Context, accountName string) (bool, error)
 }
 type takenAccountName struct {
-	client Client
-	logger *zap.Logger
+	client  Client
+	metrics utils.Metrics
+	logger  *zap.Logger
 }
 
-func NewTakenAccountName(client Client, logger *zap.Logger) TakenAccountName {
+func NewTakenAccountName(client Client, metrics utils.Metrics, logger *zap.Logger) TakenAccountName {
 	return &takenAccountName{
-		client: client,
-		logger: logger,
+		client:  client,
+		metrics: metrics,
+		logger:  logger,
 	}
 }
 func (c takenAccountName) Add(ctx context.Context, accountName string) error {
@@ -43,5 +45,6 @@ func (c takenAccountName) Has(ctx context.Context, accountName string) (bool, er
 		logger.With(zap.Error(err)).Error("failed to check if account name is in set in cache")
 		return false, err
 	}
+	c.metrics.IncCacheRequestCount(utils.CacheNameTakenAccountName, result)
 	return result, nil
 }
@@ -3,6 +3,7 @@ package cache
 import (
 	"GoLoad/internal/utils"
 	"context"
+	"errors"
 	"fmt"
 
 	"go.uber.org/zap"
@@ -13,14 +14,16 @@ type TokenPublicKey interface {
 	Set(ctx context.Context, id uint64, bytes []byte) error
 }
 type tokenPublicKey struct {
-	client Client
-	logger *zap.Logger
+	client  Client
+	metrics utils.Metrics
+	logger  *zap.Logger
 }
 
-func NewTokenPublicKey(client Client, logger *zap.Logger) TokenPublicKey {
+func NewTokenPublicKey(client Client, metrics utils.Metrics, logger *zap.Logger) TokenPublicKey {
 	return &tokenPublicKey{
-		client: client,
-		logger: logger,
+		client:  client,
+		metrics: metrics,
+		logger:  logger,
 	}
 }
 func (c tokenPublicKey) getTokenPublicKeyCacheKey(id uint64) string {
@@ -32,9 +35,14 @@ func (c tokenPublicKey) Get(ctx context.Context, id uint64) ([]byte, error) {
 	cacheKey := c.getTokenPublicKeyCacheKey(id)
 	cacheEntry, err := c.client.Get(ctx, cacheKey)
 	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			c.metrics.IncCacheRequestCount(utils.CacheNameTokenPublicKey, false)
+			return nil, err
+		}
 		logger.With(zap.Error(err)).Error("failed to get token public key cache")
 		return nil, err
 	}
+	c.metrics.IncCacheRequestCount(utils.CacheNameTokenPublicKey, cacheEntry != nil)
 	if cacheEntry == nil {
 		return nil, ErrCacheMiss
 	}
@@ -6,4 +6,8 @@ var WireSet = wire.NewSet(
 	NewRedisClient,
 	NewTokenPublicKey,
 	NewTakenAccountName,
+	NewRevokedToken,
+	NewOIDCLoginState,
+	NewLoginAttempt,
+	NewRateLimit,
 )
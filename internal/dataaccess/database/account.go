@@ -1,8 +1,10 @@
 package database
 
 import (
+	"GoLoad/internal/generated/grpc/go_load"
 	"GoLoad/internal/utils"
 	"context"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"go.uber.org/zap"
@@ -16,18 +18,46 @@ var (
 )
 
 const (
-	ColNameAccountsID          = "id"
-	ColNameAccountsAccountName = "account_name"
+	ColNameAccountsID           = "id"
+	ColNameAccountsAccountName  = "account_name"
+	ColNameAccountsRole         = "role"
+	ColNameAccountsDisabledAt   = "disabled_at"
+	ColNameAccountsTokenVersion = "token_version"
 )
 
 type Account struct {
-	ID          uint64 `db:"id" goqu:"skipinsert,skipupdate"`
-	AccountName string `db:"account_name"`
+	ID          uint64              `db:"id" goqu:"skipinsert,skipupdate"`
+	AccountName string              `db:"account_name"`
+	Role        go_load.AccountRole `db:"role"`
+	DisabledAt  *time.Time          `db:"disabled_at"`
+	// TokenVersion is embedded in the session tokens of the account. Increasing it invalidates all of them.
+	TokenVersion uint64 `db:"token_version" goqu:"skipinsert,skipupdate"`
+}
+
+func (a Account) IsDisabled() bool {
+	return a.DisabledAt != nil
+}
+
+// AccountUsage aggregates the download tasks of an account.
+type AccountUsage struct {
+	Account
+	DownloadTaskCount       uint64 `db:"download_task_count"`
+	ActiveDownloadTaskCount uint64 `db:"active_download_task_count"`
+	TotalFileSize           uint64 `db:"total_file_size"`
 }
 type AccountDataAccessor interface {
 	CreateAccount(ctx context.Context, account Account) (uint64, error)
 	GetAccountByID(ctx context.Context, id uint64) (Account, error)
 	GetAccountByAccountName(ctx context.Context, accountName string) (Account, error)
+	UpdateAccountRole(ctx context.Context, id uint64, role go_load.AccountRole) error
+	// UpdateAccountDisabledAt disables the account at the given time, or enables it if disabledAt is nil.
+	UpdateAccountDisabledAt(ctx context.Context, id uint64, disabledAt *time.Time) error
+	IncreaseAccountTokenVersion(ctx context.Context, id uint64) error
+	// DeleteAccount fails while the password or any download task of the account is still in the database.
+	DeleteAccount(ctx context.Context, id uint64) error
+	GetAccountCount(ctx context.Context) (uint64, error)
+	// GetAccountUsageList returns the usage of accounts ordered by id, including accounts without any download task.
+	GetAccountUsageList(ctx context.Context, offset uint64, limit uint64) ([]AccountUsage, error)
 	WithDatabase(database Database) AccountDataAccessor
 }
 type accountDataAccessor struct {
@@ -50,6 +80,7 @@ func (a accountDataAccessor) CreateAccount(ctx context.Context, account Account)
 		Insert(TabNameAccounts).
 		Rows(goqu.Record{
 			ColNameAccountsAccountName: account.AccountName,
+			ColNameAccountsRole:        account.Role,
 		}).
 		Executor().
 		ExecContext(ctx)
@@ -105,6 +136,115 @@ func (a *accountDataAccessor) GetAccountByAccountName(ctx context.Context, accou
 	return account, nil
 }
 
+// UpdateAccountRole implements AccountDataAccessor.
+func (a *accountDataAccessor) UpdateAccountRole(ctx context.Context, id uint64, role go_load.AccountRole) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id)).With(zap.String("role", role.String()))
+
+	if _, err := a.database.
+		Update(TabNameAccounts).
+		Set(goqu.Record{ColNameAccountsRole: role}).
+		Where(goqu.Ex{ColNameAccountsID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to update account role")
+		return status.Error(codes.Internal, "failed to update account role")
+	}
+	return nil
+}
+
+// UpdateAccountDisabledAt implements AccountDataAccessor.
+func (a *accountDataAccessor) UpdateAccountDisabledAt(ctx context.Context, id uint64, disabledAt *time.Time) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Update(TabNameAccounts).
+		Set(goqu.Record{ColNameAccountsDisabledAt: disabledAt}).
+		Where(goqu.Ex{ColNameAccountsID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to update account disabled at")
+		return status.Error(codes.Internal, "failed to update account disabled at")
+	}
+	return nil
+}
+
+// IncreaseAccountTokenVersion implements AccountDataAccessor.
+func (a *accountDataAccessor) IncreaseAccountTokenVersion(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Update(TabNameAccounts).
+		Set(goqu.Record{ColNameAccountsTokenVersion: goqu.L("? + 1", goqu.C(ColNameAccountsTokenVersion))}).
+		Where(goqu.Ex{ColNameAccountsID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to increase account token version")
+		return status.Error(codes.Internal, "failed to increase account token version")
+	}
+	return nil
+}
+func (a *accountDataAccessor) DeleteAccount(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Delete(TabNameAccounts).
+		Where(goqu.Ex{ColNameAccountsID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete account")
+		return status.Error(codes.Internal, "failed to delete account")
+	}
+	return nil
+}
+
+// GetAccountCount implements AccountDataAccessor.
+func (a *accountDataAccessor) GetAccountCount(ctx context.Context) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger)
+
+	count, err := a.database.From(TabNameAccounts).CountContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to count account")
+		return 0, status.Error(codes.Internal, "failed to count account")
+	}
+	return uint64(count), nil
+}
+
+// GetAccountUsageList implements AccountDataAccessor.
+func (a *accountDataAccessor) GetAccountUsageList(ctx context.Context, offset uint64, limit uint64) ([]AccountUsage, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("offset", offset)).With(zap.Uint64("limit", limit))
+
+	activeDownloadStatusList := []go_load.DownloadStatus{go_load.DownloadStatus_Pending, go_load.DownloadStatus_Downloading}
+	query := a.database.
+		Select(
+			TabNameAccounts.Col(ColNameAccountsID),
+			TabNameAccounts.Col(ColNameAccountsAccountName),
+			TabNameAccounts.Col(ColNameAccountsRole),
+			TabNameAccounts.Col(ColNameAccountsDisabledAt),
+			TabNameAccounts.Col(ColNameAccountsTokenVersion),
+			goqu.COUNT(TabNameDownloadTasks.Col(ColNameDownloadTaskID)).As("download_task_count"),
+			goqu.COALESCE(goqu.SUM(goqu.Case().
+				When(TabNameDownloadTasks.Col(ColNameDownloadTaskDownloadStatus).In(activeDownloadStatusList), 1).
+				Else(0)), 0).As("active_download_task_count"),
+			goqu.COALESCE(goqu.SUM(TabNameDownloadTasks.Col(ColNameDownloadTaskFileSize)), 0).As("total_file_size"),
+		).
+		From(TabNameAccounts).
+		LeftJoin(TabNameDownloadTasks, goqu.On(
+			TabNameDownloadTasks.Col(ColNameDownloadTaskOfAccountID).Eq(TabNameAccounts.Col(ColNameAccountsID)),
+		)).
+		GroupBy(TabNameAccounts.Col(ColNameAccountsID)).
+		Order(TabNameAccounts.Col(ColNameAccountsID).Asc()).
+		Offset(uint(offset))
+	if limit > 0 {
+		query = query.Limit(uint(limit))
+	}
+	accountUsageList := make([]AccountUsage, 0)
+	if err := query.Executor().ScanStructsContext(ctx, &accountUsageList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get account usage list")
+		return nil, status.Error(codes.Internal, "failed to get account usage list")
+	}
+	return accountUsageList, nil
+}
+
 // WithDatabase implements AccountDataAccessor.
 func (a *accountDataAccessor) WithDatabase(database Database) AccountDataAccessor {
 	return &accountDataAccessor{
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	TabNameAccountIdentities   = goqu.T("account_identities")
+	ErrAccountIdentityNotFound = status.Error(codes.NotFound, "account identity not found")
+)
+
+const (
+	ColNameAccountIdentityID          = "id"
+	ColNameAccountIdentityOfAccountID = "of_account_id"
+	ColNameAccountIdentityIssuer      = "issuer"
+	ColNameAccountIdentitySubject     = "subject"
+)
+
+// AccountIdentity links an account to an identity of an OpenID Connect provider, identified by the iss and sub claims
+// of its ID tokens.
+type AccountIdentity struct {
+	ID          uint64    `db:"id" goqu:"skipinsert,skipupdate"`
+	OfAccountID uint64    `db:"of_account_id"`
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	Email       *string   `db:"email"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+type AccountIdentityDataAccessor interface {
+	CreateAccountIdentity(ctx context.Context, accountIdentity AccountIdentity) (uint64, error)
+	GetAccountIdentity(ctx context.Context, id uint64) (AccountIdentity, error)
+	GetAccountIdentityByIssuerAndSubject(ctx context.Context, issuer string, subject string) (AccountIdentity, error)
+	GetAccountIdentityListOfAccount(ctx context.Context, accountID uint64) ([]AccountIdentity, error)
+	DeleteAccountIdentity(ctx context.Context, id uint64) error
+	WithDatabase(database Database) AccountIdentityDataAccessor
+}
+type accountIdentityDataAccessor struct {
+	database Database
+	logger   *zap.Logger
+}
+
+func NewAccountIdentityDataAccessor(database *goqu.Database, logger *zap.Logger) AccountIdentityDataAccessor {
+	return &accountIdentityDataAccessor{
+		database: database,
+		logger:   logger,
+	}
+}
+func (a accountIdentityDataAccessor) CreateAccountIdentity(ctx context.Context, accountIdentity AccountIdentity) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).
+		With(zap.Uint64("of_account_id", accountIdentity.OfAccountID)).
+		With(zap.String("issuer", accountIdentity.Issuer))
+
+	result, err := a.database.
+		Insert(TabNameAccountIdentities).
+		Rows(accountIdentity).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create account identity")
+		return 0, status.Error(codes.Internal, "failed to create account identity")
+	}
+	lastInsertedID, err := result.LastInsertId()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get last inserted id")
+		return 0, status.Error(codes.Internal, "failed to get last inserted id")
+	}
+	return uint64(lastInsertedID), nil
+}
+func (a accountIdentityDataAccessor) getAccountIdentity(
+	ctx context.Context,
+	logger *zap.Logger,
+	expression goqu.Ex,
+) (AccountIdentity, error) {
+	accountIdentity := AccountIdentity{}
+	found, err := a.database.
+		Select().
+		From(TabNameAccountIdentities).
+		Where(expression).
+		ScanStructContext(ctx, &accountIdentity)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get account identity")
+		return AccountIdentity{}, status.Error(codes.Internal, "failed to get account identity")
+	}
+	if !found {
+		logger.Warn("account identity not found")
+		return AccountIdentity{}, ErrAccountIdentityNotFound
+	}
+	return accountIdentity, nil
+}
+func (a accountIdentityDataAccessor) GetAccountIdentity(ctx context.Context, id uint64) (AccountIdentity, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+	return a.getAccountIdentity(ctx, logger, goqu.Ex{ColNameAccountIdentityID: id})
+}
+func (a accountIdentityDataAccessor) GetAccountIdentityByIssuerAndSubject(
+	ctx context.Context,
+	issuer string,
+	subject string,
+) (AccountIdentity, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.String("issuer", issuer))
+	return a.getAccountIdentity(ctx, logger, goqu.Ex{
+		ColNameAccountIdentityIssuer:  issuer,
+		ColNameAccountIdentitySubject: subject,
+	})
+}
+func (a accountIdentityDataAccessor) GetAccountIdentityListOfAccount(ctx context.Context, accountID uint64) ([]AccountIdentity, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("account_id", accountID))
+
+	accountIdentityList := make([]AccountIdentity, 0)
+	if err := a.database.
+		Select().
+		From(TabNameAccountIdentities).
+		Where(goqu.Ex{ColNameAccountIdentityOfAccountID: accountID}).
+		Order(goqu.C(ColNameAccountIdentityID).Asc()).
+		Executor().
+		ScanStructsContext(ctx, &accountIdentityList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get account identity list of account")
+		return nil, status.Error(codes.Internal, "failed to get account identity list of account")
+	}
+	return accountIdentityList, nil
+}
+func (a accountIdentityDataAccessor) DeleteAccountIdentity(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Delete(TabNameAccountIdentities).
+		Where(goqu.Ex{ColNameAccountIdentityID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete account identity")
+		return status.Error(codes.Internal, "failed to delete account identity")
+	}
+	return nil
+}
+func (a accountIdentityDataAccessor) WithDatabase(database Database) AccountIdentityDataAccessor {
+	return &accountIdentityDataAccessor{
+		database: database,
+		logger:   a.logger,
+	}
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	TabNameAPIKeys    = goqu.T("api_keys")
+	ErrAPIKeyNotFound = status.Error(codes.NotFound, "api key not found")
+)
+
+const (
+	ColNameAPIKeyID          = "id"
+	ColNameAPIKeyOfAccountID = "of_account_id"
+	ColNameAPIKeyKeyHash     = "key_hash"
+	ColNameAPIKeyLastUsedAt  = "last_used_at"
+)
+
+type APIKey struct {
+	ID          uint64       `db:"id" goqu:"skipinsert,skipupdate"`
+	OfAccountID uint64       `db:"of_account_id" goqu:"skipupdate"`
+	APIKeyName  string       `db:"api_key_name"`
+	KeyPrefix   string       `db:"key_prefix" goqu:"skipupdate"`
+	KeyHash     string       `db:"key_hash" goqu:"skipupdate"`
+	Scopes      APIKeyScopes `db:"scopes"`
+	ExpiresAt   *time.Time   `db:"expires_at"`
+	LastUsedAt  *time.Time   `db:"last_used_at"`
+	CreatedAt   time.Time    `db:"created_at" goqu:"skipupdate"`
+}
+
+// IsExpired tells whether the API key has an expiry that has passed.
+func (a APIKey) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
+type APIKeyDataAccessor interface {
+	CreateAPIKey(ctx context.Context, apiKey APIKey) (uint64, error)
+	GetAPIKey(ctx context.Context, id uint64) (APIKey, error)
+	GetAPIKeyByKeyHash(ctx context.Context, keyHash string) (APIKey, error)
+	GetAPIKeyListOfAccount(ctx context.Context, accountID uint64) ([]APIKey, error)
+	UpdateAPIKeyLastUsedAt(ctx context.Context, id uint64, lastUsedAt time.Time) error
+	DeleteAPIKey(ctx context.Context, id uint64) error
+	WithDatabase(database Database) APIKeyDataAccessor
+}
+type apiKeyDataAccessor struct {
+	database Database
+	logger   *zap.Logger
+}
+
+func NewAPIKeyDataAccessor(database *goqu.Database, logger *zap.Logger) APIKeyDataAccessor {
+	return &apiKeyDataAccessor{
+		database: database,
+		logger:   logger,
+	}
+}
+func (a apiKeyDataAccessor) CreateAPIKey(ctx context.Context, apiKey APIKey) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).
+		With(zap.Uint64("of_account_id", apiKey.OfAccountID)).
+		With(zap.String("api_key_name", apiKey.APIKeyName))
+
+	result, err := a.database.
+		Insert(TabNameAPIKeys).
+		Rows(apiKey).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create api key")
+		return 0, status.Error(codes.Internal, "failed to create api key")
+	}
+	lastInsertedID, err := result.LastInsertId()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get last inserted id")
+		return 0, status.Error(codes.Internal, "failed to get last inserted id")
+	}
+	return uint64(lastInsertedID), nil
+}
+func (a apiKeyDataAccessor) getAPIKey(ctx context.Context, logger *zap.Logger, expression goqu.Ex) (APIKey, error) {
+	apiKey := APIKey{}
+	found, err := a.database.
+		Select().
+		From(TabNameAPIKeys).
+		Where(expression).
+		ScanStructContext(ctx, &apiKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get api key")
+		return APIKey{}, status.Error(codes.Internal, "failed to get api key")
+	}
+	if !found {
+		logger.Warn("api key not found")
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return apiKey, nil
+}
+func (a apiKeyDataAccessor) GetAPIKey(ctx context.Context, id uint64) (APIKey, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+	return a.getAPIKey(ctx, logger, goqu.Ex{ColNameAPIKeyID: id})
+}
+func (a apiKeyDataAccessor) GetAPIKeyByKeyHash(ctx context.Context, keyHash string) (APIKey, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger)
+	return a.getAPIKey(ctx, logger, goqu.Ex{ColNameAPIKeyKeyHash: keyHash})
+}
+func (a apiKeyDataAccessor) GetAPIKeyListOfAccount(ctx context.Context, accountID uint64) ([]APIKey, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("account_id", accountID))
+
+	apiKeyList := make([]APIKey, 0)
+	if err := a.database.
+		Select().
+		From(TabNameAPIKeys).
+		Where(goqu.Ex{ColNameAPIKeyOfAccountID: accountID}).
+		Order(goqu.C(ColNameAPIKeyID).Asc()).
+		Executor().
+		ScanStructsContext(ctx, &apiKeyList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get api key list of account")
+		return nil, status.Error(codes.Internal, "failed to get api key list of account")
+	}
+	return apiKeyList, nil
+}
+func (a apiKeyDataAccessor) UpdateAPIKeyLastUsedAt(ctx context.Context, id uint64, lastUsedAt time.Time) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Update(TabNameAPIKeys).
+		Prepared(true).
+		Set(goqu.Record{ColNameAPIKeyLastUsedAt: lastUsedAt}).
+		Where(goqu.Ex{ColNameAPIKeyID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to update api key last used at")
+		return status.Error(codes.Internal, "failed to update api key last used at")
+	}
+	return nil
+}
+func (a apiKeyDataAccessor) DeleteAPIKey(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("id", id))
+
+	if _, err := a.database.
+		Delete(TabNameAPIKeys).
+		Where(goqu.Ex{ColNameAPIKeyID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete api key")
+		return status.Error(codes.Internal, "failed to delete api key")
+	}
+	return nil
+}
+func (a apiKeyDataAccessor) WithDatabase(database Database) APIKeyDataAccessor {
+	return &apiKeyDataAccessor{
+		database: database,
+		logger:   a.logger,
+	}
+}
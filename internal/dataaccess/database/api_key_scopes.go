@@ -0,0 +1,48 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"GoLoad/internal/generated/grpc/go_load"
+)
+
+// APIKeyScopes stores the scopes of an API key as a JSON array of scope names.
+type APIKeyScopes struct {
+	Scopes []go_load.APIKeyScope
+}
+
+func (a *APIKeyScopes) Scan(src any) error {
+	var srcBytes []byte
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		srcBytes = src
+	case string:
+		srcBytes = []byte(src)
+	default:
+		return fmt.Errorf("unsupported type for api key scopes scan: %T", src)
+	}
+	scopeNameList := make([]string, 0)
+	if err := json.Unmarshal(srcBytes, &scopeNameList); err != nil {
+		return err
+	}
+	a.Scopes = make([]go_load.APIKeyScope, 0, len(scopeNameList))
+	for _, scopeName := range scopeNameList {
+		scope, ok := go_load.APIKeyScope_value[scopeName]
+		if !ok {
+			return fmt.Errorf("unknown api key scope: %s", scopeName)
+		}
+		a.Scopes = append(a.Scopes, go_load.APIKeyScope(scope))
+	}
+	return nil
+}
+func (a APIKeyScopes) Value() (driver.Value, error) {
+	scopeNameList := make([]string, 0, len(a.Scopes))
+	for _, scope := range a.Scopes {
+		scopeNameList = append(scopeNameList, scope.String())
+	}
+	return json.Marshal(scopeNameList)
+}
@@ -7,9 +7,13 @@ import (
 	"log"
 
 	"GoLoad/internal/configs"
+	"GoLoad/internal/utils"
 
+	"github.com/XSAM/otelsql"
 	"github.com/doug-martin/goqu/v9"
-	_ "github.com/go-sql-driver/mysql" // Import MySQL driver
+	"github.com/go-sql-driver/mysql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -41,7 +45,15 @@ type Database interface {
 	Update(table interface{}) *goqu.UpdateDataset
 }
 
-func InitializeAndMigrateUpDB(databaseConfig configs.Database, logger *zap.Logger) (*sql.DB, func(), error) {
+// mysqlTLSConfigName is the name the TLS config is registered with the MySQL driver under, for the connection string
+// to refer to it.
+const mysqlTLSConfigName = "goload"
+
+func InitializeAndMigrateUpDB(
+	databaseConfig configs.Database,
+	tracerProvider trace.TracerProvider,
+	logger *zap.Logger,
+) (*sql.DB, func(), error) {
 	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
 		databaseConfig.Username,
 		databaseConfig.Password,
@@ -49,7 +61,29 @@ func InitializeAndMigrateUpDB(databaseConfig configs.Database, logger *zap.Logge
 		databaseConfig.Port,
 		databaseConfig.Database,
 	)
-	db, err := sql.Open("mysql", connectionString)
+	tlsConfig, err := utils.NewClientTLSConfig(databaseConfig.TLS, logger)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create database tls config")
+		return nil, nil, err
+	}
+	if tlsConfig != nil {
+		if err = mysql.RegisterTLSConfig(mysqlTLSConfigName, tlsConfig); err != nil {
+			logger.With(zap.Error(err)).Error("failed to register database tls config")
+			return nil, nil, err
+		}
+		connectionString += "&tls=" + mysqlTLSConfigName
+	}
+	db, err := otelsql.Open(
+		"mysql",
+		connectionString,
+		otelsql.WithTracerProvider(tracerProvider),
+		otelsql.WithAttributes(semconv.DBSystemMySQL, semconv.DBNamespace(databaseConfig.Database)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		log.Printf("error connecting to the database: %+v\n", err)
 		return nil, nil, err
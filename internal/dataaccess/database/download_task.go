@@ -4,6 +4,8 @@ import (
 	"GoLoad/internal/generated/grpc/go_load"
 	"GoLoad/internal/utils"
 	"context"
+	"strings"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"go.uber.org/zap"
@@ -17,34 +19,122 @@ var (
 )
 
 const (
-	ColNameDownloadTaskID             = "id"
-	ColNameDownloadTaskOfAccountID    = "of_account_id"
-	ColNameDownloadTaskDownloadType   = "download_type"
-	ColNameDownloadTaskURL            = "url"
-	ColNameDownloadTaskDownloadStatus = "download_status"
-	ColNameDownloadTaskMetadata       = "metadata"
+	ColNameDownloadTaskID                  = "id"
+	ColNameDownloadTaskOfAccountID         = "of_account_id"
+	ColNameDownloadTaskOfWorkspaceID       = "of_workspace_id"
+	ColNameDownloadTaskDownloadType        = "download_type"
+	ColNameDownloadTaskURL                 = "url"
+	ColNameDownloadTaskDownloadStatus      = "download_status"
+	ColNameDownloadTaskMetadata            = "metadata"
+	ColNameDownloadTaskPostProcessingSteps = "post_processing_steps"
+	ColNameDownloadTaskBatchID             = "batch_id"
+	ColNameDownloadTaskCreatedAt           = "created_at"
+	ColNameDownloadTaskUpdatedAt           = "updated_at"
+	ColNameDownloadTaskFileSize            = "file_size"
+	ColNameDownloadTaskStartedAt           = "started_at"
+	ColNameDownloadTaskFinishedAt          = "finished_at"
+	// ColNameDownloadTaskHTTPRequestOptions holds a redacted copy of the options, the full options are only stored
+	// encrypted.
+	ColNameDownloadTaskHTTPRequestOptions          = "http_request_options"
+	ColNameDownloadTaskEncryptedHTTPRequestOptions = "encrypted_http_request_options"
 )
 
 type DownloadTaskDataAccessor interface {
 	CreateDownloadTask(ctx context.Context, task DownloadTask) (uint64, error)
-	GetDownloadTaskListOfAccount(ctx context.Context, accountID, offset, limit uint64) ([]DownloadTask, error)
-	GetDownloadTaskCountOfAccount(ctx context.Context, accountID uint64) (uint64, error)
+	// GetDownloadTaskList and GetDownloadTaskCount cover the download tasks of all accounts, unless
+	// filter.OfAccountID or filter.Owner is set.
+	GetDownloadTaskList(ctx context.Context, params GetDownloadTaskListParams) ([]DownloadTask, error)
+	GetDownloadTaskCount(ctx context.Context, filter DownloadTaskListFilter) (uint64, error)
+	// GetDownloadTaskCountByDownloadStatus counts the download tasks of all accounts. Statuses without any download
+	// task are not in the result.
+	GetDownloadTaskCountByDownloadStatus(ctx context.Context) (map[go_load.DownloadStatus]uint64, error)
 	GetDownloadTask(ctx context.Context, id uint64) (DownloadTask, error)
 	GetDownloadTaskWithXLock(ctx context.Context, id uint64) (DownloadTask, error)
 	UpdateDownloadTask(ctx context.Context, task DownloadTask) error
 	DeleteDownloadTask(ctx context.Context, id uint64) error
+	UpdatePendingAndFailedDownloadTaskOfBatchStatusToCancelled(ctx context.Context, owner DownloadTaskOwner, batchID string) (uint64, error)
 	GetPendingDownloadTaskIDList(ctx context.Context) ([]uint64, error)
 	UpdateDownloadingAndFailedDownloadTaskStatusToPending(ctx context.Context) error
+	// GetFinishedDownloadTaskListBefore returns up to limit download tasks that finished before the given time.
+	GetFinishedDownloadTaskListBefore(ctx context.Context, finishedBefore time.Time, limit uint64) ([]DownloadTask, error)
 	WithDatabase(database Database) DownloadTaskDataAccessor
 }
 
 type DownloadTask struct {
-	ID             uint64                 `db:"id" goqu:"skipinsert,skipupdate"`
-	OfAccountID    uint64                 `db:"of_account_id" goqu:"skipupdate"`
-	DownloadType   go_load.DownloadType   `db:"download_type"`
-	URL            string                 `db:"url"`
-	DownloadStatus go_load.DownloadStatus `db:"download_status"`
-	Metadata       JSON                   `db:"metadata"`
+	ID                  uint64                 `db:"id" goqu:"skipinsert,skipupdate"`
+	OfAccountID         uint64                 `db:"of_account_id" goqu:"skipupdate"`
+	OfWorkspaceID       *uint64                `db:"of_workspace_id" goqu:"skipupdate"`
+	DownloadType        go_load.DownloadType   `db:"download_type"`
+	URL                 string                 `db:"url"`
+	DownloadStatus      go_load.DownloadStatus `db:"download_status"`
+	Metadata            JSON                   `db:"metadata"`
+	PostProcessingSteps PostProcessingSteps    `db:"post_processing_steps" goqu:"skipupdate"`
+	BatchID             string                 `db:"batch_id" goqu:"skipupdate"`
+	CreatedAt           time.Time              `db:"created_at" goqu:"skipupdate"`
+	UpdatedAt           time.Time              `db:"updated_at" goqu:"skipupdate"`
+	FileSize            uint64                 `db:"file_size"`
+	StartedAt           *time.Time             `db:"started_at"`
+	FinishedAt          *time.Time             `db:"finished_at"`
+	HTTPRequestOptions  HTTPRequestOptions     `db:"http_request_options" goqu:"skipupdate"`
+	// EncryptedHTTPRequestOptions is empty if the download task has no http request options.
+	EncryptedHTTPRequestOptions []byte `db:"encrypted_http_request_options" goqu:"skipupdate"`
+}
+
+func (d DownloadTask) Owner() DownloadTaskOwner {
+	return DownloadTaskOwner{
+		OfAccountID:   d.OfAccountID,
+		OfWorkspaceID: d.OfWorkspaceID,
+	}
+}
+
+// DownloadTaskOwner is either a workspace, if OfWorkspaceID is set, or the personal space of an account. The
+// personal space of an account does not include the download tasks it created in workspaces.
+type DownloadTaskOwner struct {
+	OfAccountID   uint64
+	OfWorkspaceID *uint64
+}
+
+func (d DownloadTaskOwner) expression() goqu.Expression {
+	if d.OfWorkspaceID != nil {
+		return goqu.C(ColNameDownloadTaskOfWorkspaceID).Eq(*d.OfWorkspaceID)
+	}
+	return goqu.And(
+		goqu.C(ColNameDownloadTaskOfAccountID).Eq(d.OfAccountID),
+		goqu.C(ColNameDownloadTaskOfWorkspaceID).IsNull(),
+	)
+}
+
+// DownloadTaskListFilter filters download tasks. Empty fields, zero ids, zero times and a nil owner do not filter.
+// OfAccountID matches every download task created by the account, including the ones in workspaces.
+type DownloadTaskListFilter struct {
+	Owner              *DownloadTaskOwner
+	OfAccountID        uint64
+	BatchID            string
+	DownloadStatusList []go_load.DownloadStatus
+	DownloadTypeList   []go_load.DownloadType
+	URLContains        string
+	CreatedAfter       time.Time
+	CreatedBefore      time.Time
+	UpdatedAfter       time.Time
+	UpdatedBefore      time.Time
+}
+
+// DownloadTaskListCursor points to the last download task of the previous page, identified by its value of the
+// sort column and its id.
+type DownloadTaskListCursor struct {
+	SortValue any
+	ID        uint64
+}
+type GetDownloadTaskListParams struct {
+	Filter DownloadTaskListFilter
+	// SortColumn is one of ColNameDownloadTaskCreatedAt, ColNameDownloadTaskUpdatedAt, ColNameDownloadTaskFileSize
+	// or ColNameDownloadTaskDownloadStatus. Ties are broken by id.
+	SortColumn string
+	Descending bool
+	// Cursor takes precedence over Offset if it is not nil.
+	Cursor *DownloadTaskListCursor
+	Offset uint64
+	Limit  uint64
 }
 
 type downloadTaskDataAccessor struct {
@@ -90,36 +180,153 @@ func (d downloadTaskDataAccessor) DeleteDownloadTask(ctx context.Context, id uin
 	}
 	return nil
 }
-func (d downloadTaskDataAccessor) GetDownloadTaskCountOfAccount(ctx context.Context, accountID uint64) (uint64, error) {
-	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("account_id", accountID))
+func (d downloadTaskDataAccessor) UpdatePendingAndFailedDownloadTaskOfBatchStatusToCancelled(
+	ctx context.Context,
+	owner DownloadTaskOwner,
+	batchID string,
+) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).
+		With(zap.Any("owner", owner)).
+		With(zap.String("batch_id", batchID))
+
+	result, err := d.database.
+		Update(TabNameDownloadTasks).
+		Set(goqu.Record{
+			ColNameDownloadTaskDownloadStatus: go_load.DownloadStatus_Cancelled,
+			ColNameDownloadTaskFinishedAt:     goqu.L("CURRENT_TIMESTAMP(6)"),
+		}).
+		Where(
+			owner.expression(),
+			goqu.C(ColNameDownloadTaskBatchID).Eq(batchID),
+			goqu.C(ColNameDownloadTaskDownloadStatus).In(go_load.DownloadStatus_Pending, go_load.DownloadStatus_Failed),
+		).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to update pending and failed download task of batch status to cancelled")
+		return 0, status.Error(codes.Internal, "failed to update pending and failed download task of batch status to cancelled")
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get affected row count")
+		return 0, status.Error(codes.Internal, "failed to get affected row count")
+	}
+	return uint64(affectedRowCount), nil
+}
+func (d downloadTaskDataAccessor) getDownloadTaskListExpressionList(filter DownloadTaskListFilter) []goqu.Expression {
+	expressionList := make([]goqu.Expression, 0)
+	if filter.Owner != nil {
+		expressionList = append(expressionList, filter.Owner.expression())
+	}
+	if filter.OfAccountID != 0 {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskOfAccountID).Eq(filter.OfAccountID))
+	}
+	if filter.BatchID != "" {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskBatchID).Eq(filter.BatchID))
+	}
+	if len(filter.DownloadStatusList) > 0 {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskDownloadStatus).In(filter.DownloadStatusList))
+	}
+	if len(filter.DownloadTypeList) > 0 {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskDownloadType).In(filter.DownloadTypeList))
+	}
+	if filter.URLContains != "" {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskURL).ILike("%"+escapeLikePattern(filter.URLContains)+"%"))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskCreatedAt).Gte(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskCreatedAt).Lt(filter.CreatedBefore))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskUpdatedAt).Gte(filter.UpdatedAfter))
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		expressionList = append(expressionList, goqu.C(ColNameDownloadTaskUpdatedAt).Lt(filter.UpdatedBefore))
+	}
+	return expressionList
+}
+
+// getCursorExpression selects the rows after the cursor in the (sort column, id) order.
+func (d downloadTaskDataAccessor) getCursorExpression(sortColumn string, descending bool, cursor DownloadTaskListCursor) goqu.Expression {
+	if descending {
+		return goqu.Or(
+			goqu.C(sortColumn).Lt(cursor.SortValue),
+			goqu.And(goqu.C(sortColumn).Eq(cursor.SortValue), goqu.C(ColNameDownloadTaskID).Lt(cursor.ID)),
+		)
+	}
+	return goqu.Or(
+		goqu.C(sortColumn).Gt(cursor.SortValue),
+		goqu.And(goqu.C(sortColumn).Eq(cursor.SortValue), goqu.C(ColNameDownloadTaskID).Gt(cursor.ID)),
+	)
+}
+func (d downloadTaskDataAccessor) GetDownloadTaskCount(ctx context.Context, filter DownloadTaskListFilter) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Any("filter", filter))
 
 	count, err := d.database.
 		From(TabNameDownloadTasks).
-		Where(goqu.Ex{ColNameDownloadTaskOfAccountID: accountID}).
+		Prepared(true).
+		Where(d.getDownloadTaskListExpressionList(filter)...).
 		CountContext(ctx)
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to count download task of user")
-		return 0, status.Error(codes.Internal, "failed to count download task of user")
+		logger.With(zap.Error(err)).Error("failed to count download task")
+		return 0, status.Error(codes.Internal, "failed to count download task")
 	}
 	return uint64(count), nil
 }
-func (d downloadTaskDataAccessor) GetDownloadTaskListOfAccount(ctx context.Context, accountID uint64, offset uint64, limit uint64) ([]DownloadTask, error) {
-	logger := utils.LoggerWithContext(ctx, d.logger).
-		With(zap.Uint64("account_id", accountID)).
-		With(zap.Uint64("offset", offset)).
-		With(zap.Uint64("limit", limit))
+func (d downloadTaskDataAccessor) GetDownloadTaskCountByDownloadStatus(ctx context.Context) (map[go_load.DownloadStatus]uint64, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger)
 
-	downloadTaskList := make([]DownloadTask, 0)
+	rowList := make([]struct {
+		DownloadStatus go_load.DownloadStatus `db:"download_status"`
+		Count          uint64                 `db:"count"`
+	}, 0)
 	if err := d.database.
+		Select(goqu.C(ColNameDownloadTaskDownloadStatus), goqu.COUNT(goqu.Star()).As("count")).
+		From(TabNameDownloadTasks).
+		GroupBy(goqu.C(ColNameDownloadTaskDownloadStatus)).
+		Executor().
+		ScanStructsContext(ctx, &rowList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to count download task by download status")
+		return nil, status.Error(codes.Internal, "failed to count download task by download status")
+	}
+	countMap := make(map[go_load.DownloadStatus]uint64, len(rowList))
+	for _, row := range rowList {
+		countMap[row.DownloadStatus] = row.Count
+	}
+	return countMap, nil
+}
+func (d downloadTaskDataAccessor) GetDownloadTaskList(ctx context.Context, params GetDownloadTaskListParams) ([]DownloadTask, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Any("params", params))
+
+	expressionList := d.getDownloadTaskListExpressionList(params.Filter)
+	sortColumn := goqu.C(params.SortColumn).Asc()
+	idColumn := goqu.C(ColNameDownloadTaskID).Asc()
+	if params.Descending {
+		sortColumn = goqu.C(params.SortColumn).Desc()
+		idColumn = goqu.C(ColNameDownloadTaskID).Desc()
+	}
+	// The query is prepared so that times keep their fractional seconds, which interpolation would drop.
+	query := d.database.
 		Select().
 		From(TabNameDownloadTasks).
-		Where(goqu.Ex{ColNameAccountPasswordsOfAccountID: accountID}).
-		Offset(uint(offset)).
-		Limit(uint(limit)).
+		Prepared(true).
+		Order(sortColumn, idColumn).
+		Limit(uint(params.Limit))
+	if params.Cursor != nil {
+		expressionList = append(expressionList, d.getCursorExpression(params.SortColumn, params.Descending, *params.Cursor))
+	} else {
+		query = query.Offset(uint(params.Offset))
+	}
+
+	downloadTaskList := make([]DownloadTask, 0)
+	if err := query.
+		Where(expressionList...).
 		Executor().
 		ScanStructsContext(ctx, &downloadTaskList); err != nil {
-		logger.With(zap.Error(err)).Error("failed to get download task list of account")
-		return nil, status.Error(codes.Internal, "failed to get download task list of account")
+		logger.With(zap.Error(err)).Error("failed to get download task list")
+		return nil, status.Error(codes.Internal, "failed to get download task list")
 	}
 	return downloadTaskList, nil
 }
@@ -200,10 +407,11 @@ func (d downloadTaskDataAccessor) UpdateDownloadingAndFailedDownloadTaskStatusTo
 		Update(TabNameDownloadTasks).
 		Set(goqu.Record{
 			ColNameDownloadTaskDownloadStatus: go_load.DownloadStatus_Pending,
+			ColNameDownloadTaskFinishedAt:     nil,
 		}).
 		Where(
 			goqu.C(ColNameDownloadTaskDownloadStatus).
-				In(go_load.DownloadStatus_Pending, go_load.DownloadStatus_Failed),
+				In(go_load.DownloadStatus_Downloading, go_load.DownloadStatus_Failed),
 		).Executor().
 		ExecContext(ctx); err != nil {
 		logger.With(zap.Error(err)).Error("failed to update downloading and failed download task status to pending")
@@ -211,6 +419,30 @@ func (d downloadTaskDataAccessor) UpdateDownloadingAndFailedDownloadTaskStatusTo
 	}
 	return nil
 }
+func (d downloadTaskDataAccessor) GetFinishedDownloadTaskListBefore(
+	ctx context.Context,
+	finishedBefore time.Time,
+	limit uint64,
+) ([]DownloadTask, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).
+		With(zap.Time("finished_before", finishedBefore)).
+		With(zap.Uint64("limit", limit))
+
+	downloadTaskList := make([]DownloadTask, 0)
+	if err := d.database.
+		Select().
+		From(TabNameDownloadTasks).
+		Prepared(true).
+		Where(goqu.C(ColNameDownloadTaskFinishedAt).Lt(finishedBefore)).
+		Order(goqu.C(ColNameDownloadTaskFinishedAt).Asc()).
+		Limit(uint(limit)).
+		Executor().
+		ScanStructsContext(ctx, &downloadTaskList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get finished download task list")
+		return nil, status.Error(codes.Internal, "failed to get finished download task list")
+	}
+	return downloadTaskList, nil
+}
 
 func (d downloadTaskDataAccessor) WithDatabase(database Database) DownloadTaskDataAccessor {
 	return &downloadTaskDataAccessor{
@@ -218,3 +450,9 @@ func (d downloadTaskDataAccessor) WithDatabase(database Database) DownloadTaskDa
 		logger:   d.logger,
 	}
 }
+
+var likePatternReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLikePattern(value string) string {
+	return likePatternReplacer.Replace(value)
+}
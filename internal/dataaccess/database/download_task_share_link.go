@@ -0,0 +1,143 @@
+package database
+
+import (
+	"GoLoad/internal/utils"
+	"context"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	TabNameDownloadTaskShareLinks    = goqu.T("download_task_share_links")
+	ErrDownloadTaskShareLinkNotFound = status.Error(codes.NotFound, "download task share link not found")
+)
+
+const (
+	ColNameDownloadTaskShareLinkID               = "id"
+	ColNameDownloadTaskShareLinkOfDownloadTaskID = "of_download_task_id"
+	ColNameDownloadTaskShareLinkExpireTime       = "expire_time"
+	ColNameDownloadTaskShareLinkMaxDownloadCount = "max_download_count"
+	ColNameDownloadTaskShareLinkDownloadCount    = "download_count"
+	ColNameDownloadTaskShareLinkRevoked          = "revoked"
+)
+
+type DownloadTaskShareLink struct {
+	ID               uint64    `db:"id" goqu:"skipinsert,skipupdate"`
+	OfDownloadTaskID uint64    `db:"of_download_task_id" goqu:"skipupdate"`
+	ExpireTime       time.Time `db:"expire_time"`
+	MaxDownloadCount uint64    `db:"max_download_count"`
+	DownloadCount    uint64    `db:"download_count"`
+	Revoked          bool      `db:"revoked"`
+}
+type DownloadTaskShareLinkDataAccessor interface {
+	CreateDownloadTaskShareLink(ctx context.Context, shareLink DownloadTaskShareLink) (uint64, error)
+	GetDownloadTaskShareLink(ctx context.Context, id uint64) (DownloadTaskShareLink, error)
+	// IncreaseDownloadTaskShareLinkDownloadCount increases the download count of a share link that is not revoked,
+	// not expired and has not reached its max download count. It returns false if no such share link exists.
+	IncreaseDownloadTaskShareLinkDownloadCount(ctx context.Context, id uint64) (bool, error)
+	RevokeDownloadTaskShareLink(ctx context.Context, id uint64) error
+	WithDatabase(database Database) DownloadTaskShareLinkDataAccessor
+}
+type downloadTaskShareLinkDataAccessor struct {
+	database Database
+	logger   *zap.Logger
+}
+
+func NewDownloadTaskShareLinkDataAccessor(database *goqu.Database, logger *zap.Logger) DownloadTaskShareLinkDataAccessor {
+	return &downloadTaskShareLinkDataAccessor{
+		database: database,
+		logger:   logger,
+	}
+}
+func (d downloadTaskShareLinkDataAccessor) CreateDownloadTaskShareLink(ctx context.Context, shareLink DownloadTaskShareLink) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Any("share_link", shareLink))
+
+	result, err := d.database.
+		Insert(TabNameDownloadTaskShareLinks).
+		Rows(shareLink).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create download task share link")
+		return 0, status.Error(codes.Internal, "failed to create download task share link")
+	}
+	lastInsertedID, err := result.LastInsertId()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get last inserted id")
+		return 0, status.Error(codes.Internal, "failed to get last inserted id")
+	}
+	return uint64(lastInsertedID), nil
+}
+func (d downloadTaskShareLinkDataAccessor) GetDownloadTaskShareLink(ctx context.Context, id uint64) (DownloadTaskShareLink, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("id", id))
+
+	shareLink := DownloadTaskShareLink{}
+	found, err := d.database.
+		Select().
+		From(TabNameDownloadTaskShareLinks).
+		Where(goqu.Ex{ColNameDownloadTaskShareLinkID: id}).
+		ScanStructContext(ctx, &shareLink)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get download task share link")
+		return DownloadTaskShareLink{}, status.Error(codes.Internal, "failed to get download task share link")
+	}
+	if !found {
+		logger.Warn("download task share link not found")
+		return DownloadTaskShareLink{}, ErrDownloadTaskShareLinkNotFound
+	}
+	return shareLink, nil
+}
+func (d downloadTaskShareLinkDataAccessor) IncreaseDownloadTaskShareLinkDownloadCount(ctx context.Context, id uint64) (bool, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("id", id))
+
+	result, err := d.database.
+		Update(TabNameDownloadTaskShareLinks).
+		Set(goqu.Record{
+			ColNameDownloadTaskShareLinkDownloadCount: goqu.L("? + 1", goqu.C(ColNameDownloadTaskShareLinkDownloadCount)),
+		}).
+		Where(
+			goqu.C(ColNameDownloadTaskShareLinkID).Eq(id),
+			goqu.C(ColNameDownloadTaskShareLinkRevoked).IsFalse(),
+			goqu.C(ColNameDownloadTaskShareLinkExpireTime).Gt(time.Now()),
+			goqu.Or(
+				goqu.C(ColNameDownloadTaskShareLinkMaxDownloadCount).Eq(0),
+				goqu.C(ColNameDownloadTaskShareLinkDownloadCount).Lt(goqu.C(ColNameDownloadTaskShareLinkMaxDownloadCount)),
+			),
+		).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to increase download task share link download count")
+		return false, status.Error(codes.Internal, "failed to increase download task share link download count")
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get affected row count")
+		return false, status.Error(codes.Internal, "failed to get affected row count")
+	}
+	return affectedRowCount > 0, nil
+}
+func (d downloadTaskShareLinkDataAccessor) RevokeDownloadTaskShareLink(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("id", id))
+
+	if _, err := d.database.
+		Update(TabNameDownloadTaskShareLinks).
+		Set(goqu.Record{ColNameDownloadTaskShareLinkRevoked: true}).
+		Where(goqu.Ex{ColNameDownloadTaskShareLinkID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to revoke download task share link")
+		return status.Error(codes.Internal, "failed to revoke download task share link")
+	}
+	return nil
+}
+func (d downloadTaskShareLinkDataAccessor) WithDatabase(database Database) DownloadTaskShareLinkDataAccessor {
+	return &downloadTaskShareLinkDataAccessor{
+		database: database,
+		logger:   d.logger,
+	}
+}
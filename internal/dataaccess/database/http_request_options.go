@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"GoLoad/internal/generated/grpc/go_load"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// HTTPRequestOptions stores http request options as a protojson encoded message.
+type HTTPRequestOptions struct {
+	Options *go_load.HTTPRequestOptions
+}
+
+func (h *HTTPRequestOptions) Scan(src any) error {
+	var srcBytes []byte
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		srcBytes = src
+	case string:
+		srcBytes = []byte(src)
+	default:
+		return fmt.Errorf("unsupported type for http request options scan: %T", src)
+	}
+	h.Options = new(go_load.HTTPRequestOptions)
+	return protojson.Unmarshal(srcBytes, h.Options)
+}
+func (h HTTPRequestOptions) Value() (driver.Value, error) {
+	if h.Options == nil {
+		return nil, nil
+	}
+	return protojson.Marshal(h.Options)
+}
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS download_task_share_links (
+    id BIGINT UNSIGNED AUTO_INCREMENT,
+    of_download_task_id BIGINT UNSIGNED NOT NULL,
+    expire_time DATETIME NOT NULL,
+    max_download_count BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    download_count BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (id),
+    FOREIGN KEY (of_download_task_id) REFERENCES download_tasks(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS download_task_share_links;
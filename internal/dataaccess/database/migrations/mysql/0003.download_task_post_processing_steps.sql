@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE download_tasks ADD COLUMN post_processing_steps TEXT NULL;
+
+-- +migrate Down
+ALTER TABLE download_tasks DROP COLUMN post_processing_steps;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE download_tasks ADD COLUMN batch_id VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX download_tasks_batch_id ON download_tasks (batch_id);
+
+-- +migrate Down
+DROP INDEX download_tasks_batch_id ON download_tasks;
+
+ALTER TABLE download_tasks DROP COLUMN batch_id;
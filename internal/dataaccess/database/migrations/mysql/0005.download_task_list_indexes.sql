@@ -0,0 +1,25 @@
+-- +migrate Up
+ALTER TABLE download_tasks
+    ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    ADD COLUMN file_size BIGINT UNSIGNED NOT NULL DEFAULT 0;
+
+CREATE INDEX download_tasks_of_account_id_created_at_id ON download_tasks (of_account_id, created_at, id);
+
+CREATE INDEX download_tasks_of_account_id_updated_at_id ON download_tasks (of_account_id, updated_at, id);
+
+CREATE INDEX download_tasks_of_account_id_file_size_id ON download_tasks (of_account_id, file_size, id);
+
+CREATE INDEX download_tasks_of_account_id_download_status_id ON download_tasks (of_account_id, download_status, id);
+
+-- +migrate Down
+-- The foreign key on of_account_id needs an index once the composite ones are gone.
+ALTER TABLE download_tasks
+    ADD INDEX download_tasks_of_account_id (of_account_id),
+    DROP INDEX download_tasks_of_account_id_download_status_id,
+    DROP INDEX download_tasks_of_account_id_file_size_id,
+    DROP INDEX download_tasks_of_account_id_updated_at_id,
+    DROP INDEX download_tasks_of_account_id_created_at_id,
+    DROP COLUMN file_size,
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at;
@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE download_tasks
+    ADD COLUMN started_at DATETIME(6) NULL,
+    ADD COLUMN finished_at DATETIME(6) NULL;
+
+CREATE INDEX download_tasks_finished_at ON download_tasks (finished_at);
+
+-- +migrate Down
+DROP INDEX download_tasks_finished_at ON download_tasks;
+
+ALTER TABLE download_tasks
+    DROP COLUMN finished_at,
+    DROP COLUMN started_at;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE accounts
+    ADD COLUMN role SMALLINT NOT NULL DEFAULT 1,
+    ADD COLUMN disabled_at DATETIME(6) NULL;
+
+-- +migrate Down
+ALTER TABLE accounts
+    DROP COLUMN disabled_at,
+    DROP COLUMN role;
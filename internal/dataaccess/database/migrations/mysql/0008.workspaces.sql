@@ -0,0 +1,30 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS workspaces (
+    id BIGINT UNSIGNED AUTO_INCREMENT,
+    workspace_name VARCHAR(256) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    of_workspace_id BIGINT UNSIGNED NOT NULL,
+    of_account_id BIGINT UNSIGNED NOT NULL,
+    role SMALLINT NOT NULL,
+    PRIMARY KEY (of_workspace_id, of_account_id),
+    INDEX workspace_members_of_account_id (of_account_id),
+    FOREIGN KEY (of_workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (of_account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+ALTER TABLE download_tasks
+    ADD COLUMN of_workspace_id BIGINT UNSIGNED NULL,
+    ADD CONSTRAINT download_tasks_of_workspace_id FOREIGN KEY (of_workspace_id) REFERENCES workspaces(id);
+
+-- +migrate Down
+ALTER TABLE download_tasks
+    DROP FOREIGN KEY download_tasks_of_workspace_id,
+    DROP COLUMN of_workspace_id;
+
+DROP TABLE IF EXISTS workspace_members;
+
+DROP TABLE IF EXISTS workspaces;
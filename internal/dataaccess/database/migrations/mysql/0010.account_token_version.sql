@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE accounts
+    ADD COLUMN token_version BIGINT UNSIGNED NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE accounts
+    DROP COLUMN token_version;
@@ -0,0 +1,20 @@
+-- +migrate Up
+-- Keys created before this migration have no private key, they stop signing now and retire once their tokens expire.
+ALTER TABLE token_public_keys
+    ADD COLUMN algorithm VARCHAR(16) NOT NULL DEFAULT 'RS512',
+    ADD COLUMN encrypted_private_key BLOB NULL,
+    ADD COLUMN signing_starts_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN signing_ends_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
+
+CREATE INDEX token_public_keys_signing_ends_at ON token_public_keys (signing_ends_at);
+
+-- +migrate Down
+DROP INDEX token_public_keys_signing_ends_at ON token_public_keys;
+
+ALTER TABLE token_public_keys
+    DROP COLUMN created_at,
+    DROP COLUMN signing_ends_at,
+    DROP COLUMN signing_starts_at,
+    DROP COLUMN encrypted_private_key,
+    DROP COLUMN algorithm;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS account_identities (
+    id BIGINT UNSIGNED AUTO_INCREMENT,
+    of_account_id BIGINT UNSIGNED NOT NULL,
+    issuer VARCHAR(256) NOT NULL,
+    subject VARCHAR(256) NOT NULL,
+    email VARCHAR(320) NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY account_identities_issuer_subject (issuer, subject),
+    INDEX account_identities_of_account_id (of_account_id),
+    FOREIGN KEY (of_account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS account_identities;
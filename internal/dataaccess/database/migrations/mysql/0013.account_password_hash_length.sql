@@ -0,0 +1,8 @@
+-- +migrate Up
+-- argon2id hashes carry their parameters and salt, and grow with them.
+ALTER TABLE account_passwords
+    MODIFY hash VARCHAR(512) NOT NULL;
+
+-- +migrate Down
+ALTER TABLE account_passwords
+    MODIFY hash VARCHAR(128) NOT NULL;
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- http_request_options is the redacted copy that is shown back, the full options are only stored encrypted.
+ALTER TABLE download_tasks
+    ADD COLUMN http_request_options TEXT NULL,
+    ADD COLUMN encrypted_http_request_options MEDIUMBLOB NULL;
+
+-- +migrate Down
+ALTER TABLE download_tasks
+    DROP COLUMN http_request_options,
+    DROP COLUMN encrypted_http_request_options;
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"GoLoad/internal/generated/grpc/go_load"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// PostProcessingSteps stores a list of post-processing steps as a JSON array of protojson encoded messages.
+type PostProcessingSteps struct {
+	Steps []*go_load.PostProcessingStep
+}
+
+func (p *PostProcessingSteps) Scan(src any) error {
+	var srcBytes []byte
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		srcBytes = src
+	case string:
+		srcBytes = []byte(src)
+	default:
+		return fmt.Errorf("unsupported type for post processing steps scan: %T", src)
+	}
+	rawSteps := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(srcBytes, &rawSteps); err != nil {
+		return err
+	}
+	p.Steps = make([]*go_load.PostProcessingStep, 0, len(rawSteps))
+	for _, rawStep := range rawSteps {
+		step := new(go_load.PostProcessingStep)
+		if err := protojson.Unmarshal(rawStep, step); err != nil {
+			return err
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return nil
+}
+func (p PostProcessingSteps) Value() (driver.Value, error) {
+	if len(p.Steps) == 0 {
+		return nil, nil
+	}
+	rawSteps := make([]json.RawMessage, 0, len(p.Steps))
+	for _, step := range p.Steps {
+		rawStep, err := protojson.Marshal(step)
+		if err != nil {
+			return nil, err
+		}
+		rawSteps = append(rawSteps, rawStep)
+	}
+	return json.Marshal(rawSteps)
+}
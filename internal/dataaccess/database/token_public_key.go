@@ -4,6 +4,7 @@ import (
 	"GoLoad/internal/utils"
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"go.uber.org/zap"
@@ -12,18 +13,35 @@ import (
 )
 
 const (
-	TabNameTokenPublicKeys          = "token_public_keys"
-	ColNameTokenPublicKeysID        = "id"
-	ColNameTokenPublicKeysPublicKey = "public_key"
+	TabNameTokenPublicKeys                    = "token_public_keys"
+	ColNameTokenPublicKeysID                  = "id"
+	ColNameTokenPublicKeysPublicKey           = "public_key"
+	ColNameTokenPublicKeysAlgorithm           = "algorithm"
+	ColNameTokenPublicKeysEncryptedPrivateKey = "encrypted_private_key"
+	ColNameTokenPublicKeysSigningStartsAt     = "signing_starts_at"
+	ColNameTokenPublicKeysSigningEndsAt       = "signing_ends_at"
+	ColNameTokenPublicKeysCreatedAt           = "created_at"
 )
 
+// TokenPublicKey is a token signing key. Keys sign new tokens between SigningStartsAt and SigningEndsAt, and their
+// public key is kept until the last token they signed expires.
 type TokenPublicKey struct {
 	ID        uint64 `db:"id" goqu:"skipinsert,skipupdate"`
 	PublicKey []byte `db:"public_key"`
+	Algorithm string `db:"algorithm"`
+	// EncryptedPrivateKey is nil for the keys created before signing keys were stored.
+	EncryptedPrivateKey []byte    `db:"encrypted_private_key"`
+	SigningStartsAt     time.Time `db:"signing_starts_at"`
+	SigningEndsAt       time.Time `db:"signing_ends_at"`
+	CreatedAt           time.Time `db:"created_at"`
 }
 type TokenPublicKeyDataAccessor interface {
 	CreatePublicKey(ctx context.Context, tokenPublicKey TokenPublicKey) (uint64, error)
 	GetPublicKey(ctx context.Context, id uint64) (TokenPublicKey, error)
+	// GetPublicKeyListSigningAfter returns the keys that stop signing after signingEndsAfter, oldest first.
+	GetPublicKeyListSigningAfter(ctx context.Context, signingEndsAfter time.Time) ([]TokenPublicKey, error)
+	// DeletePublicKeysSigningBefore deletes the keys that stopped signing at or before signingEndsBefore.
+	DeletePublicKeysSigningBefore(ctx context.Context, signingEndsBefore time.Time) (uint64, error)
 	WithDatabase(database Database) TokenPublicKeyDataAccessor
 }
 type tokenPublicKeyDataAccessor struct {
@@ -43,7 +61,12 @@ func (a tokenPublicKeyDataAccessor) CreatePublicKey(ctx context.Context, tokenPu
 	result, err := a.database.
 		Insert(TabNameTokenPublicKeys).
 		Rows(goqu.Record{
-			ColNameTokenPublicKeysPublicKey: tokenPublicKey.PublicKey,
+			ColNameTokenPublicKeysPublicKey:           tokenPublicKey.PublicKey,
+			ColNameTokenPublicKeysAlgorithm:           tokenPublicKey.Algorithm,
+			ColNameTokenPublicKeysEncryptedPrivateKey: tokenPublicKey.EncryptedPrivateKey,
+			ColNameTokenPublicKeysSigningStartsAt:     tokenPublicKey.SigningStartsAt,
+			ColNameTokenPublicKeysSigningEndsAt:       tokenPublicKey.SigningEndsAt,
+			ColNameTokenPublicKeysCreatedAt:           tokenPublicKey.CreatedAt,
 		}).
 		Executor().
 		ExecContext(ctx)
@@ -80,6 +103,44 @@ func (a tokenPublicKeyDataAccessor) GetPublicKey(ctx context.Context, id uint64)
 	}
 	return tokenPublicKey, nil
 }
+func (a tokenPublicKeyDataAccessor) GetPublicKeyListSigningAfter(
+	ctx context.Context,
+	signingEndsAfter time.Time,
+) ([]TokenPublicKey, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Time("signing_ends_after", signingEndsAfter))
+
+	tokenPublicKeyList := make([]TokenPublicKey, 0)
+	if err := a.database.
+		Select().
+		From(TabNameTokenPublicKeys).
+		Where(goqu.C(ColNameTokenPublicKeysSigningEndsAt).Gt(signingEndsAfter)).
+		Order(goqu.C(ColNameTokenPublicKeysSigningStartsAt).Asc(), goqu.C(ColNameTokenPublicKeysID).Asc()).
+		Executor().
+		ScanStructsContext(ctx, &tokenPublicKeyList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get public key list")
+		return nil, status.Error(codes.Internal, "failed to get public key list")
+	}
+	return tokenPublicKeyList, nil
+}
+func (a tokenPublicKeyDataAccessor) DeletePublicKeysSigningBefore(ctx context.Context, signingEndsBefore time.Time) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Time("signing_ends_before", signingEndsBefore))
+
+	result, err := a.database.
+		Delete(TabNameTokenPublicKeys).
+		Where(goqu.C(ColNameTokenPublicKeysSigningEndsAt).Lte(signingEndsBefore)).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete public keys")
+		return 0, status.Error(codes.Internal, "failed to delete public keys")
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get affected row count")
+		return 0, status.Error(codes.Internal, "failed to get affected row count")
+	}
+	return uint64(affectedRowCount), nil
+}
 func (a tokenPublicKeyDataAccessor) WithDatabase(database Database) TokenPublicKeyDataAccessor {
 	a.database = database
 	return a
@@ -12,4 +12,9 @@ var WireSet = wire.NewSet(
 	NewAccountPasswordDataAccessor,
 	NewDownloadTaskDataAccessor,
 	NewTokenPublicKeyDataAccessor,
+	NewDownloadTaskShareLinkDataAccessor,
+	NewWorkspaceDataAccessor,
+	NewWorkspaceMemberDataAccessor,
+	NewAPIKeyDataAccessor,
+	NewAccountIdentityDataAccessor,
 )
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	TabNameWorkspaces    = goqu.T("workspaces")
+	ErrWorkspaceNotFound = status.Error(codes.NotFound, "workspace not found")
+)
+
+const (
+	ColNameWorkspaceID            = "id"
+	ColNameWorkspaceWorkspaceName = "workspace_name"
+	ColNameWorkspaceCreatedAt     = "created_at"
+)
+
+type Workspace struct {
+	ID            uint64    `db:"id" goqu:"skipinsert,skipupdate"`
+	WorkspaceName string    `db:"workspace_name"`
+	CreatedAt     time.Time `db:"created_at" goqu:"skipupdate"`
+}
+
+// WorkspaceOfAccount is a workspace along with the role of an account in it.
+type WorkspaceOfAccount struct {
+	Workspace
+	Role go_load.WorkspaceRole `db:"role"`
+}
+type WorkspaceDataAccessor interface {
+	CreateWorkspace(ctx context.Context, workspace Workspace) (uint64, error)
+	GetWorkspace(ctx context.Context, id uint64) (Workspace, error)
+	// GetWorkspaceWithXLock locks the workspace until the end of the transaction, which serializes the changes to its
+	// members.
+	GetWorkspaceWithXLock(ctx context.Context, id uint64) (Workspace, error)
+	GetWorkspaceListOfAccount(ctx context.Context, accountID uint64) ([]WorkspaceOfAccount, error)
+	// DeleteWorkspace deletes the members of the workspace along with it. It fails while the workspace still has
+	// download tasks.
+	DeleteWorkspace(ctx context.Context, id uint64) error
+	WithDatabase(database Database) WorkspaceDataAccessor
+}
+type workspaceDataAccessor struct {
+	database Database
+	logger   *zap.Logger
+}
+
+func NewWorkspaceDataAccessor(database *goqu.Database, logger *zap.Logger) WorkspaceDataAccessor {
+	return &workspaceDataAccessor{
+		database: database,
+		logger:   logger,
+	}
+}
+func (w workspaceDataAccessor) CreateWorkspace(ctx context.Context, workspace Workspace) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, w.logger).With(zap.Any("workspace", workspace))
+
+	result, err := w.database.
+		Insert(TabNameWorkspaces).
+		Rows(workspace).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create workspace")
+		return 0, status.Error(codes.Internal, "failed to create workspace")
+	}
+	lastInsertedID, err := result.LastInsertId()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get last inserted id")
+		return 0, status.Error(codes.Internal, "failed to get last inserted id")
+	}
+	return uint64(lastInsertedID), nil
+}
+func (w workspaceDataAccessor) getWorkspace(ctx context.Context, id uint64, withXLock bool) (Workspace, error) {
+	logger := utils.LoggerWithContext(ctx, w.logger).With(zap.Uint64("id", id))
+
+	query := w.database.
+		Select().
+		From(TabNameWorkspaces).
+		Where(goqu.Ex{ColNameWorkspaceID: id})
+	if withXLock {
+		query = query.ForUpdate(goqu.Wait)
+	}
+	workspace := Workspace{}
+	found, err := query.ScanStructContext(ctx, &workspace)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get workspace")
+		return Workspace{}, status.Error(codes.Internal, "failed to get workspace")
+	}
+	if !found {
+		logger.Warn("workspace not found")
+		return Workspace{}, ErrWorkspaceNotFound
+	}
+	return workspace, nil
+}
+func (w workspaceDataAccessor) GetWorkspace(ctx context.Context, id uint64) (Workspace, error) {
+	return w.getWorkspace(ctx, id, false)
+}
+func (w workspaceDataAccessor) GetWorkspaceWithXLock(ctx context.Context, id uint64) (Workspace, error) {
+	return w.getWorkspace(ctx, id, true)
+}
+func (w workspaceDataAccessor) GetWorkspaceListOfAccount(ctx context.Context, accountID uint64) ([]WorkspaceOfAccount, error) {
+	logger := utils.LoggerWithContext(ctx, w.logger).With(zap.Uint64("account_id", accountID))
+
+	workspaceList := make([]WorkspaceOfAccount, 0)
+	if err := w.database.
+		Select(
+			TabNameWorkspaces.Col(ColNameWorkspaceID),
+			TabNameWorkspaces.Col(ColNameWorkspaceWorkspaceName),
+			TabNameWorkspaces.Col(ColNameWorkspaceCreatedAt),
+			TabNameWorkspaceMembers.Col(ColNameWorkspaceMemberRole),
+		).
+		From(TabNameWorkspaces).
+		Join(TabNameWorkspaceMembers, goqu.On(
+			TabNameWorkspaceMembers.Col(ColNameWorkspaceMemberOfWorkspaceID).Eq(TabNameWorkspaces.Col(ColNameWorkspaceID)),
+		)).
+		Where(TabNameWorkspaceMembers.Col(ColNameWorkspaceMemberOfAccountID).Eq(accountID)).
+		Order(TabNameWorkspaces.Col(ColNameWorkspaceID).Asc()).
+		Executor().
+		ScanStructsContext(ctx, &workspaceList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to get workspace list of account")
+		return nil, status.Error(codes.Internal, "failed to get workspace list of account")
+	}
+	return workspaceList, nil
+}
+func (w workspaceDataAccessor) DeleteWorkspace(ctx context.Context, id uint64) error {
+	logger := utils.LoggerWithContext(ctx, w.logger).With(zap.Uint64("id", id))
+
+	if _, err := w.database.
+		Delete(TabNameWorkspaces).
+		Where(goqu.Ex{ColNameWorkspaceID: id}).
+		Executor().
+		ExecContext(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete workspace")
+		return status.Error(codes.Internal, "failed to delete workspace")
+	}
+	return nil
+}
+func (w workspaceDataAccessor) WithDatabase(database Database) WorkspaceDataAccessor {
+	return &workspaceDataAccessor{
+		database: database,
+		logger:   w.logger,
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
 	"os"
 	"path"
+	"sync"
+	"time"
 
 	"GoLoad/internal/configs"
 	"GoLoad/internal/utils"
@@ -18,20 +22,95 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+var (
+	ErrPresignedURLNotSupported = status.Error(codes.Unimplemented, "presigned url is not supported")
+	errInvalidRange             = status.Error(codes.InvalidArgument, "invalid range")
+)
+
+type FileInfo struct {
+	Size    int64
+	ModTime time.Time
+	ETag    string
+}
+
 type Client interface {
 	Write(ctx context.Context, filePath string) (io.WriteCloser, error)
 	Read(ctx context.Context, filePath string) (io.ReadCloser, error)
+	// ReadRange reads length bytes starting at offset. A length of 0 reads until the end of the file.
+	ReadRange(ctx context.Context, filePath string, offset, length uint64) (io.ReadCloser, error)
+	Stat(ctx context.Context, filePath string) (FileInfo, error)
+	// GetPresignedURL returns a URL the file can be read from without credentials, or ErrPresignedURLNotSupported.
+	GetPresignedURL(ctx context.Context, filePath string, expiresIn time.Duration) (string, error)
+	// Delete removes the file. Deleting a file that does not exist is not an error.
+	Delete(ctx context.Context, filePath string) error
+	// Ping checks that the storage backend is reachable.
+	Ping(ctx context.Context) error
 }
 
-func NewClient(downloadConfig configs.Download, logger *zap.Logger) (Client, error) {
+func NewClient(downloadConfig configs.Download, metrics utils.Metrics, logger *zap.Logger) (Client, error) {
+	var (
+		client Client
+		err    error
+	)
 	switch downloadConfig.Mode {
 	case configs.DownloadModeLocal:
-		return NewLocalClient(downloadConfig, logger)
+		client, err = NewLocalClient(downloadConfig, logger)
 	case configs.DownloadModeS3:
-		return NewS3Client(downloadConfig, logger)
+		client, err = NewS3Client(downloadConfig, logger)
 	default:
 		return nil, fmt.Errorf("unsupported download mode: %s", downloadConfig.Mode)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return newMetricsClient(client, string(downloadConfig.Mode), metrics), nil
+}
+
+// metricsClient records the write latency of the wrapped client's backend.
+type metricsClient struct {
+	Client
+	backend string
+	metrics utils.Metrics
+}
+
+func newMetricsClient(client Client, backend string, metrics utils.Metrics) Client {
+	return &metricsClient{
+		Client:  client,
+		backend: backend,
+		metrics: metrics,
+	}
+}
+func (m metricsClient) Write(ctx context.Context, filePath string) (io.WriteCloser, error) {
+	writeCloser, err := m.Client.Write(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsWriteCloser{
+		writeCloser: writeCloser,
+		backend:     m.backend,
+		metrics:     m.metrics,
+	}, nil
+}
+
+type metricsWriteCloser struct {
+	writeCloser io.WriteCloser
+	backend     string
+	metrics     utils.Metrics
+}
+
+func (m metricsWriteCloser) Write(p []byte) (int, error) {
+	startTime := time.Now()
+	n, err := m.writeCloser.Write(p)
+	m.metrics.ObserveStorageWrite(m.backend, "write", time.Since(startTime))
+	return n, err
+}
+
+// Close is observed separately since it is where buffered data is flushed and, for s3, where the upload completes.
+func (m metricsWriteCloser) Close() error {
+	startTime := time.Now()
+	err := m.writeCloser.Close()
+	m.metrics.ObserveStorageWrite(m.backend, "close", time.Since(startTime))
+	return err
 }
 
 type bufferedFileReader struct {
@@ -45,6 +124,28 @@ func newBufferedFileReader(file *os.File) io.ReadCloser {
 		bufferedReader: bufio.NewReader(file),
 	}
 }
+func newBufferedFileRangeReader(file *os.File, length uint64) io.ReadCloser {
+	var reader io.Reader = file
+	if length > 0 {
+		reader = io.LimitReader(file, int64(length))
+	}
+	return &bufferedFileReader{
+		file:           file,
+		bufferedReader: bufio.NewReader(reader),
+	}
+}
+
+// clampRangeLength fails if the offset does not fit in an int64, and returns 0, meaning until the end of the file, if
+// offset+length does not either, since no file reaches that far.
+func clampRangeLength(offset, length uint64) (uint64, error) {
+	if offset > math.MaxInt64 {
+		return 0, errInvalidRange
+	}
+	if length > math.MaxInt64-offset {
+		return 0, nil
+	}
+	return length, nil
+}
 func (b bufferedFileReader) Close() error {
 	return b.file.Close()
 }
@@ -68,6 +169,16 @@ func NewLocalClient(downloadConfig configs.Download, logger *zap.Logger) (Client
 		logger:            logger,
 	}, nil
 }
+func (l LocalClient) Ping(context.Context) error {
+	fileInfo, err := os.Stat(l.downloadDirectory)
+	if err != nil {
+		return err
+	}
+	if !fileInfo.IsDir() {
+		return fmt.Errorf("download directory %s is not a directory", l.downloadDirectory)
+	}
+	return nil
+}
 func (l LocalClient) Read(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	logger := utils.LoggerWithContext(ctx, l.logger).With(zap.String("file_path", filePath))
 
@@ -79,10 +190,65 @@ func (l LocalClient) Read(ctx context.Context, filePath string) (io.ReadCloser,
 	}
 	return newBufferedFileReader(file), nil
 }
+func (l LocalClient) ReadRange(ctx context.Context, filePath string, offset, length uint64) (io.ReadCloser, error) {
+	logger := utils.LoggerWithContext(ctx, l.logger).
+		With(zap.String("file_path", filePath)).
+		With(zap.Uint64("offset", offset)).
+		With(zap.Uint64("length", length))
+
+	length, err := clampRangeLength(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	absolutePath := path.Join(l.downloadDirectory, filePath)
+	file, err := os.Open(absolutePath)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to open file")
+		return nil, status.Error(codes.Internal, "failed to open file")
+	}
+	if _, err := file.Seek(int64(offset), io.SeekStart); err != nil {
+		file.Close()
+		logger.With(zap.Error(err)).Error("failed to seek file")
+		return nil, status.Error(codes.Internal, "failed to seek file")
+	}
+	return newBufferedFileRangeReader(file, length), nil
+}
+func (l LocalClient) Stat(ctx context.Context, filePath string) (FileInfo, error) {
+	logger := utils.LoggerWithContext(ctx, l.logger).With(zap.String("file_path", filePath))
+
+	absolutePath := path.Join(l.downloadDirectory, filePath)
+	fileInfo, err := os.Stat(absolutePath)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to stat file")
+		return FileInfo{}, status.Error(codes.Internal, "failed to stat file")
+	}
+	return FileInfo{
+		Size:    fileInfo.Size(),
+		ModTime: fileInfo.ModTime(),
+		ETag:    fmt.Sprintf(`"%x-%x"`, fileInfo.ModTime().UnixNano(), fileInfo.Size()),
+	}, nil
+}
+func (l LocalClient) GetPresignedURL(_ context.Context, _ string, _ time.Duration) (string, error) {
+	return "", ErrPresignedURLNotSupported
+}
+func (l LocalClient) Delete(ctx context.Context, filePath string) error {
+	logger := utils.LoggerWithContext(ctx, l.logger).With(zap.String("file_path", filePath))
+
+	absolutePath := path.Join(l.downloadDirectory, filePath)
+	if err := os.Remove(absolutePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.With(zap.Error(err)).Error("failed to delete file")
+		return status.Error(codes.Internal, "failed to delete file")
+	}
+	return nil
+}
 func (l *LocalClient) Write(ctx context.Context, filePath string) (io.WriteCloser, error) {
 	logger := utils.LoggerWithContext(ctx, l.logger).With(zap.String("file_path", filePath))
 
 	absolutePath := path.Join(l.downloadDirectory, filePath)
+	if err := os.MkdirAll(path.Dir(absolutePath), os.ModePerm); err != nil {
+		logger.With(zap.Error(err)).Error("failed to create parent directory")
+		return nil, status.Error(codes.Internal, "failed to create parent directory")
+	}
 	file, err := os.Create(absolutePath)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to open file")
@@ -91,45 +257,48 @@ func (l *LocalClient) Write(ctx context.Context, filePath string) (io.WriteClose
 	return file, nil
 }
 
-type s3ClientReadWriteCloser struct {
-	writtenData []byte
-	isClosed    bool
+// s3ClientWriteCloser streams written data into a PutObject call running in the background. Close waits for
+// the upload to finish, so the object can be read as soon as Close returns.
+type s3ClientWriteCloser struct {
+	pipeWriter *io.PipeWriter
+	doneChan   chan error
+	closeOnce  *sync.Once
+	closeErr   error
 }
 
-func newS3ClientReadWriteCloser(ctx context.Context, minioClient *minio.Client, logger *zap.Logger, bucketName, objectName string) io.ReadWriteCloser {
+func newS3ClientWriteCloser(ctx context.Context, minioClient *minio.Client, logger *zap.Logger, bucketName, objectName string) io.WriteCloser {
 	logger = utils.LoggerWithContext(ctx, logger)
 
-	readWriteCloser := &s3ClientReadWriteCloser{
-		writtenData: make([]byte, 0),
-		isClosed:    false,
+	pipeReader, pipeWriter := io.Pipe()
+	writeCloser := &s3ClientWriteCloser{
+		pipeWriter: pipeWriter,
+		doneChan:   make(chan error, 1),
+		closeOnce:  new(sync.Once),
 	}
 	go func() {
-		if _, err := minioClient.PutObjectWithContext(
-			ctx, bucketName, objectName, readWriteCloser, -1, minio.PutObjectOptions{},
-		); err != nil {
+		_, err := minioClient.PutObjectWithContext(ctx, bucketName, objectName, pipeReader, -1, minio.PutObjectOptions{})
+		if err != nil {
 			logger.With(zap.Error(err)).Error("failed to put object")
 		}
+		pipeReader.CloseWithError(err)
+		writeCloser.doneChan <- err
 	}()
-	return readWriteCloser
+	return writeCloser
 }
-func (s *s3ClientReadWriteCloser) Close() error {
-	s.isClosed = true
-	return nil
-}
-func (s *s3ClientReadWriteCloser) Read(p []byte) (int, error) {
-	if len(s.writtenData) > 0 {
-		writtenLength := copy(p, s.writtenData)
-		s.writtenData = s.writtenData[writtenLength:]
-		return writtenLength, nil
-	}
-	if s.isClosed {
-		return 0, io.EOF
-	}
-	return 0, nil
+func (s *s3ClientWriteCloser) Close() error {
+	s.closeOnce.Do(func() {
+		if err := s.pipeWriter.Close(); err != nil {
+			s.closeErr = err
+			return
+		}
+		if err := <-s.doneChan; err != nil {
+			s.closeErr = status.Error(codes.Internal, "failed to put object")
+		}
+	})
+	return s.closeErr
 }
-func (s *s3ClientReadWriteCloser) Write(p []byte) (int, error) {
-	s.writtenData = append(s.writtenData, p...)
-	return len(p), nil
+func (s *s3ClientWriteCloser) Write(p []byte) (int, error) {
+	return s.pipeWriter.Write(p)
 }
 
 type S3Client struct {
@@ -139,17 +308,38 @@ type S3Client struct {
 }
 
 func NewS3Client(downloadConfig configs.Download, logger *zap.Logger) (Client, error) {
-	minioClient, err := minio.New(downloadConfig.Address, downloadConfig.Username, downloadConfig.Password, false)
+	tlsConfig, err := utils.NewClientTLSConfig(downloadConfig.TLS, logger)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create s3 tls config")
+		return nil, err
+	}
+	minioClient, err := minio.New(downloadConfig.Address, downloadConfig.Username, downloadConfig.Password, tlsConfig != nil)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to create minio client")
 		return nil, err
 	}
+	if tlsConfig != nil {
+		transport, _ := http.DefaultTransport.(*http.Transport)
+		transport = transport.Clone()
+		transport.TLSClientConfig = tlsConfig
+		minioClient.SetCustomTransport(transport)
+	}
 	return &S3Client{
 		minioClient: minioClient,
 		bucket:      downloadConfig.Bucket,
 		logger:      logger,
 	}, nil
 }
+func (s S3Client) Ping(context.Context) error {
+	exists, err := s.minioClient.BucketExists(s.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", s.bucket)
+	}
+	return nil
+}
 func (s S3Client) Read(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	logger := utils.LoggerWithContext(ctx, s.logger).With(zap.String("file_path", filePath))
 
@@ -160,7 +350,68 @@ func (s S3Client) Read(ctx context.Context, filePath string) (io.ReadCloser, err
 	}
 	return object, nil
 }
+func (s S3Client) ReadRange(ctx context.Context, filePath string, offset, length uint64) (io.ReadCloser, error) {
+	logger := utils.LoggerWithContext(ctx, s.logger).
+		With(zap.String("file_path", filePath)).
+		With(zap.Uint64("offset", offset)).
+		With(zap.Uint64("length", length))
+
+	length, err := clampRangeLength(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	options := minio.GetObjectOptions{}
+	if offset > 0 || length > 0 {
+		end := int64(0)
+		if length > 0 {
+			end = int64(offset + length - 1)
+		}
+		if err := options.SetRange(int64(offset), end); err != nil {
+			logger.With(zap.Error(err)).Error("failed to set s3 object range")
+			return nil, status.Error(codes.InvalidArgument, "invalid range")
+		}
+	}
+	object, err := s.minioClient.GetObjectWithContext(ctx, s.bucket, filePath, options)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get s3 object")
+		return nil, status.Error(codes.Internal, "failed to get s3 object")
+	}
+	return object, nil
+}
+func (s S3Client) Stat(ctx context.Context, filePath string) (FileInfo, error) {
+	logger := utils.LoggerWithContext(ctx, s.logger).With(zap.String("file_path", filePath))
+
+	objectInfo, err := s.minioClient.StatObject(s.bucket, filePath, minio.StatObjectOptions{})
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to stat s3 object")
+		return FileInfo{}, status.Error(codes.Internal, "failed to stat s3 object")
+	}
+	return FileInfo{
+		Size:    objectInfo.Size,
+		ModTime: objectInfo.LastModified,
+		ETag:    fmt.Sprintf(`"%s"`, objectInfo.ETag),
+	}, nil
+}
+func (s S3Client) GetPresignedURL(ctx context.Context, filePath string, expiresIn time.Duration) (string, error) {
+	logger := utils.LoggerWithContext(ctx, s.logger).With(zap.String("file_path", filePath))
+
+	presignedURL, err := s.minioClient.PresignedGetObject(s.bucket, filePath, expiresIn, nil)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to presign s3 object url")
+		return "", status.Error(codes.Internal, "failed to presign s3 object url")
+	}
+	return presignedURL.String(), nil
+}
+func (s S3Client) Delete(ctx context.Context, filePath string) error {
+	logger := utils.LoggerWithContext(ctx, s.logger).With(zap.String("file_path", filePath))
+
+	if err := s.minioClient.RemoveObject(s.bucket, filePath); err != nil {
+		logger.With(zap.Error(err)).Error("failed to delete s3 object")
+		return status.Error(codes.Internal, "failed to delete s3 object")
+	}
+	return nil
+}
 
 func (s S3Client) Write(ctx context.Context, filePath string) (io.WriteCloser, error) {
-	return newS3ClientReadWriteCloser(ctx, s.minioClient, s.logger, s.bucket, filePath), nil
+	return newS3ClientWriteCloser(ctx, s.minioClient, s.logger, s.bucket, filePath), nil
 }
@@ -0,0 +1,391 @@
+package file
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	EncryptionAlgorithmAES256GCMChunked = "AES256-GCM-CHUNKED"
+
+	dataKeyByteCount = 32
+	// gcmTagByteCount is what AES-GCM adds to each chunk.
+	gcmTagByteCount = 16
+	// The nonce of each chunk is the nonce prefix of the file, followed by the index of the chunk and a byte that
+	// marks the last chunk, so that chunks can be neither reordered nor dropped from the end.
+	chunkNonceSuffixByteCount = 5
+	maxEncryptionChunkSize    = 64 << 20
+)
+
+var (
+	errInvalidEncryptionChunkSize     = fmt.Errorf("encryption chunk size must be between 1 and %d bytes", maxEncryptionChunkSize)
+	errInvalidEncryptedFile           = errors.New("invalid encrypted file")
+	errEncryptedFileTooLarge          = errors.New("encrypted file has too many chunks")
+	errUnsupportedEncryptionAlgorithm = errors.New("unsupported encryption algorithm")
+)
+
+// EncryptionMetadata is what it takes to decrypt a file besides the master key. It is not stored in the file, but
+// along with what the file belongs to.
+//
+// The file is split into chunks of ChunkSize bytes, the last one possibly shorter, each sealed with AES-GCM using
+// the data key of the file. Ranges of the file can then be read by decrypting only the chunks they overlap.
+type EncryptionMetadata struct {
+	Algorithm      string `json:"algorithm"`
+	MasterKeyID    string `json:"master_key_id"`
+	WrappedDataKey []byte `json:"wrapped_data_key"`
+	NoncePrefix    []byte `json:"nonce_prefix"`
+	ChunkSize      uint64 `json:"chunk_size"`
+}
+
+// EncryptedClient encrypts the files written through Client. Files are read back with the metadata they were written
+// with, nil metadata reads a file as is, e.g. one stored before encryption was enabled.
+type EncryptedClient interface {
+	// Write returns nil metadata if encryption is not enabled. The metadata is known before anything is written.
+	Write(ctx context.Context, filePath string) (io.WriteCloser, *EncryptionMetadata, error)
+	Read(ctx context.Context, filePath string, metadata *EncryptionMetadata) (io.ReadCloser, error)
+	// ReadRange reads length bytes of the decrypted file starting at offset. A length of 0 reads until the end of the
+	// file.
+	ReadRange(ctx context.Context, filePath string, metadata *EncryptionMetadata, offset, length uint64) (io.ReadCloser, error)
+	// Stat returns the size of the decrypted file.
+	Stat(ctx context.Context, filePath string, metadata *EncryptionMetadata) (FileInfo, error)
+}
+type encryptedClient struct {
+	client    Client
+	kms       KMS
+	enabled   bool
+	chunkSize uint64
+	logger    *zap.Logger
+}
+
+func NewEncryptedClient(client Client, kms KMS, downloadConfig configs.Download, logger *zap.Logger) (EncryptedClient, error) {
+	encryptionConfig := downloadConfig.Encryption
+	if encryptionConfig.Enabled && encryptionConfig.CurrentMasterKeyID == "" {
+		logger.Error("encryption is enabled but current_master_key_id is not set")
+		return nil, errCurrentMasterKeyNotSet
+	}
+	var chunkSize uint64
+	if encryptionConfig.Enabled {
+		var err error
+		chunkSize, err = encryptionConfig.GetChunkSizeInBytes()
+		if err != nil {
+			logger.With(zap.Error(err)).Error("failed to parse chunk_size")
+			return nil, err
+		}
+		if chunkSize == 0 || chunkSize > maxEncryptionChunkSize {
+			return nil, errInvalidEncryptionChunkSize
+		}
+	}
+	return &encryptedClient{
+		client:    client,
+		kms:       kms,
+		enabled:   encryptionConfig.Enabled,
+		chunkSize: chunkSize,
+		logger:    logger,
+	}, nil
+}
+
+func newDataKeyAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+func getChunkNonce(noncePrefix []byte, chunkIndex uint64, isLastChunk bool) []byte {
+	nonce := make([]byte, 0, len(noncePrefix)+chunkNonceSuffixByteCount)
+	nonce = append(nonce, noncePrefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, uint32(chunkIndex))
+	if isLastChunk {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+func (e encryptedClient) Write(ctx context.Context, filePath string) (io.WriteCloser, *EncryptionMetadata, error) {
+	logger := utils.LoggerWithContext(ctx, e.logger).With(zap.String("file_path", filePath))
+
+	if !e.enabled {
+		writeCloser, err := e.client.Write(ctx, filePath)
+		return writeCloser, nil, err
+	}
+	dataKey := make([]byte, dataKeyByteCount)
+	if _, err := rand.Read(dataKey); err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate data key")
+		return nil, nil, status.Error(codes.Internal, "failed to generate data key")
+	}
+	masterKeyID, wrappedDataKey, err := e.kms.WrapDataKey(ctx, dataKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to wrap data key")
+		return nil, nil, status.Error(codes.Internal, "failed to wrap data key")
+	}
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create data key cipher")
+		return nil, nil, status.Error(codes.Internal, "failed to create data key cipher")
+	}
+	noncePrefix := make([]byte, aead.NonceSize()-chunkNonceSuffixByteCount)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate nonce prefix")
+		return nil, nil, status.Error(codes.Internal, "failed to generate nonce prefix")
+	}
+	writeCloser, err := e.client.Write(ctx, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := &EncryptionMetadata{
+		Algorithm:      EncryptionAlgorithmAES256GCMChunked,
+		MasterKeyID:    masterKeyID,
+		WrappedDataKey: wrappedDataKey,
+		NoncePrefix:    noncePrefix,
+		ChunkSize:      e.chunkSize,
+	}
+	return &encryptingWriteCloser{
+		writeCloser: writeCloser,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		buffer:      make([]byte, 0, e.chunkSize),
+		sealedChunk: make([]byte, 0, e.chunkSize+gcmTagByteCount),
+	}, metadata, nil
+}
+
+// encryptingWriteCloser holds back a full chunk until more is written, since the last chunk is sealed differently
+// and only Close tells which one it is.
+type encryptingWriteCloser struct {
+	writeCloser io.WriteCloser
+	aead        cipher.AEAD
+	noncePrefix []byte
+	chunkIndex  uint64
+	buffer      []byte
+	sealedChunk []byte
+	closed      bool
+}
+
+func (e *encryptingWriteCloser) sealChunk(isLastChunk bool) error {
+	if e.chunkIndex > math.MaxUint32 {
+		return errEncryptedFileTooLarge
+	}
+	e.sealedChunk = e.aead.Seal(e.sealedChunk[:0], getChunkNonce(e.noncePrefix, e.chunkIndex, isLastChunk), e.buffer, nil)
+	if _, err := e.writeCloser.Write(e.sealedChunk); err != nil {
+		return err
+	}
+	e.chunkIndex++
+	e.buffer = e.buffer[:0]
+	return nil
+}
+func (e *encryptingWriteCloser) Write(p []byte) (int, error) {
+	writtenByteCount := 0
+	for len(p) > 0 {
+		if len(e.buffer) == cap(e.buffer) {
+			if err := e.sealChunk(false); err != nil {
+				return writtenByteCount, err
+			}
+		}
+		n := copy(e.buffer[len(e.buffer):cap(e.buffer)], p)
+		e.buffer = e.buffer[:len(e.buffer)+n]
+		p = p[n:]
+		writtenByteCount += n
+	}
+	return writtenByteCount, nil
+}
+func (e *encryptingWriteCloser) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	err := e.sealChunk(true)
+	if closeErr := e.writeCloser.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// encryptedFileLayout locates the chunks of an encrypted file of a given size.
+type encryptedFileLayout struct {
+	chunkSize       uint64
+	sealedChunkSize uint64
+	sealedSize      uint64
+	chunkCount      uint64
+	size            uint64
+}
+
+func newEncryptedFileLayout(chunkSize uint64, overhead uint64, sealedSize uint64) (encryptedFileLayout, error) {
+	sealedChunkSize := chunkSize + overhead
+	chunkCount := (sealedSize + sealedChunkSize - 1) / sealedChunkSize
+	if chunkCount == 0 || sealedSize-(chunkCount-1)*sealedChunkSize < overhead {
+		return encryptedFileLayout{}, errInvalidEncryptedFile
+	}
+	return encryptedFileLayout{
+		chunkSize:       chunkSize,
+		sealedChunkSize: sealedChunkSize,
+		sealedSize:      sealedSize,
+		chunkCount:      chunkCount,
+		size:            sealedSize - chunkCount*overhead,
+	}, nil
+}
+func (e encryptedFileLayout) getSealedChunkLength(chunkIndex uint64) uint64 {
+	if chunkIndex == e.chunkCount-1 {
+		return e.sealedSize - chunkIndex*e.sealedChunkSize
+	}
+	return e.sealedChunkSize
+}
+
+func (e encryptedClient) getDataKeyAEAD(ctx context.Context, metadata *EncryptionMetadata) (cipher.AEAD, error) {
+	if metadata.Algorithm != EncryptionAlgorithmAES256GCMChunked {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedEncryptionAlgorithm, metadata.Algorithm)
+	}
+	if metadata.ChunkSize == 0 || metadata.ChunkSize > maxEncryptionChunkSize {
+		return nil, errInvalidEncryptionChunkSize
+	}
+	dataKey, err := e.kms.UnwrapDataKey(ctx, metadata.MasterKeyID, metadata.WrappedDataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.NoncePrefix) != aead.NonceSize()-chunkNonceSuffixByteCount {
+		return nil, errInvalidEncryptedFile
+	}
+	return aead, nil
+}
+func (e encryptedClient) Read(ctx context.Context, filePath string, metadata *EncryptionMetadata) (io.ReadCloser, error) {
+	if metadata == nil {
+		return e.client.Read(ctx, filePath)
+	}
+	return e.ReadRange(ctx, filePath, metadata, 0, 0)
+}
+func (e encryptedClient) ReadRange(
+	ctx context.Context,
+	filePath string,
+	metadata *EncryptionMetadata,
+	offset, length uint64,
+) (io.ReadCloser, error) {
+	logger := utils.LoggerWithContext(ctx, e.logger).
+		With(zap.String("file_path", filePath)).
+		With(zap.Uint64("offset", offset)).
+		With(zap.Uint64("length", length))
+
+	if metadata == nil {
+		return e.client.ReadRange(ctx, filePath, offset, length)
+	}
+	aead, err := e.getDataKeyAEAD(ctx, metadata)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get data key")
+		return nil, status.Error(codes.Internal, "failed to get data key")
+	}
+	fileInfo, err := e.client.Stat(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := newEncryptedFileLayout(metadata.ChunkSize, gcmTagByteCount, uint64(fileInfo.Size))
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get encrypted file layout")
+		return nil, status.Error(codes.Internal, "failed to read encrypted file")
+	}
+	if offset >= layout.size {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	end := layout.size
+	if length > 0 && length < end-offset {
+		end = offset + length
+	}
+	firstChunkIndex := offset / layout.chunkSize
+	lastChunkIndex := (end - 1) / layout.chunkSize
+	sealedOffset := firstChunkIndex * layout.sealedChunkSize
+	sealedEnd := min(layout.sealedSize, (lastChunkIndex+1)*layout.sealedChunkSize)
+	reader, err := e.client.ReadRange(ctx, filePath, sealedOffset, sealedEnd-sealedOffset)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReadCloser{
+		reader:         reader,
+		aead:           aead,
+		noncePrefix:    metadata.NoncePrefix,
+		layout:         layout,
+		chunkIndex:     firstChunkIndex,
+		skipByteCount:  offset - firstChunkIndex*layout.chunkSize,
+		remainingCount: end - offset,
+		sealedChunk:    make([]byte, layout.sealedChunkSize),
+	}, nil
+}
+func (e encryptedClient) Stat(ctx context.Context, filePath string, metadata *EncryptionMetadata) (FileInfo, error) {
+	logger := utils.LoggerWithContext(ctx, e.logger).With(zap.String("file_path", filePath))
+
+	fileInfo, err := e.client.Stat(ctx, filePath)
+	if err != nil || metadata == nil {
+		return fileInfo, err
+	}
+	layout, err := newEncryptedFileLayout(metadata.ChunkSize, gcmTagByteCount, uint64(fileInfo.Size))
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get encrypted file layout")
+		return FileInfo{}, status.Error(codes.Internal, "failed to stat encrypted file")
+	}
+	fileInfo.Size = int64(layout.size)
+	return fileInfo, nil
+}
+
+// decryptingReadCloser decrypts the chunks of a range one at a time, dropping what comes before the offset in the
+// first chunk and what comes after the end of the range in the last one.
+type decryptingReadCloser struct {
+	reader         io.ReadCloser
+	aead           cipher.AEAD
+	noncePrefix    []byte
+	layout         encryptedFileLayout
+	chunkIndex     uint64
+	skipByteCount  uint64
+	remainingCount uint64
+	sealedChunk    []byte
+	chunk          []byte
+}
+
+func (d *decryptingReadCloser) openChunk() error {
+	sealedChunk := d.sealedChunk[:d.layout.getSealedChunkLength(d.chunkIndex)]
+	if _, err := io.ReadFull(d.reader, sealedChunk); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return errInvalidEncryptedFile
+		}
+		return err
+	}
+	isLastChunk := d.chunkIndex == d.layout.chunkCount-1
+	chunk, err := d.aead.Open(sealedChunk[:0], getChunkNonce(d.noncePrefix, d.chunkIndex, isLastChunk), sealedChunk, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidEncryptedFile, err)
+	}
+	d.chunk = chunk[d.skipByteCount:]
+	d.skipByteCount = 0
+	d.chunkIndex++
+	return nil
+}
+func (d *decryptingReadCloser) Read(p []byte) (int, error) {
+	if d.remainingCount == 0 {
+		return 0, io.EOF
+	}
+	for len(d.chunk) == 0 {
+		if err := d.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p[:min(uint64(len(p)), d.remainingCount)], d.chunk)
+	d.chunk = d.chunk[n:]
+	d.remainingCount -= uint64(n)
+	return n, nil
+}
+func (d *decryptingReadCloser) Close() error {
+	return d.reader.Close()
+}
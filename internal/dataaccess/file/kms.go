@@ -0,0 +1,104 @@
+package file
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"GoLoad/internal/configs"
+
+	"go.uber.org/zap"
+)
+
+const masterKeyByteCount = 32
+
+var (
+	errInvalidMasterKey       = fmt.Errorf("master key must be %d base64 encoded bytes", masterKeyByteCount)
+	errMasterKeyNotFound      = errors.New("master key not found")
+	errInvalidWrappedDataKey  = errors.New("invalid wrapped data key")
+	errCurrentMasterKeyNotSet = errors.New("current master key is not set")
+)
+
+// KMS wraps the data keys of files with a master key that it keeps, so that the master key never has to leave it,
+// e.g. when it is a key management service.
+type KMS interface {
+	// WrapDataKey returns the id of the master key the data key is wrapped with, which UnwrapDataKey needs.
+	WrapDataKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+	UnwrapDataKey(ctx context.Context, masterKeyID string, wrappedDataKey []byte) ([]byte, error)
+}
+
+func NewKMS(downloadConfig configs.Download, logger *zap.Logger) (KMS, error) {
+	switch downloadConfig.Encryption.KMS {
+	case "", configs.KMSTypeLocal:
+		return NewLocalKMS(downloadConfig, logger)
+	default:
+		return nil, fmt.Errorf("unsupported kms: %s", downloadConfig.Encryption.KMS)
+	}
+}
+
+// localKMS keeps the master keys of the configuration. Data keys are sealed with AES-GCM, prefixed by their nonce,
+// with the id of the master key as additional data.
+type localKMS struct {
+	masterKeyAEADMap   map[string]cipher.AEAD
+	currentMasterKeyID string
+	logger             *zap.Logger
+}
+
+func NewLocalKMS(downloadConfig configs.Download, logger *zap.Logger) (KMS, error) {
+	encryptionConfig := downloadConfig.Encryption
+	masterKeyAEADMap := make(map[string]cipher.AEAD, len(encryptionConfig.MasterKeys))
+	for masterKeyID, encodedMasterKey := range encryptionConfig.MasterKeys {
+		masterKey, err := base64.StdEncoding.DecodeString(encodedMasterKey)
+		if err != nil || len(masterKey) != masterKeyByteCount {
+			logger.With(zap.String("master_key_id", masterKeyID)).Error("failed to parse master key")
+			return nil, fmt.Errorf("%w: %s", errInvalidMasterKey, masterKeyID)
+		}
+		block, err := aes.NewCipher(masterKey)
+		if err != nil {
+			return nil, err
+		}
+		if masterKeyAEADMap[masterKeyID], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	if encryptionConfig.CurrentMasterKeyID != "" {
+		if _, ok := masterKeyAEADMap[encryptionConfig.CurrentMasterKeyID]; !ok {
+			return nil, fmt.Errorf("%w: %s", errMasterKeyNotFound, encryptionConfig.CurrentMasterKeyID)
+		}
+	}
+	return &localKMS{
+		masterKeyAEADMap:   masterKeyAEADMap,
+		currentMasterKeyID: encryptionConfig.CurrentMasterKeyID,
+		logger:             logger,
+	}, nil
+}
+func (l localKMS) WrapDataKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	if l.currentMasterKeyID == "" {
+		return "", nil, errCurrentMasterKeyNotSet
+	}
+	aead := l.masterKeyAEADMap[l.currentMasterKeyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return l.currentMasterKeyID, aead.Seal(nonce, nonce, dataKey, []byte(l.currentMasterKeyID)), nil
+}
+func (l localKMS) UnwrapDataKey(_ context.Context, masterKeyID string, wrappedDataKey []byte) ([]byte, error) {
+	aead, ok := l.masterKeyAEADMap[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errMasterKeyNotFound, masterKeyID)
+	}
+	if len(wrappedDataKey) < aead.NonceSize() {
+		return nil, errInvalidWrappedDataKey
+	}
+	nonce, sealedDataKey := wrappedDataKey[:aead.NonceSize()], wrappedDataKey[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealedDataKey, []byte(masterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidWrappedDataKey, err)
+	}
+	return dataKey, nil
+}
@@ -4,4 +4,6 @@ import "github.com/google/wire"
 
 var WireSet = wire.NewSet(
 	NewClient,
+	NewKMS,
+	NewEncryptedClient,
 )
@@ -0,0 +1,29 @@
+package consumer
+
+import "github.com/IBM/sarama"
+
+// consumerMessageCarrier lets propagators read the trace context from the headers of a message.
+type consumerMessageCarrier struct {
+	message *sarama.ConsumerMessage
+}
+
+func (c consumerMessageCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set is a no-op, consumed messages are read only.
+func (c consumerMessageCarrier) Set(string, string) {}
+func (c consumerMessageCarrier) Keys() []string {
+	keyList := make([]string, 0, len(c.message.Headers))
+	for _, header := range c.message.Headers {
+		if header != nil {
+			keyList = append(keyList, string(header.Key))
+		}
+	}
+	return keyList
+}
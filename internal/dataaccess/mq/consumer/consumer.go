@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 
 	"GoLoad/internal/configs"
 	"GoLoad/internal/utils"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -18,12 +23,21 @@ type HandlerFunc func(ctx context.Context, queueName string, payload []byte) err
 type consumerHandler struct {
 	handlerFunc       HandlerFunc
 	exitSignalChannel chan os.Signal
+	metrics           utils.Metrics
+	tracer            trace.Tracer
 }
 
-func newConsumerHandler(handlerFunc HandlerFunc, exitSignalChannel chan os.Signal) *consumerHandler {
+func newConsumerHandler(
+	handlerFunc HandlerFunc,
+	exitSignalChannel chan os.Signal,
+	metrics utils.Metrics,
+	tracer trace.Tracer,
+) *consumerHandler {
 	return &consumerHandler{
 		handlerFunc:       handlerFunc,
 		exitSignalChannel: exitSignalChannel,
+		metrics:           metrics,
+		tracer:            tracer,
 	}
 }
 func (h consumerHandler) Setup(sarama.ConsumerGroupSession) error {
@@ -32,6 +46,38 @@ func (h consumerHandler) Setup(sarama.ConsumerGroupSession) error {
 func (h consumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
+
+// handleMessage runs the handler within a consumer span that continues the trace of the producer, with the request id
+// of the producer, both read from the message headers.
+func (h consumerHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	carrier := consumerMessageCarrier{message: message}
+	requestID := carrier.Get(utils.RequestIDHeaderName)
+	if !utils.IsValidRequestID(requestID) {
+		requestID = utils.NewRequestID()
+	}
+	ctx = utils.ContextWithRequestID(ctx, requestID)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	ctx, span := h.tracer.Start(
+		ctx,
+		"process "+message.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.FormatInt(int64(message.Partition), 10)),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+		),
+	)
+	defer span.End()
+
+	if err := h.handlerFunc(ctx, message.Topic, message.Value); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to handle message")
+		return err
+	}
+	return nil
+}
 func (h consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for {
 		select {
@@ -40,7 +86,10 @@ func (h consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 				session.Commit()
 				return nil
 			}
-			if err := h.handlerFunc(session.Context(), message.Topic, message.Value); err != nil {
+			// The high water mark is the offset of the next message to be produced to the partition.
+			h.metrics.SetMQConsumerLag(message.Topic, message.Partition, claim.HighWaterMarkOffset()-message.Offset-1)
+			if err := h.handleMessage(session.Context(), message); err != nil {
+				h.metrics.IncMQConsumerHandlerErrorCount(message.Topic)
 				return err
 			}
 		case <-h.exitSignalChannel:
@@ -58,23 +107,50 @@ type Consumer interface {
 type consumer struct {
 	saramaConsumer            sarama.ConsumerGroup
 	queueNameToHandlerFuncMap map[string]HandlerFunc
+	metrics                   utils.Metrics
+	tracer                    trace.Tracer
 	logger                    *zap.Logger
 }
 
-func newSaramaConfig(mqConfig configs.MQ) *sarama.Config {
+func newSaramaConfig(mqConfig configs.MQ, logger *zap.Logger) (*sarama.Config, error) {
 	saramaConfig := sarama.NewConfig()
 	saramaConfig.ClientID = mqConfig.ClientID
 	saramaConfig.Metadata.Full = true
-	return saramaConfig
+	tlsConfig, err := utils.NewClientTLSConfig(mqConfig.TLS, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka tls config: %w", err)
+	}
+	if tlsConfig != nil {
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+	if mqConfig.SASL.Enabled {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		saramaConfig.Net.SASL.User = mqConfig.SASL.Username
+		saramaConfig.Net.SASL.Password = mqConfig.SASL.Password
+	}
+	return saramaConfig, nil
 }
-func NewConsumer(mqConfig configs.MQ, logger *zap.Logger) (Consumer, error) {
-	saramaConsumer, err := sarama.NewConsumerGroup(mqConfig.Addresses, mqConfig.ClientID, newSaramaConfig(mqConfig))
+func NewConsumer(
+	mqConfig configs.MQ,
+	metrics utils.Metrics,
+	tracerProvider trace.TracerProvider,
+	logger *zap.Logger,
+) (Consumer, error) {
+	saramaConfig, err := newSaramaConfig(mqConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	saramaConsumer, err := sarama.NewConsumerGroup(mqConfig.Addresses, mqConfig.ClientID, saramaConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sarama consumer: %w", err)
 	}
 	return &consumer{
 		saramaConsumer:            saramaConsumer,
 		queueNameToHandlerFuncMap: make(map[string]HandlerFunc),
+		metrics:                   metrics,
+		tracer:                    tracerProvider.Tracer(utils.TracerName),
 		logger:                    logger,
 	}, nil
 }
@@ -92,7 +168,7 @@ func (c consumer) Start(ctx context.Context) error {
 			if err := c.saramaConsumer.Consume(
 				context.Background(),
 				[]string{queueName},
-				newConsumerHandler(handlerFunc, exitSignalChannel),
+				newConsumerHandler(handlerFunc, exitSignalChannel, c.metrics, c.tracer),
 			); err != nil {
 				logger.
 					With(zap.String("queue_name", queueName)).
@@ -0,0 +1,33 @@
+package producer
+
+import "github.com/IBM/sarama"
+
+// producerMessageCarrier lets propagators write trace context into the headers of a message.
+type producerMessageCarrier struct {
+	message *sarama.ProducerMessage
+}
+
+func (p producerMessageCarrier) Get(key string) string {
+	for _, header := range p.message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+func (p producerMessageCarrier) Set(key string, value string) {
+	for i, header := range p.message.Headers {
+		if string(header.Key) == key {
+			p.message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	p.message.Headers = append(p.message.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+func (p producerMessageCarrier) Keys() []string {
+	keyList := make([]string, 0, len(p.message.Headers))
+	for _, header := range p.message.Headers {
+		keyList = append(keyList, string(header.Key))
+	}
+	return keyList
+}
@@ -7,49 +7,138 @@ import (
 	"fmt"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
+	grpccodes "google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type Client interface {
 	Produce(ctx context.Context, queueName string, payload []byte) error
+	ProduceBatch(ctx context.Context, queueName string, payloadList [][]byte) error
+	// Ping checks that the brokers are reachable by refreshing the cluster metadata.
+	Ping(ctx context.Context) error
 }
 type client struct {
+	saramaClient       sarama.Client
 	saramaSyncProducer sarama.SyncProducer
+	tracer             trace.Tracer
 	logger             *zap.Logger
 }
 
-func newSaramaConfig(mqConfig configs.MQ) *sarama.Config {
+func newSaramaConfig(mqConfig configs.MQ, logger *zap.Logger) (*sarama.Config, error) {
 	saramaConfig := sarama.NewConfig()
 	saramaConfig.Producer.Retry.Max = 1
 	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
 	saramaConfig.Producer.Return.Successes = true
 	saramaConfig.ClientID = mqConfig.ClientID
 	saramaConfig.Metadata.Full = true
-	return saramaConfig
+	tlsConfig, err := utils.NewClientTLSConfig(mqConfig.TLS, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka tls config: %w", err)
+	}
+	if tlsConfig != nil {
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+	if mqConfig.SASL.Enabled {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		saramaConfig.Net.SASL.User = mqConfig.SASL.Username
+		saramaConfig.Net.SASL.Password = mqConfig.SASL.Password
+	}
+	return saramaConfig, nil
 }
-func NewClient(mqConfig configs.MQ, logger *zap.Logger) (Client, error) {
-	saramaSyncProducer, err := sarama.NewSyncProducer(mqConfig.Addresses, newSaramaConfig(mqConfig))
+func NewClient(mqConfig configs.MQ, tracerProvider trace.TracerProvider, logger *zap.Logger) (Client, error) {
+	saramaConfig, err := newSaramaConfig(mqConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	saramaClient, err := sarama.NewClient(mqConfig.Addresses, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sarama client: %w", err)
+	}
+	saramaSyncProducer, err := sarama.NewSyncProducerFromClient(saramaClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sarama sync producer: %w", err)
 	}
 	return &client{
+		saramaClient:       saramaClient,
 		saramaSyncProducer: saramaSyncProducer,
+		tracer:             tracerProvider.Tracer(utils.TracerName),
 		logger:             logger,
 	}, nil
 }
+
+// startProduceSpan starts a producer span. The trace context of the span and the request id are written to the
+// headers of the messages sent within it, so that consumers can continue the trace and log the request id.
+func (c client) startProduceSpan(ctx context.Context, queueName string, messageCount int) (context.Context, trace.Span) {
+	return c.tracer.Start(
+		ctx,
+		"publish "+queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(queueName),
+			semconv.MessagingBatchMessageCount(messageCount),
+		),
+	)
+}
+func (c client) newProducerMessage(ctx context.Context, queueName string, payload []byte) *sarama.ProducerMessage {
+	message := &sarama.ProducerMessage{
+		Topic: queueName,
+		Value: sarama.ByteEncoder(payload),
+	}
+	carrier := producerMessageCarrier{message: message}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if requestID := utils.RequestIDFromContext(ctx); requestID != "" {
+		carrier.Set(utils.RequestIDHeaderName, requestID)
+	}
+	return message
+}
 func (c client) Produce(ctx context.Context, queueName string, payload []byte) error {
 	logger := utils.LoggerWithContext(ctx, c.logger).
 		With(zap.String("queue_name", queueName)).
 		With(zap.ByteString("payload", payload))
 
-	if _, _, err := c.saramaSyncProducer.SendMessage(&sarama.ProducerMessage{
-		Topic: queueName,
-		Value: sarama.ByteEncoder(payload),
-	}); err != nil {
+	ctx, span := c.startProduceSpan(ctx, queueName, 1)
+	defer span.End()
+
+	if _, _, err := c.saramaSyncProducer.SendMessage(c.newProducerMessage(ctx, queueName, payload)); err != nil {
 		logger.With(zap.Error(err)).Error("failed to produce message")
-		return status.Error(codes.Internal, "failed to produce message")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to produce message")
+		return status.Error(grpccodes.Internal, "failed to produce message")
+	}
+	return nil
+}
+func (c client) ProduceBatch(ctx context.Context, queueName string, payloadList [][]byte) error {
+	logger := utils.LoggerWithContext(ctx, c.logger).
+		With(zap.String("queue_name", queueName)).
+		With(zap.Int("len(payload_list)", len(payloadList)))
+
+	if len(payloadList) == 0 {
+		return nil
+	}
+	ctx, span := c.startProduceSpan(ctx, queueName, len(payloadList))
+	defer span.End()
+
+	messageList := make([]*sarama.ProducerMessage, 0, len(payloadList))
+	for _, payload := range payloadList {
+		messageList = append(messageList, c.newProducerMessage(ctx, queueName, payload))
+	}
+	if err := c.saramaSyncProducer.SendMessages(messageList); err != nil {
+		logger.With(zap.Error(err)).Error("failed to produce message batch")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to produce message batch")
+		return status.Error(grpccodes.Internal, "failed to produce message batch")
 	}
 	return nil
 }
+func (c client) Ping(context.Context) error {
+	return c.saramaClient.RefreshMetadata()
+}
@@ -19,6 +19,7 @@ type DownloadTaskCreated struct {
 }
 type DownloadTaskCreatedProducer interface {
 	Produce(ctx context.Context, event DownloadTaskCreated) error
+	ProduceBatch(ctx context.Context, eventList []DownloadTaskCreated) error
 }
 type downloadTaskCreatedProducer struct {
 	client Client
@@ -46,3 +47,22 @@ func (d downloadTaskCreatedProducer) Produce(ctx context.Context, event Download
 	}
 	return nil
 }
+func (d downloadTaskCreatedProducer) ProduceBatch(ctx context.Context, eventList []DownloadTaskCreated) error {
+	logger := utils.LoggerWithContext(ctx, d.logger)
+
+	eventBytesList := make([][]byte, 0, len(eventList))
+	for _, event := range eventList {
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			logger.With(zap.Error(err)).Error("failed to marshal download task created event")
+			return status.Error(codes.Internal, "failed to marshal download task created event")
+		}
+		eventBytesList = append(eventBytesList, eventBytes)
+	}
+	err := d.client.ProduceBatch(ctx, MessageQueueDownloadTaskCreated, eventBytesList)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to produce download task created event batch")
+		return status.Error(codes.Internal, "failed to produce download task created event batch")
+	}
+	return nil
+}
@@ -19,13 +19,34 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GoLoadService_CreateAccount_FullMethodName       = "/go_load.GoLoadService/CreateAccount"
-	GoLoadService_CreateSession_FullMethodName       = "/go_load.GoLoadService/CreateSession"
-	GoLoadService_CreateDownloadTask_FullMethodName  = "/go_load.GoLoadService/CreateDownloadTask"
-	GoLoadService_GetDownloadTaskList_FullMethodName = "/go_load.GoLoadService/GetDownloadTaskList"
-	GoLoadService_UpdateDownloadTask_FullMethodName  = "/go_load.GoLoadService/UpdateDownloadTask"
-	GoLoadService_DeleteDownloadTask_FullMethodName  = "/go_load.GoLoadService/DeleteDownloadTask"
-	GoLoadService_GetDownloadTaskFile_FullMethodName = "/go_load.GoLoadService/GetDownloadTaskFile"
+	GoLoadService_CreateAccount_FullMethodName               = "/go_load.GoLoadService/CreateAccount"
+	GoLoadService_CreateSession_FullMethodName               = "/go_load.GoLoadService/CreateSession"
+	GoLoadService_DeleteSession_FullMethodName               = "/go_load.GoLoadService/DeleteSession"
+	GoLoadService_DeleteAllSessions_FullMethodName           = "/go_load.GoLoadService/DeleteAllSessions"
+	GoLoadService_ChangePassword_FullMethodName              = "/go_load.GoLoadService/ChangePassword"
+	GoLoadService_DeleteAccount_FullMethodName               = "/go_load.GoLoadService/DeleteAccount"
+	GoLoadService_CreateDownloadTask_FullMethodName          = "/go_load.GoLoadService/CreateDownloadTask"
+	GoLoadService_CreateDownloadTasks_FullMethodName         = "/go_load.GoLoadService/CreateDownloadTasks"
+	GoLoadService_ImportDownloadTasks_FullMethodName         = "/go_load.GoLoadService/ImportDownloadTasks"
+	GoLoadService_GetDownloadTaskList_FullMethodName         = "/go_load.GoLoadService/GetDownloadTaskList"
+	GoLoadService_UpdateDownloadTask_FullMethodName          = "/go_load.GoLoadService/UpdateDownloadTask"
+	GoLoadService_DeleteDownloadTask_FullMethodName          = "/go_load.GoLoadService/DeleteDownloadTask"
+	GoLoadService_CancelDownloadTaskBatch_FullMethodName     = "/go_load.GoLoadService/CancelDownloadTaskBatch"
+	GoLoadService_DeleteDownloadTaskBatch_FullMethodName     = "/go_load.GoLoadService/DeleteDownloadTaskBatch"
+	GoLoadService_GetDownloadTaskFile_FullMethodName         = "/go_load.GoLoadService/GetDownloadTaskFile"
+	GoLoadService_CreateDownloadTaskShareLink_FullMethodName = "/go_load.GoLoadService/CreateDownloadTaskShareLink"
+	GoLoadService_RevokeDownloadTaskShareLink_FullMethodName = "/go_load.GoLoadService/RevokeDownloadTaskShareLink"
+	GoLoadService_CreateWorkspace_FullMethodName             = "/go_load.GoLoadService/CreateWorkspace"
+	GoLoadService_GetWorkspaceList_FullMethodName            = "/go_load.GoLoadService/GetWorkspaceList"
+	GoLoadService_GetWorkspaceMemberList_FullMethodName      = "/go_load.GoLoadService/GetWorkspaceMemberList"
+	GoLoadService_AddWorkspaceMember_FullMethodName          = "/go_load.GoLoadService/AddWorkspaceMember"
+	GoLoadService_UpdateWorkspaceMember_FullMethodName       = "/go_load.GoLoadService/UpdateWorkspaceMember"
+	GoLoadService_RemoveWorkspaceMember_FullMethodName       = "/go_load.GoLoadService/RemoveWorkspaceMember"
+	GoLoadService_CreateAPIKey_FullMethodName                = "/go_load.GoLoadService/CreateAPIKey"
+	GoLoadService_ListAPIKeys_FullMethodName                 = "/go_load.GoLoadService/ListAPIKeys"
+	GoLoadService_RevokeAPIKey_FullMethodName                = "/go_load.GoLoadService/RevokeAPIKey"
+	GoLoadService_ListAccountIdentities_FullMethodName       = "/go_load.GoLoadService/ListAccountIdentities"
+	GoLoadService_UnlinkAccountIdentity_FullMethodName       = "/go_load.GoLoadService/UnlinkAccountIdentity"
 )
 
 // GoLoadServiceClient is the client API for GoLoadService service.
@@ -34,11 +55,32 @@ const (
 type GoLoadServiceClient interface {
 	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error)
 	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
+	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
+	DeleteAllSessions(ctx context.Context, in *DeleteAllSessionsRequest, opts ...grpc.CallOption) (*DeleteAllSessionsResponse, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error)
+	DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error)
 	CreateDownloadTask(ctx context.Context, in *CreateDownloadTaskRequest, opts ...grpc.CallOption) (*CreateDownloadTaskResponse, error)
+	CreateDownloadTasks(ctx context.Context, in *CreateDownloadTasksRequest, opts ...grpc.CallOption) (*CreateDownloadTasksResponse, error)
+	ImportDownloadTasks(ctx context.Context, in *ImportDownloadTasksRequest, opts ...grpc.CallOption) (*ImportDownloadTasksResponse, error)
 	GetDownloadTaskList(ctx context.Context, in *GetDownloadTaskListRequest, opts ...grpc.CallOption) (*GetDownloadTaskListResponse, error)
 	UpdateDownloadTask(ctx context.Context, in *UpdateDownloadTaskRequest, opts ...grpc.CallOption) (*UpdateDownloadTaskResponse, error)
 	DeleteDownloadTask(ctx context.Context, in *DeleteDownloadTaskRequest, opts ...grpc.CallOption) (*DeleteDownloadTaskResponse, error)
+	CancelDownloadTaskBatch(ctx context.Context, in *CancelDownloadTaskBatchRequest, opts ...grpc.CallOption) (*CancelDownloadTaskBatchResponse, error)
+	DeleteDownloadTaskBatch(ctx context.Context, in *DeleteDownloadTaskBatchRequest, opts ...grpc.CallOption) (*DeleteDownloadTaskBatchResponse, error)
 	GetDownloadTaskFile(ctx context.Context, in *GetDownloadTaskFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetDownloadTaskFileResponse], error)
+	CreateDownloadTaskShareLink(ctx context.Context, in *CreateDownloadTaskShareLinkRequest, opts ...grpc.CallOption) (*CreateDownloadTaskShareLinkResponse, error)
+	RevokeDownloadTaskShareLink(ctx context.Context, in *RevokeDownloadTaskShareLinkRequest, opts ...grpc.CallOption) (*RevokeDownloadTaskShareLinkResponse, error)
+	CreateWorkspace(ctx context.Context, in *CreateWorkspaceRequest, opts ...grpc.CallOption) (*CreateWorkspaceResponse, error)
+	GetWorkspaceList(ctx context.Context, in *GetWorkspaceListRequest, opts ...grpc.CallOption) (*GetWorkspaceListResponse, error)
+	GetWorkspaceMemberList(ctx context.Context, in *GetWorkspaceMemberListRequest, opts ...grpc.CallOption) (*GetWorkspaceMemberListResponse, error)
+	AddWorkspaceMember(ctx context.Context, in *AddWorkspaceMemberRequest, opts ...grpc.CallOption) (*AddWorkspaceMemberResponse, error)
+	UpdateWorkspaceMember(ctx context.Context, in *UpdateWorkspaceMemberRequest, opts ...grpc.CallOption) (*UpdateWorkspaceMemberResponse, error)
+	RemoveWorkspaceMember(ctx context.Context, in *RemoveWorkspaceMemberRequest, opts ...grpc.CallOption) (*RemoveWorkspaceMemberResponse, error)
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error)
+	ListAccountIdentities(ctx context.Context, in *ListAccountIdentitiesRequest, opts ...grpc.CallOption) (*ListAccountIdentitiesResponse, error)
+	UnlinkAccountIdentity(ctx context.Context, in *UnlinkAccountIdentityRequest, opts ...grpc.CallOption) (*UnlinkAccountIdentityResponse, error)
 }
 
 type goLoadServiceClient struct {
@@ -69,6 +111,46 @@ func (c *goLoadServiceClient) CreateSession(ctx context.Context, in *CreateSessi
 	return out, nil
 }
 
+func (c *goLoadServiceClient) DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSessionResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_DeleteSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) DeleteAllSessions(ctx context.Context, in *DeleteAllSessionsRequest, opts ...grpc.CallOption) (*DeleteAllSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAllSessionsResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_DeleteAllSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangePasswordResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeleteAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAccountResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_DeleteAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *goLoadServiceClient) CreateDownloadTask(ctx context.Context, in *CreateDownloadTaskRequest, opts ...grpc.CallOption) (*CreateDownloadTaskResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CreateDownloadTaskResponse)
@@ -79,6 +161,26 @@ func (c *goLoadServiceClient) CreateDownloadTask(ctx context.Context, in *Create
 	return out, nil
 }
 
+func (c *goLoadServiceClient) CreateDownloadTasks(ctx context.Context, in *CreateDownloadTasksRequest, opts ...grpc.CallOption) (*CreateDownloadTasksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateDownloadTasksResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_CreateDownloadTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) ImportDownloadTasks(ctx context.Context, in *ImportDownloadTasksRequest, opts ...grpc.CallOption) (*ImportDownloadTasksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportDownloadTasksResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_ImportDownloadTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *goLoadServiceClient) GetDownloadTaskList(ctx context.Context, in *GetDownloadTaskListRequest, opts ...grpc.CallOption) (*GetDownloadTaskListResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetDownloadTaskListResponse)
@@ -109,6 +211,26 @@ func (c *goLoadServiceClient) DeleteDownloadTask(ctx context.Context, in *Delete
 	return out, nil
 }
 
+func (c *goLoadServiceClient) CancelDownloadTaskBatch(ctx context.Context, in *CancelDownloadTaskBatchRequest, opts ...grpc.CallOption) (*CancelDownloadTaskBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelDownloadTaskBatchResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_CancelDownloadTaskBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) DeleteDownloadTaskBatch(ctx context.Context, in *DeleteDownloadTaskBatchRequest, opts ...grpc.CallOption) (*DeleteDownloadTaskBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDownloadTaskBatchResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_DeleteDownloadTaskBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *goLoadServiceClient) GetDownloadTaskFile(ctx context.Context, in *GetDownloadTaskFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetDownloadTaskFileResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &GoLoadService_ServiceDesc.Streams[0], GoLoadService_GetDownloadTaskFile_FullMethodName, cOpts...)
@@ -128,17 +250,168 @@ func (c *goLoadServiceClient) GetDownloadTaskFile(ctx context.Context, in *GetDo
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type GoLoadService_GetDownloadTaskFileClient = grpc.ServerStreamingClient[GetDownloadTaskFileResponse]
 
+func (c *goLoadServiceClient) CreateDownloadTaskShareLink(ctx context.Context, in *CreateDownloadTaskShareLinkRequest, opts ...grpc.CallOption) (*CreateDownloadTaskShareLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateDownloadTaskShareLinkResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_CreateDownloadTaskShareLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) RevokeDownloadTaskShareLink(ctx context.Context, in *RevokeDownloadTaskShareLinkRequest, opts ...grpc.CallOption) (*RevokeDownloadTaskShareLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeDownloadTaskShareLinkResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_RevokeDownloadTaskShareLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) CreateWorkspace(ctx context.Context, in *CreateWorkspaceRequest, opts ...grpc.CallOption) (*CreateWorkspaceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateWorkspaceResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_CreateWorkspace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) GetWorkspaceList(ctx context.Context, in *GetWorkspaceListRequest, opts ...grpc.CallOption) (*GetWorkspaceListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWorkspaceListResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_GetWorkspaceList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) GetWorkspaceMemberList(ctx context.Context, in *GetWorkspaceMemberListRequest, opts ...grpc.CallOption) (*GetWorkspaceMemberListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWorkspaceMemberListResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_GetWorkspaceMemberList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) AddWorkspaceMember(ctx context.Context, in *AddWorkspaceMemberRequest, opts ...grpc.CallOption) (*AddWorkspaceMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddWorkspaceMemberResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_AddWorkspaceMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) UpdateWorkspaceMember(ctx context.Context, in *UpdateWorkspaceMemberRequest, opts ...grpc.CallOption) (*UpdateWorkspaceMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateWorkspaceMemberResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_UpdateWorkspaceMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) RemoveWorkspaceMember(ctx context.Context, in *RemoveWorkspaceMemberRequest, opts ...grpc.CallOption) (*RemoveWorkspaceMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveWorkspaceMemberResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_RemoveWorkspaceMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPIKeysResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAPIKeyResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) ListAccountIdentities(ctx context.Context, in *ListAccountIdentitiesRequest, opts ...grpc.CallOption) (*ListAccountIdentitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAccountIdentitiesResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_ListAccountIdentities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadServiceClient) UnlinkAccountIdentity(ctx context.Context, in *UnlinkAccountIdentityRequest, opts ...grpc.CallOption) (*UnlinkAccountIdentityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlinkAccountIdentityResponse)
+	err := c.cc.Invoke(ctx, GoLoadService_UnlinkAccountIdentity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GoLoadServiceServer is the server API for GoLoadService service.
 // All implementations must embed UnimplementedGoLoadServiceServer
 // for forward compatibility.
 type GoLoadServiceServer interface {
 	CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error)
 	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
+	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	DeleteAllSessions(context.Context, *DeleteAllSessionsRequest) (*DeleteAllSessionsResponse, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error)
+	DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error)
 	CreateDownloadTask(context.Context, *CreateDownloadTaskRequest) (*CreateDownloadTaskResponse, error)
+	CreateDownloadTasks(context.Context, *CreateDownloadTasksRequest) (*CreateDownloadTasksResponse, error)
+	ImportDownloadTasks(context.Context, *ImportDownloadTasksRequest) (*ImportDownloadTasksResponse, error)
 	GetDownloadTaskList(context.Context, *GetDownloadTaskListRequest) (*GetDownloadTaskListResponse, error)
 	UpdateDownloadTask(context.Context, *UpdateDownloadTaskRequest) (*UpdateDownloadTaskResponse, error)
 	DeleteDownloadTask(context.Context, *DeleteDownloadTaskRequest) (*DeleteDownloadTaskResponse, error)
+	CancelDownloadTaskBatch(context.Context, *CancelDownloadTaskBatchRequest) (*CancelDownloadTaskBatchResponse, error)
+	DeleteDownloadTaskBatch(context.Context, *DeleteDownloadTaskBatchRequest) (*DeleteDownloadTaskBatchResponse, error)
 	GetDownloadTaskFile(*GetDownloadTaskFileRequest, grpc.ServerStreamingServer[GetDownloadTaskFileResponse]) error
+	CreateDownloadTaskShareLink(context.Context, *CreateDownloadTaskShareLinkRequest) (*CreateDownloadTaskShareLinkResponse, error)
+	RevokeDownloadTaskShareLink(context.Context, *RevokeDownloadTaskShareLinkRequest) (*RevokeDownloadTaskShareLinkResponse, error)
+	CreateWorkspace(context.Context, *CreateWorkspaceRequest) (*CreateWorkspaceResponse, error)
+	GetWorkspaceList(context.Context, *GetWorkspaceListRequest) (*GetWorkspaceListResponse, error)
+	GetWorkspaceMemberList(context.Context, *GetWorkspaceMemberListRequest) (*GetWorkspaceMemberListResponse, error)
+	AddWorkspaceMember(context.Context, *AddWorkspaceMemberRequest) (*AddWorkspaceMemberResponse, error)
+	UpdateWorkspaceMember(context.Context, *UpdateWorkspaceMemberRequest) (*UpdateWorkspaceMemberResponse, error)
+	RemoveWorkspaceMember(context.Context, *RemoveWorkspaceMemberRequest) (*RemoveWorkspaceMemberResponse, error)
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error)
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error)
+	ListAccountIdentities(context.Context, *ListAccountIdentitiesRequest) (*ListAccountIdentitiesResponse, error)
+	UnlinkAccountIdentity(context.Context, *UnlinkAccountIdentityRequest) (*UnlinkAccountIdentityResponse, error)
 	mustEmbedUnimplementedGoLoadServiceServer()
 }
 
@@ -155,9 +428,27 @@ func (UnimplementedGoLoadServiceServer) CreateAccount(context.Context, *CreateAc
 func (UnimplementedGoLoadServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
 }
+func (UnimplementedGoLoadServiceServer) DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
+}
+func (UnimplementedGoLoadServiceServer) DeleteAllSessions(context.Context, *DeleteAllSessionsRequest) (*DeleteAllSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAllSessions not implemented")
+}
+func (UnimplementedGoLoadServiceServer) ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedGoLoadServiceServer) DeleteAccount(context.Context, *DeleteAccountRequest) (*DeleteAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
 func (UnimplementedGoLoadServiceServer) CreateDownloadTask(context.Context, *CreateDownloadTaskRequest) (*CreateDownloadTaskResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateDownloadTask not implemented")
 }
+func (UnimplementedGoLoadServiceServer) CreateDownloadTasks(context.Context, *CreateDownloadTasksRequest) (*CreateDownloadTasksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDownloadTasks not implemented")
+}
+func (UnimplementedGoLoadServiceServer) ImportDownloadTasks(context.Context, *ImportDownloadTasksRequest) (*ImportDownloadTasksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportDownloadTasks not implemented")
+}
 func (UnimplementedGoLoadServiceServer) GetDownloadTaskList(context.Context, *GetDownloadTaskListRequest) (*GetDownloadTaskListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDownloadTaskList not implemented")
 }
@@ -167,9 +458,54 @@ func (UnimplementedGoLoadServiceServer) UpdateDownloadTask(context.Context, *Upd
 func (UnimplementedGoLoadServiceServer) DeleteDownloadTask(context.Context, *DeleteDownloadTaskRequest) (*DeleteDownloadTaskResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteDownloadTask not implemented")
 }
+func (UnimplementedGoLoadServiceServer) CancelDownloadTaskBatch(context.Context, *CancelDownloadTaskBatchRequest) (*CancelDownloadTaskBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelDownloadTaskBatch not implemented")
+}
+func (UnimplementedGoLoadServiceServer) DeleteDownloadTaskBatch(context.Context, *DeleteDownloadTaskBatchRequest) (*DeleteDownloadTaskBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDownloadTaskBatch not implemented")
+}
 func (UnimplementedGoLoadServiceServer) GetDownloadTaskFile(*GetDownloadTaskFileRequest, grpc.ServerStreamingServer[GetDownloadTaskFileResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method GetDownloadTaskFile not implemented")
 }
+func (UnimplementedGoLoadServiceServer) CreateDownloadTaskShareLink(context.Context, *CreateDownloadTaskShareLinkRequest) (*CreateDownloadTaskShareLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDownloadTaskShareLink not implemented")
+}
+func (UnimplementedGoLoadServiceServer) RevokeDownloadTaskShareLink(context.Context, *RevokeDownloadTaskShareLinkRequest) (*RevokeDownloadTaskShareLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeDownloadTaskShareLink not implemented")
+}
+func (UnimplementedGoLoadServiceServer) CreateWorkspace(context.Context, *CreateWorkspaceRequest) (*CreateWorkspaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWorkspace not implemented")
+}
+func (UnimplementedGoLoadServiceServer) GetWorkspaceList(context.Context, *GetWorkspaceListRequest) (*GetWorkspaceListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkspaceList not implemented")
+}
+func (UnimplementedGoLoadServiceServer) GetWorkspaceMemberList(context.Context, *GetWorkspaceMemberListRequest) (*GetWorkspaceMemberListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkspaceMemberList not implemented")
+}
+func (UnimplementedGoLoadServiceServer) AddWorkspaceMember(context.Context, *AddWorkspaceMemberRequest) (*AddWorkspaceMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddWorkspaceMember not implemented")
+}
+func (UnimplementedGoLoadServiceServer) UpdateWorkspaceMember(context.Context, *UpdateWorkspaceMemberRequest) (*UpdateWorkspaceMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateWorkspaceMember not implemented")
+}
+func (UnimplementedGoLoadServiceServer) RemoveWorkspaceMember(context.Context, *RemoveWorkspaceMemberRequest) (*RemoveWorkspaceMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveWorkspaceMember not implemented")
+}
+func (UnimplementedGoLoadServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedGoLoadServiceServer) ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedGoLoadServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
+func (UnimplementedGoLoadServiceServer) ListAccountIdentities(context.Context, *ListAccountIdentitiesRequest) (*ListAccountIdentitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountIdentities not implemented")
+}
+func (UnimplementedGoLoadServiceServer) UnlinkAccountIdentity(context.Context, *UnlinkAccountIdentityRequest) (*UnlinkAccountIdentityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlinkAccountIdentity not implemented")
+}
 func (UnimplementedGoLoadServiceServer) mustEmbedUnimplementedGoLoadServiceServer() {}
 func (UnimplementedGoLoadServiceServer) testEmbeddedByValue()                       {}
 
@@ -227,6 +563,78 @@ func _GoLoadService_CreateSession_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GoLoadService_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).DeleteSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_DeleteSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).DeleteSession(ctx, req.(*DeleteSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_DeleteAllSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAllSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).DeleteAllSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_DeleteAllSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).DeleteAllSessions(ctx, req.(*DeleteAllSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_DeleteAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).DeleteAccount(ctx, req.(*DeleteAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _GoLoadService_CreateDownloadTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateDownloadTaskRequest)
 	if err := dec(in); err != nil {
@@ -245,6 +653,42 @@ func _GoLoadService_CreateDownloadTask_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GoLoadService_CreateDownloadTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDownloadTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).CreateDownloadTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_CreateDownloadTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).CreateDownloadTasks(ctx, req.(*CreateDownloadTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_ImportDownloadTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportDownloadTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).ImportDownloadTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_ImportDownloadTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).ImportDownloadTasks(ctx, req.(*ImportDownloadTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _GoLoadService_GetDownloadTaskList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetDownloadTaskListRequest)
 	if err := dec(in); err != nil {
@@ -299,17 +743,287 @@ func _GoLoadService_DeleteDownloadTask_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GoLoadService_GetDownloadTaskFile_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(GetDownloadTaskFileRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func _GoLoadService_CancelDownloadTaskBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelDownloadTaskBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return srv.(GoLoadServiceServer).GetDownloadTaskFile(m, &grpc.GenericServerStream[GetDownloadTaskFileRequest, GetDownloadTaskFileResponse]{ServerStream: stream})
-}
-
-// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).CancelDownloadTaskBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_CancelDownloadTaskBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).CancelDownloadTaskBatch(ctx, req.(*CancelDownloadTaskBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_DeleteDownloadTaskBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDownloadTaskBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).DeleteDownloadTaskBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_DeleteDownloadTaskBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).DeleteDownloadTaskBatch(ctx, req.(*DeleteDownloadTaskBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_GetDownloadTaskFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetDownloadTaskFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoLoadServiceServer).GetDownloadTaskFile(m, &grpc.GenericServerStream[GetDownloadTaskFileRequest, GetDownloadTaskFileResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type GoLoadService_GetDownloadTaskFileServer = grpc.ServerStreamingServer[GetDownloadTaskFileResponse]
 
+func _GoLoadService_CreateDownloadTaskShareLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDownloadTaskShareLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).CreateDownloadTaskShareLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_CreateDownloadTaskShareLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).CreateDownloadTaskShareLink(ctx, req.(*CreateDownloadTaskShareLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_RevokeDownloadTaskShareLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeDownloadTaskShareLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).RevokeDownloadTaskShareLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_RevokeDownloadTaskShareLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).RevokeDownloadTaskShareLink(ctx, req.(*RevokeDownloadTaskShareLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_CreateWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).CreateWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_CreateWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).CreateWorkspace(ctx, req.(*CreateWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_GetWorkspaceList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkspaceListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).GetWorkspaceList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_GetWorkspaceList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).GetWorkspaceList(ctx, req.(*GetWorkspaceListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_GetWorkspaceMemberList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkspaceMemberListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).GetWorkspaceMemberList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_GetWorkspaceMemberList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).GetWorkspaceMemberList(ctx, req.(*GetWorkspaceMemberListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_AddWorkspaceMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddWorkspaceMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).AddWorkspaceMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_AddWorkspaceMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).AddWorkspaceMember(ctx, req.(*AddWorkspaceMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_UpdateWorkspaceMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateWorkspaceMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).UpdateWorkspaceMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_UpdateWorkspaceMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).UpdateWorkspaceMember(ctx, req.(*UpdateWorkspaceMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_RemoveWorkspaceMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveWorkspaceMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).RemoveWorkspaceMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_RemoveWorkspaceMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).RemoveWorkspaceMember(ctx, req.(*RemoveWorkspaceMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).ListAPIKeys(ctx, req.(*ListAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_ListAccountIdentities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountIdentitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).ListAccountIdentities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_ListAccountIdentities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).ListAccountIdentities(ctx, req.(*ListAccountIdentitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadService_UnlinkAccountIdentity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlinkAccountIdentityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadServiceServer).UnlinkAccountIdentity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadService_UnlinkAccountIdentity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadServiceServer).UnlinkAccountIdentity(ctx, req.(*UnlinkAccountIdentityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GoLoadService_ServiceDesc is the grpc.ServiceDesc for GoLoadService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -325,10 +1039,34 @@ var GoLoadService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateSession",
 			Handler:    _GoLoadService_CreateSession_Handler,
 		},
+		{
+			MethodName: "DeleteSession",
+			Handler:    _GoLoadService_DeleteSession_Handler,
+		},
+		{
+			MethodName: "DeleteAllSessions",
+			Handler:    _GoLoadService_DeleteAllSessions_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _GoLoadService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _GoLoadService_DeleteAccount_Handler,
+		},
 		{
 			MethodName: "CreateDownloadTask",
 			Handler:    _GoLoadService_CreateDownloadTask_Handler,
 		},
+		{
+			MethodName: "CreateDownloadTasks",
+			Handler:    _GoLoadService_CreateDownloadTasks_Handler,
+		},
+		{
+			MethodName: "ImportDownloadTasks",
+			Handler:    _GoLoadService_ImportDownloadTasks_Handler,
+		},
 		{
 			MethodName: "GetDownloadTaskList",
 			Handler:    _GoLoadService_GetDownloadTaskList_Handler,
@@ -341,6 +1079,66 @@ var GoLoadService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteDownloadTask",
 			Handler:    _GoLoadService_DeleteDownloadTask_Handler,
 		},
+		{
+			MethodName: "CancelDownloadTaskBatch",
+			Handler:    _GoLoadService_CancelDownloadTaskBatch_Handler,
+		},
+		{
+			MethodName: "DeleteDownloadTaskBatch",
+			Handler:    _GoLoadService_DeleteDownloadTaskBatch_Handler,
+		},
+		{
+			MethodName: "CreateDownloadTaskShareLink",
+			Handler:    _GoLoadService_CreateDownloadTaskShareLink_Handler,
+		},
+		{
+			MethodName: "RevokeDownloadTaskShareLink",
+			Handler:    _GoLoadService_RevokeDownloadTaskShareLink_Handler,
+		},
+		{
+			MethodName: "CreateWorkspace",
+			Handler:    _GoLoadService_CreateWorkspace_Handler,
+		},
+		{
+			MethodName: "GetWorkspaceList",
+			Handler:    _GoLoadService_GetWorkspaceList_Handler,
+		},
+		{
+			MethodName: "GetWorkspaceMemberList",
+			Handler:    _GoLoadService_GetWorkspaceMemberList_Handler,
+		},
+		{
+			MethodName: "AddWorkspaceMember",
+			Handler:    _GoLoadService_AddWorkspaceMember_Handler,
+		},
+		{
+			MethodName: "UpdateWorkspaceMember",
+			Handler:    _GoLoadService_UpdateWorkspaceMember_Handler,
+		},
+		{
+			MethodName: "RemoveWorkspaceMember",
+			Handler:    _GoLoadService_RemoveWorkspaceMember_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _GoLoadService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _GoLoadService_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _GoLoadService_RevokeAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAccountIdentities",
+			Handler:    _GoLoadService_ListAccountIdentities_Handler,
+		},
+		{
+			MethodName: "UnlinkAccountIdentity",
+			Handler:    _GoLoadService_UnlinkAccountIdentity_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -351,3 +1149,413 @@ var GoLoadService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "api/go_load.proto",
 }
+
+const (
+	GoLoadAdminService_GetDownloadTaskList_FullMethodName  = "/go_load.GoLoadAdminService/GetDownloadTaskList"
+	GoLoadAdminService_RetryDownloadTask_FullMethodName    = "/go_load.GoLoadAdminService/RetryDownloadTask"
+	GoLoadAdminService_FailDownloadTask_FullMethodName     = "/go_load.GoLoadAdminService/FailDownloadTask"
+	GoLoadAdminService_DisableAccount_FullMethodName       = "/go_load.GoLoadAdminService/DisableAccount"
+	GoLoadAdminService_EnableAccount_FullMethodName        = "/go_load.GoLoadAdminService/EnableAccount"
+	GoLoadAdminService_ResetAccountPassword_FullMethodName = "/go_load.GoLoadAdminService/ResetAccountPassword"
+	GoLoadAdminService_UpdateAccountRole_FullMethodName    = "/go_load.GoLoadAdminService/UpdateAccountRole"
+	GoLoadAdminService_GetQueueDepth_FullMethodName        = "/go_load.GoLoadAdminService/GetQueueDepth"
+	GoLoadAdminService_GetAccountUsageList_FullMethodName  = "/go_load.GoLoadAdminService/GetAccountUsageList"
+)
+
+// GoLoadAdminServiceClient is the client API for GoLoadAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GoLoadAdminService is only available to accounts with the Admin role, and is exposed by the gateway under /admin.
+type GoLoadAdminServiceClient interface {
+	GetDownloadTaskList(ctx context.Context, in *AdminGetDownloadTaskListRequest, opts ...grpc.CallOption) (*AdminGetDownloadTaskListResponse, error)
+	RetryDownloadTask(ctx context.Context, in *AdminRetryDownloadTaskRequest, opts ...grpc.CallOption) (*AdminRetryDownloadTaskResponse, error)
+	FailDownloadTask(ctx context.Context, in *AdminFailDownloadTaskRequest, opts ...grpc.CallOption) (*AdminFailDownloadTaskResponse, error)
+	DisableAccount(ctx context.Context, in *AdminDisableAccountRequest, opts ...grpc.CallOption) (*AdminDisableAccountResponse, error)
+	EnableAccount(ctx context.Context, in *AdminEnableAccountRequest, opts ...grpc.CallOption) (*AdminEnableAccountResponse, error)
+	ResetAccountPassword(ctx context.Context, in *AdminResetAccountPasswordRequest, opts ...grpc.CallOption) (*AdminResetAccountPasswordResponse, error)
+	UpdateAccountRole(ctx context.Context, in *AdminUpdateAccountRoleRequest, opts ...grpc.CallOption) (*AdminUpdateAccountRoleResponse, error)
+	GetQueueDepth(ctx context.Context, in *AdminGetQueueDepthRequest, opts ...grpc.CallOption) (*AdminGetQueueDepthResponse, error)
+	GetAccountUsageList(ctx context.Context, in *AdminGetAccountUsageListRequest, opts ...grpc.CallOption) (*AdminGetAccountUsageListResponse, error)
+}
+
+type goLoadAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoLoadAdminServiceClient(cc grpc.ClientConnInterface) GoLoadAdminServiceClient {
+	return &goLoadAdminServiceClient{cc}
+}
+
+func (c *goLoadAdminServiceClient) GetDownloadTaskList(ctx context.Context, in *AdminGetDownloadTaskListRequest, opts ...grpc.CallOption) (*AdminGetDownloadTaskListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminGetDownloadTaskListResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_GetDownloadTaskList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) RetryDownloadTask(ctx context.Context, in *AdminRetryDownloadTaskRequest, opts ...grpc.CallOption) (*AdminRetryDownloadTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminRetryDownloadTaskResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_RetryDownloadTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) FailDownloadTask(ctx context.Context, in *AdminFailDownloadTaskRequest, opts ...grpc.CallOption) (*AdminFailDownloadTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminFailDownloadTaskResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_FailDownloadTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) DisableAccount(ctx context.Context, in *AdminDisableAccountRequest, opts ...grpc.CallOption) (*AdminDisableAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminDisableAccountResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_DisableAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) EnableAccount(ctx context.Context, in *AdminEnableAccountRequest, opts ...grpc.CallOption) (*AdminEnableAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminEnableAccountResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_EnableAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) ResetAccountPassword(ctx context.Context, in *AdminResetAccountPasswordRequest, opts ...grpc.CallOption) (*AdminResetAccountPasswordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminResetAccountPasswordResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_ResetAccountPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) UpdateAccountRole(ctx context.Context, in *AdminUpdateAccountRoleRequest, opts ...grpc.CallOption) (*AdminUpdateAccountRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminUpdateAccountRoleResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_UpdateAccountRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) GetQueueDepth(ctx context.Context, in *AdminGetQueueDepthRequest, opts ...grpc.CallOption) (*AdminGetQueueDepthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminGetQueueDepthResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_GetQueueDepth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goLoadAdminServiceClient) GetAccountUsageList(ctx context.Context, in *AdminGetAccountUsageListRequest, opts ...grpc.CallOption) (*AdminGetAccountUsageListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminGetAccountUsageListResponse)
+	err := c.cc.Invoke(ctx, GoLoadAdminService_GetAccountUsageList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GoLoadAdminServiceServer is the server API for GoLoadAdminService service.
+// All implementations must embed UnimplementedGoLoadAdminServiceServer
+// for forward compatibility.
+//
+// GoLoadAdminService is only available to accounts with the Admin role, and is exposed by the gateway under /admin.
+type GoLoadAdminServiceServer interface {
+	GetDownloadTaskList(context.Context, *AdminGetDownloadTaskListRequest) (*AdminGetDownloadTaskListResponse, error)
+	RetryDownloadTask(context.Context, *AdminRetryDownloadTaskRequest) (*AdminRetryDownloadTaskResponse, error)
+	FailDownloadTask(context.Context, *AdminFailDownloadTaskRequest) (*AdminFailDownloadTaskResponse, error)
+	DisableAccount(context.Context, *AdminDisableAccountRequest) (*AdminDisableAccountResponse, error)
+	EnableAccount(context.Context, *AdminEnableAccountRequest) (*AdminEnableAccountResponse, error)
+	ResetAccountPassword(context.Context, *AdminResetAccountPasswordRequest) (*AdminResetAccountPasswordResponse, error)
+	UpdateAccountRole(context.Context, *AdminUpdateAccountRoleRequest) (*AdminUpdateAccountRoleResponse, error)
+	GetQueueDepth(context.Context, *AdminGetQueueDepthRequest) (*AdminGetQueueDepthResponse, error)
+	GetAccountUsageList(context.Context, *AdminGetAccountUsageListRequest) (*AdminGetAccountUsageListResponse, error)
+	mustEmbedUnimplementedGoLoadAdminServiceServer()
+}
+
+// UnimplementedGoLoadAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGoLoadAdminServiceServer struct{}
+
+func (UnimplementedGoLoadAdminServiceServer) GetDownloadTaskList(context.Context, *AdminGetDownloadTaskListRequest) (*AdminGetDownloadTaskListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDownloadTaskList not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) RetryDownloadTask(context.Context, *AdminRetryDownloadTaskRequest) (*AdminRetryDownloadTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryDownloadTask not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) FailDownloadTask(context.Context, *AdminFailDownloadTaskRequest) (*AdminFailDownloadTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FailDownloadTask not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) DisableAccount(context.Context, *AdminDisableAccountRequest) (*AdminDisableAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableAccount not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) EnableAccount(context.Context, *AdminEnableAccountRequest) (*AdminEnableAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableAccount not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) ResetAccountPassword(context.Context, *AdminResetAccountPasswordRequest) (*AdminResetAccountPasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetAccountPassword not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) UpdateAccountRole(context.Context, *AdminUpdateAccountRoleRequest) (*AdminUpdateAccountRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAccountRole not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) GetQueueDepth(context.Context, *AdminGetQueueDepthRequest) (*AdminGetQueueDepthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQueueDepth not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) GetAccountUsageList(context.Context, *AdminGetAccountUsageListRequest) (*AdminGetAccountUsageListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountUsageList not implemented")
+}
+func (UnimplementedGoLoadAdminServiceServer) mustEmbedUnimplementedGoLoadAdminServiceServer() {}
+func (UnimplementedGoLoadAdminServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeGoLoadAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoLoadAdminServiceServer will
+// result in compilation errors.
+type UnsafeGoLoadAdminServiceServer interface {
+	mustEmbedUnimplementedGoLoadAdminServiceServer()
+}
+
+func RegisterGoLoadAdminServiceServer(s grpc.ServiceRegistrar, srv GoLoadAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedGoLoadAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GoLoadAdminService_ServiceDesc, srv)
+}
+
+func _GoLoadAdminService_GetDownloadTaskList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminGetDownloadTaskListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).GetDownloadTaskList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_GetDownloadTaskList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).GetDownloadTaskList(ctx, req.(*AdminGetDownloadTaskListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_RetryDownloadTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminRetryDownloadTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).RetryDownloadTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_RetryDownloadTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).RetryDownloadTask(ctx, req.(*AdminRetryDownloadTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_FailDownloadTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminFailDownloadTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).FailDownloadTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_FailDownloadTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).FailDownloadTask(ctx, req.(*AdminFailDownloadTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_DisableAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminDisableAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).DisableAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_DisableAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).DisableAccount(ctx, req.(*AdminDisableAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_EnableAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminEnableAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).EnableAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_EnableAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).EnableAccount(ctx, req.(*AdminEnableAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_ResetAccountPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminResetAccountPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).ResetAccountPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_ResetAccountPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).ResetAccountPassword(ctx, req.(*AdminResetAccountPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_UpdateAccountRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUpdateAccountRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).UpdateAccountRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_UpdateAccountRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).UpdateAccountRole(ctx, req.(*AdminUpdateAccountRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_GetQueueDepth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminGetQueueDepthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).GetQueueDepth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_GetQueueDepth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).GetQueueDepth(ctx, req.(*AdminGetQueueDepthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoLoadAdminService_GetAccountUsageList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminGetAccountUsageListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoLoadAdminServiceServer).GetAccountUsageList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoLoadAdminService_GetAccountUsageList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoLoadAdminServiceServer).GetAccountUsageList(ctx, req.(*AdminGetAccountUsageListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GoLoadAdminService_ServiceDesc is the grpc.ServiceDesc for GoLoadAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoLoadAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "go_load.GoLoadAdminService",
+	HandlerType: (*GoLoadAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDownloadTaskList",
+			Handler:    _GoLoadAdminService_GetDownloadTaskList_Handler,
+		},
+		{
+			MethodName: "RetryDownloadTask",
+			Handler:    _GoLoadAdminService_RetryDownloadTask_Handler,
+		},
+		{
+			MethodName: "FailDownloadTask",
+			Handler:    _GoLoadAdminService_FailDownloadTask_Handler,
+		},
+		{
+			MethodName: "DisableAccount",
+			Handler:    _GoLoadAdminService_DisableAccount_Handler,
+		},
+		{
+			MethodName: "EnableAccount",
+			Handler:    _GoLoadAdminService_EnableAccount_Handler,
+		},
+		{
+			MethodName: "ResetAccountPassword",
+			Handler:    _GoLoadAdminService_ResetAccountPassword_Handler,
+		},
+		{
+			MethodName: "UpdateAccountRole",
+			Handler:    _GoLoadAdminService_UpdateAccountRole_Handler,
+		},
+		{
+			MethodName: "GetQueueDepth",
+			Handler:    _GoLoadAdminService_GetQueueDepth_Handler,
+		},
+		{
+			MethodName: "GetAccountUsageList",
+			Handler:    _GoLoadAdminService_GetAccountUsageList_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/go_load.proto",
+}
@@ -0,0 +1,181 @@
+package grpc
+
+import (
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
+	"context"
+)
+
+// AdminHandler serves GoLoadAdminService. Its callers are authorized by newAdminAuthorizationUnaryServerInterceptor.
+type AdminHandler struct {
+	go_load.UnimplementedGoLoadAdminServiceServer
+	adminLogic logic.Admin
+}
+
+func NewAdminHandler(adminLogic logic.Admin) go_load.GoLoadAdminServiceServer {
+	return &AdminHandler{
+		adminLogic: adminLogic,
+	}
+}
+
+// GetDownloadTaskList implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) GetDownloadTaskList(
+	ctx context.Context,
+	request *go_load.AdminGetDownloadTaskListRequest,
+) (*go_load.AdminGetDownloadTaskListResponse, error) {
+	output, err := a.adminLogic.GetDownloadTaskList(ctx, logic.AdminGetDownloadTaskListParams{
+		OfAccountID: request.GetOfAccountId(),
+		DownloadTaskListQuery: logic.DownloadTaskListQuery{
+			BatchID:            request.GetBatchId(),
+			DownloadStatusList: request.GetDownloadStatusList(),
+			DownloadTypeList:   request.GetDownloadTypeList(),
+			URLContains:        request.GetUrlContains(),
+			CreatedAfter:       getTimestampTime(request.GetCreateTimeAfter()),
+			CreatedBefore:      getTimestampTime(request.GetCreateTimeBefore()),
+			UpdatedAfter:       getTimestampTime(request.GetUpdateTimeAfter()),
+			UpdatedBefore:      getTimestampTime(request.GetUpdateTimeBefore()),
+			SortField:          request.GetSortField(),
+			SortOrder:          request.GetSortOrder(),
+			PageToken:          request.GetPageToken(),
+			Offset:             request.GetOffset(),
+			Limit:              request.GetLimit(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminGetDownloadTaskListResponse{
+		DownloadTaskList:       output.DownloadTaskList,
+		TotalDownloadTaskCount: output.TotalDownloadTaskCount,
+		NextPageToken:          output.NextPageToken,
+	}, nil
+}
+
+// RetryDownloadTask implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) RetryDownloadTask(
+	ctx context.Context,
+	request *go_load.AdminRetryDownloadTaskRequest,
+) (*go_load.AdminRetryDownloadTaskResponse, error) {
+	downloadTask, err := a.adminLogic.RetryDownloadTask(ctx, logic.AdminUpdateDownloadTaskParams{
+		DownloadTaskID: request.GetDownloadTaskId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminRetryDownloadTaskResponse{
+		DownloadTask: downloadTask,
+	}, nil
+}
+
+// FailDownloadTask implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) FailDownloadTask(
+	ctx context.Context,
+	request *go_load.AdminFailDownloadTaskRequest,
+) (*go_load.AdminFailDownloadTaskResponse, error) {
+	downloadTask, err := a.adminLogic.FailDownloadTask(ctx, logic.AdminUpdateDownloadTaskParams{
+		DownloadTaskID: request.GetDownloadTaskId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminFailDownloadTaskResponse{
+		DownloadTask: downloadTask,
+	}, nil
+}
+
+// DisableAccount implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) DisableAccount(
+	ctx context.Context,
+	request *go_load.AdminDisableAccountRequest,
+) (*go_load.AdminDisableAccountResponse, error) {
+	account, err := a.adminLogic.DisableAccount(ctx, logic.AdminUpdateAccountParams{
+		AccountID: request.GetAccountId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminDisableAccountResponse{
+		Account: account,
+	}, nil
+}
+
+// EnableAccount implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) EnableAccount(
+	ctx context.Context,
+	request *go_load.AdminEnableAccountRequest,
+) (*go_load.AdminEnableAccountResponse, error) {
+	account, err := a.adminLogic.EnableAccount(ctx, logic.AdminUpdateAccountParams{
+		AccountID: request.GetAccountId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminEnableAccountResponse{
+		Account: account,
+	}, nil
+}
+
+// ResetAccountPassword implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) ResetAccountPassword(
+	ctx context.Context,
+	request *go_load.AdminResetAccountPasswordRequest,
+) (*go_load.AdminResetAccountPasswordResponse, error) {
+	if err := a.adminLogic.ResetAccountPassword(ctx, logic.AdminResetAccountPasswordParams{
+		AccountID: request.GetAccountId(),
+		Password:  request.GetPassword(),
+	}); err != nil {
+		return nil, err
+	}
+	return &go_load.AdminResetAccountPasswordResponse{}, nil
+}
+
+// UpdateAccountRole implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) UpdateAccountRole(
+	ctx context.Context,
+	request *go_load.AdminUpdateAccountRoleRequest,
+) (*go_load.AdminUpdateAccountRoleResponse, error) {
+	account, err := a.adminLogic.UpdateAccountRole(ctx, logic.AdminUpdateAccountRoleParams{
+		AccountID: request.GetAccountId(),
+		Role:      request.GetRole(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminUpdateAccountRoleResponse{
+		Account: account,
+	}, nil
+}
+
+// GetQueueDepth implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) GetQueueDepth(
+	ctx context.Context,
+	_ *go_load.AdminGetQueueDepthRequest,
+) (*go_load.AdminGetQueueDepthResponse, error) {
+	output, err := a.adminLogic.GetQueueDepth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminGetQueueDepthResponse{
+		PendingDownloadTaskCount:     output.PendingDownloadTaskCount,
+		DownloadingDownloadTaskCount: output.DownloadingDownloadTaskCount,
+		DownloadStatusCountList:      output.DownloadStatusCountList,
+	}, nil
+}
+
+// GetAccountUsageList implements go_load.GoLoadAdminServiceServer.
+func (a *AdminHandler) GetAccountUsageList(
+	ctx context.Context,
+	request *go_load.AdminGetAccountUsageListRequest,
+) (*go_load.AdminGetAccountUsageListResponse, error) {
+	output, err := a.adminLogic.GetAccountUsageList(ctx, logic.AdminGetAccountUsageListParams{
+		Offset: request.GetOffset(),
+		Limit:  request.GetLimit(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AdminGetAccountUsageListResponse{
+		AccountUsageList:  output.AccountUsageList,
+		TotalAccountCount: output.TotalAccountCount,
+	}, nil
+}
@@ -7,45 +7,121 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
+	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
 	//nolint:gosec // This is just to specify the metadata name
 	AuthTokenMetadataName = "GOLOAD_AUTH"
+	// AuthorizationMetadataName carries "Bearer <token>", which is how API keys are usually sent.
+	AuthorizationMetadataName = "authorization"
+	authorizationBearerPrefix = "Bearer "
+	// forwardedForMetadataName is set by the gateway to the client address, appended to any X-Forwarded-For header
+	// of the request.
+	forwardedForMetadataName = "x-forwarded-for"
 )
 
 type Handler struct {
 	go_load.UnimplementedGoLoadServiceServer
 	accountLogic                                 logic.Account
 	downloadTaskLogic                            logic.DownloadTask
+	downloadTaskShareLinkLogic                   logic.DownloadTaskShareLink
+	workspaceLogic                               logic.Workspace
+	apiKeyLogic                                  logic.APIKey
+	oidcLogic                                    logic.OIDC
 	getDownloadTaskFileResponseBufferSizeInBytes uint64
 }
 
-func NewHandler(accountLogic logic.Account, downloadTaskLogic logic.DownloadTask, grpcConfig configs.GRPC) (go_load.GoLoadServiceServer, error) {
+func NewHandler(
+	accountLogic logic.Account,
+	downloadTaskLogic logic.DownloadTask,
+	downloadTaskShareLinkLogic logic.DownloadTaskShareLink,
+	workspaceLogic logic.Workspace,
+	apiKeyLogic logic.APIKey,
+	oidcLogic logic.OIDC,
+	grpcConfig configs.GRPC,
+) (go_load.GoLoadServiceServer, error) {
 	getDownloadTaskFileResponseBufferSizeInBytes, err := grpcConfig.GetDownloadTaskFile.GetResponseBufferSizeInBytes()
 	if err != nil {
 		return nil, err
 	}
 	return &Handler{
-		accountLogic:      accountLogic,
-		downloadTaskLogic: downloadTaskLogic,
+		accountLogic:               accountLogic,
+		downloadTaskLogic:          downloadTaskLogic,
+		downloadTaskShareLinkLogic: downloadTaskShareLinkLogic,
+		workspaceLogic:             workspaceLogic,
+		apiKeyLogic:                apiKeyLogic,
+		oidcLogic:                  oidcLogic,
 		getDownloadTaskFileResponseBufferSizeInBytes: getDownloadTaskFileResponseBufferSizeInBytes,
 	}, nil
 }
 
-func (a *Handler) getAuthTokenMetadata(ctx context.Context) string {
+func getAuthTokenMetadata(ctx context.Context) string {
 	metadata, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return ""
 	}
-	metadataValues := metadata.Get(AuthTokenMetadataName)
-	if len(metadataValues) == 0 {
+	if metadataValues := metadata.Get(AuthTokenMetadataName); len(metadataValues) > 0 {
+		return metadataValues[0]
+	}
+	if metadataValues := metadata.Get(AuthorizationMetadataName); len(metadataValues) > 0 {
+		return GetBearerToken(metadataValues[0])
+	}
+	return ""
+}
+
+// GetBearerToken returns the token of an authorization header value, or an empty string if it is not a bearer token.
+func GetBearerToken(authorization string) string {
+	if len(authorization) < len(authorizationBearerPrefix) ||
+		!strings.EqualFold(authorization[:len(authorizationBearerPrefix)], authorizationBearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(authorizationBearerPrefix):])
+}
+
+// getClientIP returns the IP of the peer, or the one the gateway forwarded for if the peer is a local gateway. Entries
+// before the last one of X-Forwarded-For are set by the client and cannot be trusted.
+func getClientIP(ctx context.Context) string {
+	peer, ok := peer.FromContext(ctx)
+	if !ok || peer.Addr == nil {
 		return ""
 	}
-	return metadataValues[0]
+	peerIP := peer.Addr.String()
+	if host, _, err := net.SplitHostPort(peerIP); err == nil {
+		peerIP = host
+	}
+	if ip := net.ParseIP(peerIP); ip == nil || !ip.IsLoopback() {
+		return peerIP
+	}
+	metadata, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return peerIP
+	}
+	forwardedForValues := metadata.Get(forwardedForMetadataName)
+	if len(forwardedForValues) == 0 {
+		return peerIP
+	}
+	forwardedForList := strings.Split(forwardedForValues[len(forwardedForValues)-1], ",")
+	if forwardedFor := strings.TrimSpace(forwardedForList[len(forwardedForList)-1]); forwardedFor != "" {
+		return forwardedFor
+	}
+	return peerIP
+}
+
+// getTimestampTime returns the zero time for an unset timestamp, so that it does not filter.
+func getTimestampTime(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime()
 }
 
 // CreateAccount implements go_load.GoLoadServiceServer.
@@ -65,9 +141,12 @@ func (a *Handler) CreateAccount(ctx context.Context, request *go_load.CreateAcco
 // CreateDownloadTask implements go_load.GoLoadServiceServer.
 func (a *Handler) CreateDownloadTask(ctx context.Context, request *go_load.CreateDownloadTaskRequest) (*go_load.CreateDownloadTaskResponse, error) {
 	output, err := a.downloadTaskLogic.CreateDownloadTask(ctx, logic.CreateDownloadTaskParams{
-		Token:        a.getAuthTokenMetadata(ctx),
-		DownloadType: request.GetDownloadType(),
-		URL:          request.GetUrl(),
+		Token:               getAuthTokenMetadata(ctx),
+		WorkspaceID:         request.GetWorkspaceId(),
+		DownloadType:        request.GetDownloadType(),
+		URL:                 request.GetUrl(),
+		PostProcessingSteps: request.GetPostProcessingSteps(),
+		HTTPRequestOptions:  request.GetHttpRequestOptions(),
 	})
 	if err != nil {
 		return nil, err
@@ -77,11 +156,97 @@ func (a *Handler) CreateDownloadTask(ctx context.Context, request *go_load.Creat
 	}, nil
 }
 
+// CreateDownloadTasks implements go_load.GoLoadServiceServer.
+func (a *Handler) CreateDownloadTasks(
+	ctx context.Context,
+	request *go_load.CreateDownloadTasksRequest,
+) (*go_load.CreateDownloadTasksResponse, error) {
+	output, err := a.downloadTaskLogic.CreateDownloadTaskBatch(ctx, logic.CreateDownloadTaskBatchParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		DownloadTaskList: lo.Map(request.GetDownloadTaskList(), func(item *go_load.CreateDownloadTaskRequest, _ int) logic.CreateDownloadTaskBatchItem {
+			return logic.CreateDownloadTaskBatchItem{
+				DownloadType:        item.GetDownloadType(),
+				URL:                 item.GetUrl(),
+				PostProcessingSteps: item.GetPostProcessingSteps(),
+				HTTPRequestOptions:  item.GetHttpRequestOptions(),
+			}
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.CreateDownloadTasksResponse{
+		BatchId:    output.BatchID,
+		ResultList: output.ResultList,
+	}, nil
+}
+
+// ImportDownloadTasks implements go_load.GoLoadServiceServer.
+func (a *Handler) ImportDownloadTasks(
+	ctx context.Context,
+	request *go_load.ImportDownloadTasksRequest,
+) (*go_load.ImportDownloadTasksResponse, error) {
+	output, err := a.downloadTaskLogic.ImportDownloadTasks(ctx, logic.ImportDownloadTasksParams{
+		Token:               getAuthTokenMetadata(ctx),
+		WorkspaceID:         request.GetWorkspaceId(),
+		Format:              request.GetFormat(),
+		Manifest:            request.GetManifest(),
+		DownloadType:        request.GetDownloadType(),
+		PostProcessingSteps: request.GetPostProcessingSteps(),
+		HTTPRequestOptions:  request.GetHttpRequestOptions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.ImportDownloadTasksResponse{
+		BatchId:    output.BatchID,
+		ResultList: output.ResultList,
+	}, nil
+}
+
+// CancelDownloadTaskBatch implements go_load.GoLoadServiceServer.
+func (a *Handler) CancelDownloadTaskBatch(
+	ctx context.Context,
+	request *go_load.CancelDownloadTaskBatchRequest,
+) (*go_load.CancelDownloadTaskBatchResponse, error) {
+	cancelledDownloadTaskCount, err := a.downloadTaskLogic.CancelDownloadTaskBatch(ctx, logic.CancelDownloadTaskBatchParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		BatchID:     request.GetBatchId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.CancelDownloadTaskBatchResponse{
+		CancelledDownloadTaskCount: cancelledDownloadTaskCount,
+	}, nil
+}
+
+// DeleteDownloadTaskBatch implements go_load.GoLoadServiceServer.
+func (a *Handler) DeleteDownloadTaskBatch(
+	ctx context.Context,
+	request *go_load.DeleteDownloadTaskBatchRequest,
+) (*go_load.DeleteDownloadTaskBatchResponse, error) {
+	deletedDownloadTaskCount, err := a.downloadTaskLogic.DeleteDownloadTaskBatch(ctx, logic.DeleteDownloadTaskBatchParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		BatchID:     request.GetBatchId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.DeleteDownloadTaskBatchResponse{
+		DeletedDownloadTaskCount: deletedDownloadTaskCount,
+	}, nil
+}
+
 // CreateSession implements go_load.GoLoadServiceServer.
 func (a *Handler) CreateSession(ctx context.Context, request *go_load.CreateSessionRequest) (*go_load.CreateSessionResponse, error) {
 	output, err := a.accountLogic.CreateSession(ctx, logic.CreateSessionParams{
 		AccountName: request.GetAccountName(),
 		Password:    request.GetPassword(),
+		ClientIP:    getClientIP(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -95,10 +260,67 @@ func (a *Handler) CreateSession(ctx context.Context, request *go_load.CreateSess
 	}, nil
 }
 
+// DeleteSession implements go_load.GoLoadServiceServer.
+func (a *Handler) DeleteSession(ctx context.Context, request *go_load.DeleteSessionRequest) (*go_load.DeleteSessionResponse, error) {
+	if err := a.accountLogic.DeleteSession(ctx, logic.DeleteSessionParams{
+		Token: getAuthTokenMetadata(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	// An empty token tells the gateway to clear the auth cookie.
+	if err := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadataName, "")); err != nil {
+		return nil, err
+	}
+	return &go_load.DeleteSessionResponse{}, nil
+}
+
+// DeleteAllSessions implements go_load.GoLoadServiceServer.
+func (a *Handler) DeleteAllSessions(ctx context.Context, request *go_load.DeleteAllSessionsRequest) (*go_load.DeleteAllSessionsResponse, error) {
+	if err := a.accountLogic.DeleteAllSessions(ctx, logic.DeleteAllSessionsParams{
+		Token: getAuthTokenMetadata(ctx),
+	}); err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadataName, "")); err != nil {
+		return nil, err
+	}
+	return &go_load.DeleteAllSessionsResponse{}, nil
+}
+
+// ChangePassword implements go_load.GoLoadServiceServer.
+func (a *Handler) ChangePassword(ctx context.Context, request *go_load.ChangePasswordRequest) (*go_load.ChangePasswordResponse, error) {
+	output, err := a.accountLogic.ChangePassword(ctx, logic.ChangePasswordParams{
+		Token:       getAuthTokenMetadata(ctx),
+		OldPassword: request.GetOldPassword(),
+		NewPassword: request.GetNewPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadataName, output.Token)); err != nil {
+		return nil, err
+	}
+	return &go_load.ChangePasswordResponse{}, nil
+}
+
+// DeleteAccount implements go_load.GoLoadServiceServer.
+func (a *Handler) DeleteAccount(ctx context.Context, request *go_load.DeleteAccountRequest) (*go_load.DeleteAccountResponse, error) {
+	if err := a.accountLogic.DeleteAccount(ctx, logic.DeleteAccountParams{
+		Token:    getAuthTokenMetadata(ctx),
+		Password: request.GetPassword(),
+	}); err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadataName, "")); err != nil {
+		return nil, err
+	}
+	return &go_load.DeleteAccountResponse{}, nil
+}
+
 // DeleteDownloadTask implements go_load.GoLoadServiceServer.
 func (a *Handler) DeleteDownloadTask(ctx context.Context, request *go_load.DeleteDownloadTaskRequest) (*go_load.DeleteDownloadTaskResponse, error) {
 	if err := a.downloadTaskLogic.DeleteDownloadTask(ctx, logic.DeleteDownloadTaskParams{
-		Token:          a.getAuthTokenMetadata(ctx),
+		Token:          getAuthTokenMetadata(ctx),
 		DownloadTaskID: request.GetDownloadTaskId(),
 	}); err != nil {
 		return nil, err
@@ -109,8 +331,10 @@ func (a *Handler) DeleteDownloadTask(ctx context.Context, request *go_load.Delet
 // GetDownloadTaskFile implements go_load.GoLoadServiceServer.
 func (a *Handler) GetDownloadTaskFile(request *go_load.GetDownloadTaskFileRequest, server go_load.GoLoadService_GetDownloadTaskFileServer) error {
 	outputReader, err := a.downloadTaskLogic.GetDownloadTaskFile(server.Context(), logic.GetDownloadTaskFileParams{
-		Token:          a.getAuthTokenMetadata(server.Context()),
+		Token:          getAuthTokenMetadata(server.Context()),
 		DownloadTaskID: request.GetDownloadTaskId(),
+		Offset:         request.GetOffset(),
+		Length:         request.GetLength(),
 	})
 	if err != nil {
 		return err
@@ -141,9 +365,23 @@ func (a *Handler) GetDownloadTaskFile(request *go_load.GetDownloadTaskFileReques
 // GetDownloadTaskList implements go_load.GoLoadServiceServer.
 func (a *Handler) GetDownloadTaskList(ctx context.Context, request *go_load.GetDownloadTaskListRequest) (*go_load.GetDownloadTaskListResponse, error) {
 	output, err := a.downloadTaskLogic.GetDownloadTaskList(ctx, logic.GetDownloadTaskListParams{
-		Token:  a.getAuthTokenMetadata(ctx),
-		Offset: request.GetOffset(),
-		Limit:  request.GetLimit(),
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		DownloadTaskListQuery: logic.DownloadTaskListQuery{
+			BatchID:            request.GetBatchId(),
+			DownloadStatusList: request.GetDownloadStatusList(),
+			DownloadTypeList:   request.GetDownloadTypeList(),
+			URLContains:        request.GetUrlContains(),
+			CreatedAfter:       getTimestampTime(request.GetCreateTimeAfter()),
+			CreatedBefore:      getTimestampTime(request.GetCreateTimeBefore()),
+			UpdatedAfter:       getTimestampTime(request.GetUpdateTimeAfter()),
+			UpdatedBefore:      getTimestampTime(request.GetUpdateTimeBefore()),
+			SortField:          request.GetSortField(),
+			SortOrder:          request.GetSortOrder(),
+			PageToken:          request.GetPageToken(),
+			Offset:             request.GetOffset(),
+			Limit:              request.GetLimit(),
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -151,13 +389,14 @@ func (a *Handler) GetDownloadTaskList(ctx context.Context, request *go_load.GetD
 	return &go_load.GetDownloadTaskListResponse{
 		DownloadTaskList:       output.DownloadTaskList,
 		TotalDownloadTaskCount: output.TotalDownloadTaskCount,
+		NextPageToken:          output.NextPageToken,
 	}, nil
 }
 
 // UpdateDownloadTask implements go_load.GoLoadServiceServer.
 func (a *Handler) UpdateDownloadTask(ctx context.Context, request *go_load.UpdateDownloadTaskRequest) (*go_load.UpdateDownloadTaskResponse, error) {
 	output, err := a.downloadTaskLogic.UpdateDownloadTask(ctx, logic.UpdateDownloadTaskParams{
-		Token:          a.getAuthTokenMetadata(ctx),
+		Token:          getAuthTokenMetadata(ctx),
 		DownloadTaskID: request.GetDownloadTaskId(),
 		URL:            request.GetUrl(),
 	})
@@ -168,3 +407,204 @@ func (a *Handler) UpdateDownloadTask(ctx context.Context, request *go_load.Updat
 		DownloadTask: output.DownloadTask,
 	}, nil
 }
+
+// CreateDownloadTaskShareLink implements go_load.GoLoadServiceServer.
+func (a *Handler) CreateDownloadTaskShareLink(
+	ctx context.Context,
+	request *go_load.CreateDownloadTaskShareLinkRequest,
+) (*go_load.CreateDownloadTaskShareLinkResponse, error) {
+	output, err := a.downloadTaskShareLinkLogic.CreateDownloadTaskShareLink(ctx, logic.CreateDownloadTaskShareLinkParams{
+		Token:            getAuthTokenMetadata(ctx),
+		DownloadTaskID:   request.GetDownloadTaskId(),
+		ExpiresIn:        time.Duration(request.GetExpiresInSeconds()) * time.Second,
+		MaxDownloadCount: request.GetMaxDownloadCount(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.CreateDownloadTaskShareLinkResponse{
+		DownloadTaskShareLink: output.DownloadTaskShareLink,
+	}, nil
+}
+
+// RevokeDownloadTaskShareLink implements go_load.GoLoadServiceServer.
+func (a *Handler) RevokeDownloadTaskShareLink(
+	ctx context.Context,
+	request *go_load.RevokeDownloadTaskShareLinkRequest,
+) (*go_load.RevokeDownloadTaskShareLinkResponse, error) {
+	if err := a.downloadTaskShareLinkLogic.RevokeDownloadTaskShareLink(ctx, logic.RevokeDownloadTaskShareLinkParams{
+		Token:                   getAuthTokenMetadata(ctx),
+		DownloadTaskShareLinkID: request.GetDownloadTaskShareLinkId(),
+	}); err != nil {
+		return nil, err
+	}
+	return &go_load.RevokeDownloadTaskShareLinkResponse{}, nil
+}
+
+// CreateWorkspace implements go_load.GoLoadServiceServer.
+func (a *Handler) CreateWorkspace(ctx context.Context, request *go_load.CreateWorkspaceRequest) (*go_load.CreateWorkspaceResponse, error) {
+	workspace, err := a.workspaceLogic.CreateWorkspace(ctx, logic.CreateWorkspaceParams{
+		Token:         getAuthTokenMetadata(ctx),
+		WorkspaceName: request.GetWorkspaceName(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.CreateWorkspaceResponse{
+		Workspace: workspace,
+	}, nil
+}
+
+// GetWorkspaceList implements go_load.GoLoadServiceServer.
+func (a *Handler) GetWorkspaceList(ctx context.Context, _ *go_load.GetWorkspaceListRequest) (*go_load.GetWorkspaceListResponse, error) {
+	workspaceList, err := a.workspaceLogic.GetWorkspaceList(ctx, logic.GetWorkspaceListParams{
+		Token: getAuthTokenMetadata(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.GetWorkspaceListResponse{
+		WorkspaceList: workspaceList,
+	}, nil
+}
+
+// GetWorkspaceMemberList implements go_load.GoLoadServiceServer.
+func (a *Handler) GetWorkspaceMemberList(
+	ctx context.Context,
+	request *go_load.GetWorkspaceMemberListRequest,
+) (*go_load.GetWorkspaceMemberListResponse, error) {
+	workspaceMemberList, err := a.workspaceLogic.GetWorkspaceMemberList(ctx, logic.GetWorkspaceMemberListParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.GetWorkspaceMemberListResponse{
+		WorkspaceMemberList: workspaceMemberList,
+	}, nil
+}
+
+// AddWorkspaceMember implements go_load.GoLoadServiceServer.
+func (a *Handler) AddWorkspaceMember(
+	ctx context.Context,
+	request *go_load.AddWorkspaceMemberRequest,
+) (*go_load.AddWorkspaceMemberResponse, error) {
+	workspaceMember, err := a.workspaceLogic.AddWorkspaceMember(ctx, logic.AddWorkspaceMemberParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		AccountName: request.GetAccountName(),
+		Role:        request.GetRole(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.AddWorkspaceMemberResponse{
+		WorkspaceMember: workspaceMember,
+	}, nil
+}
+
+// UpdateWorkspaceMember implements go_load.GoLoadServiceServer.
+func (a *Handler) UpdateWorkspaceMember(
+	ctx context.Context,
+	request *go_load.UpdateWorkspaceMemberRequest,
+) (*go_load.UpdateWorkspaceMemberResponse, error) {
+	workspaceMember, err := a.workspaceLogic.UpdateWorkspaceMember(ctx, logic.UpdateWorkspaceMemberParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		AccountID:   request.GetAccountId(),
+		Role:        request.GetRole(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.UpdateWorkspaceMemberResponse{
+		WorkspaceMember: workspaceMember,
+	}, nil
+}
+
+// RemoveWorkspaceMember implements go_load.GoLoadServiceServer.
+func (a *Handler) RemoveWorkspaceMember(
+	ctx context.Context,
+	request *go_load.RemoveWorkspaceMemberRequest,
+) (*go_load.RemoveWorkspaceMemberResponse, error) {
+	if err := a.workspaceLogic.RemoveWorkspaceMember(ctx, logic.RemoveWorkspaceMemberParams{
+		Token:       getAuthTokenMetadata(ctx),
+		WorkspaceID: request.GetWorkspaceId(),
+		AccountID:   request.GetAccountId(),
+	}); err != nil {
+		return nil, err
+	}
+	return &go_load.RemoveWorkspaceMemberResponse{}, nil
+}
+
+// CreateAPIKey implements go_load.GoLoadServiceServer.
+func (a *Handler) CreateAPIKey(ctx context.Context, request *go_load.CreateAPIKeyRequest) (*go_load.CreateAPIKeyResponse, error) {
+	output, err := a.apiKeyLogic.CreateAPIKey(ctx, logic.CreateAPIKeyParams{
+		Token:      getAuthTokenMetadata(ctx),
+		APIKeyName: request.GetApiKeyName(),
+		Scopes:     request.GetScopes(),
+		ExpiresIn:  time.Duration(request.GetExpiresInSeconds()) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.CreateAPIKeyResponse{
+		ApiKey: output.APIKey,
+		Key:    output.Key,
+	}, nil
+}
+
+// ListAPIKeys implements go_load.GoLoadServiceServer.
+func (a *Handler) ListAPIKeys(ctx context.Context, _ *go_load.ListAPIKeysRequest) (*go_load.ListAPIKeysResponse, error) {
+	apiKeyList, err := a.apiKeyLogic.GetAPIKeyList(ctx, logic.GetAPIKeyListParams{
+		Token: getAuthTokenMetadata(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.ListAPIKeysResponse{
+		ApiKeyList: apiKeyList,
+	}, nil
+}
+
+// RevokeAPIKey implements go_load.GoLoadServiceServer.
+func (a *Handler) RevokeAPIKey(ctx context.Context, request *go_load.RevokeAPIKeyRequest) (*go_load.RevokeAPIKeyResponse, error) {
+	if err := a.apiKeyLogic.RevokeAPIKey(ctx, logic.RevokeAPIKeyParams{
+		Token:    getAuthTokenMetadata(ctx),
+		APIKeyID: request.GetApiKeyId(),
+	}); err != nil {
+		return nil, err
+	}
+	return &go_load.RevokeAPIKeyResponse{}, nil
+}
+
+// ListAccountIdentities implements go_load.GoLoadServiceServer.
+func (a *Handler) ListAccountIdentities(
+	ctx context.Context,
+	_ *go_load.ListAccountIdentitiesRequest,
+) (*go_load.ListAccountIdentitiesResponse, error) {
+	accountIdentityList, err := a.oidcLogic.ListAccountIdentities(ctx, logic.ListAccountIdentitiesParams{
+		Token: getAuthTokenMetadata(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &go_load.ListAccountIdentitiesResponse{
+		AccountIdentityList: accountIdentityList,
+	}, nil
+}
+
+// UnlinkAccountIdentity implements go_load.GoLoadServiceServer.
+func (a *Handler) UnlinkAccountIdentity(
+	ctx context.Context,
+	request *go_load.UnlinkAccountIdentityRequest,
+) (*go_load.UnlinkAccountIdentityResponse, error) {
+	if err := a.oidcLogic.UnlinkAccountIdentity(ctx, logic.UnlinkAccountIdentityParams{
+		Token:             getAuthTokenMetadata(ctx),
+		AccountIdentityID: request.GetAccountIdentityId(),
+	}); err != nil {
+		return nil, err
+	}
+	return &go_load.UnlinkAccountIdentityResponse{}, nil
+}
@@ -0,0 +1,197 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// getRequestContext uses the request id sent by the client, generating one if it is missing or invalid, and sends it
+// back in the response header.
+func getRequestContext(ctx context.Context, logger *zap.Logger) context.Context {
+	requestID := ""
+	if incomingMetadata, ok := metadata.FromIncomingContext(ctx); ok {
+		if requestIDValues := incomingMetadata.Get(utils.RequestIDHeaderName); len(requestIDValues) > 0 {
+			requestID = requestIDValues[0]
+		}
+	}
+	if !utils.IsValidRequestID(requestID) {
+		requestID = utils.NewRequestID()
+	}
+	ctx = utils.ContextWithRequestID(ctx, requestID)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(utils.RequestIDHeaderName, requestID)); err != nil {
+		utils.LoggerWithContext(ctx, logger).With(zap.Error(err)).Warn("failed to set request id header")
+	}
+	return ctx
+}
+
+type requestContextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (r requestContextServerStream) Context() context.Context {
+	return r.ctx
+}
+
+func newRequestIDUnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(getRequestContext(ctx, logger), req)
+	}
+}
+func newRequestIDStreamServerInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, requestContextServerStream{
+			ServerStream: stream,
+			ctx:          getRequestContext(stream.Context(), logger),
+		})
+	}
+}
+
+func newMetricsUnaryServerInterceptor(metrics utils.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		startTime := time.Now()
+		resp, err := handler(ctx, req)
+		metrics.ObserveGRPCRequest(info.FullMethod, status.Code(err), time.Since(startTime))
+		return resp, err
+	}
+}
+func newMetricsStreamServerInterceptor(metrics utils.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		startTime := time.Now()
+		err := handler(srv, stream)
+		metrics.ObserveGRPCRequest(info.FullMethod, status.Code(err), time.Since(startTime))
+		return err
+	}
+}
+
+// newAdminAuthorizationUnaryServerInterceptor only lets admins call the methods of GoLoadAdminService. The methods of
+// other services are left to their handlers.
+func newAdminAuthorizationUnaryServerInterceptor(adminLogic logic.Admin) grpc.UnaryServerInterceptor {
+	adminMethodPrefix := "/" + go_load.GoLoadAdminService_ServiceDesc.ServiceName + "/"
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, adminMethodPrefix) {
+			if err := adminLogic.AuthorizeAdmin(ctx, getAuthTokenMetadata(ctx)); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// apiKeyScopeOfMethod lists the methods API keys can be used for, along with the scope they require.
+var apiKeyScopeOfMethod = map[string]go_load.APIKeyScope{
+	go_load.GoLoadService_GetDownloadTaskList_FullMethodName:    go_load.APIKeyScope_ReadDownloadTasks,
+	go_load.GoLoadService_GetWorkspaceList_FullMethodName:       go_load.APIKeyScope_ReadDownloadTasks,
+	go_load.GoLoadService_GetWorkspaceMemberList_FullMethodName: go_load.APIKeyScope_ReadDownloadTasks,
+	go_load.GoLoadService_CreateDownloadTask_FullMethodName:     go_load.APIKeyScope_CreateDownloadTasks,
+	go_load.GoLoadService_CreateDownloadTasks_FullMethodName:    go_load.APIKeyScope_CreateDownloadTasks,
+	go_load.GoLoadService_ImportDownloadTasks_FullMethodName:    go_load.APIKeyScope_CreateDownloadTasks,
+	go_load.GoLoadService_GetDownloadTaskFile_FullMethodName:    go_load.APIKeyScope_DownloadFiles,
+}
+
+// newAPIKeyScopeUnaryServerInterceptor rejects requests authenticated with an API key that does not have the scope of
+// the method. Methods outside of apiKeyScopeOfMethod cannot be called with API keys at all.
+func newAPIKeyScopeUnaryServerInterceptor(apiKeyLogic logic.APIKey) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := apiKeyLogic.AuthorizeAPIKeyScope(ctx, getAuthTokenMetadata(ctx), apiKeyScopeOfMethod[info.FullMethod]); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+func newAPIKeyScopeStreamServerInterceptor(apiKeyLogic logic.APIKey) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		if err := apiKeyLogic.AuthorizeAPIKeyScope(ctx, getAuthTokenMetadata(ctx), apiKeyScopeOfMethod[info.FullMethod]); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// tokenRefreshSkippedMethods issue or revoke session tokens themselves.
+var tokenRefreshSkippedMethods = map[string]bool{
+	go_load.GoLoadService_CreateSession_FullMethodName:     true,
+	go_load.GoLoadService_DeleteSession_FullMethodName:     true,
+	go_load.GoLoadService_DeleteAllSessions_FullMethodName: true,
+	go_load.GoLoadService_ChangePassword_FullMethodName:    true,
+	go_load.GoLoadService_DeleteAccount_FullMethodName:     true,
+}
+
+// newTokenRefreshUnaryServerInterceptor sends a new session token in the response header of successful requests made
+// with a session token that is about to expire, so that active sessions slide forward instead of expiring.
+func newTokenRefreshUnaryServerInterceptor(tokenLogic logic.Token, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil || tokenRefreshSkippedMethods[info.FullMethod] {
+			return resp, err
+		}
+		token := getAuthTokenMetadata(ctx)
+		if token == "" {
+			return resp, err
+		}
+		newToken, _, refreshErr := tokenLogic.RefreshToken(ctx, token)
+		if refreshErr != nil {
+			utils.LoggerWithContext(ctx, logger).With(zap.Error(refreshErr)).Warn("failed to refresh token")
+			return resp, err
+		}
+		if newToken == "" {
+			return resp, err
+		}
+		if headerErr := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenMetadataName, newToken)); headerErr != nil {
+			utils.LoggerWithContext(ctx, logger).With(zap.Error(headerErr)).Warn("failed to set refreshed token header")
+		}
+		return resp, err
+	}
+}
+
+// setRetryAfterHeader tells the client when to retry a request rejected by a rate limit.
+func setRetryAfterHeader(err error, setHeader func(metadata.MD) error) error {
+	if retryAfter, ok := utils.GetRetryAfter(err); ok {
+		return setHeader(metadata.Pairs(utils.RetryAfterHeaderName, retryAfter))
+	}
+	return nil
+}
+
+// newRateLimitUnaryServerInterceptor rejects requests over the rate limit of their method.
+func newRateLimitUnaryServerInterceptor(rateLimitLogic logic.RateLimit, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := rateLimitLogic.CheckRateLimit(ctx, logic.CheckRateLimitParams{
+			Method:   info.FullMethod,
+			Token:    getAuthTokenMetadata(ctx),
+			ClientIP: getClientIP(ctx),
+		}); err != nil {
+			if headerErr := setRetryAfterHeader(err, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); headerErr != nil {
+				utils.LoggerWithContext(ctx, logger).With(zap.Error(headerErr)).Warn("failed to set retry after header")
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+func newRateLimitStreamServerInterceptor(rateLimitLogic logic.RateLimit, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		if err := rateLimitLogic.CheckRateLimit(ctx, logic.CheckRateLimitParams{
+			Method:   info.FullMethod,
+			Token:    getAuthTokenMetadata(ctx),
+			ClientIP: getClientIP(ctx),
+		}); err != nil {
+			if headerErr := setRetryAfterHeader(err, stream.SetHeader); headerErr != nil {
+				utils.LoggerWithContext(ctx, logger).With(zap.Error(headerErr)).Warn("failed to set retry after header")
+			}
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
@@ -3,52 +3,168 @@ package grpc
 import (
 	"context"
 	"net"
+	"time"
 
 	"GoLoad/internal/configs"
 	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
 	"GoLoad/internal/utils"
 
-	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"buf.build/go/protovalidate"
+	grpcprotovalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type Server interface {
 	Start(ctx context.Context) error
 }
 type server struct {
-	handler    go_load.GoLoadServiceServer
-	grpcConfig configs.GRPC
-	logger     *zap.Logger
+	handler        go_load.GoLoadServiceServer
+	adminHandler   go_load.GoLoadAdminServiceServer
+	adminLogic     logic.Admin
+	apiKeyLogic    logic.APIKey
+	tokenLogic     logic.Token
+	rateLimitLogic logic.RateLimit
+	grpcConfig     configs.GRPC
+	healthConfig   configs.Health
+	healthLogic    logic.Health
+	metrics        utils.Metrics
+	tracerProvider trace.TracerProvider
+	logger         *zap.Logger
 }
 
-func NewServer(handler go_load.GoLoadServiceServer, grpcConfig configs.GRPC, logger *zap.Logger) Server {
+func NewServer(
+	handler go_load.GoLoadServiceServer,
+	adminHandler go_load.GoLoadAdminServiceServer,
+	adminLogic logic.Admin,
+	apiKeyLogic logic.APIKey,
+	tokenLogic logic.Token,
+	rateLimitLogic logic.RateLimit,
+	grpcConfig configs.GRPC,
+	healthConfig configs.Health,
+	healthLogic logic.Health,
+	metrics utils.Metrics,
+	tracerProvider trace.TracerProvider,
+	logger *zap.Logger,
+) Server {
 	return &server{
-		handler:    handler,
-		grpcConfig: grpcConfig,
-		logger:     logger,
+		handler:        handler,
+		adminHandler:   adminHandler,
+		adminLogic:     adminLogic,
+		apiKeyLogic:    apiKeyLogic,
+		tokenLogic:     tokenLogic,
+		rateLimitLogic: rateLimitLogic,
+		grpcConfig:     grpcConfig,
+		healthConfig:   healthConfig,
+		healthLogic:    healthLogic,
+		metrics:        metrics,
+		tracerProvider: tracerProvider,
+		logger:         logger,
+	}
+}
+func (s *server) updateHealthServingStatus(ctx context.Context, healthServer *health.Server) {
+	servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
+	if !s.healthLogic.CheckReadiness(ctx).IsReady() {
+		servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	// The empty service name stands for the server as a whole.
+	healthServer.SetServingStatus("", servingStatus)
+	healthServer.SetServingStatus(go_load.GoLoadService_ServiceDesc.ServiceName, servingStatus)
+	healthServer.SetServingStatus(go_load.GoLoadAdminService_ServiceDesc.ServiceName, servingStatus)
+}
+
+// runHealthServingStatusUpdater keeps the serving status of the health service in sync with the readiness of the
+// server, and reports not serving as soon as the server starts shutting down.
+func (s *server) runHealthServingStatusUpdater(
+	ctx context.Context,
+	healthServer *health.Server,
+	checkInterval time.Duration,
+) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		s.updateHealthServingStatus(ctx, healthServer)
+		select {
+		case <-ticker.C:
+		case <-s.healthLogic.ShutdownChannel():
+			healthServer.Shutdown()
+			return
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 func (s *server) Start(ctx context.Context) error {
 	logger := utils.LoggerWithContext(ctx, s.logger)
 
+	tlsConfig, err := utils.NewServerTLSConfig(s.grpcConfig.TLS, s.logger)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create tls config")
+		return err
+	}
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
 	listener, err := net.Listen("tcp", s.grpcConfig.Address)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to open tcp listener")
 		return err
 	}
 	defer listener.Close()
+	requestValidator, err := protovalidate.New()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create request validator")
+		return err
+	}
 	// server := grpc.NewServer()
 	server := grpc.NewServer(
+		grpc.Creds(transportCredentials),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(s.tracerProvider))),
 		grpc.ChainUnaryInterceptor(
-			validator.UnaryServerInterceptor(),
+			newRequestIDUnaryServerInterceptor(s.logger),
+			newMetricsUnaryServerInterceptor(s.metrics),
+			// Rate limited requests are rejected before the interceptors and handlers querying the database.
+			newRateLimitUnaryServerInterceptor(s.rateLimitLogic, s.logger),
+			newAPIKeyScopeUnaryServerInterceptor(s.apiKeyLogic),
+			newAdminAuthorizationUnaryServerInterceptor(s.adminLogic),
+			grpcprotovalidate.UnaryServerInterceptor(requestValidator),
+			newTokenRefreshUnaryServerInterceptor(s.tokenLogic, s.logger),
 		),
 		grpc.ChainStreamInterceptor(
-			validator.StreamServerInterceptor(),
+			newRequestIDStreamServerInterceptor(s.logger),
+			newMetricsStreamServerInterceptor(s.metrics),
+			newRateLimitStreamServerInterceptor(s.rateLimitLogic, s.logger),
+			newAPIKeyScopeStreamServerInterceptor(s.apiKeyLogic),
+			grpcprotovalidate.StreamServerInterceptor(requestValidator),
 		),
 	)
 	go_load.RegisterGoLoadServiceServer(server, s.handler)
+	go_load.RegisterGoLoadAdminServiceServer(server, s.adminHandler)
+
+	checkInterval, err := s.healthConfig.GetCheckIntervalDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse health check interval")
+		return err
+	}
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	go s.runHealthServingStatusUpdater(ctx, healthServer, checkInterval)
+
+	// Stop accepting new RPCs once ctx is done and let in-flight ones finish.
+	go func() {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		server.GracefulStop()
+	}()
 
-	logger.With(zap.String("address", s.grpcConfig.Address)).Info("starting grpc server")
+	logger.With(zap.String("address", s.grpcConfig.Address)).With(zap.Bool("tls", tlsConfig != nil)).Info("starting grpc server")
 	return server.Serve(listener)
 }
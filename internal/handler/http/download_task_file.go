@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DownloadTaskFilePathPattern    = "GET /download-tasks/{download_task_id}/file"
+	downloadTaskFilePathValueID    = "download_task_id"
+	httpResponseHeaderETag         = "ETag"
+	httpResponseHeaderContentType  = "Content-Type"
+	httpResponseHeaderDisposition  = "Content-Disposition"
+	httpContentDispositionTypeFile = "attachment"
+)
+
+var (
+	errInvalidSeekWhence  = errors.New("invalid seek whence")
+	errNegativeSeekOffset = errors.New("negative seek offset")
+)
+
+type rangeReaderOpener func(ctx context.Context, offset uint64) (io.ReadCloser, error)
+
+// rangeReadSeeker adapts ranged reads into an io.ReadSeeker, so http.ServeContent can take care of Range,
+// If-Range and conditional request headers. The underlying reader is only opened on the first Read after a Seek.
+type rangeReadSeeker struct {
+	ctx    context.Context
+	open   rangeReaderOpener
+	size   int64
+	offset int64
+	reader io.ReadCloser
+}
+
+func newRangeReadSeeker(ctx context.Context, open rangeReaderOpener, size int64) *rangeReadSeeker {
+	return &rangeReadSeeker{
+		ctx:  ctx,
+		open: open,
+		size: size,
+	}
+}
+func (r *rangeReadSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.reader == nil {
+		reader, err := r.open(r.ctx, uint64(r.offset))
+		if err != nil {
+			return 0, err
+		}
+		r.reader = reader
+	}
+	readByteCount, err := r.reader.Read(p)
+	r.offset += int64(readByteCount)
+	return readByteCount, err
+}
+func (r *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = r.offset + offset
+	case io.SeekEnd:
+		newOffset = r.size + offset
+	default:
+		return 0, errInvalidSeekWhence
+	}
+	if newOffset < 0 {
+		return 0, errNegativeSeekOffset
+	}
+	if newOffset != r.offset {
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+		r.offset = newOffset
+	}
+	return newOffset, nil
+}
+func (r *rangeReadSeeker) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	err := r.reader.Close()
+	r.reader = nil
+	return err
+}
+
+type fileResponse struct {
+	FileName    string
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+// serveFile writes a file response with Content-Type, ETag and Content-Disposition set, delegating Range handling
+// to http.ServeContent.
+func serveFile(w http.ResponseWriter, r *http.Request, fileResponse fileResponse, readSeeker io.ReadSeeker) {
+	if fileResponse.ContentType != "" {
+		w.Header().Set(httpResponseHeaderContentType, fileResponse.ContentType)
+	}
+	if fileResponse.ETag != "" {
+		w.Header().Set(httpResponseHeaderETag, fileResponse.ETag)
+	}
+	w.Header().Set(httpResponseHeaderDisposition, mime.FormatMediaType(httpContentDispositionTypeFile, map[string]string{
+		"filename": fileResponse.FileName,
+	}))
+	http.ServeContent(w, r, fileResponse.FileName, fileResponse.ModTime, readSeeker)
+}
+
+func writeErrorResponse(w http.ResponseWriter, err error) {
+	errorStatus, _ := status.FromError(err)
+	http.Error(w, errorStatus.Message(), runtime.HTTPStatusFromCode(errorStatus.Code()))
+}
+
+type downloadTaskFileHandler struct {
+	downloadTaskLogic logic.DownloadTask
+	apiKeyLogic       logic.APIKey
+	logger            *zap.Logger
+}
+
+func newDownloadTaskFileHandler(downloadTaskLogic logic.DownloadTask, apiKeyLogic logic.APIKey, logger *zap.Logger) http.Handler {
+	return &downloadTaskFileHandler{
+		downloadTaskLogic: downloadTaskLogic,
+		apiKeyLogic:       apiKeyLogic,
+		logger:            logger,
+	}
+}
+func (d downloadTaskFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := utils.LoggerWithContext(r.Context(), d.logger)
+
+	downloadTaskID, err := strconv.ParseUint(r.PathValue(downloadTaskFilePathValueID), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid download task id", http.StatusBadRequest)
+		return
+	}
+	token := getRequestAuthToken(r)
+	if err = d.apiKeyLogic.AuthorizeAPIKeyScope(r.Context(), token, go_load.APIKeyScope_DownloadFiles); err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	fileInfo, err := d.downloadTaskLogic.GetDownloadTaskFileInfo(r.Context(), logic.GetDownloadTaskFileInfoParams{
+		Token:          token,
+		DownloadTaskID: downloadTaskID,
+	})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	readSeeker := newRangeReadSeeker(r.Context(), func(ctx context.Context, offset uint64) (io.ReadCloser, error) {
+		return d.downloadTaskLogic.GetDownloadTaskFile(ctx, logic.GetDownloadTaskFileParams{
+			Token:          token,
+			DownloadTaskID: downloadTaskID,
+			Offset:         offset,
+		})
+	}, int64(fileInfo.Size))
+	defer func() {
+		if closeErr := readSeeker.Close(); closeErr != nil {
+			logger.With(zap.Error(closeErr)).Warn("failed to close download task file reader")
+		}
+	}()
+	serveFile(w, r, fileResponse{
+		FileName:    fileInfo.FileName,
+		ContentType: fileInfo.ContentType,
+		ETag:        fileInfo.ETag,
+		ModTime:     fileInfo.ModTime,
+	}, readSeeker)
+}
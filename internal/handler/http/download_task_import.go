@@ -0,0 +1,145 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	DownloadTaskImportPathPattern      = "POST /download-tasks/import"
+	downloadTaskImportQueryFormat      = "format"
+	downloadTaskImportQueryType        = "download_type"
+	downloadTaskImportQueryWorkspaceID = "workspace_id"
+	downloadTaskImportFormFieldFile    = "file"
+	httpRequestHeaderContentType       = "Content-Type"
+	httpContentTypeMultipartFormData   = "multipart/form-data"
+	httpContentTypeApplicationJSON     = "application/json"
+	downloadTaskImportManifestOverhead = 1 << 20
+)
+
+var errManifestFilePartNotFound = errors.New("manifest file part not found")
+
+// downloadTaskImportHandler accepts a manifest either as the raw request body or as the "file" field of a
+// multipart form, so that manifests can be uploaded from a browser form.
+type downloadTaskImportHandler struct {
+	downloadTaskLogic      logic.DownloadTask
+	apiKeyLogic            logic.APIKey
+	maxManifestSizeInBytes uint64
+	logger                 *zap.Logger
+}
+
+func newDownloadTaskImportHandler(
+	downloadTaskLogic logic.DownloadTask,
+	apiKeyLogic logic.APIKey,
+	maxManifestSizeInBytes uint64,
+	logger *zap.Logger,
+) http.Handler {
+	return &downloadTaskImportHandler{
+		downloadTaskLogic:      downloadTaskLogic,
+		apiKeyLogic:            apiKeyLogic,
+		maxManifestSizeInBytes: maxManifestSizeInBytes,
+		logger:                 logger,
+	}
+}
+func (d downloadTaskImportHandler) readMultipartManifest(r *http.Request) ([]byte, error) {
+	multipartReader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errManifestFilePartNotFound
+			}
+			return nil, err
+		}
+		if part.FormName() == downloadTaskImportFormFieldFile {
+			manifest, err := d.readManifest(part)
+			part.Close()
+			return manifest, err
+		}
+		part.Close()
+	}
+}
+func (d downloadTaskImportHandler) readManifest(reader io.Reader) ([]byte, error) {
+	// One extra byte is read so that the logic layer can reject manifests over the limit.
+	return io.ReadAll(io.LimitReader(reader, int64(d.maxManifestSizeInBytes)+1))
+}
+func (d downloadTaskImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := utils.LoggerWithContext(r.Context(), d.logger)
+
+	format, ok := go_load.DownloadTaskManifestFormat_value[r.URL.Query().Get(downloadTaskImportQueryFormat)]
+	if !ok && r.URL.Query().Has(downloadTaskImportQueryFormat) {
+		http.Error(w, "invalid manifest format", http.StatusBadRequest)
+		return
+	}
+	downloadType, ok := go_load.DownloadType_value[r.URL.Query().Get(downloadTaskImportQueryType)]
+	if !ok && r.URL.Query().Has(downloadTaskImportQueryType) {
+		http.Error(w, "invalid download type", http.StatusBadRequest)
+		return
+	}
+	var workspaceID uint64
+	if r.URL.Query().Has(downloadTaskImportQueryWorkspaceID) {
+		var parseErr error
+		workspaceID, parseErr = strconv.ParseUint(r.URL.Query().Get(downloadTaskImportQueryWorkspaceID), 10, 64)
+		if parseErr != nil {
+			http.Error(w, "invalid workspace id", http.StatusBadRequest)
+			return
+		}
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, int64(d.maxManifestSizeInBytes)+downloadTaskImportManifestOverhead)
+	var (
+		manifest []byte
+		err      error
+	)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(httpRequestHeaderContentType)); mediaType == httpContentTypeMultipartFormData {
+		manifest, err = d.readMultipartManifest(r)
+	} else {
+		manifest, err = d.readManifest(r.Body)
+	}
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to read download task manifest")
+		http.Error(w, "failed to read manifest", http.StatusBadRequest)
+		return
+	}
+	token := getRequestAuthToken(r)
+	if err = d.apiKeyLogic.AuthorizeAPIKeyScope(r.Context(), token, go_load.APIKeyScope_CreateDownloadTasks); err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	output, err := d.downloadTaskLogic.ImportDownloadTasks(r.Context(), logic.ImportDownloadTasksParams{
+		Token:        token,
+		WorkspaceID:  workspaceID,
+		Format:       go_load.DownloadTaskManifestFormat(format),
+		Manifest:     manifest,
+		DownloadType: go_load.DownloadType(downloadType),
+	})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	responseBytes, err := protojson.Marshal(&go_load.ImportDownloadTasksResponse{
+		BatchId:    output.BatchID,
+		ResultList: output.ResultList,
+	})
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to marshal import download tasks response")
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpResponseHeaderContentType, httpContentTypeApplicationJSON)
+	if _, err := w.Write(responseBytes); err != nil {
+		logger.With(zap.Error(err)).Warn("failed to write import download tasks response")
+	}
+}
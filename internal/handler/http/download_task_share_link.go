@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DownloadTaskShareLinkPathPattern    = "GET /share/{share_link_token}"
+	downloadTaskShareLinkPathValueToken = "share_link_token"
+	httpRequestHeaderRange              = "Range"
+	httpRequestHeaderIfRange            = "If-Range"
+	httpResponseHeaderContentRange      = "Content-Range"
+	httpRangeUnitPrefix                 = "bytes="
+)
+
+var errInvalidRange = errors.New("invalid range")
+
+type downloadTaskShareLinkHandler struct {
+	downloadTaskShareLinkLogic logic.DownloadTaskShareLink
+	logger                     *zap.Logger
+}
+
+func newDownloadTaskShareLinkHandler(downloadTaskShareLinkLogic logic.DownloadTaskShareLink, logger *zap.Logger) http.Handler {
+	return &downloadTaskShareLinkHandler{
+		downloadTaskShareLinkLogic: downloadTaskShareLinkLogic,
+		logger:                     logger,
+	}
+}
+
+// isRangeApplied tells whether http.ServeContent honors the Range header, which it ignores if If-Range does not match
+// the file.
+func isRangeApplied(r *http.Request, etag string, modTime time.Time) bool {
+	ifRange := r.Header.Get(httpRequestHeaderIfRange)
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return strings.HasPrefix(ifRange, `"`) && strings.HasPrefix(etag, `"`) && ifRange == etag
+	}
+	ifRangeTime, err := http.ParseTime(ifRange)
+	return err == nil && !modTime.IsZero() && ifRangeTime.Unix() == modTime.Unix()
+}
+
+// includesFirstByte resolves the ranges of a Range header against the file size like http.ServeContent, and tells
+// whether any of them includes the first byte of the file, e.g. "bytes=-<size>" does. Every complete copy of the
+// file goes through a response that includes its first byte, however the ranges are split.
+func includesFirstByte(rangeHeader string, size int64) (bool, error) {
+	if rangeHeader == "" || size == 0 {
+		return true, nil
+	}
+	rangeSpecList, ok := strings.CutPrefix(rangeHeader, httpRangeUnitPrefix)
+	if !ok {
+		return false, errInvalidRange
+	}
+	var (
+		included   = false
+		overlapped = false
+	)
+	for _, rangeSpec := range strings.Split(rangeSpecList, ",") {
+		rangeSpec = textproto.TrimString(rangeSpec)
+		if rangeSpec == "" {
+			continue
+		}
+		startText, endText, ok := strings.Cut(rangeSpec, "-")
+		if !ok {
+			return false, errInvalidRange
+		}
+		startText, endText = textproto.TrimString(startText), textproto.TrimString(endText)
+		if startText == "" {
+			suffixLength, err := strconv.ParseInt(endText, 10, 64)
+			if err != nil || suffixLength < 0 {
+				return false, errInvalidRange
+			}
+			overlapped = true
+			included = included || suffixLength >= size
+			continue
+		}
+		start, err := strconv.ParseInt(startText, 10, 64)
+		if err != nil || start < 0 {
+			return false, errInvalidRange
+		}
+		if endText != "" {
+			end, err := strconv.ParseInt(endText, 10, 64)
+			if err != nil || end < start {
+				return false, errInvalidRange
+			}
+		}
+		if start >= size {
+			continue
+		}
+		overlapped = true
+		included = included || start == 0
+	}
+	if !overlapped {
+		return false, errInvalidRange
+	}
+	return included, nil
+}
+
+// countDownload counts the responses that include the first byte of the file against the share link's max download
+// count, so that ranged requests resuming a download are free but no complete copy is.
+func (d downloadTaskShareLinkHandler) countDownload(
+	w http.ResponseWriter,
+	r *http.Request,
+	shareLinkToken string,
+	output logic.OpenDownloadTaskShareLinkOutput,
+) bool {
+	if r.Method == http.MethodHead {
+		return true
+	}
+	if output.PresignedURL == "" {
+		rangeHeader := r.Header.Get(httpRequestHeaderRange)
+		if !isRangeApplied(r, output.ETag, output.ModTime) {
+			rangeHeader = ""
+		}
+		included, err := includesFirstByte(rangeHeader, int64(output.Size))
+		if err != nil {
+			w.Header().Set(httpResponseHeaderContentRange, fmt.Sprintf("bytes */%d", output.Size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return false
+		}
+		if !included {
+			return true
+		}
+	}
+	if err := d.downloadTaskShareLinkLogic.CountDownloadTaskShareLinkDownload(r.Context(),
+		logic.CountDownloadTaskShareLinkDownloadParams{ShareLinkToken: shareLinkToken}); err != nil {
+		writeErrorResponse(w, err)
+		return false
+	}
+	return true
+}
+func (d downloadTaskShareLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := utils.LoggerWithContext(r.Context(), d.logger)
+
+	shareLinkToken := r.PathValue(downloadTaskShareLinkPathValueToken)
+	output, err := d.downloadTaskShareLinkLogic.OpenDownloadTaskShareLink(r.Context(), logic.OpenDownloadTaskShareLinkParams{
+		ShareLinkToken: shareLinkToken,
+	})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	if !d.countDownload(w, r, shareLinkToken, output) {
+		return
+	}
+	if output.PresignedURL != "" {
+		http.Redirect(w, r, output.PresignedURL, http.StatusFound)
+		return
+	}
+	readSeeker := newRangeReadSeeker(r.Context(), func(ctx context.Context, offset uint64) (io.ReadCloser, error) {
+		return d.downloadTaskShareLinkLogic.GetDownloadTaskShareLinkFile(ctx, logic.GetDownloadTaskShareLinkFileParams{
+			ShareLinkToken: shareLinkToken,
+			Offset:         offset,
+		})
+	}, int64(output.Size))
+	defer func() {
+		if closeErr := readSeeker.Close(); closeErr != nil {
+			logger.With(zap.Error(closeErr)).Warn("failed to close download task share link file reader")
+		}
+	}()
+	serveFile(w, r, fileResponse{
+		FileName:    output.FileName,
+		ContentType: output.ContentType,
+		ETag:        output.ETag,
+		ModTime:     output.ModTime,
+	}, readSeeker)
+}
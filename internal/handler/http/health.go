@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	HealthzPathPattern          = "GET " + healthzPath
+	ReadyzPathPattern           = "GET " + readyzPath
+	healthzPath                 = "/healthz"
+	readyzPath                  = "/readyz"
+	httpContentTypeJSON         = "application/json"
+	httpResponseHeaderCacheCtrl = "Cache-Control"
+	httpCacheControlNoStore     = "no-store"
+)
+
+type livenessResponse struct {
+	Status string `json:"status"`
+}
+
+func writeJSONResponse(w http.ResponseWriter, statusCode int, response any, logger *zap.Logger) {
+	w.Header().Set(httpResponseHeaderContentType, httpContentTypeJSON)
+	w.Header().Set(httpResponseHeaderCacheCtrl, httpCacheControlNoStore)
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.With(zap.Error(err)).Warn("failed to write json response")
+	}
+}
+
+// healthzHandler reports that the process is up, without checking any dependency, so that a dependency outage does
+// not get the server restarted.
+type healthzHandler struct {
+	logger *zap.Logger
+}
+
+func newHealthzHandler(logger *zap.Logger) http.Handler {
+	return &healthzHandler{
+		logger: logger,
+	}
+}
+func (h healthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, livenessResponse{Status: logic.HealthStatusUp}, utils.LoggerWithContext(r.Context(), h.logger))
+}
+
+type readyzHandler struct {
+	healthLogic logic.Health
+	logger      *zap.Logger
+}
+
+func newReadyzHandler(healthLogic logic.Health, logger *zap.Logger) http.Handler {
+	return &readyzHandler{
+		healthLogic: healthLogic,
+		logger:      logger,
+	}
+}
+func (h readyzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	output := h.healthLogic.CheckReadiness(r.Context())
+	statusCode := http.StatusOK
+	if !output.IsReady() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeJSONResponse(w, statusCode, output, utils.LoggerWithContext(r.Context(), h.logger))
+}
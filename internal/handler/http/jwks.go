@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	JWKSPathPattern = "GET /.well-known/jwks.json"
+	// jwksCacheControl lets verifiers cache the key set for a while, new signing keys are published rotation_overlap
+	// before they start signing.
+	jwksCacheControl = "public, max-age=300"
+)
+
+// jwksHandler serves the public keys of the session tokens, so that other services can verify them.
+type jwksHandler struct {
+	tokenLogic logic.Token
+	logger     *zap.Logger
+}
+
+func newJWKSHandler(tokenLogic logic.Token, logger *zap.Logger) http.Handler {
+	return &jwksHandler{
+		tokenLogic: tokenLogic,
+		logger:     logger,
+	}
+}
+func (h jwksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := utils.LoggerWithContext(r.Context(), h.logger)
+
+	jsonWebKeySet, err := h.tokenLogic.GetJSONWebKeySet(r.Context())
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	w.Header().Set(httpResponseHeaderContentType, httpContentTypeJSON)
+	w.Header().Set(httpResponseHeaderCacheCtrl, jwksCacheControl)
+	if err := json.NewEncoder(w).Encode(jsonWebKeySet); err != nil {
+		logger.With(zap.Error(err)).Warn("failed to write jwks response")
+	}
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type RateLimit func(pattern string, baseHandler http.Handler) http.Handler
+
+// getClientIP returns the IP of the peer. The HTTP server faces the clients directly, so X-Forwarded-For is set by
+// them and cannot be trusted.
+func getClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// NewRateLimit rejects the requests over the rate limit of the handler's pattern, such as
+// "GET /share/{share_link_token}", with 429 Too Many Requests and a Retry-After header, as the gRPC server does for
+// the gateway's requests.
+func NewRateLimit(rateLimitLogic logic.RateLimit, getAuthToken func(*http.Request) string, logger *zap.Logger) RateLimit {
+	return func(pattern string, baseHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := rateLimitLogic.CheckRateLimit(r.Context(), logic.CheckRateLimitParams{
+				Method:   pattern,
+				Token:    getAuthToken(r),
+				ClientIP: getClientIP(r),
+			})
+			if err == nil {
+				baseHandler.ServeHTTP(w, r)
+				return
+			}
+			if status.Code(err) != codes.ResourceExhausted {
+				utils.LoggerWithContext(r.Context(), logger).With(zap.Error(err)).Error("failed to check rate limit")
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if retryAfter, ok := utils.GetRetryAfter(err); ok {
+				w.Header().Set(utils.RetryAfterHeaderName, retryAfter)
+			}
+			http.Error(w, status.Convert(err).Message(), http.StatusTooManyRequests)
+		})
+	}
+}
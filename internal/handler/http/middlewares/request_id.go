@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"GoLoad/internal/utils"
+)
+
+type RequestID func(http.Handler) http.Handler
+
+// NewRequestID uses the X-Request-ID header of the request, generating a request id if it is missing or invalid. The
+// request id is stored in the request's context and sent back in the response's X-Request-ID header.
+func NewRequestID() RequestID {
+	return func(baseHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(utils.RequestIDHeaderName)
+			if !utils.IsValidRequestID(requestID) {
+				requestID = utils.NewRequestID()
+			}
+			w.Header().Set(utils.RequestIDHeaderName, requestID)
+			baseHandler.ServeHTTP(w, r.WithContext(utils.ContextWithRequestID(r.Context(), requestID)))
+		})
+	}
+}
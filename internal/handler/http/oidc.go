@@ -0,0 +1,130 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"GoLoad/internal/handler/http/servemuxoptions"
+	"GoLoad/internal/logic"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	OIDCLoginPathPattern    = "GET " + oidcPathPrefix + "/login"
+	OIDCCallbackPathPattern = "GET " + oidcPathPrefix + "/callback"
+	oidcPathPrefix          = "/oidc"
+	// oidcStateCookieName binds a login to the browser that started it, so that a callback URL sent by someone else
+	// cannot log the browser in to their account.
+	oidcStateCookieName         = "GOLOAD_OIDC_STATE"
+	oidcLoginQueryLink          = "link"
+	oidcCallbackQueryState      = "state"
+	oidcCallbackQueryCode       = "code"
+	oidcCallbackQueryError      = "error"
+	defaultPostLoginRedirectURL = "/"
+)
+
+// setOIDCStateCookie is lax so that it is sent along with the redirect from the identity provider.
+func setOIDCStateCookie(w http.ResponseWriter, state string, expiresIn time.Duration) {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     oidcPathPrefix,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(expiresIn.Seconds()),
+	}
+	if state == "" {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
+
+// oidcLoginHandler sends the browser to the identity provider. With ?link=true, the identity is linked to the account
+// of the session instead of logged in with.
+type oidcLoginHandler struct {
+	oidcLogic logic.OIDC
+	logger    *zap.Logger
+}
+
+func newOIDCLoginHandler(oidcLogic logic.OIDC, logger *zap.Logger) http.Handler {
+	return &oidcLoginHandler{
+		oidcLogic: oidcLogic,
+		logger:    logger,
+	}
+}
+func (o oidcLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := logic.StartOIDCLoginParams{}
+	if r.URL.Query().Get(oidcLoginQueryLink) == "true" {
+		params.LinkToken = getRequestAuthToken(r)
+		if params.LinkToken == "" {
+			http.Error(w, "log in to link an identity", http.StatusUnauthorized)
+			return
+		}
+	}
+	output, err := o.oidcLogic.StartLogin(r.Context(), params)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	setOIDCStateCookie(w, output.State, output.ExpiresIn)
+	http.Redirect(w, r, output.AuthorizationURL, http.StatusFound)
+}
+
+// oidcCallbackHandler completes the login once the identity provider sends the browser back, and sets the auth cookie
+// the same way the gateway does for CreateSession.
+type oidcCallbackHandler struct {
+	oidcLogic            logic.OIDC
+	tokenExpiresIn       time.Duration
+	postLoginRedirectURL string
+	logger               *zap.Logger
+}
+
+func newOIDCCallbackHandler(
+	oidcLogic logic.OIDC,
+	tokenExpiresIn time.Duration,
+	postLoginRedirectURL string,
+	logger *zap.Logger,
+) http.Handler {
+	if postLoginRedirectURL == "" {
+		postLoginRedirectURL = defaultPostLoginRedirectURL
+	}
+	return &oidcCallbackHandler{
+		oidcLogic:            oidcLogic,
+		tokenExpiresIn:       tokenExpiresIn,
+		postLoginRedirectURL: postLoginRedirectURL,
+		logger:               logger,
+	}
+}
+func (o oidcCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := utils.LoggerWithContext(r.Context(), o.logger)
+
+	query := r.URL.Query()
+	if providerError := query.Get(oidcCallbackQueryError); providerError != "" {
+		logger.With(zap.String("error", providerError)).Warn("identity provider returned an error")
+		http.Error(w, "identity provider did not complete the login", http.StatusUnauthorized)
+		return
+	}
+	state := query.Get(oidcCallbackQueryState)
+	stateCookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(state)) != 1 {
+		logger.Warn("oidc callback state does not match the state cookie")
+		http.Error(w, "single sign-on login is invalid or has expired", http.StatusUnauthorized)
+		return
+	}
+	setOIDCStateCookie(w, "", 0)
+	output, err := o.oidcLogic.CompleteLogin(r.Context(), logic.CompleteOIDCLoginParams{
+		State: state,
+		Code:  query.Get(oidcCallbackQueryCode),
+	})
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	if output.Token != "" {
+		servemuxoptions.SetAuthCookie(w, AuthTokenCookieName, output.Token, o.tokenExpiresIn)
+	}
+	http.Redirect(w, r, o.postLoginRedirectURL, http.StatusFound)
+}
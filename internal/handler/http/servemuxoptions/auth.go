@@ -21,6 +21,27 @@ func WithAuthCookieToAuthMetadata(authCookieName, authMetadataName string) runti
 		})
 	})
 }
+
+// SetAuthCookie sets the auth cookie to the token, or expires it if the token is empty.
+func SetAuthCookie(w http.ResponseWriter, authCookieName, token string, expiresInDuration time.Duration) {
+	if token == "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     authCookieName,
+			Value:    "",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+			MaxAge:   -1,
+		})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Value:    token,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  time.Now().Add(expiresInDuration),
+	})
+}
 func WithAuthMetadataToAuthCookie(authMetadataName, authCookieName string, expiresInDuration time.Duration) runtime.ServeMuxOption {
 	return runtime.WithForwardResponseOption(func(ctx context.Context, w http.ResponseWriter, m proto.Message) error {
 		metadata, ok := runtime.ServerMetadataFromContext(ctx)
@@ -31,13 +52,8 @@ func WithAuthMetadataToAuthCookie(authMetadataName, authCookieName string, expir
 		if len(authMetadataValues) == 0 {
 			return nil
 		}
-		http.SetCookie(w, &http.Cookie{
-			Name:     authCookieName,
-			Value:    authMetadataValues[0],
-			HttpOnly: true,
-			SameSite: http.SameSiteStrictMode,
-			Expires:  time.Now().Add(expiresInDuration),
-		})
+		// An empty token means the session was deleted.
+		SetAuthCookie(w, authCookieName, authMetadataValues[0], expiresInDuration)
 		return nil
 	})
 }
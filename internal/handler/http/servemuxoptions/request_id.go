@@ -0,0 +1,22 @@
+package servemuxoptions
+
+import (
+	"context"
+	"net/http"
+
+	"GoLoad/internal/utils"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+// WithRequestIDMetadata forwards the request id assigned by the RequestID middleware to the gRPC server.
+func WithRequestIDMetadata(requestIDMetadataName string) runtime.ServeMuxOption {
+	return runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+		requestID := utils.RequestIDFromContext(r.Context())
+		if requestID == "" {
+			return make(metadata.MD)
+		}
+		return metadata.Pairs(requestIDMetadataName, requestID)
+	})
+}
@@ -0,0 +1,28 @@
+package servemuxoptions
+
+import (
+	"context"
+	"net/http"
+
+	"GoLoad/internal/utils"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// WithRetryAfterHeader sets the Retry-After header of errors carrying a retry delay, such as rate limit rejections,
+// which the gateway already maps to HTTP 429.
+func WithRetryAfterHeader() runtime.ServeMuxOption {
+	return runtime.WithErrorHandler(func(
+		ctx context.Context,
+		mux *runtime.ServeMux,
+		marshaler runtime.Marshaler,
+		w http.ResponseWriter,
+		r *http.Request,
+		err error,
+	) {
+		if retryAfter, ok := utils.GetRetryAfter(err); ok {
+			w.Header().Set(utils.RetryAfterHeaderName, retryAfter)
+		}
+		runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+	})
+}
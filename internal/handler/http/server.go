@@ -2,81 +2,254 @@ package http
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"GoLoad/internal/configs"
 	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/logic"
 	"GoLoad/internal/utils"
 
 	handlerGRPC "GoLoad/internal/handler/grpc"
+	"GoLoad/internal/handler/http/middlewares"
 	"GoLoad/internal/handler/http/servemuxoptions"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
 	//nolint:gosec // This is just to specify the cookie name
-	AuthTokenCookieName = "GOLOAD_AUTH"
+	AuthTokenCookieName            = "GOLOAD_AUTH"
+	httpRequestHeaderAuthorization = "Authorization"
+	// AdminPathPrefix is the path prefix under which the gateway exposes GoLoadAdminService.
+	AdminPathPrefix     = "/admin"
+	httpShutdownTimeout = 30 * time.Second
 )
 
 type Server interface {
 	Start(ctx context.Context) error
 }
 type server struct {
-	grpcConfig configs.GRPC
-	httpConfig configs.HTTP
-	authConfig configs.Auth
-	logger     *zap.Logger
+	grpcConfig                 configs.GRPC
+	httpConfig                 configs.HTTP
+	authConfig                 configs.Auth
+	downloadConfig             configs.Download
+	metricsConfig              configs.Metrics
+	downloadTaskLogic          logic.DownloadTask
+	downloadTaskShareLinkLogic logic.DownloadTaskShareLink
+	apiKeyLogic                logic.APIKey
+	tokenLogic                 logic.Token
+	oidcLogic                  logic.OIDC
+	rateLimitLogic             logic.RateLimit
+	healthLogic                logic.Health
+	metrics                    utils.Metrics
+	tracerProvider             trace.TracerProvider
+	logger                     *zap.Logger
 }
 
-func NewServer(grpcConfig configs.GRPC, httpConfig configs.HTTP, authConfig configs.Auth, logger *zap.Logger) Server {
+func NewServer(
+	grpcConfig configs.GRPC,
+	httpConfig configs.HTTP,
+	authConfig configs.Auth,
+	downloadConfig configs.Download,
+	metricsConfig configs.Metrics,
+	downloadTaskLogic logic.DownloadTask,
+	downloadTaskShareLinkLogic logic.DownloadTaskShareLink,
+	apiKeyLogic logic.APIKey,
+	tokenLogic logic.Token,
+	oidcLogic logic.OIDC,
+	rateLimitLogic logic.RateLimit,
+	healthLogic logic.Health,
+	metrics utils.Metrics,
+	tracerProvider trace.TracerProvider,
+	logger *zap.Logger,
+) Server {
 	return &server{
-		grpcConfig: grpcConfig,
-		httpConfig: httpConfig,
-		authConfig: authConfig,
-		logger:     logger,
+		grpcConfig:                 grpcConfig,
+		httpConfig:                 httpConfig,
+		authConfig:                 authConfig,
+		downloadConfig:             downloadConfig,
+		metricsConfig:              metricsConfig,
+		downloadTaskLogic:          downloadTaskLogic,
+		downloadTaskShareLinkLogic: downloadTaskShareLinkLogic,
+		apiKeyLogic:                apiKeyLogic,
+		tokenLogic:                 tokenLogic,
+		oidcLogic:                  oidcLogic,
+		rateLimitLogic:             rateLimitLogic,
+		healthLogic:                healthLogic,
+		metrics:                    metrics,
+		tracerProvider:             tracerProvider,
+		logger:                     logger,
 	}
 }
-func (s server) getGRPCGatewayHandler(ctx context.Context) (http.Handler, error) {
+
+// getRequestAuthToken returns the token of the auth cookie, falling back to a bearer token in the Authorization
+// header, the same way the gRPC handlers fall back to the authorization metadata.
+func getRequestAuthToken(r *http.Request) string {
+	if cookie, err := r.Cookie(AuthTokenCookieName); err == nil {
+		return cookie.Value
+	}
+	return handlerGRPC.GetBearerToken(r.Header.Get(httpRequestHeaderAuthorization))
+}
+func (s server) newGRPCGatewayServeMux() (*runtime.ServeMux, error) {
 	tokenExpiresInDuration, err := s.authConfig.Token.GetExpiresInDuration()
 	if err != nil {
 		return nil, err
 	}
-	grpcMux := runtime.NewServeMux(
+	return runtime.NewServeMux(
 		servemuxoptions.WithAuthCookieToAuthMetadata(AuthTokenCookieName, handlerGRPC.AuthTokenMetadataName),
 		servemuxoptions.WithAuthMetadataToAuthCookie(
 			handlerGRPC.AuthTokenMetadataName, AuthTokenCookieName, tokenExpiresInDuration),
 		servemuxoptions.WithRemoveGoAuthMetadata(handlerGRPC.AuthTokenMetadataName),
-	)
-	err = go_load.RegisterGoLoadServiceHandlerFromEndpoint(
-		ctx,
-		grpcMux,
-		s.grpcConfig.Address,
-		[]grpc.DialOption{
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-		})
+		servemuxoptions.WithRequestIDMetadata(utils.RequestIDHeaderName),
+		servemuxoptions.WithRetryAfterHeader(),
+	), nil
+}
+func (s server) getGRPCGatewayDialOptions() ([]grpc.DialOption, error) {
+	tlsConfig, err := utils.NewClientTLSConfig(s.httpConfig.GRPCClientTLS, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(s.tracerProvider))),
+	}, nil
+}
+func (s server) getGRPCGatewayHandler(ctx context.Context) (http.Handler, error) {
+	grpcMux, err := s.newGRPCGatewayServeMux()
+	if err != nil {
+		return nil, err
+	}
+	dialOptions, err := s.getGRPCGatewayDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	err = go_load.RegisterGoLoadServiceHandlerFromEndpoint(ctx, grpcMux, s.grpcConfig.Address, dialOptions)
 	if err != nil {
 		return nil, err
 	}
 	return grpcMux, nil
 }
+
+// getAdminGRPCGatewayHandler serves GoLoadAdminService under AdminPathPrefix, on its own mux so that the admin
+// methods are not reachable outside of it.
+func (s server) getAdminGRPCGatewayHandler(ctx context.Context) (http.Handler, error) {
+	grpcMux, err := s.newGRPCGatewayServeMux()
+	if err != nil {
+		return nil, err
+	}
+	dialOptions, err := s.getGRPCGatewayDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	err = go_load.RegisterGoLoadAdminServiceHandlerFromEndpoint(ctx, grpcMux, s.grpcConfig.Address, dialOptions)
+	if err != nil {
+		return nil, err
+	}
+	return http.StripPrefix(AdminPathPrefix, grpcMux), nil
+}
+
+// getTracingHandler starts a span for every request except metrics scrapes and health probes. The trace context of the request is
+// forwarded to the gRPC server by the gateway.
+func (s server) getTracingHandler(handler http.Handler) http.Handler {
+	return otelhttp.NewHandler(
+		handler,
+		"http",
+		otelhttp.WithTracerProvider(s.tracerProvider),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			if r.URL.Path == healthzPath || r.URL.Path == readyzPath {
+				return false
+			}
+			return !s.metricsConfig.Enabled || r.URL.Path != s.metricsConfig.Path
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
 func (s server) Start(ctx context.Context) error {
 	logger := utils.LoggerWithContext(ctx, s.logger)
 
-	grpcGatewayHandler, err := s.getGRPCGatewayHandler(ctx)
+	// The gateway connection outlives ctx so that requests still in flight during shutdown can complete.
+	grpcGatewayHandler, err := s.getGRPCGatewayHandler(context.WithoutCancel(ctx))
+	if err != nil {
+		return err
+	}
+	adminGRPCGatewayHandler, err := s.getAdminGRPCGatewayHandler(context.WithoutCancel(ctx))
+	if err != nil {
+		return err
+	}
+	maxManifestSizeInBytes, err := s.downloadConfig.Batch.GetMaxManifestSizeInBytes()
 	if err != nil {
 		return err
 	}
+	tokenExpiresInDuration, err := s.authConfig.Token.GetExpiresInDuration()
+	if err != nil {
+		return err
+	}
+	// The requests to the gateway are rate limited by the gRPC server, the ones to the handlers of the mux here.
+	rateLimit := middlewares.NewRateLimit(s.rateLimitLogic, getRequestAuthToken, s.logger)
+	httpServeMux := http.NewServeMux()
+	httpServeMux.Handle(DownloadTaskImportPathPattern, rateLimit(DownloadTaskImportPathPattern,
+		newDownloadTaskImportHandler(s.downloadTaskLogic, s.apiKeyLogic, maxManifestSizeInBytes, s.logger)))
+	httpServeMux.Handle(DownloadTaskFilePathPattern, rateLimit(DownloadTaskFilePathPattern,
+		newDownloadTaskFileHandler(s.downloadTaskLogic, s.apiKeyLogic, s.logger)))
+	httpServeMux.Handle(DownloadTaskShareLinkPathPattern, rateLimit(DownloadTaskShareLinkPathPattern,
+		newDownloadTaskShareLinkHandler(s.downloadTaskShareLinkLogic, s.logger)))
+	httpServeMux.Handle(JWKSPathPattern, rateLimit(JWKSPathPattern, newJWKSHandler(s.tokenLogic, s.logger)))
+	httpServeMux.Handle(OIDCLoginPathPattern, rateLimit(OIDCLoginPathPattern, newOIDCLoginHandler(s.oidcLogic, s.logger)))
+	httpServeMux.Handle(OIDCCallbackPathPattern, rateLimit(OIDCCallbackPathPattern, newOIDCCallbackHandler(
+		s.oidcLogic, tokenExpiresInDuration, s.authConfig.OIDC.PostLoginRedirectURL, s.logger)))
+	httpServeMux.Handle(HealthzPathPattern, newHealthzHandler(s.logger))
+	httpServeMux.Handle(ReadyzPathPattern, newReadyzHandler(s.healthLogic, s.logger))
+	if s.metricsConfig.Enabled {
+		httpServeMux.Handle(s.metricsConfig.Path, s.metrics.Handler())
+	}
+	httpServeMux.Handle(AdminPathPrefix+"/", adminGRPCGatewayHandler)
+	httpServeMux.Handle("/", grpcGatewayHandler)
+	tlsConfig, err := utils.NewServerTLSConfig(s.httpConfig.TLS, s.logger)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create tls config")
+		return err
+	}
 	httpServer := http.Server{
 		Addr:              s.httpConfig.Address,
 		ReadHeaderTimeout: time.Minute,
-		Handler:           grpcGatewayHandler,
+		Handler:           s.getTracingHandler(middlewares.NewRequestID()(httpServeMux)),
+		TLSConfig:         tlsConfig,
 	}
 
-	logger.With(zap.String("address", s.httpConfig.Address)).Info("starting http server")
-	return httpServer.ListenAndServe()
+	// Stop accepting new connections once ctx is done and let in-flight requests finish.
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.With(zap.Error(err)).Error("failed to shutdown http server gracefully")
+		}
+	}()
+
+	logger.With(zap.String("address", s.httpConfig.Address)).With(zap.Bool("tls", tlsConfig != nil)).Info("starting http server")
+	if tlsConfig != nil {
+		// The certificate is served by tlsConfig.GetCertificate, so that it can be reloaded.
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
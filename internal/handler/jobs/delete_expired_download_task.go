@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"GoLoad/internal/logic"
+	"context"
+)
+
+type DeleteExpiredDownloadTask interface {
+	Run(context.Context) error
+}
+type deleteExpiredDownloadTask struct {
+	downloadTaskLogic logic.DownloadTask
+}
+
+func NewDeleteExpiredDownloadTask(downloadTaskLogic logic.DownloadTask) DeleteExpiredDownloadTask {
+	return &deleteExpiredDownloadTask{
+		downloadTaskLogic: downloadTaskLogic,
+	}
+}
+func (d deleteExpiredDownloadTask) Run(ctx context.Context) error {
+	return d.downloadTaskLogic.DeleteExpiredDownloadTask(ctx)
+}
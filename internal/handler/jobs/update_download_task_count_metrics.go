@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"GoLoad/internal/logic"
+	"context"
+)
+
+type UpdateDownloadTaskCountMetrics interface {
+	Run(context.Context) error
+}
+type updateDownloadTaskCountMetrics struct {
+	downloadTaskLogic logic.DownloadTask
+}
+
+func NewUpdateDownloadTaskCountMetrics(downloadTaskLogic logic.DownloadTask) UpdateDownloadTaskCountMetrics {
+	return &updateDownloadTaskCountMetrics{
+		downloadTaskLogic: downloadTaskLogic,
+	}
+}
+func (u updateDownloadTaskCountMetrics) Run(ctx context.Context) error {
+	return u.downloadTaskLogic.UpdateDownloadTaskCountMetrics(ctx)
+}
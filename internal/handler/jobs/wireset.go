@@ -5,4 +5,7 @@ import "github.com/google/wire"
 var WireSet = wire.NewSet(
 	NewExecuteAllPendingDownloadTask,
 	NewUpdateDownloadingAndFailedDownloadTaskStatusToPending,
+	NewDeleteExpiredDownloadTask,
+	NewUpdateDownloadTaskCountMetrics,
+	NewRotateTokenSigningKey,
 )
@@ -1,19 +1,41 @@
 package logic
 
 import (
+	"GoLoad/internal/configs"
 	"GoLoad/internal/dataaccess/cache"
 	"GoLoad/internal/dataaccess/database"
 	"GoLoad/internal/generated/grpc/go_load"
 	"GoLoad/internal/utils"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// dummyPassword is hashed once so that logins of unknown accounts take as long as those of existing ones.
+	dummyPassword = "dummy password"
+)
+
+var (
+	errAccountDisabled = status.Error(codes.PermissionDenied, "account is disabled")
+	// errInvalidCredentials does not tell whether the account exists, has a password or the password is wrong.
+	errInvalidCredentials        = status.Error(codes.Unauthenticated, "invalid account name or password")
+	errIncorrectPassword         = status.Error(codes.Unauthenticated, "incorrect password")
+	errTooManyFailedLogins       = status.Error(codes.ResourceExhausted, "too many failed login attempts, try again later")
+	errAccountLastWorkspaceOwner = status.Error(codes.FailedPrecondition,
+		"account is the last owner of a workspace with other members, transfer the ownership first")
+)
+
 type CreateAccountParams struct {
 	AccountName string
 	Password    string
@@ -25,42 +47,100 @@ type CreateAccountOutput struct {
 type CreateSessionParams struct {
 	AccountName string
 	Password    string
+	// ClientIP is counted against the per IP login lockout if it is not empty.
+	ClientIP string
 }
 type CreateSessionOutput struct {
 	Account *go_load.Account
 	Token   string
 }
+type DeleteSessionParams struct {
+	Token string
+}
+type DeleteAllSessionsParams struct {
+	Token string
+}
+type ChangePasswordParams struct {
+	Token string
+	// OldPassword is ignored if the account has no password yet, such as one created by single sign-on.
+	OldPassword string
+	NewPassword string
+}
+type ChangePasswordOutput struct {
+	// Token replaces the session token of the request, which is revoked along with the other sessions.
+	Token string
+}
+type DeleteAccountParams struct {
+	Token string
+	// Password is ignored if the account has no password.
+	Password string
+}
 
 type Account interface {
 	CreateAccount(ctx context.Context, params CreateAccountParams) (CreateAccountOutput, error)
 	CreateSession(ctx context.Context, params CreateSessionParams) (CreateSessionOutput, error)
+	// DeleteSession revokes the session token, logging the account out of the current session.
+	DeleteSession(ctx context.Context, params DeleteSessionParams) error
+	// DeleteAllSessions revokes every session token issued to the account, logging it out everywhere.
+	DeleteAllSessions(ctx context.Context, params DeleteAllSessionsParams) error
+	// ChangePassword sets the password of the account and revokes all of its sessions.
+	ChangePassword(ctx context.Context, params ChangePasswordParams) (ChangePasswordOutput, error)
+	// DeleteAccount deletes the account along with its download tasks and their files, and the workspaces it is the
+	// only member of.
+	DeleteAccount(ctx context.Context, params DeleteAccountParams) error
 }
 type account struct {
 	goquDatabase                *goqu.Database
 	takenAccountNameCache       cache.TakenAccountName
+	loginAttemptCache           cache.LoginAttempt
 	accountDataAccessor         database.AccountDataAccessor
 	accountPasswordDataAccessor database.AccountPasswordDataAccessor
+	workspaceDataAccessor       database.WorkspaceDataAccessor
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor
 	hashLogic                   Hash
 	tokenLogic                  Token
+	downloadTaskLogic           DownloadTask
+	authConfig                  configs.Auth
 	logger                      *zap.Logger
+	dummyPasswordHashOnce       sync.Once
+	dummyPasswordHash           string
 }
 
-func NewAccount(goquDatabase *goqu.Database, takenAccountNameCache cache.TakenAccountName, accountDataAccessor database.AccountDataAccessor,
-	accountPasswordDataAccessor database.AccountPasswordDataAccessor, hashLogic Hash, tokenLogic Token, logger *zap.Logger) Account {
+func NewAccount(
+	goquDatabase *goqu.Database,
+	takenAccountNameCache cache.TakenAccountName,
+	loginAttemptCache cache.LoginAttempt,
+	accountDataAccessor database.AccountDataAccessor,
+	accountPasswordDataAccessor database.AccountPasswordDataAccessor,
+	workspaceDataAccessor database.WorkspaceDataAccessor,
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor,
+	hashLogic Hash,
+	tokenLogic Token,
+	downloadTaskLogic DownloadTask,
+	authConfig configs.Auth,
+	logger *zap.Logger,
+) Account {
 	return &account{
 		goquDatabase:                goquDatabase,
 		takenAccountNameCache:       takenAccountNameCache,
+		loginAttemptCache:           loginAttemptCache,
 		accountDataAccessor:         accountDataAccessor,
 		accountPasswordDataAccessor: accountPasswordDataAccessor,
+		workspaceDataAccessor:       workspaceDataAccessor,
+		workspaceMemberDataAccessor: workspaceMemberDataAccessor,
 		hashLogic:                   hashLogic,
 		tokenLogic:                  tokenLogic,
+		downloadTaskLogic:           downloadTaskLogic,
+		authConfig:                  authConfig,
 		logger:                      logger,
 	}
 }
-func (a account) databaseAccountToProtoAccount(account database.Account) *go_load.Account {
+func databaseAccountToProtoAccount(account database.Account) *go_load.Account {
 	return &go_load.Account{
 		Id:          account.ID,
 		AccountName: account.AccountName,
+		Role:        account.Role,
+		Disabled:    account.IsDisabled(),
 	}
 }
 
@@ -86,6 +166,9 @@ func (a *account) isAccountAccountNameTaken(ctx context.Context, accountName str
 }
 
 func (a *account) CreateAccount(ctx context.Context, params CreateAccountParams) (CreateAccountOutput, error) {
+	if err := validatePassword(a.authConfig.PasswordPolicy, params.AccountName, params.Password); err != nil {
+		return CreateAccountOutput{}, err
+	}
 	accountNameTaken, err := a.isAccountAccountNameTaken(ctx, params.AccountName)
 	if err != nil {
 		return CreateAccountOutput{}, status.Error(codes.Internal, "failed to check if account name is taken")
@@ -95,8 +178,13 @@ func (a *account) CreateAccount(ctx context.Context, params CreateAccountParams)
 	}
 	var accountID uint64
 	txErr := a.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		role := go_load.AccountRole_User
+		if lo.Contains(a.authConfig.AdminAccountNameList, params.AccountName) {
+			role = go_load.AccountRole_Admin
+		}
 		accountID, err = a.accountDataAccessor.WithDatabase(td).CreateAccount(ctx, database.Account{
 			AccountName: params.AccountName,
+			Role:        role,
 		})
 		if err != nil {
 			return err
@@ -121,28 +209,379 @@ func (a *account) CreateAccount(ctx context.Context, params CreateAccountParams)
 		AccountName: params.AccountName,
 	}, nil
 }
-func (a *account) CreateSession(ctx context.Context, params CreateSessionParams) (CreateSessionOutput, error) {
-	existingAccount, err := a.accountDataAccessor.GetAccountByAccountName(ctx, params.AccountName)
+
+// loginSubject is what failed logins are counted against, either an account name or a client IP.
+type loginSubject struct {
+	name                  string
+	maxFailedAttemptCount uint64
+}
+
+func (a *account) getAccountLoginSubjectList(accountName string) []loginSubject {
+	if a.authConfig.LoginLockout.MaxFailedAttemptsPerAccount == 0 {
+		return nil
+	}
+	// Account names are compared case insensitively by the database.
+	return []loginSubject{{
+		name:                  fmt.Sprintf("account:%s", strings.ToLower(accountName)),
+		maxFailedAttemptCount: a.authConfig.LoginLockout.MaxFailedAttemptsPerAccount,
+	}}
+}
+func (a *account) getLoginSubjectList(accountName string, clientIP string) []loginSubject {
+	subjectList := a.getAccountLoginSubjectList(accountName)
+	if a.authConfig.LoginLockout.MaxFailedAttemptsPerIP > 0 && clientIP != "" {
+		subjectList = append(subjectList, loginSubject{
+			name:                  fmt.Sprintf("ip:%s", clientIP),
+			maxFailedAttemptCount: a.authConfig.LoginLockout.MaxFailedAttemptsPerIP,
+		})
+	}
+	return subjectList
+}
+
+// isLoginLocked lets the login through if the cache cannot be reached, rather than locking everyone out.
+func (a *account) isLoginLocked(ctx context.Context, subjectList []loginSubject) bool {
+	logger := utils.LoggerWithContext(ctx, a.logger)
+
+	for _, subject := range subjectList {
+		locked, err := a.loginAttemptCache.IsLocked(ctx, subject.name)
+		if err != nil {
+			logger.With(zap.Error(err)).Warn("failed to check login lock, will ignore it")
+			continue
+		}
+		if locked {
+			return true
+		}
+	}
+	return false
+}
+
+// getLockoutDuration doubles the lockout duration for every failed attempt past the limit.
+func (a *account) getLockoutDuration(subject loginSubject, failedAttemptCount uint64) (time.Duration, error) {
+	lockoutDuration, err := a.authConfig.LoginLockout.GetLockoutDuration()
 	if err != nil {
-		return CreateSessionOutput{}, err
+		return 0, err
 	}
-	existingAccountPassword, err := a.accountPasswordDataAccessor.GetAccountPassword(ctx, existingAccount.ID)
+	maxLockoutDuration, err := a.authConfig.LoginLockout.GetMaxLockoutDuration()
 	if err != nil {
-		return CreateSessionOutput{}, err
+		return 0, err
+	}
+	for i := subject.maxFailedAttemptCount; i < failedAttemptCount && lockoutDuration < maxLockoutDuration; i++ {
+		lockoutDuration *= 2
+	}
+	return min(lockoutDuration, maxLockoutDuration), nil
+}
+func (a *account) recordFailedLogin(ctx context.Context, subjectList []loginSubject) {
+	logger := utils.LoggerWithContext(ctx, a.logger)
+
+	failedAttemptWindow, err := a.authConfig.LoginLockout.GetFailedAttemptWindowDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse failed_attempt_window")
+		return
 	}
-	isHashEqual, err := a.hashLogic.IsHashEqual(ctx, params.Password, existingAccountPassword.Hash)
+	maxLockoutDuration, err := a.authConfig.LoginLockout.GetMaxLockoutDuration()
 	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_lockout_duration")
+		return
+	}
+	for _, subject := range subjectList {
+		subjectLogger := logger.With(zap.String("subject", subject.name))
+		// The count has to outlive the longest lockout for the lockout to keep growing.
+		failedAttemptCount, increaseErr := a.loginAttemptCache.IncreaseFailedAttemptCount(
+			ctx, subject.name, max(failedAttemptWindow, maxLockoutDuration))
+		if increaseErr != nil {
+			subjectLogger.With(zap.Error(increaseErr)).Warn("failed to count failed login")
+			continue
+		}
+		if failedAttemptCount < subject.maxFailedAttemptCount {
+			continue
+		}
+		lockoutDuration, lockoutDurationErr := a.getLockoutDuration(subject, failedAttemptCount)
+		if lockoutDurationErr != nil {
+			subjectLogger.With(zap.Error(lockoutDurationErr)).Error("failed to parse lockout duration")
+			continue
+		}
+		if lockErr := a.loginAttemptCache.Lock(ctx, subject.name, lockoutDuration); lockErr != nil {
+			subjectLogger.With(zap.Error(lockErr)).Warn("failed to lock out login")
+			continue
+		}
+		subjectLogger.
+			With(zap.Uint64("failed_attempt_count", failedAttemptCount)).
+			With(zap.Duration("lockout_duration", lockoutDuration)).
+			Warn("login locked out after too many failed attempts")
+	}
+}
+
+// resetFailedLogin only resets the count of the account, so that a client cannot keep guessing the passwords of
+// other accounts by logging into its own one in between.
+func (a *account) resetFailedLogin(ctx context.Context, accountName string) {
+	for _, subject := range a.getAccountLoginSubjectList(accountName) {
+		if err := a.loginAttemptCache.ResetFailedAttemptCount(ctx, subject.name); err != nil {
+			utils.LoggerWithContext(ctx, a.logger).With(zap.Error(err)).Warn("failed to reset failed login count")
+		}
+	}
+}
+func (a *account) getDummyPasswordHash(ctx context.Context) string {
+	a.dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, err := a.hashLogic.Hash(ctx, dummyPassword)
+		if err != nil {
+			utils.LoggerWithContext(ctx, a.logger).With(zap.Error(err)).Error("failed to hash dummy password")
+			return
+		}
+		a.dummyPasswordHash = dummyPasswordHash
+	})
+	return a.dummyPasswordHash
+}
+
+// isAccountPasswordEqual returns false along with hasPassword set to false if the account has no password.
+func (a *account) isAccountPasswordEqual(ctx context.Context, accountID uint64, password string) (isEqual bool, hasPassword bool, err error) {
+	accountPassword, err := a.accountPasswordDataAccessor.GetAccountPassword(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	isEqual, err = a.hashLogic.IsHashEqual(ctx, password, accountPassword.Hash)
+	if err != nil {
+		return false, true, err
+	}
+	if isEqual && a.hashLogic.IsHashOutdated(ctx, accountPassword.Hash) {
+		a.rehashAccountPassword(ctx, accountID, password)
+	}
+	return isEqual, true, nil
+}
+
+// rehashAccountPassword replaces an outdated hash of the password, now that it is known. Failing to do so does not
+// fail the login, it is retried on the next one.
+func (a *account) rehashAccountPassword(ctx context.Context, accountID uint64, password string) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("account_id", accountID))
+
+	hashedPassword, err := a.hashLogic.Hash(ctx, password)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to rehash account password")
+		return
+	}
+	if err := a.accountPasswordDataAccessor.UpdateAccountPassword(ctx, database.AccountPassword{
+		OfAccountID: accountID,
+		Hash:        hashedPassword,
+	}); err != nil {
+		logger.With(zap.Error(err)).Warn("failed to update rehashed account password")
+		return
+	}
+	logger.Info("rehashed outdated account password")
+}
+
+// verifyLoginPassword returns errInvalidCredentials if the account does not exist, has no password or the password
+// is wrong, taking about as long in every case.
+func (a *account) verifyLoginPassword(ctx context.Context, accountName string, password string) (database.Account, error) {
+	existingAccount, err := a.accountDataAccessor.GetAccountByAccountName(ctx, accountName)
+	if err != nil {
+		if !errors.Is(err, database.ErrAccountNotFound) {
+			return database.Account{}, err
+		}
+		if _, err := a.hashLogic.IsHashEqual(ctx, password, a.getDummyPasswordHash(ctx)); err != nil {
+			return database.Account{}, err
+		}
+		return database.Account{}, errInvalidCredentials
+	}
+	isEqual, hasPassword, err := a.isAccountPasswordEqual(ctx, existingAccount.ID, password)
+	if err != nil {
+		return database.Account{}, err
+	}
+	if !hasPassword {
+		if _, err := a.hashLogic.IsHashEqual(ctx, password, a.getDummyPasswordHash(ctx)); err != nil {
+			return database.Account{}, err
+		}
+		return database.Account{}, errInvalidCredentials
+	}
+	if !isEqual {
+		return database.Account{}, errInvalidCredentials
+	}
+	return existingAccount, nil
+}
+func (a *account) CreateSession(ctx context.Context, params CreateSessionParams) (CreateSessionOutput, error) {
+	subjectList := a.getLoginSubjectList(params.AccountName, params.ClientIP)
+	if a.isLoginLocked(ctx, subjectList) {
+		return CreateSessionOutput{}, errTooManyFailedLogins
+	}
+	existingAccount, err := a.verifyLoginPassword(ctx, params.AccountName, params.Password)
+	if err != nil {
+		if errors.Is(err, errInvalidCredentials) {
+			a.recordFailedLogin(ctx, subjectList)
+		}
 		return CreateSessionOutput{}, err
 	}
-	if !isHashEqual {
-		return CreateSessionOutput{}, status.Error(codes.Unauthenticated, "incorrect password")
+	a.resetFailedLogin(ctx, params.AccountName)
+	if existingAccount.IsDisabled() {
+		return CreateSessionOutput{}, errAccountDisabled
 	}
 	token, _, err := a.tokenLogic.GetToken(ctx, existingAccount.ID)
 	if err != nil {
 		return CreateSessionOutput{}, err
 	}
 	return CreateSessionOutput{
-		Account: a.databaseAccountToProtoAccount(existingAccount),
+		Account: databaseAccountToProtoAccount(existingAccount),
 		Token:   token,
 	}, nil
 }
+func (a *account) DeleteSession(ctx context.Context, params DeleteSessionParams) error {
+	if isAPIKey(params.Token) {
+		return errAPIKeyCannotManageSessions
+	}
+	if _, _, err := a.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token); err != nil {
+		return err
+	}
+	return a.tokenLogic.RevokeToken(ctx, params.Token)
+}
+func (a *account) DeleteAllSessions(ctx context.Context, params DeleteAllSessionsParams) error {
+	if isAPIKey(params.Token) {
+		return errAPIKeyCannotManageSessions
+	}
+	accountID, _, err := a.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return err
+	}
+	if err := a.tokenLogic.RevokeAllTokensOfAccount(ctx, accountID); err != nil {
+		return err
+	}
+	utils.LoggerWithContext(ctx, a.logger).Info("revoked all sessions of account")
+	return nil
+}
+
+// verifyAccountPassword checks the password of an account that is already logged in, counting wrong passwords
+// against the lockout of the account. It returns whether the account has a password.
+func (a *account) verifyAccountPassword(ctx context.Context, existingAccount database.Account, password string) (bool, error) {
+	subjectList := a.getAccountLoginSubjectList(existingAccount.AccountName)
+	if a.isLoginLocked(ctx, subjectList) {
+		return false, errTooManyFailedLogins
+	}
+	isEqual, hasPassword, err := a.isAccountPasswordEqual(ctx, existingAccount.ID, password)
+	if err != nil {
+		return false, err
+	}
+	if hasPassword && !isEqual {
+		a.recordFailedLogin(ctx, subjectList)
+		return true, errIncorrectPassword
+	}
+	return hasPassword, nil
+}
+func (a *account) getSessionAccount(ctx context.Context, token string) (database.Account, error) {
+	if isAPIKey(token) {
+		return database.Account{}, errAPIKeyCannotManageAccount
+	}
+	accountID, _, err := a.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	if err != nil {
+		return database.Account{}, err
+	}
+	return a.accountDataAccessor.GetAccountByID(ctx, accountID)
+}
+func (a *account) ChangePassword(ctx context.Context, params ChangePasswordParams) (ChangePasswordOutput, error) {
+	existingAccount, err := a.getSessionAccount(ctx, params.Token)
+	if err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	hasPassword, err := a.verifyAccountPassword(ctx, existingAccount, params.OldPassword)
+	if err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	if err := validatePassword(a.authConfig.PasswordPolicy, existingAccount.AccountName, params.NewPassword); err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	hashedPassword, err := a.hashLogic.Hash(ctx, params.NewPassword)
+	if err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	accountPassword := database.AccountPassword{
+		OfAccountID: existingAccount.ID,
+		Hash:        hashedPassword,
+	}
+	if hasPassword {
+		err = a.accountPasswordDataAccessor.UpdateAccountPassword(ctx, accountPassword)
+	} else {
+		err = a.accountPasswordDataAccessor.CreateAccountPassword(ctx, accountPassword)
+	}
+	if err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	if err := a.tokenLogic.RevokeAllTokensOfAccount(ctx, existingAccount.ID); err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	token, _, err := a.tokenLogic.GetToken(ctx, existingAccount.ID)
+	if err != nil {
+		return ChangePasswordOutput{}, err
+	}
+	utils.LoggerWithContext(ctx, a.logger).Info("changed account password")
+	return ChangePasswordOutput{
+		Token: token,
+	}, nil
+}
+
+// getOnlyMemberWorkspaceIDList returns the workspaces the account is the only member of. It fails if the account is
+// the last owner of a workspace that has other members, which would be left without an owner.
+func (a *account) getOnlyMemberWorkspaceIDList(ctx context.Context, accountID uint64) ([]uint64, error) {
+	workspaceList, err := a.workspaceDataAccessor.GetWorkspaceListOfAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	workspaceIDList := make([]uint64, 0)
+	for _, workspace := range workspaceList {
+		memberList, err := a.workspaceMemberDataAccessor.GetWorkspaceMemberList(ctx, workspace.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(memberList) <= 1 {
+			workspaceIDList = append(workspaceIDList, workspace.ID)
+			continue
+		}
+		if workspace.Role != go_load.WorkspaceRole_Owner {
+			continue
+		}
+		ownerCount := lo.CountBy(memberList, func(item database.WorkspaceMember) bool {
+			return item.Role == go_load.WorkspaceRole_Owner
+		})
+		if ownerCount <= 1 {
+			return nil, errAccountLastWorkspaceOwner
+		}
+	}
+	return workspaceIDList, nil
+}
+
+// DeleteAccount deletes the files and download tasks before the account, so that a failure leaves an account that
+// can be deleted again rather than files nobody can reach. The account name stays in the taken set of the cache.
+func (a *account) DeleteAccount(ctx context.Context, params DeleteAccountParams) error {
+	existingAccount, err := a.getSessionAccount(ctx, params.Token)
+	if err != nil {
+		return err
+	}
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("deleted_account_id", existingAccount.ID))
+
+	if _, err := a.verifyAccountPassword(ctx, existingAccount, params.Password); err != nil {
+		return err
+	}
+	workspaceIDList, err := a.getOnlyMemberWorkspaceIDList(ctx, existingAccount.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.downloadTaskLogic.DeleteDownloadTaskListOfAccount(ctx, DeleteDownloadTaskListOfAccountParams{
+		AccountID:       existingAccount.ID,
+		WorkspaceIDList: workspaceIDList,
+	}); err != nil {
+		return err
+	}
+	// API keys, workspace memberships and identities are deleted along with the account by the database.
+	txErr := a.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		for _, workspaceID := range workspaceIDList {
+			if err := a.workspaceDataAccessor.WithDatabase(td).DeleteWorkspace(ctx, workspaceID); err != nil {
+				return err
+			}
+		}
+		if err := a.accountPasswordDataAccessor.WithDatabase(td).DeleteAccountPassword(ctx, existingAccount.ID); err != nil {
+			return err
+		}
+		return a.accountDataAccessor.WithDatabase(td).DeleteAccount(ctx, existingAccount.ID)
+	})
+	if txErr != nil {
+		return txErr
+	}
+	logger.With(zap.Int("deleted_workspace_count", len(workspaceIDList))).Info("deleted account")
+	return nil
+}
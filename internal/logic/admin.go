@@ -0,0 +1,373 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/dataaccess/mq/producer"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errAccountNotAdmin             = status.Error(codes.PermissionDenied, "account is not an admin")
+	errCannotModifyOwnAccount      = status.Error(codes.FailedPrecondition, "admins cannot disable or demote their own account")
+	errDownloadTaskIsDownloading   = status.Error(codes.FailedPrecondition, "download task is downloading")
+	errDownloadTaskAlreadyFinished = status.Error(codes.FailedPrecondition, "download task has already finished")
+	errInvalidAccountRole          = status.Error(codes.InvalidArgument, "invalid account role")
+	errPasswordIsEmpty             = status.Error(codes.InvalidArgument, "password is empty")
+)
+
+type AdminGetDownloadTaskListParams struct {
+	// OfAccountID restricts the list to the download tasks of an account if it is not zero.
+	OfAccountID uint64
+	DownloadTaskListQuery
+}
+type AdminUpdateDownloadTaskParams struct {
+	DownloadTaskID uint64
+}
+type AdminUpdateAccountParams struct {
+	AccountID uint64
+}
+type AdminResetAccountPasswordParams struct {
+	AccountID uint64
+	Password  string
+}
+type AdminUpdateAccountRoleParams struct {
+	AccountID uint64
+	Role      go_load.AccountRole
+}
+type AdminGetQueueDepthOutput struct {
+	PendingDownloadTaskCount     uint64
+	DownloadingDownloadTaskCount uint64
+	DownloadStatusCountList      []*go_load.DownloadStatusCount
+}
+type AdminGetAccountUsageListParams struct {
+	Offset uint64
+	Limit  uint64
+}
+type AdminGetAccountUsageListOutput struct {
+	AccountUsageList  []*go_load.AccountUsage
+	TotalAccountCount uint64
+}
+
+// Admin manages the accounts and download tasks of every tenant. Callers are expected to have checked AuthorizeAdmin
+// first.
+type Admin interface {
+	// AuthorizeAdmin returns an error unless the token belongs to an enabled admin account.
+	AuthorizeAdmin(ctx context.Context, token string) error
+	GetDownloadTaskList(ctx context.Context, params AdminGetDownloadTaskListParams) (GetDownloadTaskListOutput, error)
+	// RetryDownloadTask puts a download task that is not downloading back to pending and queues it again.
+	RetryDownloadTask(ctx context.Context, params AdminUpdateDownloadTaskParams) (*go_load.DownloadTask, error)
+	// FailDownloadTask marks a pending or downloading download task as failed.
+	FailDownloadTask(ctx context.Context, params AdminUpdateDownloadTaskParams) (*go_load.DownloadTask, error)
+	DisableAccount(ctx context.Context, params AdminUpdateAccountParams) (*go_load.Account, error)
+	EnableAccount(ctx context.Context, params AdminUpdateAccountParams) (*go_load.Account, error)
+	ResetAccountPassword(ctx context.Context, params AdminResetAccountPasswordParams) error
+	UpdateAccountRole(ctx context.Context, params AdminUpdateAccountRoleParams) (*go_load.Account, error)
+	GetQueueDepth(ctx context.Context) (AdminGetQueueDepthOutput, error)
+	GetAccountUsageList(ctx context.Context, params AdminGetAccountUsageListParams) (AdminGetAccountUsageListOutput, error)
+}
+type admin struct {
+	goquDatabase                *goqu.Database
+	accountDataAccessor         database.AccountDataAccessor
+	accountPasswordDataAccessor database.AccountPasswordDataAccessor
+	downloadTaskDataAccessor    database.DownloadTaskDataAccessor
+	downloadTaskCreatedProducer producer.DownloadTaskCreatedProducer
+	hashLogic                   Hash
+	tokenLogic                  Token
+	authConfig                  configs.Auth
+	logger                      *zap.Logger
+}
+
+func NewAdmin(
+	goquDatabase *goqu.Database,
+	accountDataAccessor database.AccountDataAccessor,
+	accountPasswordDataAccessor database.AccountPasswordDataAccessor,
+	downloadTaskDataAccessor database.DownloadTaskDataAccessor,
+	downloadTaskCreatedProducer producer.DownloadTaskCreatedProducer,
+	hashLogic Hash,
+	tokenLogic Token,
+	authConfig configs.Auth,
+	logger *zap.Logger,
+) Admin {
+	return &admin{
+		goquDatabase:                goquDatabase,
+		accountDataAccessor:         accountDataAccessor,
+		accountPasswordDataAccessor: accountPasswordDataAccessor,
+		downloadTaskDataAccessor:    downloadTaskDataAccessor,
+		downloadTaskCreatedProducer: downloadTaskCreatedProducer,
+		hashLogic:                   hashLogic,
+		tokenLogic:                  tokenLogic,
+		authConfig:                  authConfig,
+		logger:                      logger,
+	}
+}
+func (a admin) AuthorizeAdmin(ctx context.Context, token string) error {
+	accountID, _, err := a.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	if err != nil {
+		return err
+	}
+	account, err := a.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if account.Role != go_load.AccountRole_Admin {
+		utils.LoggerWithContext(ctx, a.logger).Warn("account without admin role tried to use the admin api")
+		return errAccountNotAdmin
+	}
+	return nil
+}
+
+func (a admin) GetDownloadTaskList(ctx context.Context, params AdminGetDownloadTaskListParams) (GetDownloadTaskListOutput, error) {
+	downloadTaskList, totalDownloadTaskCount, nextPageToken, err := getDownloadTaskList(
+		ctx, a.downloadTaskDataAccessor, database.DownloadTaskListFilter{OfAccountID: params.OfAccountID}, params.DownloadTaskListQuery)
+	if err != nil {
+		return GetDownloadTaskListOutput{}, err
+	}
+	accountMap, err := getDownloadTaskListAccountMap(ctx, a.accountDataAccessor, downloadTaskList)
+	if err != nil {
+		return GetDownloadTaskListOutput{}, err
+	}
+	return GetDownloadTaskListOutput{
+		TotalDownloadTaskCount: totalDownloadTaskCount,
+		DownloadTaskList: lo.Map(downloadTaskList, func(item database.DownloadTask, _ int) *go_load.DownloadTask {
+			return databaseDownloadTaskToProtoDownloadTask(item, accountMap[item.OfAccountID])
+		}),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// updateDownloadTask applies update to the download task while holding a lock on it, and returns the updated task.
+func (a admin) updateDownloadTask(
+	ctx context.Context,
+	downloadTaskID uint64,
+	update func(downloadTask *database.DownloadTask) error,
+) (*go_load.DownloadTask, error) {
+	var downloadTask database.DownloadTask
+	txErr := a.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		var err error
+		downloadTask, err = a.downloadTaskDataAccessor.WithDatabase(td).GetDownloadTaskWithXLock(ctx, downloadTaskID)
+		if err != nil {
+			return err
+		}
+		if err = update(&downloadTask); err != nil {
+			return err
+		}
+		downloadTask.UpdatedAt = time.Now()
+		return a.downloadTaskDataAccessor.WithDatabase(td).UpdateDownloadTask(ctx, downloadTask)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	account, err := a.accountDataAccessor.GetAccountByID(ctx, downloadTask.OfAccountID)
+	if err != nil {
+		return nil, err
+	}
+	return databaseDownloadTaskToProtoDownloadTask(downloadTask, account), nil
+}
+func (a admin) RetryDownloadTask(ctx context.Context, params AdminUpdateDownloadTaskParams) (*go_load.DownloadTask, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("download_task_id", params.DownloadTaskID))
+
+	downloadTask, err := a.updateDownloadTask(ctx, params.DownloadTaskID,
+		func(downloadTask *database.DownloadTask) error {
+			if downloadTask.DownloadStatus == go_load.DownloadStatus_Downloading {
+				return errDownloadTaskIsDownloading
+			}
+			downloadTask.DownloadStatus = go_load.DownloadStatus_Pending
+			downloadTask.Metadata = database.JSON{Data: make(map[string]any)}
+			downloadTask.FileSize = 0
+			downloadTask.StartedAt = nil
+			downloadTask.FinishedAt = nil
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	// The message is only produced once the task is pending, otherwise it could be consumed and skipped before the
+	// transaction commits. If producing fails, the execute all pending download task job still picks the task up.
+	if err := a.downloadTaskCreatedProducer.Produce(ctx, producer.DownloadTaskCreated{
+		ID: downloadTask.GetId(),
+	}); err != nil {
+		logger.With(zap.Error(err)).Warn("failed to produce download task created message for retried download task")
+	}
+	logger.Info("admin retried download task")
+	return downloadTask, nil
+}
+
+// FailDownloadTask does not stop a download that is still running, whose result overwrites the failed status when it
+// finishes. It is meant for download tasks whose executor is gone.
+func (a admin) FailDownloadTask(ctx context.Context, params AdminUpdateDownloadTaskParams) (*go_load.DownloadTask, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("download_task_id", params.DownloadTaskID))
+
+	downloadTask, err := a.updateDownloadTask(ctx, params.DownloadTaskID,
+		func(downloadTask *database.DownloadTask) error {
+			if downloadTask.DownloadStatus != go_load.DownloadStatus_Pending &&
+				downloadTask.DownloadStatus != go_load.DownloadStatus_Downloading {
+				return errDownloadTaskAlreadyFinished
+			}
+			finishedAt := time.Now()
+			downloadTask.DownloadStatus = go_load.DownloadStatus_Failed
+			downloadTask.FinishedAt = &finishedAt
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("admin failed download task")
+	return downloadTask, nil
+}
+
+// updateAccount applies update to an account other than the caller's own, and returns the updated account.
+func (a admin) updateAccount(
+	ctx context.Context,
+	accountID uint64,
+	update func(accountDataAccessor database.AccountDataAccessor, account *database.Account) error,
+) (*go_load.Account, error) {
+	if utils.AccountIDFromContext(ctx) == accountID {
+		return nil, errCannotModifyOwnAccount
+	}
+	account, err := a.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(a.accountDataAccessor, &account); err != nil {
+		return nil, err
+	}
+	return databaseAccountToProtoAccount(account), nil
+}
+func (a admin) DisableAccount(ctx context.Context, params AdminUpdateAccountParams) (*go_load.Account, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("target_account_id", params.AccountID))
+
+	account, err := a.updateAccount(ctx, params.AccountID,
+		func(accountDataAccessor database.AccountDataAccessor, account *database.Account) error {
+			if account.IsDisabled() {
+				return nil
+			}
+			disabledAt := time.Now()
+			account.DisabledAt = &disabledAt
+			return accountDataAccessor.UpdateAccountDisabledAt(ctx, account.ID, account.DisabledAt)
+		})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("admin disabled account")
+	return account, nil
+}
+func (a admin) EnableAccount(ctx context.Context, params AdminUpdateAccountParams) (*go_load.Account, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("target_account_id", params.AccountID))
+
+	account, err := a.updateAccount(ctx, params.AccountID,
+		func(accountDataAccessor database.AccountDataAccessor, account *database.Account) error {
+			account.DisabledAt = nil
+			return accountDataAccessor.UpdateAccountDisabledAt(ctx, account.ID, nil)
+		})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("admin enabled account")
+	return account, nil
+}
+func (a admin) ResetAccountPassword(ctx context.Context, params AdminResetAccountPasswordParams) error {
+	logger := utils.LoggerWithContext(ctx, a.logger).With(zap.Uint64("target_account_id", params.AccountID))
+
+	account, err := a.accountDataAccessor.GetAccountByID(ctx, params.AccountID)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(a.authConfig.PasswordPolicy, account.AccountName, params.Password); err != nil {
+		return err
+	}
+	hashedPassword, err := a.hashLogic.Hash(ctx, params.Password)
+	if err != nil {
+		return err
+	}
+	if err := a.accountPasswordDataAccessor.UpdateAccountPassword(ctx, database.AccountPassword{
+		OfAccountID: params.AccountID,
+		Hash:        hashedPassword,
+	}); err != nil {
+		return err
+	}
+	// Sessions opened with the old password must not outlive it.
+	if err := a.tokenLogic.RevokeAllTokensOfAccount(ctx, params.AccountID); err != nil {
+		return err
+	}
+	logger.Info("admin reset account password")
+	return nil
+}
+func (a admin) UpdateAccountRole(ctx context.Context, params AdminUpdateAccountRoleParams) (*go_load.Account, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).
+		With(zap.Uint64("target_account_id", params.AccountID)).
+		With(zap.String("role", params.Role.String()))
+
+	if params.Role != go_load.AccountRole_User && params.Role != go_load.AccountRole_Admin {
+		return nil, errInvalidAccountRole
+	}
+	account, err := a.updateAccount(ctx, params.AccountID,
+		func(accountDataAccessor database.AccountDataAccessor, account *database.Account) error {
+			account.Role = params.Role
+			return accountDataAccessor.UpdateAccountRole(ctx, account.ID, params.Role)
+		})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("admin updated account role")
+	return account, nil
+}
+func (a admin) GetQueueDepth(ctx context.Context) (AdminGetQueueDepthOutput, error) {
+	downloadStatusCountMap, err := a.downloadTaskDataAccessor.GetDownloadTaskCountByDownloadStatus(ctx)
+	if err != nil {
+		return AdminGetQueueDepthOutput{}, err
+	}
+	downloadStatusCountList := make([]*go_load.DownloadStatusCount, 0, len(go_load.DownloadStatus_name))
+	for downloadStatusValue := range go_load.DownloadStatus_name {
+		downloadStatus := go_load.DownloadStatus(downloadStatusValue)
+		if downloadStatus == go_load.DownloadStatus_UndefinedStatus {
+			continue
+		}
+		downloadStatusCountList = append(downloadStatusCountList, &go_load.DownloadStatusCount{
+			DownloadStatus: downloadStatus,
+			Count:          downloadStatusCountMap[downloadStatus],
+		})
+	}
+	sort.Slice(downloadStatusCountList, func(i, j int) bool {
+		return downloadStatusCountList[i].DownloadStatus < downloadStatusCountList[j].DownloadStatus
+	})
+	return AdminGetQueueDepthOutput{
+		PendingDownloadTaskCount:     downloadStatusCountMap[go_load.DownloadStatus_Pending],
+		DownloadingDownloadTaskCount: downloadStatusCountMap[go_load.DownloadStatus_Downloading],
+		DownloadStatusCountList:      downloadStatusCountList,
+	}, nil
+}
+func (a admin) GetAccountUsageList(
+	ctx context.Context,
+	params AdminGetAccountUsageListParams,
+) (AdminGetAccountUsageListOutput, error) {
+	totalAccountCount, err := a.accountDataAccessor.GetAccountCount(ctx)
+	if err != nil {
+		return AdminGetAccountUsageListOutput{}, err
+	}
+	accountUsageList, err := a.accountDataAccessor.GetAccountUsageList(ctx, params.Offset, params.Limit)
+	if err != nil {
+		return AdminGetAccountUsageListOutput{}, err
+	}
+	return AdminGetAccountUsageListOutput{
+		AccountUsageList: lo.Map(accountUsageList, func(item database.AccountUsage, _ int) *go_load.AccountUsage {
+			return &go_load.AccountUsage{
+				Account:                 databaseAccountToProtoAccount(item.Account),
+				DownloadTaskCount:       item.DownloadTaskCount,
+				ActiveDownloadTaskCount: item.ActiveDownloadTaskCount,
+				TotalFileSize:           item.TotalFileSize,
+			}
+		}),
+		TotalAccountCount: totalAccountCount,
+	}, nil
+}
@@ -0,0 +1,217 @@
+package logic
+
+import (
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// apiKeyPrefix tells API keys apart from session tokens.
+	apiKeyPrefix                   = "gl_"
+	apiKeyRandomByteCount          = 32
+	apiKeyDisplayPrefixLength      = len(apiKeyPrefix) + 8
+	maxAPIKeyNameLength            = 256
+	apiKeyLastUsedAtUpdateInterval = time.Minute
+)
+
+var (
+	errInvalidAPIKey              = status.Error(codes.Unauthenticated, "invalid api key")
+	errAPIKeyExpired              = status.Error(codes.Unauthenticated, "api key has expired")
+	errAPIKeyScopeNotGranted      = status.Error(codes.PermissionDenied, "api key does not have the scope required by this method")
+	errAPIKeyCannotManageAPIKeys  = status.Error(codes.PermissionDenied, "api keys cannot be used to manage api keys")
+	errAPIKeyCannotManageSessions = status.Error(codes.PermissionDenied, "api keys cannot be used to manage sessions")
+	errAPIKeyCannotManageAccount  = status.Error(codes.PermissionDenied, "api keys cannot be used to manage the account")
+	errInvalidAPIKeyName          = status.Error(codes.InvalidArgument, "api key name must be between 1 and 256 characters")
+	errInvalidAPIKeyScope         = status.Error(codes.InvalidArgument, "api key scopes must be non-empty and valid")
+)
+
+type CreateAPIKeyParams struct {
+	Token      string
+	APIKeyName string
+	Scopes     []go_load.APIKeyScope
+	// ExpiresIn is zero for API keys that do not expire.
+	ExpiresIn time.Duration
+}
+type CreateAPIKeyOutput struct {
+	APIKey *go_load.APIKey
+	// Key is only available on creation, only its hash is stored.
+	Key string
+}
+type GetAPIKeyListParams struct {
+	Token string
+}
+type RevokeAPIKeyParams struct {
+	Token    string
+	APIKeyID uint64
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+func hashAPIKey(key string) string {
+	keyHash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(keyHash[:])
+}
+func generateAPIKey() (string, error) {
+	randomBytes := make([]byte, apiKeyRandomByteCount)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+func databaseAPIKeyToProtoAPIKey(apiKey database.APIKey) *go_load.APIKey {
+	return &go_load.APIKey{
+		Id:           apiKey.ID,
+		ApiKeyName:   apiKey.APIKeyName,
+		KeyPrefix:    apiKey.KeyPrefix,
+		Scopes:       apiKey.Scopes.Scopes,
+		ExpireTime:   getProtoTimestamp(apiKey.ExpiresAt),
+		LastUsedTime: getProtoTimestamp(apiKey.LastUsedAt),
+		CreateTime:   getProtoTimestamp(&apiKey.CreatedAt),
+	}
+}
+
+// APIKey manages the long-lived API keys of accounts, meant for machine clients. API keys are accepted wherever a
+// session token is, but only for the methods allowed by their scopes.
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, params CreateAPIKeyParams) (CreateAPIKeyOutput, error)
+	GetAPIKeyList(ctx context.Context, params GetAPIKeyListParams) ([]*go_load.APIKey, error)
+	RevokeAPIKey(ctx context.Context, params RevokeAPIKeyParams) error
+	// AuthorizeAPIKeyScope returns an error if the token is an API key without the scope. Other tokens are left to
+	// Token. An undefined scope is never granted.
+	AuthorizeAPIKeyScope(ctx context.Context, token string, scope go_load.APIKeyScope) error
+}
+type apiKey struct {
+	apiKeyDataAccessor database.APIKeyDataAccessor
+	tokenLogic         Token
+	logger             *zap.Logger
+}
+
+func NewAPIKey(
+	apiKeyDataAccessor database.APIKeyDataAccessor,
+	tokenLogic Token,
+	logger *zap.Logger,
+) APIKey {
+	return &apiKey{
+		apiKeyDataAccessor: apiKeyDataAccessor,
+		tokenLogic:         tokenLogic,
+		logger:             logger,
+	}
+}
+
+// getSessionAccountID only accepts session tokens, so that a leaked API key cannot be used to create more keys.
+func (a apiKey) getSessionAccountID(ctx context.Context, token string) (uint64, error) {
+	if isAPIKey(token) {
+		return 0, errAPIKeyCannotManageAPIKeys
+	}
+	accountID, _, err := a.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	return accountID, err
+}
+func (a apiKey) CreateAPIKey(ctx context.Context, params CreateAPIKeyParams) (CreateAPIKeyOutput, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger)
+
+	nameLength := utf8.RuneCountInString(params.APIKeyName)
+	if nameLength == 0 || nameLength > maxAPIKeyNameLength {
+		return CreateAPIKeyOutput{}, errInvalidAPIKeyName
+	}
+	if len(params.Scopes) == 0 {
+		return CreateAPIKeyOutput{}, errInvalidAPIKeyScope
+	}
+	for _, scope := range params.Scopes {
+		if _, ok := go_load.APIKeyScope_name[int32(scope)]; !ok || scope == go_load.APIKeyScope_UndefinedAPIKeyScope {
+			return CreateAPIKeyOutput{}, errInvalidAPIKeyScope
+		}
+	}
+	accountID, err := a.getSessionAccountID(ctx, params.Token)
+	if err != nil {
+		return CreateAPIKeyOutput{}, err
+	}
+	key, err := generateAPIKey()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate api key")
+		return CreateAPIKeyOutput{}, status.Error(codes.Internal, "failed to generate api key")
+	}
+	now := time.Now()
+	apiKey := database.APIKey{
+		OfAccountID: accountID,
+		APIKeyName:  params.APIKeyName,
+		KeyPrefix:   key[:apiKeyDisplayPrefixLength],
+		KeyHash:     hashAPIKey(key),
+		Scopes:      database.APIKeyScopes{Scopes: lo.Uniq(params.Scopes)},
+		CreatedAt:   now,
+	}
+	if params.ExpiresIn > 0 {
+		expiresAt := now.Add(params.ExpiresIn)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	apiKey.ID, err = a.apiKeyDataAccessor.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return CreateAPIKeyOutput{}, err
+	}
+	return CreateAPIKeyOutput{
+		APIKey: databaseAPIKeyToProtoAPIKey(apiKey),
+		Key:    key,
+	}, nil
+}
+func (a apiKey) GetAPIKeyList(ctx context.Context, params GetAPIKeyListParams) ([]*go_load.APIKey, error) {
+	accountID, err := a.getSessionAccountID(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	apiKeyList, err := a.apiKeyDataAccessor.GetAPIKeyListOfAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(apiKeyList, func(item database.APIKey, _ int) *go_load.APIKey {
+		return databaseAPIKeyToProtoAPIKey(item)
+	}), nil
+}
+func (a apiKey) RevokeAPIKey(ctx context.Context, params RevokeAPIKeyParams) error {
+	accountID, err := a.getSessionAccountID(ctx, params.Token)
+	if err != nil {
+		return err
+	}
+	apiKey, err := a.apiKeyDataAccessor.GetAPIKey(ctx, params.APIKeyID)
+	if err != nil {
+		return err
+	}
+	if apiKey.OfAccountID != accountID {
+		return status.Error(codes.PermissionDenied, "trying to revoke an api key the account does not own")
+	}
+	return a.apiKeyDataAccessor.DeleteAPIKey(ctx, apiKey.ID)
+}
+func (a apiKey) AuthorizeAPIKeyScope(ctx context.Context, token string, scope go_load.APIKeyScope) error {
+	if !isAPIKey(token) {
+		return nil
+	}
+	apiKey, err := a.apiKeyDataAccessor.GetAPIKeyByKeyHash(ctx, hashAPIKey(token))
+	if err != nil {
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			return errInvalidAPIKey
+		}
+		return err
+	}
+	if scope == go_load.APIKeyScope_UndefinedAPIKeyScope || !lo.Contains(apiKey.Scopes.Scopes, scope) {
+		utils.LoggerWithContext(ctx, a.logger).
+			With(zap.Uint64("api_key_id", apiKey.ID)).
+			With(zap.Stringer("scope", scope)).
+			Warn("api key used outside of its scopes")
+		return errAPIKeyScopeNotGranted
+	}
+	return nil
+}
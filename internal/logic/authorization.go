@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Permission uint8
+
+const (
+	PermissionViewDownloadTask Permission = iota + 1
+	// PermissionManageDownloadTask covers creating, updating, cancelling, deleting and sharing download tasks.
+	PermissionManageDownloadTask
+	PermissionManageWorkspaceMember
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermissionViewDownloadTask:
+		return "view download tasks"
+	case PermissionManageDownloadTask:
+		return "manage download tasks"
+	case PermissionManageWorkspaceMember:
+		return "manage workspace members"
+	default:
+		return "unknown permission"
+	}
+}
+
+// hasWorkspacePermission tells whether a workspace role grants the permission. Each role grants the permissions of
+// the roles below it.
+func hasWorkspacePermission(role go_load.WorkspaceRole, permission Permission) bool {
+	switch permission {
+	case PermissionViewDownloadTask:
+		return role == go_load.WorkspaceRole_Viewer ||
+			role == go_load.WorkspaceRole_Downloader ||
+			role == go_load.WorkspaceRole_Owner
+	case PermissionManageDownloadTask:
+		return role == go_load.WorkspaceRole_Downloader || role == go_load.WorkspaceRole_Owner
+	case PermissionManageWorkspaceMember:
+		return role == go_load.WorkspaceRole_Owner
+	default:
+		return false
+	}
+}
+
+func newPermissionDeniedError(permission Permission) error {
+	return status.Errorf(codes.PermissionDenied, "account does not have permission to %s", permission)
+}
+
+// newDownloadTaskOwner returns the workspace if workspaceID is not zero, or else the personal space of the account.
+func newDownloadTaskOwner(accountID uint64, workspaceID uint64) database.DownloadTaskOwner {
+	owner := database.DownloadTaskOwner{OfAccountID: accountID}
+	if workspaceID != 0 {
+		owner.OfWorkspaceID = &workspaceID
+	}
+	return owner
+}
+
+// Authorization decides whether an account may act on download tasks and workspaces. Accounts have every permission
+// on their personal space, and the permissions granted by their role in the workspaces they are a member of.
+type Authorization interface {
+	AuthorizeDownloadTask(ctx context.Context, accountID uint64, downloadTask database.DownloadTask, permission Permission) error
+	AuthorizeDownloadTaskOwner(ctx context.Context, accountID uint64, owner database.DownloadTaskOwner, permission Permission) error
+	// AuthorizeWorkspace returns the membership of the account in the workspace if its role grants the permission.
+	AuthorizeWorkspace(ctx context.Context, accountID uint64, workspaceID uint64, permission Permission) (database.WorkspaceMember, error)
+}
+type authorization struct {
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor
+	logger                      *zap.Logger
+}
+
+func NewAuthorization(
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor,
+	logger *zap.Logger,
+) Authorization {
+	return &authorization{
+		workspaceMemberDataAccessor: workspaceMemberDataAccessor,
+		logger:                      logger,
+	}
+}
+func (a authorization) AuthorizeDownloadTask(
+	ctx context.Context,
+	accountID uint64,
+	downloadTask database.DownloadTask,
+	permission Permission,
+) error {
+	return a.AuthorizeDownloadTaskOwner(ctx, accountID, downloadTask.Owner(), permission)
+}
+func (a authorization) AuthorizeDownloadTaskOwner(
+	ctx context.Context,
+	accountID uint64,
+	owner database.DownloadTaskOwner,
+	permission Permission,
+) error {
+	if owner.OfWorkspaceID != nil {
+		_, err := a.AuthorizeWorkspace(ctx, accountID, *owner.OfWorkspaceID, permission)
+		return err
+	}
+	if owner.OfAccountID != accountID {
+		utils.LoggerWithContext(ctx, a.logger).
+			With(zap.Uint64("of_account_id", owner.OfAccountID)).
+			With(zap.Stringer("permission", permission)).
+			Warn("account tried to access the personal space of another account")
+		return newPermissionDeniedError(permission)
+	}
+	return nil
+}
+func (a authorization) AuthorizeWorkspace(
+	ctx context.Context,
+	accountID uint64,
+	workspaceID uint64,
+	permission Permission,
+) (database.WorkspaceMember, error) {
+	logger := utils.LoggerWithContext(ctx, a.logger).
+		With(zap.Uint64("workspace_id", workspaceID)).
+		With(zap.Stringer("permission", permission))
+
+	member, err := a.workspaceMemberDataAccessor.GetWorkspaceMember(ctx, workspaceID, accountID)
+	if err != nil {
+		if errors.Is(err, database.ErrWorkspaceMemberNotFound) {
+			logger.Warn("account tried to access a workspace it is not a member of")
+			return database.WorkspaceMember{}, newPermissionDeniedError(permission)
+		}
+		return database.WorkspaceMember{}, err
+	}
+	if !hasWorkspacePermission(member.Role, permission) {
+		logger.With(zap.Stringer("role", member.Role)).Warn("workspace role does not grant the permission")
+		return database.WorkspaceMember{}, newPermissionDeniedError(permission)
+	}
+	return member, nil
+}
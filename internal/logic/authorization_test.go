@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testAccountID      uint64 = 1
+	testOtherAccountID uint64 = 2
+	testWorkspaceID    uint64 = 10
+)
+
+var errTestDatabase = errors.New("database is down")
+
+// fakeWorkspaceMemberDataAccessor only implements GetWorkspaceMember, the other methods panic through the embedded
+// nil interface.
+type fakeWorkspaceMemberDataAccessor struct {
+	database.WorkspaceMemberDataAccessor
+	memberMap map[uint64]map[uint64]go_load.WorkspaceRole
+	err       error
+}
+
+func (f fakeWorkspaceMemberDataAccessor) GetWorkspaceMember(
+	_ context.Context,
+	workspaceID uint64,
+	accountID uint64,
+) (database.WorkspaceMember, error) {
+	if f.err != nil {
+		return database.WorkspaceMember{}, f.err
+	}
+	role, ok := f.memberMap[workspaceID][accountID]
+	if !ok {
+		return database.WorkspaceMember{}, database.ErrWorkspaceMemberNotFound
+	}
+	return database.WorkspaceMember{OfWorkspaceID: workspaceID, OfAccountID: accountID, Role: role}, nil
+}
+
+func newTestAuthorization(role go_load.WorkspaceRole) Authorization {
+	memberMap := map[uint64]map[uint64]go_load.WorkspaceRole{testWorkspaceID: {}}
+	if role != go_load.WorkspaceRole_UndefinedWorkspaceRole {
+		memberMap[testWorkspaceID][testAccountID] = role
+	}
+	return NewAuthorization(fakeWorkspaceMemberDataAccessor{memberMap: memberMap}, zap.NewNop())
+}
+
+func assertErrorCode(t *testing.T, err error, expectedCode codes.Code) {
+	t.Helper()
+	if expectedCode == codes.OK {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return
+	}
+	if code := status.Code(err); code != expectedCode {
+		t.Fatalf("expected code %s, got %v", expectedCode, err)
+	}
+}
+
+var testPermissionList = []Permission{
+	PermissionViewDownloadTask,
+	PermissionManageDownloadTask,
+	PermissionManageWorkspaceMember,
+}
+
+func TestAuthorizeDownloadTaskInPersonalSpace(t *testing.T) {
+	testCases := []struct {
+		name         string
+		ofAccountID  uint64
+		expectedCode codes.Code
+	}{
+		{name: "owner", ofAccountID: testAccountID, expectedCode: codes.OK},
+		{name: "other account", ofAccountID: testOtherAccountID, expectedCode: codes.PermissionDenied},
+	}
+	for _, testCase := range testCases {
+		for _, permission := range testPermissionList {
+			t.Run(testCase.name+"/"+permission.String(), func(t *testing.T) {
+				authorization := newTestAuthorization(go_load.WorkspaceRole_UndefinedWorkspaceRole)
+				downloadTask := database.DownloadTask{OfAccountID: testCase.ofAccountID}
+				err := authorization.AuthorizeDownloadTask(context.Background(), testAccountID, downloadTask, permission)
+				assertErrorCode(t, err, testCase.expectedCode)
+			})
+		}
+	}
+}
+
+func TestAuthorizeWorkspaceRolePermission(t *testing.T) {
+	testCases := []struct {
+		role          go_load.WorkspaceRole
+		permission    Permission
+		expectedCode  codes.Code
+		expectedGrant bool
+	}{
+		{go_load.WorkspaceRole_UndefinedWorkspaceRole, PermissionViewDownloadTask, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_UndefinedWorkspaceRole, PermissionManageDownloadTask, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_UndefinedWorkspaceRole, PermissionManageWorkspaceMember, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_Viewer, PermissionViewDownloadTask, codes.OK, true},
+		{go_load.WorkspaceRole_Viewer, PermissionManageDownloadTask, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_Viewer, PermissionManageWorkspaceMember, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_Downloader, PermissionViewDownloadTask, codes.OK, true},
+		{go_load.WorkspaceRole_Downloader, PermissionManageDownloadTask, codes.OK, true},
+		{go_load.WorkspaceRole_Downloader, PermissionManageWorkspaceMember, codes.PermissionDenied, false},
+		{go_load.WorkspaceRole_Owner, PermissionViewDownloadTask, codes.OK, true},
+		{go_load.WorkspaceRole_Owner, PermissionManageDownloadTask, codes.OK, true},
+		{go_load.WorkspaceRole_Owner, PermissionManageWorkspaceMember, codes.OK, true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.role.String()+"/"+testCase.permission.String(), func(t *testing.T) {
+			if granted := hasWorkspacePermission(testCase.role, testCase.permission); granted != testCase.expectedGrant {
+				t.Fatalf("expected hasWorkspacePermission to return %v", testCase.expectedGrant)
+			}
+
+			authorization := newTestAuthorization(testCase.role)
+			member, err := authorization.AuthorizeWorkspace(
+				context.Background(), testAccountID, testWorkspaceID, testCase.permission)
+			assertErrorCode(t, err, testCase.expectedCode)
+			if err == nil && member.Role != testCase.role {
+				t.Fatalf("expected member with role %s, got %s", testCase.role, member.Role)
+			}
+
+			// Download tasks in the workspace are authorized by the role, whoever created them.
+			workspaceID := testWorkspaceID
+			downloadTask := database.DownloadTask{OfAccountID: testOtherAccountID, OfWorkspaceID: &workspaceID}
+			err = authorization.AuthorizeDownloadTask(context.Background(), testAccountID, downloadTask, testCase.permission)
+			assertErrorCode(t, err, testCase.expectedCode)
+
+			err = authorization.AuthorizeDownloadTaskOwner(
+				context.Background(), testAccountID, newDownloadTaskOwner(testAccountID, testWorkspaceID), testCase.permission)
+			assertErrorCode(t, err, testCase.expectedCode)
+		})
+	}
+}
+
+func TestAuthorizeWorkspaceDatabaseError(t *testing.T) {
+	authorization := NewAuthorization(fakeWorkspaceMemberDataAccessor{err: errTestDatabase}, zap.NewNop())
+	_, err := authorization.AuthorizeWorkspace(
+		context.Background(), testAccountID, testWorkspaceID, PermissionViewDownloadTask)
+	if !errors.Is(err, errTestDatabase) {
+		t.Fatalf("expected the database error, got %v", err)
+	}
+}
@@ -8,38 +8,110 @@ import (
 	"GoLoad/internal/generated/grpc/go_load"
 	"GoLoad/internal/utils"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"buf.build/go/protovalidate"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/gammazero/workerpool"
+	"github.com/google/uuid"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
 	downloadTaskMetadataFieldNameFileName = "file-name"
+	deleteDownloadTaskListPageSize        = 100
+	// downloadTaskMetadataFieldNameFileEncryptionMetadata maps the names of the encrypted files of a download task to
+	// their encryption metadata.
+	downloadTaskMetadataFieldNameFileEncryptionMetadata = "file-encryption-metadata"
 )
 
 type CreateDownloadTaskParams struct {
-	Token        string
-	DownloadType go_load.DownloadType
-	URL          string
+	Token string
+	// WorkspaceID creates the download task in a workspace instead of the personal space of the account if it is not
+	// zero. The same applies to the WorkspaceID of the other params.
+	WorkspaceID         uint64
+	DownloadType        go_load.DownloadType
+	URL                 string
+	PostProcessingSteps []*go_load.PostProcessingStep
+	HTTPRequestOptions  *go_load.HTTPRequestOptions
 }
 type CreateDownloadTaskOutput struct {
 	DownloadTask *go_load.DownloadTask
 }
+type CreateDownloadTaskBatchItem struct {
+	DownloadType        go_load.DownloadType
+	URL                 string
+	PostProcessingSteps []*go_load.PostProcessingStep
+	HTTPRequestOptions  *go_load.HTTPRequestOptions
+}
+type CreateDownloadTaskBatchParams struct {
+	Token            string
+	WorkspaceID      uint64
+	DownloadTaskList []CreateDownloadTaskBatchItem
+}
+type CreateDownloadTaskBatchOutput struct {
+	BatchID    string
+	ResultList []*go_load.CreateDownloadTaskBatchItemResult
+}
+type ImportDownloadTasksParams struct {
+	Token               string
+	WorkspaceID         uint64
+	Format              go_load.DownloadTaskManifestFormat
+	Manifest            []byte
+	DownloadType        go_load.DownloadType
+	PostProcessingSteps []*go_load.PostProcessingStep
+	HTTPRequestOptions  *go_load.HTTPRequestOptions
+}
+type CancelDownloadTaskBatchParams struct {
+	Token       string
+	WorkspaceID uint64
+	BatchID     string
+}
+type DeleteDownloadTaskBatchParams struct {
+	Token       string
+	WorkspaceID uint64
+	BatchID     string
+}
+
+// DownloadTaskListQuery filters, sorts and paginates a download task list.
+type DownloadTaskListQuery struct {
+	BatchID            string
+	DownloadStatusList []go_load.DownloadStatus
+	DownloadTypeList   []go_load.DownloadType
+	URLContains        string
+	CreatedAfter       time.Time
+	CreatedBefore      time.Time
+	UpdatedAfter       time.Time
+	UpdatedBefore      time.Time
+	SortField          go_load.DownloadTaskListSortField
+	SortOrder          go_load.SortOrder
+	PageToken          string
+	Offset             uint64
+	Limit              uint64
+}
 type GetDownloadTaskListParams struct {
-	Token  string
-	Offset uint64
-	Limit  uint64
+	Token       string
+	WorkspaceID uint64
+	DownloadTaskListQuery
 }
 type GetDownloadTaskListOutput struct {
 	TotalDownloadTaskCount uint64
 	DownloadTaskList       []*go_load.DownloadTask
+	NextPageToken          string
 }
 type UpdateDownloadTaskParams struct {
 	Token          string
@@ -53,77 +125,302 @@ type DeleteDownloadTaskParams struct {
 	Token          string
 	DownloadTaskID uint64
 }
+type DeleteDownloadTaskListOfAccountParams struct {
+	AccountID uint64
+	// WorkspaceIDList lists workspaces whose download tasks are deleted too, whoever created them.
+	WorkspaceIDList []uint64
+}
 type GetDownloadTaskFileParams struct {
 	Token          string
 	DownloadTaskID uint64
+	Offset         uint64
+	Length         uint64
+}
+type GetDownloadTaskFileInfoParams struct {
+	Token          string
+	DownloadTaskID uint64
+}
+type GetDownloadTaskFileInfoOutput struct {
+	FileName    string
+	ContentType string
+	Size        uint64
+	ModTime     time.Time
+	ETag        string
 }
 
 type DownloadTask interface {
 	CreateDownloadTask(context.Context, CreateDownloadTaskParams) (CreateDownloadTaskOutput, error)
+	CreateDownloadTaskBatch(context.Context, CreateDownloadTaskBatchParams) (CreateDownloadTaskBatchOutput, error)
+	ImportDownloadTasks(context.Context, ImportDownloadTasksParams) (CreateDownloadTaskBatchOutput, error)
+	CancelDownloadTaskBatch(context.Context, CancelDownloadTaskBatchParams) (uint64, error)
+	DeleteDownloadTaskBatch(context.Context, DeleteDownloadTaskBatchParams) (uint64, error)
 	GetDownloadTaskList(context.Context, GetDownloadTaskListParams) (GetDownloadTaskListOutput, error)
 	UpdateDownloadTask(context.Context, UpdateDownloadTaskParams) (UpdateDownloadTaskOutput, error)
 	DeleteDownloadTask(context.Context, DeleteDownloadTaskParams) error
+	// DeleteDownloadTaskListOfAccount deletes every download task created by the account, including the ones in
+	// workspaces, along with their files. It is not authorized, callers are expected to have checked the account.
+	DeleteDownloadTaskListOfAccount(context.Context, DeleteDownloadTaskListOfAccountParams) error
 	ExecuteAllPendingDownloadTask(context.Context) error
 	ExecuteDownloadTask(context.Context, uint64) error
 	GetDownloadTaskFile(context.Context, GetDownloadTaskFileParams) (io.ReadCloser, error)
+	GetDownloadTaskFileInfo(context.Context, GetDownloadTaskFileInfoParams) (GetDownloadTaskFileInfoOutput, error)
 	UpdateDownloadingAndFailedDownloadTaskStatusToPending(context.Context) error
+	DeleteExpiredDownloadTask(context.Context) error
+	UpdateDownloadTaskCountMetrics(context.Context) error
 }
 type downloadTask struct {
 	tokenLogic                  Token
+	authorizationLogic          Authorization
 	accountDataAccessor         database.AccountDataAccessor
 	downloadTaskDataAccessor    database.DownloadTaskDataAccessor
 	downloadTaskCreatedProducer producer.DownloadTaskCreatedProducer
 	goquDatabase                *goqu.Database
 	fileClient                  file.Client
+	encryptedFileClient         file.EncryptedClient
+	postProcessor               PostProcessor
+	malwareScanner              MalwareScanner
+	urlPolicy                   URLPolicy
+	httpRequestOptionsLogic     HTTPRequestOptions
+	downloadConfig              configs.Download
 	cronConfig                  configs.Cron
+	metrics                     utils.Metrics
+	tracer                      trace.Tracer
 	logger                      *zap.Logger
 }
 
-func NewDownloadTask(tokenLogic Token, accountDataAccessor database.AccountDataAccessor, downloadTaskDataAccessor database.DownloadTaskDataAccessor,
+func NewDownloadTask(tokenLogic Token, authorizationLogic Authorization, accountDataAccessor database.AccountDataAccessor, downloadTaskDataAccessor database.DownloadTaskDataAccessor,
 	downloadTaskCreatedProducer producer.DownloadTaskCreatedProducer, goquDatabase *goqu.Database, fileClient file.Client,
-	cronConfig configs.Cron, logger *zap.Logger) DownloadTask {
+	encryptedFileClient file.EncryptedClient,
+	postProcessor PostProcessor, malwareScanner MalwareScanner, urlPolicy URLPolicy, httpRequestOptionsLogic HTTPRequestOptions, downloadConfig configs.Download, cronConfig configs.Cron, metrics utils.Metrics,
+	tracerProvider trace.TracerProvider, logger *zap.Logger) DownloadTask {
 	return &downloadTask{
 		tokenLogic:                  tokenLogic,
+		authorizationLogic:          authorizationLogic,
 		accountDataAccessor:         accountDataAccessor,
 		downloadTaskDataAccessor:    downloadTaskDataAccessor,
 		downloadTaskCreatedProducer: downloadTaskCreatedProducer,
 		goquDatabase:                goquDatabase,
 		fileClient:                  fileClient,
+		encryptedFileClient:         encryptedFileClient,
+		postProcessor:               postProcessor,
+		malwareScanner:              malwareScanner,
+		urlPolicy:                   urlPolicy,
+		httpRequestOptionsLogic:     httpRequestOptionsLogic,
+		downloadConfig:              downloadConfig,
 		cronConfig:                  cronConfig,
+		metrics:                     metrics,
+		tracer:                      tracerProvider.Tracer(utils.TracerName),
 		logger:                      logger,
 	}
 }
 
-func (d downloadTask) databaseDownloadTaskToProtoDownloadTask(downloadTask database.DownloadTask, account database.Account) *go_load.DownloadTask {
-	return &go_load.DownloadTask{
-		Id: downloadTask.ID,
-		OfAccount: &go_load.Account{
-			Id:          account.ID,
-			AccountName: account.AccountName,
-		},
-		DownloadType:   downloadTask.DownloadType,
-		Url:            downloadTask.URL,
-		DownloadStatus: downloadTask.DownloadStatus,
+func getProtoTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func databaseDownloadTaskToProtoDownloadTask(downloadTask database.DownloadTask, account database.Account) *go_load.DownloadTask {
+	protoDownloadTask := &go_load.DownloadTask{
+		Id:                        downloadTask.ID,
+		OfAccount:                 databaseAccountToProtoAccount(account),
+		DownloadType:              downloadTask.DownloadType,
+		Url:                       downloadTask.URL,
+		DownloadStatus:            downloadTask.DownloadStatus,
+		PostProcessingSteps:       downloadTask.PostProcessingSteps.Steps,
+		PostProcessingStepResults: getDownloadTaskPostProcessingStepResults(downloadTask),
+		BatchId:                   downloadTask.BatchID,
+		FileSize:                  downloadTask.FileSize,
+		CreateTime:                getProtoTimestamp(&downloadTask.CreatedAt),
+		UpdateTime:                getProtoTimestamp(&downloadTask.UpdatedAt),
+		StartTime:                 getProtoTimestamp(downloadTask.StartedAt),
+		FinishTime:                getProtoTimestamp(downloadTask.FinishedAt),
+		HttpRequestOptions:        downloadTask.HTTPRequestOptions.Options,
+		MalwareScanResult:         getDownloadTaskMalwareScanResult(downloadTask),
+	}
+	if downloadTask.OfWorkspaceID != nil {
+		protoDownloadTask.OfWorkspaceId = *downloadTask.OfWorkspaceID
+	}
+	return protoDownloadTask
+}
+
+// getDownloadTaskListAccountMap gets the accounts that created the download tasks, keyed by id.
+func getDownloadTaskListAccountMap(
+	ctx context.Context,
+	accountDataAccessor database.AccountDataAccessor,
+	downloadTaskList []database.DownloadTask,
+) (map[uint64]database.Account, error) {
+	accountMap := make(map[uint64]database.Account)
+	for _, downloadTask := range downloadTaskList {
+		if _, ok := accountMap[downloadTask.OfAccountID]; ok {
+			continue
+		}
+		account, err := accountDataAccessor.GetAccountByID(ctx, downloadTask.OfAccountID)
+		if err != nil {
+			return nil, err
+		}
+		accountMap[downloadTask.OfAccountID] = account
 	}
+	return accountMap, nil
 }
 
+// getDownloadTaskPostProcessingStepResults reads the step results from the metadata. The metadata holds
+// []PostProcessingStepResult right after execution and []any once read back from the database, so the value is
+// round-tripped through JSON.
+func getDownloadTaskPostProcessingStepResults(downloadTask database.DownloadTask) []*go_load.PostProcessingStepResult {
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	rawResults, ok := downloadTaskMetadata[downloadTaskMetadataFieldNamePostProcessingStepResults]
+	if !ok {
+		return nil
+	}
+	resultsJSON, err := json.Marshal(rawResults)
+	if err != nil {
+		return nil
+	}
+	results := make([]PostProcessingStepResult, 0)
+	if err := json.Unmarshal(resultsJSON, &results); err != nil {
+		return nil
+	}
+	return lo.Map(results, func(item PostProcessingStepResult, _ int) *go_load.PostProcessingStepResult {
+		return &go_load.PostProcessingStepResult{
+			Step:        item.Step,
+			Success:     item.Success,
+			Message:     item.Message,
+			OutputFiles: item.OutputFiles,
+		}
+	})
+}
+
+// getDownloadTaskMalwareScanResult reads the scan result from the metadata, round-tripping it through JSON like
+// getDownloadTaskPostProcessingStepResults.
+func getDownloadTaskMalwareScanResult(downloadTask database.DownloadTask) *go_load.MalwareScanResult {
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	rawResult, ok := downloadTaskMetadata[downloadTaskMetadataFieldNameMalwareScanResult]
+	if !ok {
+		return nil
+	}
+	resultJSON, err := json.Marshal(rawResult)
+	if err != nil {
+		return nil
+	}
+	var result MalwareScanResult
+	if err := json.Unmarshal(resultJSON, &result); err != nil {
+		return nil
+	}
+	return &go_load.MalwareScanResult{
+		Scanner:   result.Scanner,
+		Infected:  result.Infected,
+		Signature: result.Signature,
+	}
+}
+
+// getDownloadTaskFileEncryptionMetadata reads the encryption metadata of a file of the download task from the
+// metadata, round-tripping it through JSON like getDownloadTaskPostProcessingStepResults. It returns nil if the file
+// is not encrypted.
+func getDownloadTaskFileEncryptionMetadata(downloadTask database.DownloadTask, fileName string) (*file.EncryptionMetadata, error) {
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	rawMetadataMap, ok := downloadTaskMetadata[downloadTaskMetadataFieldNameFileEncryptionMetadata]
+	if !ok {
+		return nil, nil
+	}
+	metadataMapJSON, err := json.Marshal(rawMetadataMap)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to read file encryption metadata")
+	}
+	metadataMap := make(map[string]*file.EncryptionMetadata)
+	if err := json.Unmarshal(metadataMapJSON, &metadataMap); err != nil {
+		return nil, status.Error(codes.Internal, "failed to read file encryption metadata")
+	}
+	return metadataMap[fileName], nil
+}
+
+// validateDownloadTaskURLList checks the url of a download task and the urls its post-processing steps download
+// from against the url policy. validatedHostMap keeps the result for each scheme and host, as batches tend to repeat
+// them.
+func (d downloadTask) validateDownloadTaskURLList(
+	ctx context.Context,
+	rawURL string,
+	steps []*go_load.PostProcessingStep,
+	validatedHostMap map[string]error,
+) error {
+	urlList := []string{rawURL}
+	for _, step := range steps {
+		if signatureURL := step.GetVerifySignature().GetSignatureUrl(); signatureURL != "" {
+			urlList = append(urlList, signatureURL)
+		}
+	}
+	for _, item := range urlList {
+		parsedURL, err := url.Parse(item)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid url")
+		}
+		hostKey := parsedURL.Scheme + "://" + parsedURL.Host
+		validateErr, ok := validatedHostMap[hostKey]
+		if !ok {
+			validateErr = d.urlPolicy.ValidateURL(ctx, item)
+			validatedHostMap[hostKey] = validateErr
+		}
+		if validateErr != nil {
+			return validateErr
+		}
+	}
+	return nil
+}
 func (d downloadTask) CreateDownloadTask(ctx context.Context, params CreateDownloadTaskParams) (CreateDownloadTaskOutput, error) {
+	if err := ValidatePostProcessingSteps(params.PostProcessingSteps); err != nil {
+		return CreateDownloadTaskOutput{}, err
+	}
+	if err := d.validateDownloadTaskURLList(ctx, params.URL, params.PostProcessingSteps, make(map[string]error)); err != nil {
+		return CreateDownloadTaskOutput{}, err
+	}
+	if err := d.httpRequestOptionsLogic.Validate(ctx, params.HTTPRequestOptions); err != nil {
+		return CreateDownloadTaskOutput{}, err
+	}
+	encryptedHTTPRequestOptions, err := d.httpRequestOptionsLogic.Encrypt(ctx, params.HTTPRequestOptions)
+	if err != nil {
+		return CreateDownloadTaskOutput{}, err
+	}
 	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
 	if err != nil {
 		return CreateDownloadTaskOutput{}, err
 	}
+	owner := newDownloadTaskOwner(accountID, params.WorkspaceID)
+	if err = d.authorizationLogic.AuthorizeDownloadTaskOwner(ctx, accountID, owner, PermissionManageDownloadTask); err != nil {
+		return CreateDownloadTaskOutput{}, err
+	}
 	account, err := d.accountDataAccessor.GetAccountByID(ctx, accountID)
 	if err != nil {
 		return CreateDownloadTaskOutput{}, err
 	}
+	now := time.Now()
 	downloadTask := database.DownloadTask{
 		OfAccountID:    accountID,
+		OfWorkspaceID:  owner.OfWorkspaceID,
 		DownloadType:   params.DownloadType,
 		URL:            params.URL,
 		DownloadStatus: go_load.DownloadStatus_Pending,
 		Metadata: database.JSON{
 			Data: make(map[string]any),
 		},
+		PostProcessingSteps: database.PostProcessingSteps{
+			Steps: params.PostProcessingSteps,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+		HTTPRequestOptions: database.HTTPRequestOptions{
+			Options: redactHTTPRequestOptions(params.HTTPRequestOptions),
+		},
+		EncryptedHTTPRequestOptions: encryptedHTTPRequestOptions,
 	}
 	txErr := d.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
 		downloadTaskID, createDownloadTaskErr := d.downloadTaskDataAccessor.
@@ -145,31 +442,345 @@ func (d downloadTask) CreateDownloadTask(ctx context.Context, params CreateDownl
 		return CreateDownloadTaskOutput{}, txErr
 	}
 	return CreateDownloadTaskOutput{
-		DownloadTask: d.databaseDownloadTaskToProtoDownloadTask(downloadTask, account),
+		DownloadTask: databaseDownloadTaskToProtoDownloadTask(downloadTask, account),
 	}, nil
 }
-func (d downloadTask) GetDownloadTaskList(ctx context.Context, params GetDownloadTaskListParams) (GetDownloadTaskListOutput, error) {
+
+// getValidationErrorMessage joins the violations of a protovalidate error on a single line.
+func getValidationErrorMessage(err error) string {
+	var validationErr *protovalidate.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err.Error()
+	}
+	messageList := make([]string, 0, len(validationErr.Violations))
+	for _, violation := range validationErr.Violations {
+		messageList = append(messageList, fmt.Sprintf("%s: %s",
+			protovalidate.FieldPathString(violation.Proto.GetField()), violation.Proto.GetMessage()))
+	}
+	return strings.Join(messageList, "; ")
+}
+
+// validateDownloadTaskBatchItem checks the item against the rules of CreateDownloadTaskRequest in go_load.proto,
+// since the items of batches are validated one by one rather than by the gRPC server, then as CreateDownloadTask does.
+func (d downloadTask) validateDownloadTaskBatchItem(
+	ctx context.Context,
+	item CreateDownloadTaskBatchItem,
+	validatedHostMap map[string]error,
+) error {
+	if err := protovalidate.Validate(&go_load.CreateDownloadTaskRequest{
+		DownloadType:        item.DownloadType,
+		Url:                 item.URL,
+		PostProcessingSteps: item.PostProcessingSteps,
+		HttpRequestOptions:  item.HTTPRequestOptions,
+	}, protovalidate.WithFailFast()); err != nil {
+		return errors.New(getValidationErrorMessage(err))
+	}
+	if item.DownloadType != go_load.DownloadType_HTTP && item.DownloadType != go_load.DownloadType_Metalink {
+		return errors.New("unsupported download type")
+	}
+	parsedURL, err := url.ParseRequestURI(item.URL)
+	if err != nil || parsedURL.Host == "" {
+		return errors.New("invalid url")
+	}
+	if err := ValidatePostProcessingSteps(item.PostProcessingSteps); err != nil {
+		return errors.New(status.Convert(err).Message())
+	}
+	if err := d.validateDownloadTaskURLList(ctx, item.URL, item.PostProcessingSteps, validatedHostMap); err != nil {
+		return errors.New(status.Convert(err).Message())
+	}
+	if err := d.httpRequestOptionsLogic.Validate(ctx, item.HTTPRequestOptions); err != nil {
+		return errors.New(status.Convert(err).Message())
+	}
+	return nil
+}
+
+// CreateDownloadTaskBatch creates the valid items of the batch in a single transaction and reports a validation
+// error for each invalid item instead of failing the whole batch.
+func (d downloadTask) CreateDownloadTaskBatch(
+	ctx context.Context,
+	params CreateDownloadTaskBatchParams,
+) (CreateDownloadTaskBatchOutput, error) {
+	if len(params.DownloadTaskList) == 0 {
+		return CreateDownloadTaskBatchOutput{}, status.Error(codes.InvalidArgument, "download task list is empty")
+	}
+	if len(params.DownloadTaskList) > d.downloadConfig.Batch.MaxDownloadTaskCount {
+		return CreateDownloadTaskBatchOutput{}, status.Errorf(codes.InvalidArgument,
+			"download task list must not have more than %d items", d.downloadConfig.Batch.MaxDownloadTaskCount)
+	}
 	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
 	if err != nil {
-		return GetDownloadTaskListOutput{}, err
+		return CreateDownloadTaskBatchOutput{}, err
+	}
+	owner := newDownloadTaskOwner(accountID, params.WorkspaceID)
+	if err = d.authorizationLogic.AuthorizeDownloadTaskOwner(ctx, accountID, owner, PermissionManageDownloadTask); err != nil {
+		return CreateDownloadTaskBatchOutput{}, err
 	}
 	account, err := d.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return CreateDownloadTaskBatchOutput{}, err
+	}
+	batchID := uuid.NewString()
+	now := time.Now()
+	resultList := make([]*go_load.CreateDownloadTaskBatchItemResult, len(params.DownloadTaskList))
+	downloadTaskList := make([]database.DownloadTask, 0, len(params.DownloadTaskList))
+	downloadTaskIndexList := make([]int, 0, len(params.DownloadTaskList))
+	validatedHostMap := make(map[string]error)
+	for i, item := range params.DownloadTaskList {
+		resultList[i] = &go_load.CreateDownloadTaskBatchItemResult{Index: uint64(i)}
+		if validateErr := d.validateDownloadTaskBatchItem(ctx, item, validatedHostMap); validateErr != nil {
+			resultList[i].Error = validateErr.Error()
+			continue
+		}
+		encryptedHTTPRequestOptions, encryptErr := d.httpRequestOptionsLogic.Encrypt(ctx, item.HTTPRequestOptions)
+		if encryptErr != nil {
+			return CreateDownloadTaskBatchOutput{}, encryptErr
+		}
+		downloadTaskList = append(downloadTaskList, database.DownloadTask{
+			OfAccountID:    accountID,
+			OfWorkspaceID:  owner.OfWorkspaceID,
+			DownloadType:   item.DownloadType,
+			URL:            item.URL,
+			DownloadStatus: go_load.DownloadStatus_Pending,
+			Metadata: database.JSON{
+				Data: make(map[string]any),
+			},
+			PostProcessingSteps: database.PostProcessingSteps{
+				Steps: item.PostProcessingSteps,
+			},
+			BatchID:   batchID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			HTTPRequestOptions: database.HTTPRequestOptions{
+				Options: redactHTTPRequestOptions(item.HTTPRequestOptions),
+			},
+			EncryptedHTTPRequestOptions: encryptedHTTPRequestOptions,
+		})
+		downloadTaskIndexList = append(downloadTaskIndexList, i)
+	}
+	if len(downloadTaskList) > 0 {
+		txErr := d.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+			eventList := make([]producer.DownloadTaskCreated, 0, len(downloadTaskList))
+			for i := range downloadTaskList {
+				downloadTaskID, createDownloadTaskErr := d.downloadTaskDataAccessor.
+					WithDatabase(td).
+					CreateDownloadTask(ctx, downloadTaskList[i])
+				if createDownloadTaskErr != nil {
+					return createDownloadTaskErr
+				}
+				downloadTaskList[i].ID = downloadTaskID
+				eventList = append(eventList, producer.DownloadTaskCreated{
+					ID: downloadTaskID,
+				})
+			}
+			return d.downloadTaskCreatedProducer.ProduceBatch(ctx, eventList)
+		})
+		if txErr != nil {
+			return CreateDownloadTaskBatchOutput{}, txErr
+		}
+	}
+	for i, downloadTask := range downloadTaskList {
+		resultList[downloadTaskIndexList[i]].DownloadTask = databaseDownloadTaskToProtoDownloadTask(downloadTask, account)
+	}
+	return CreateDownloadTaskBatchOutput{
+		BatchID:    batchID,
+		ResultList: resultList,
+	}, nil
+}
+func (d downloadTask) ImportDownloadTasks(
+	ctx context.Context,
+	params ImportDownloadTasksParams,
+) (CreateDownloadTaskBatchOutput, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger)
+
+	maxManifestSize, err := d.downloadConfig.Batch.GetMaxManifestSizeInBytes()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_manifest_size")
+		return CreateDownloadTaskBatchOutput{}, status.Error(codes.Internal, "failed to parse max manifest size")
+	}
+	if uint64(len(params.Manifest)) > maxManifestSize {
+		return CreateDownloadTaskBatchOutput{}, status.Error(codes.InvalidArgument, "manifest is too large")
+	}
+	requestList, err := parseDownloadTaskManifest(params.Format, params.Manifest)
+	if err != nil {
+		return CreateDownloadTaskBatchOutput{}, status.Errorf(codes.InvalidArgument, "failed to parse manifest: %s", err)
+	}
+	downloadType := params.DownloadType
+	if downloadType == go_load.DownloadType_UndefinedType {
+		downloadType = go_load.DownloadType_HTTP
+	}
+	return d.CreateDownloadTaskBatch(ctx, CreateDownloadTaskBatchParams{
+		Token:       params.Token,
+		WorkspaceID: params.WorkspaceID,
+		DownloadTaskList: lo.Map(requestList, func(item *go_load.CreateDownloadTaskRequest, _ int) CreateDownloadTaskBatchItem {
+			batchItem := CreateDownloadTaskBatchItem{
+				DownloadType:        item.GetDownloadType(),
+				URL:                 item.GetUrl(),
+				PostProcessingSteps: item.GetPostProcessingSteps(),
+				HTTPRequestOptions:  item.GetHttpRequestOptions(),
+			}
+			if batchItem.DownloadType == go_load.DownloadType_UndefinedType {
+				batchItem.DownloadType = downloadType
+			}
+			if len(batchItem.PostProcessingSteps) == 0 {
+				batchItem.PostProcessingSteps = params.PostProcessingSteps
+			}
+			if batchItem.HTTPRequestOptions == nil {
+				batchItem.HTTPRequestOptions = params.HTTPRequestOptions
+			}
+			return batchItem
+		}),
+	})
+}
+
+// CancelDownloadTaskBatch cancels the tasks of the batch that have not started downloading or have failed. Tasks
+// that are already downloading are left to finish.
+func (d downloadTask) CancelDownloadTaskBatch(ctx context.Context, params CancelDownloadTaskBatchParams) (uint64, error) {
+	if params.BatchID == "" {
+		return 0, status.Error(codes.InvalidArgument, "batch id is empty")
+	}
+	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return 0, err
+	}
+	owner := newDownloadTaskOwner(accountID, params.WorkspaceID)
+	if err = d.authorizationLogic.AuthorizeDownloadTaskOwner(ctx, accountID, owner, PermissionManageDownloadTask); err != nil {
+		return 0, err
+	}
+	return d.downloadTaskDataAccessor.UpdatePendingAndFailedDownloadTaskOfBatchStatusToCancelled(ctx, owner, params.BatchID)
+}
+func (d downloadTask) DeleteDownloadTaskBatch(ctx context.Context, params DeleteDownloadTaskBatchParams) (uint64, error) {
+	if params.BatchID == "" {
+		return 0, status.Error(codes.InvalidArgument, "batch id is empty")
+	}
+	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return 0, err
+	}
+	owner := newDownloadTaskOwner(accountID, params.WorkspaceID)
+	if err = d.authorizationLogic.AuthorizeDownloadTaskOwner(ctx, accountID, owner, PermissionManageDownloadTask); err != nil {
+		return 0, err
+	}
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.String("batch_id", params.BatchID))
+
+	var deletedDownloadTaskCount uint64
+	for {
+		// Deleted download tasks leave the list, so every page is the first one.
+		downloadTaskList, err := d.downloadTaskDataAccessor.GetDownloadTaskList(ctx, database.GetDownloadTaskListParams{
+			Filter:     database.DownloadTaskListFilter{Owner: &owner, BatchID: params.BatchID},
+			SortColumn: database.ColNameDownloadTaskCreatedAt,
+			Limit:      deleteDownloadTaskListPageSize,
+		})
+		if err != nil {
+			return deletedDownloadTaskCount, err
+		}
+		for _, downloadTask := range downloadTaskList {
+			if err := d.deleteDownloadTaskFileList(ctx, downloadTask); err != nil {
+				logger.With(zap.Error(err)).With(zap.Uint64("download_task_id", downloadTask.ID)).
+					Error("failed to delete files of download task")
+				return deletedDownloadTaskCount, err
+			}
+			if err := d.downloadTaskDataAccessor.DeleteDownloadTask(ctx, downloadTask.ID); err != nil {
+				return deletedDownloadTaskCount, err
+			}
+			deletedDownloadTaskCount++
+		}
+		if len(downloadTaskList) < deleteDownloadTaskListPageSize {
+			return deletedDownloadTaskCount, nil
+		}
+	}
+}
+
+// getDownloadTaskList queries a page of the download tasks within scope, whose owner and account filters are kept,
+// along with the total count of download tasks matching the query and the token of the next page.
+func getDownloadTaskList(
+	ctx context.Context,
+	downloadTaskDataAccessor database.DownloadTaskDataAccessor,
+	scope database.DownloadTaskListFilter,
+	query DownloadTaskListQuery,
+) ([]database.DownloadTask, uint64, string, error) {
+	if query.SortField == go_load.DownloadTaskListSortField_UndefinedSortField {
+		query.SortField = go_load.DownloadTaskListSortField_SortByCreateTime
+	}
+	if query.SortOrder == go_load.SortOrder_UndefinedSortOrder {
+		query.SortOrder = go_load.SortOrder_Descending
+	}
+	sortColumn, err := getDownloadTaskListSortColumn(query.SortField)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	var cursor *database.DownloadTaskListCursor
+	if query.PageToken != "" {
+		cursor, err = decodeDownloadTaskListPageToken(query.PageToken, query.SortField, query.SortOrder)
+		if err != nil {
+			return nil, 0, "", err
+		}
+	}
+	filter := database.DownloadTaskListFilter{
+		Owner:              scope.Owner,
+		OfAccountID:        scope.OfAccountID,
+		BatchID:            query.BatchID,
+		DownloadStatusList: query.DownloadStatusList,
+		DownloadTypeList:   query.DownloadTypeList,
+		URLContains:        query.URLContains,
+		CreatedAfter:       query.CreatedAfter,
+		CreatedBefore:      query.CreatedBefore,
+		UpdatedAfter:       query.UpdatedAfter,
+		UpdatedBefore:      query.UpdatedBefore,
+	}
+	totalDownloadTaskCount, err := downloadTaskDataAccessor.GetDownloadTaskCount(ctx, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	// One extra task is queried to tell whether there is a next page.
+	queryLimit := query.Limit
+	if queryLimit > 0 {
+		queryLimit++
+	}
+	downloadTaskList, err := downloadTaskDataAccessor.GetDownloadTaskList(ctx, database.GetDownloadTaskListParams{
+		Filter:     filter,
+		SortColumn: sortColumn,
+		Descending: query.SortOrder == go_load.SortOrder_Descending,
+		Cursor:     cursor,
+		Offset:     query.Offset,
+		Limit:      queryLimit,
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	nextPageToken := ""
+	if query.Limit > 0 && uint64(len(downloadTaskList)) > query.Limit {
+		downloadTaskList = downloadTaskList[:query.Limit]
+		nextPageToken, err = encodeDownloadTaskListPageToken(query.SortField, query.SortOrder, downloadTaskList[len(downloadTaskList)-1])
+		if err != nil {
+			return nil, 0, "", status.Error(codes.Internal, "failed to encode page token")
+		}
+	}
+	return downloadTaskList, totalDownloadTaskCount, nextPageToken, nil
+}
+func (d downloadTask) GetDownloadTaskList(ctx context.Context, params GetDownloadTaskListParams) (GetDownloadTaskListOutput, error) {
+	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
 	if err != nil {
 		return GetDownloadTaskListOutput{}, err
 	}
-	totalDownloadTaskCount, err := d.downloadTaskDataAccessor.GetDownloadTaskCountOfAccount(ctx, accountID)
+	owner := newDownloadTaskOwner(accountID, params.WorkspaceID)
+	if err = d.authorizationLogic.AuthorizeDownloadTaskOwner(ctx, accountID, owner, PermissionViewDownloadTask); err != nil {
+		return GetDownloadTaskListOutput{}, err
+	}
+	downloadTaskList, totalDownloadTaskCount, nextPageToken, err := getDownloadTaskList(
+		ctx, d.downloadTaskDataAccessor, database.DownloadTaskListFilter{Owner: &owner}, params.DownloadTaskListQuery)
 	if err != nil {
 		return GetDownloadTaskListOutput{}, err
 	}
-	downloadTaskList, err := d.downloadTaskDataAccessor.GetDownloadTaskListOfAccount(ctx, accountID, params.Offset, params.Limit)
+	accountMap, err := getDownloadTaskListAccountMap(ctx, d.accountDataAccessor, downloadTaskList)
 	if err != nil {
 		return GetDownloadTaskListOutput{}, err
 	}
 	return GetDownloadTaskListOutput{
 		TotalDownloadTaskCount: totalDownloadTaskCount,
 		DownloadTaskList: lo.Map(downloadTaskList, func(item database.DownloadTask, _ int) *go_load.DownloadTask {
-			return d.databaseDownloadTaskToProtoDownloadTask(item, account)
+			return databaseDownloadTaskToProtoDownloadTask(item, accountMap[item.OfAccountID])
 		}),
+		NextPageToken: nextPageToken,
 	}, nil
 }
 func (d downloadTask) UpdateDownloadTask(ctx context.Context, params UpdateDownloadTaskParams) (UpdateDownloadTaskOutput, error) {
@@ -177,8 +788,7 @@ func (d downloadTask) UpdateDownloadTask(ctx context.Context, params UpdateDownl
 	if err != nil {
 		return UpdateDownloadTaskOutput{}, err
 	}
-	account, err := d.accountDataAccessor.GetAccountByID(ctx, accountID)
-	if err != nil {
+	if err = d.urlPolicy.ValidateURL(ctx, params.URL); err != nil {
 		return UpdateDownloadTaskOutput{}, err
 	}
 	output := UpdateDownloadTaskOutput{}
@@ -187,11 +797,17 @@ func (d downloadTask) UpdateDownloadTask(ctx context.Context, params UpdateDownl
 		if getDownloadTaskWithXLockErr != nil {
 			return getDownloadTaskWithXLockErr
 		}
-		if downloadTask.OfAccountID != accountID {
-			return status.Error(codes.PermissionDenied, "trying to update a download task the account does not own")
+		authorizeErr := d.authorizationLogic.AuthorizeDownloadTask(ctx, accountID, downloadTask, PermissionManageDownloadTask)
+		if authorizeErr != nil {
+			return authorizeErr
+		}
+		account, getAccountErr := d.accountDataAccessor.GetAccountByID(ctx, downloadTask.OfAccountID)
+		if getAccountErr != nil {
+			return getAccountErr
 		}
 		downloadTask.URL = params.URL
-		output.DownloadTask = d.databaseDownloadTaskToProtoDownloadTask(downloadTask, account)
+		downloadTask.UpdatedAt = time.Now()
+		output.DownloadTask = databaseDownloadTaskToProtoDownloadTask(downloadTask, account)
 		return d.downloadTaskDataAccessor.WithDatabase(td).UpdateDownloadTask(ctx, downloadTask)
 	})
 	if txErr != nil {
@@ -204,17 +820,32 @@ func (d downloadTask) DeleteDownloadTask(ctx context.Context, params DeleteDownl
 	if err != nil {
 		return err
 	}
-	return d.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
-		downloadTask, getDownloadTaskWithXLockErr := d.downloadTaskDataAccessor.WithDatabase(td).
+	var downloadTask database.DownloadTask
+	txErr := d.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		var getDownloadTaskWithXLockErr error
+		downloadTask, getDownloadTaskWithXLockErr = d.downloadTaskDataAccessor.WithDatabase(td).
 			GetDownloadTaskWithXLock(ctx, params.DownloadTaskID)
 		if getDownloadTaskWithXLockErr != nil {
 			return getDownloadTaskWithXLockErr
 		}
-		if downloadTask.OfAccountID != accountID {
-			return status.Error(codes.PermissionDenied, "trying to delete a download task the account does not own")
+		authorizeErr := d.authorizationLogic.AuthorizeDownloadTask(ctx, accountID, downloadTask, PermissionManageDownloadTask)
+		if authorizeErr != nil {
+			return authorizeErr
 		}
 		return d.downloadTaskDataAccessor.WithDatabase(td).DeleteDownloadTask(ctx, params.DownloadTaskID)
 	})
+	if txErr != nil {
+		return txErr
+	}
+	// The files are deleted once the download task is, so that a failed transaction does not leave it without them.
+	if err := d.deleteDownloadTaskFileList(ctx, downloadTask); err != nil {
+		utils.LoggerWithContext(ctx, d.logger).
+			With(zap.Uint64("download_task_id", downloadTask.ID)).
+			With(zap.Error(err)).
+			Error("failed to delete files of deleted download task")
+		return err
+	}
+	return nil
 }
 
 func (d downloadTask) ExecuteAllPendingDownloadTask(ctx context.Context) error {
@@ -234,8 +865,12 @@ func (d downloadTask) ExecuteAllPendingDownloadTask(ctx context.Context) error {
 		Info("pending download task found")
 
 	workerPool := workerpool.New(d.cronConfig.ExecuteAllPendingDownloadTask.ConcurrencyLimit)
+	d.metrics.SetExecutorConcurrencyLimit(d.cronConfig.ExecuteAllPendingDownloadTask.ConcurrencyLimit)
 	for _, id := range pendingDownloadTaskIDList {
 		workerPool.Submit(func() {
+			d.metrics.SetExecutorWaitingTaskCount(workerPool.WaitingQueueSize())
+			d.metrics.AddExecutorBusyWorkerCount(1)
+			defer d.metrics.AddExecutorBusyWorkerCount(-1)
 			if executeDownloadTaskErr := d.ExecuteDownloadTask(ctx, id); executeDownloadTaskErr != nil {
 				logger.
 					With(zap.Uint64("download_task_id", id)).
@@ -244,7 +879,9 @@ func (d downloadTask) ExecuteAllPendingDownloadTask(ctx context.Context) error {
 			}
 		})
 	}
+	d.metrics.SetExecutorWaitingTaskCount(workerPool.WaitingQueueSize())
 	workerPool.StopWait()
+	d.metrics.SetExecutorWaitingTaskCount(0)
 	return nil
 }
 
@@ -270,7 +907,10 @@ func (d downloadTask) updateDownloadTaskStatusFromPendingToDownloading(ctx conte
 			updated = false
 			return nil
 		}
+		startedAt := time.Now()
 		downloadTask.DownloadStatus = go_load.DownloadStatus_Downloading
+		downloadTask.StartedAt = &startedAt
+		downloadTask.FinishedAt = nil
 		err = d.downloadTaskDataAccessor.WithDatabase(td).UpdateDownloadTask(ctx, downloadTask)
 		if err != nil {
 			logger.With(zap.Error(err)).Error("failed to update download task")
@@ -288,15 +928,64 @@ func (d downloadTask) updateDownloadTaskStatusFromPendingToDownloading(ctx conte
 func (d downloadTask) updateDownloadTaskStatusToFailed(ctx context.Context, downloadTask database.DownloadTask) {
 	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("id", downloadTask.ID))
 
+	finishedAt := time.Now()
 	downloadTask.DownloadStatus = go_load.DownloadStatus_Failed
+	downloadTask.FinishedAt = &finishedAt
 	updateDownloadTaskErr := d.downloadTaskDataAccessor.UpdateDownloadTask(ctx, downloadTask)
 	if updateDownloadTaskErr != nil {
 		logger.With(zap.Error(updateDownloadTaskErr)).Warn("failed to update download task status to failed")
 	}
 }
 
+func (d downloadTask) download(
+	ctx context.Context,
+	downloadTask database.DownloadTask,
+	downloader Downloader,
+	writer *byteCountingWriter,
+) (map[string]any, error) {
+	ctx, span := d.tracer.Start(ctx, "Downloader.Download", trace.WithAttributes(
+		attribute.Int64("goload.download_task.id", int64(downloadTask.ID)),
+		attribute.String("goload.download_task.download_type", downloadTask.DownloadType.String()),
+	))
+	defer span.End()
+	// Only the host is recorded, the full url may contain credentials.
+	if parsedURL, err := url.Parse(downloadTask.URL); err == nil {
+		span.SetAttributes(attribute.String("server.address", parsedURL.Hostname()))
+	}
+
+	metadata, err := downloader.Download(ctx, writer)
+	span.SetAttributes(attribute.Int64("goload.download_task.byte_count", int64(writer.byteCount)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, "failed to download")
+	}
+	return metadata, err
+}
+
+func (d downloadTask) scan(
+	ctx context.Context,
+	downloadTask database.DownloadTask,
+	fileName string,
+	fileEncryptionMetadata *file.EncryptionMetadata,
+) (MalwareScanResult, error) {
+	ctx, span := d.tracer.Start(ctx, "MalwareScanner.Scan", trace.WithAttributes(
+		attribute.Int64("goload.download_task.id", int64(downloadTask.ID)),
+	))
+	defer span.End()
+
+	result, err := d.malwareScanner.Scan(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, "failed to scan")
+		return MalwareScanResult{}, err
+	}
+	span.SetAttributes(attribute.Bool("goload.malware_scan.infected", result.Infected))
+	return result, nil
+}
+
 func (d downloadTask) ExecuteDownloadTask(ctx context.Context, id uint64) error {
-	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("id", id))
+	ctx = utils.ContextWithDownloadTaskID(ctx, id)
+	logger := utils.LoggerWithContext(ctx, d.logger)
 
 	updated, downloadTask, err := d.updateDownloadTaskStatusFromPendingToDownloading(ctx, id)
 	if err != nil {
@@ -305,35 +994,97 @@ func (d downloadTask) ExecuteDownloadTask(ctx context.Context, id uint64) error
 	if !updated {
 		return nil
 	}
+	httpRequestOptions, err := d.httpRequestOptionsLogic.Decrypt(ctx, downloadTask.EncryptedHTTPRequestOptions)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to decrypt http request options")
+		d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
+		return err
+	}
 	var downloader Downloader
 	//nolint:exhaustive // No need to check unsupported download type
 	switch downloadTask.DownloadType {
 	case go_load.DownloadType_HTTP:
-		downloader = NewHTTPDownloader(downloadTask.URL, d.logger)
+		downloader = NewHTTPDownloader(downloadTask.URL, httpRequestOptions, d.urlPolicy.HTTPClient(), d.logger)
+	case go_load.DownloadType_Metalink:
+		downloader = NewMetalinkDownloader(
+			downloadTask.URL, httpRequestOptions, d.urlPolicy.HTTPClient(), d.downloadConfig.Metalink, d.logger)
 	default:
 		logger.With(zap.Any("download_type", downloadTask.DownloadType)).Error("unsupported download type")
 		d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
 		return nil
 	}
 	fileName := fmt.Sprintf("download_file_%d", id)
-	fileWriteCloser, err := d.fileClient.Write(ctx, fileName)
+	fileWriteCloser, fileEncryptionMetadata, err := d.encryptedFileClient.Write(ctx, fileName)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to get download file writer")
 		d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
 		return err
 	}
-	defer fileWriteCloser.Close()
-	metadata, err := downloader.Download(ctx, fileWriteCloser)
+	var (
+		downloadTypeName = downloadTask.DownloadType.String()
+		byteCountWriter  = &byteCountingWriter{writer: fileWriteCloser}
+		downloadStart    = time.Now()
+	)
+	metadata, err := d.download(ctx, downloadTask, downloader, byteCountWriter)
+	if closeErr := fileWriteCloser.Close(); err == nil {
+		err = closeErr
+	}
+	d.metrics.AddDownloadedBytes(downloadTypeName, byteCountWriter.byteCount)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to download")
 		d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
 		return err
 	}
+	d.metrics.ObserveDownload(downloadTypeName, byteCountWriter.byteCount, time.Since(downloadStart))
 	metadata[downloadTaskMetadataFieldNameFileName] = fileName
-	downloadTask.DownloadStatus = go_load.DownloadStatus_Success
+	fileEncryptionMetadataMap := make(map[string]*file.EncryptionMetadata)
+	if fileEncryptionMetadata != nil {
+		fileEncryptionMetadataMap[fileName] = fileEncryptionMetadata
+		metadata[downloadTaskMetadataFieldNameFileEncryptionMetadata] = fileEncryptionMetadataMap
+	}
+	fileInfo, err := d.encryptedFileClient.Stat(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to get downloaded file size")
+	} else {
+		downloadTask.FileSize = uint64(fileInfo.Size)
+	}
 	downloadTask.Metadata = database.JSON{
 		Data: metadata,
 	}
+	malwareScanResult, err := d.scan(ctx, downloadTask, fileName, fileEncryptionMetadata)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to scan downloaded file")
+		d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
+		return err
+	}
+	if malwareScanResult.Scanner != "" {
+		metadata[downloadTaskMetadataFieldNameMalwareScanResult] = malwareScanResult
+	}
+	if malwareScanResult.Infected {
+		// The file is kept for inspection, it is not post-processed nor served.
+		logger.With(zap.String("signature", malwareScanResult.Signature)).Warn("downloaded file is infected, quarantining")
+		finishedAt := time.Now()
+		downloadTask.DownloadStatus = go_load.DownloadStatus_Quarantined
+		downloadTask.FinishedAt = &finishedAt
+		if err = d.downloadTaskDataAccessor.UpdateDownloadTask(ctx, downloadTask); err != nil {
+			logger.With(zap.Error(err)).Error("failed to update download task status to quarantined")
+			return err
+		}
+		return nil
+	}
+	if len(downloadTask.PostProcessingSteps.Steps) > 0 {
+		results, postProcessErr := d.postProcessor.Process(
+			ctx, fileName, downloadTask.PostProcessingSteps.Steps, fileEncryptionMetadataMap)
+		metadata[downloadTaskMetadataFieldNamePostProcessingStepResults] = results
+		if postProcessErr != nil {
+			logger.With(zap.Error(postProcessErr)).Error("failed to post-process downloaded file")
+			d.updateDownloadTaskStatusToFailed(ctx, downloadTask)
+			return postProcessErr
+		}
+	}
+	finishedAt := time.Now()
+	downloadTask.DownloadStatus = go_load.DownloadStatus_Success
+	downloadTask.FinishedAt = &finishedAt
 	err = d.downloadTaskDataAccessor.UpdateDownloadTask(ctx, downloadTask)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to update download task status to success")
@@ -342,31 +1093,239 @@ func (d downloadTask) ExecuteDownloadTask(ctx context.Context, id uint64) error
 	logger.Info("download task executed successfully")
 	return nil
 }
+func getDownloadTaskFileName(downloadTask database.DownloadTask) (string, error) {
+	if downloadTask.DownloadStatus == go_load.DownloadStatus_Quarantined {
+		return "", errDownloadTaskQuarantined
+	}
+	if downloadTask.DownloadStatus != go_load.DownloadStatus_Success {
+		return "", status.Error(codes.InvalidArgument, "download task does not have status of success")
+	}
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return "", status.Error(codes.Internal, "download task metadata is not a map[string]any")
+	}
+	fileName, ok := downloadTaskMetadata[downloadTaskMetadataFieldNameFileName].(string)
+	if !ok {
+		return "", status.Error(codes.Internal, "download task metadata does not contain file name")
+	}
+	return fileName, nil
+}
+
+func getDownloadTaskContentType(downloadTask database.DownloadTask) string {
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return ""
+	}
+	contentType, _ := downloadTaskMetadata[HTTPMetadataKeyContentType].(string)
+	return contentType
+}
+
+// getDownloadTaskDisplayFileName returns the file name from the metalink or the last segment of the download task's
+// URL path, falling back to the stored file name when neither is available.
+func getDownloadTaskDisplayFileName(downloadTask database.DownloadTask, fileName string) string {
+	if downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any); ok {
+		if metalinkFileName, ok := downloadTaskMetadata[MetalinkMetadataKeyFileName].(string); ok && metalinkFileName != "" {
+			return path.Base(metalinkFileName)
+		}
+	}
+	parsedURL, err := url.Parse(downloadTask.URL)
+	if err != nil {
+		return fileName
+	}
+	baseName := path.Base(parsedURL.Path)
+	if baseName == "." || baseName == "/" {
+		return fileName
+	}
+	return baseName
+}
+
+func (d downloadTask) getDownloadTaskWithFile(ctx context.Context, token string, downloadTaskID uint64) (database.DownloadTask, string, error) {
+	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	if err != nil {
+		return database.DownloadTask{}, "", err
+	}
+	downloadTask, err := d.downloadTaskDataAccessor.GetDownloadTask(ctx, downloadTaskID)
+	if err != nil {
+		return database.DownloadTask{}, "", err
+	}
+	if err = d.authorizationLogic.AuthorizeDownloadTask(ctx, accountID, downloadTask, PermissionViewDownloadTask); err != nil {
+		return database.DownloadTask{}, "", err
+	}
+	fileName, err := getDownloadTaskFileName(downloadTask)
+	if err != nil {
+		return database.DownloadTask{}, "", err
+	}
+	return downloadTask, fileName, nil
+}
+
 func (d downloadTask) GetDownloadTaskFile(ctx context.Context, params GetDownloadTaskFileParams) (io.ReadCloser, error) {
-	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	downloadTask, fileName, err := d.getDownloadTaskWithFile(ctx, params.Token, params.DownloadTaskID)
 	if err != nil {
 		return nil, err
 	}
-	downloadTask, err := d.downloadTaskDataAccessor.GetDownloadTask(ctx, params.DownloadTaskID)
+	fileEncryptionMetadata, err := getDownloadTaskFileEncryptionMetadata(downloadTask, fileName)
 	if err != nil {
 		return nil, err
 	}
-	if downloadTask.OfAccountID != accountID {
-		return nil, status.Error(codes.PermissionDenied, "trying to get file of a download task the account does not own")
+	if params.Offset == 0 && params.Length == 0 {
+		return d.encryptedFileClient.Read(ctx, fileName, fileEncryptionMetadata)
 	}
-	if downloadTask.DownloadStatus != go_load.DownloadStatus_Success {
-		return nil, status.Error(codes.InvalidArgument, "download task does not have status of success")
+	fileInfo, err := d.encryptedFileClient.Stat(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		return nil, err
 	}
-	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
-	if !ok {
-		return nil, status.Error(codes.Internal, "download task metadata is not a map[string]any")
+	if params.Offset >= uint64(fileInfo.Size) {
+		return nil, status.Error(codes.OutOfRange, "offset is beyond the end of the file")
 	}
-	fileName, ok := downloadTaskMetadata[downloadTaskMetadataFieldNameFileName]
-	if !ok {
-		return nil, status.Error(codes.Internal, "download task metadata does not contain file name")
+	length := uint64(fileInfo.Size) - params.Offset
+	if params.Length > 0 {
+		length = min(length, params.Length)
+	}
+	return d.encryptedFileClient.ReadRange(ctx, fileName, fileEncryptionMetadata, params.Offset, length)
+}
+func (d downloadTask) GetDownloadTaskFileInfo(
+	ctx context.Context,
+	params GetDownloadTaskFileInfoParams,
+) (GetDownloadTaskFileInfoOutput, error) {
+	downloadTask, fileName, err := d.getDownloadTaskWithFile(ctx, params.Token, params.DownloadTaskID)
+	if err != nil {
+		return GetDownloadTaskFileInfoOutput{}, err
+	}
+	fileEncryptionMetadata, err := getDownloadTaskFileEncryptionMetadata(downloadTask, fileName)
+	if err != nil {
+		return GetDownloadTaskFileInfoOutput{}, err
 	}
-	return d.fileClient.Read(ctx, fileName.(string))
+	fileInfo, err := d.encryptedFileClient.Stat(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		return GetDownloadTaskFileInfoOutput{}, err
+	}
+	return GetDownloadTaskFileInfoOutput{
+		FileName:    getDownloadTaskDisplayFileName(downloadTask, fileName),
+		ContentType: getDownloadTaskContentType(downloadTask),
+		Size:        uint64(fileInfo.Size),
+		ModTime:     fileInfo.ModTime,
+		ETag:        fileInfo.ETag,
+	}, nil
 }
 func (d downloadTask) UpdateDownloadingAndFailedDownloadTaskStatusToPending(ctx context.Context) error {
 	return d.downloadTaskDataAccessor.UpdateDownloadingAndFailedDownloadTaskStatusToPending(ctx)
 }
+
+// getDownloadTaskStoredFileNameList returns the downloaded file and the files produced by its post-processing steps.
+func getDownloadTaskStoredFileNameList(downloadTask database.DownloadTask) []string {
+	downloadTaskMetadata, ok := downloadTask.Metadata.Data.(map[string]any)
+	if !ok {
+		return nil
+	}
+	fileNameList := make([]string, 0)
+	if fileName, ok := downloadTaskMetadata[downloadTaskMetadataFieldNameFileName].(string); ok && fileName != "" {
+		fileNameList = append(fileNameList, fileName)
+	}
+	for _, result := range getDownloadTaskPostProcessingStepResults(downloadTask) {
+		fileNameList = append(fileNameList, result.GetOutputFiles()...)
+	}
+	return fileNameList
+}
+
+// DeleteExpiredDownloadTask deletes the download tasks that finished longer than the retention period ago, along
+// with their files. Tasks whose files cannot be deleted are kept so that they are retried on the next run.
+func (d downloadTask) DeleteExpiredDownloadTask(ctx context.Context) error {
+	logger := utils.LoggerWithContext(ctx, d.logger)
+
+	retentionPeriod, err := d.cronConfig.DeleteExpiredDownloadTask.GetRetentionPeriodDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse retention_period")
+		return err
+	}
+	finishedBefore := time.Now().Add(-retentionPeriod)
+	batchSize := d.cronConfig.DeleteExpiredDownloadTask.BatchSize
+	deletedDownloadTaskCount := 0
+	for {
+		downloadTaskList, getErr := d.downloadTaskDataAccessor.GetFinishedDownloadTaskListBefore(ctx, finishedBefore, batchSize)
+		if getErr != nil {
+			return getErr
+		}
+		pageDeletedDownloadTaskCount := 0
+		for _, downloadTask := range downloadTaskList {
+			taskLogger := logger.With(zap.Uint64("download_task_id", downloadTask.ID))
+			if deleteFileErr := d.deleteDownloadTaskFileList(ctx, downloadTask); deleteFileErr != nil {
+				taskLogger.With(zap.Error(deleteFileErr)).Warn("failed to delete files of expired download task, will skip")
+				continue
+			}
+			if deleteErr := d.downloadTaskDataAccessor.DeleteDownloadTask(ctx, downloadTask.ID); deleteErr != nil {
+				taskLogger.With(zap.Error(deleteErr)).Warn("failed to delete expired download task, will skip")
+				continue
+			}
+			pageDeletedDownloadTaskCount++
+		}
+		deletedDownloadTaskCount += pageDeletedDownloadTaskCount
+		if uint64(len(downloadTaskList)) < batchSize || pageDeletedDownloadTaskCount == 0 {
+			break
+		}
+	}
+	logger.With(zap.Int("deleted_download_task_count", deletedDownloadTaskCount)).Info("expired download tasks deleted")
+	return nil
+}
+
+func (d downloadTask) DeleteDownloadTaskListOfAccount(ctx context.Context, params DeleteDownloadTaskListOfAccountParams) error {
+	logger := utils.LoggerWithContext(ctx, d.logger).With(zap.Uint64("account_id", params.AccountID))
+
+	filterList := []database.DownloadTaskListFilter{{OfAccountID: params.AccountID}}
+	for i := range params.WorkspaceIDList {
+		filterList = append(filterList, database.DownloadTaskListFilter{
+			Owner: &database.DownloadTaskOwner{OfWorkspaceID: &params.WorkspaceIDList[i]},
+		})
+	}
+	deletedDownloadTaskCount := 0
+	for _, filter := range filterList {
+		for {
+			// Deleted download tasks leave the list, so every page is the first one.
+			downloadTaskList, err := d.downloadTaskDataAccessor.GetDownloadTaskList(ctx, database.GetDownloadTaskListParams{
+				Filter:     filter,
+				SortColumn: database.ColNameDownloadTaskCreatedAt,
+				Limit:      deleteDownloadTaskListPageSize,
+			})
+			if err != nil {
+				return err
+			}
+			for _, downloadTask := range downloadTaskList {
+				if err := d.deleteDownloadTaskFileList(ctx, downloadTask); err != nil {
+					logger.With(zap.Error(err)).With(zap.Uint64("download_task_id", downloadTask.ID)).
+						Error("failed to delete files of download task")
+					return err
+				}
+				if err := d.downloadTaskDataAccessor.DeleteDownloadTask(ctx, downloadTask.ID); err != nil {
+					return err
+				}
+			}
+			deletedDownloadTaskCount += len(downloadTaskList)
+			if len(downloadTaskList) < deleteDownloadTaskListPageSize {
+				break
+			}
+		}
+	}
+	logger.With(zap.Int("deleted_download_task_count", deletedDownloadTaskCount)).Info("download tasks of account deleted")
+	return nil
+}
+
+func (d downloadTask) deleteDownloadTaskFileList(ctx context.Context, downloadTask database.DownloadTask) error {
+	for _, fileName := range getDownloadTaskStoredFileNameList(downloadTask) {
+		if err := d.fileClient.Delete(ctx, fileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateDownloadTaskCountMetrics sets the download task count metric of every status, including those without any
+// download task so that their count drops back to 0.
+func (d downloadTask) UpdateDownloadTaskCountMetrics(ctx context.Context) error {
+	countMap, err := d.downloadTaskDataAccessor.GetDownloadTaskCountByDownloadStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for value, name := range go_load.DownloadStatus_name {
+		d.metrics.SetDownloadTaskCount(name, countMap[go_load.DownloadStatus(value)])
+	}
+	return nil
+}
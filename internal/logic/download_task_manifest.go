@@ -0,0 +1,129 @@
+package logic
+
+import (
+	"GoLoad/internal/generated/grpc/go_load"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	errEmptyDownloadTaskManifest        = errors.New("download task manifest is empty")
+	errUnsupportedDownloadTaskManifest  = errors.New("unsupported download task manifest format")
+	errInvalidJSONDownloadTaskManifest  = errors.New("json download task manifest must be an array or an object")
+	errInvalidJSONDownloadTaskListEntry = errors.New("json download task manifest entry must be a string or an object")
+)
+
+func detectDownloadTaskManifestFormat(manifest []byte) go_load.DownloadTaskManifestFormat {
+	trimmedManifest := bytes.TrimSpace(manifest)
+	switch {
+	case bytes.HasPrefix(trimmedManifest, []byte("<")):
+		return go_load.DownloadTaskManifestFormat_MetalinkManifest
+	case bytes.HasPrefix(trimmedManifest, []byte("[")), bytes.HasPrefix(trimmedManifest, []byte("{")):
+		return go_load.DownloadTaskManifestFormat_JSONManifest
+	default:
+		return go_load.DownloadTaskManifestFormat_URLListManifest
+	}
+}
+
+// parseDownloadTaskManifest parses a manifest into download task requests. The format is detected from the content
+// if it is undefined. Fields not set by the manifest are left empty for the caller to fill in.
+func parseDownloadTaskManifest(
+	format go_load.DownloadTaskManifestFormat,
+	manifest []byte,
+) ([]*go_load.CreateDownloadTaskRequest, error) {
+	if len(bytes.TrimSpace(manifest)) == 0 {
+		return nil, errEmptyDownloadTaskManifest
+	}
+	if format == go_load.DownloadTaskManifestFormat_UndefinedManifestFormat {
+		format = detectDownloadTaskManifestFormat(manifest)
+	}
+	switch format {
+	case go_load.DownloadTaskManifestFormat_URLListManifest:
+		return parseURLListDownloadTaskManifest(manifest)
+	case go_load.DownloadTaskManifestFormat_MetalinkManifest:
+		return parseMetalinkDownloadTaskManifest(manifest)
+	case go_load.DownloadTaskManifestFormat_JSONManifest:
+		return parseJSONDownloadTaskManifest(manifest)
+	default:
+		return nil, errUnsupportedDownloadTaskManifest
+	}
+}
+
+// parseURLListDownloadTaskManifest reads one url per line, skipping empty lines and lines starting with #.
+func parseURLListDownloadTaskManifest(manifest []byte) ([]*go_load.CreateDownloadTaskRequest, error) {
+	requestList := make([]*go_load.CreateDownloadTaskRequest, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		requestList = append(requestList, &go_load.CreateDownloadTaskRequest{Url: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return requestList, nil
+}
+
+// parseMetalinkDownloadTaskManifest creates a task for the most preferred mirror of each file. To fail over between
+// mirrors, create a Metalink download task pointing to the metalink instead.
+func parseMetalinkDownloadTaskManifest(manifest []byte) ([]*go_load.CreateDownloadTaskRequest, error) {
+	fileList, err := parseMetalink(manifest)
+	if err != nil {
+		return nil, err
+	}
+	requestList := make([]*go_load.CreateDownloadTaskRequest, 0, len(fileList))
+	for _, file := range fileList {
+		requestList = append(requestList, &go_load.CreateDownloadTaskRequest{
+			Url: file.Mirrors[0].URL,
+		})
+	}
+	return requestList, nil
+}
+
+// parseJSONDownloadTaskManifest accepts either a CreateDownloadTasksRequest object or an array whose entries are
+// urls or CreateDownloadTaskRequest objects.
+func parseJSONDownloadTaskManifest(manifest []byte) ([]*go_load.CreateDownloadTaskRequest, error) {
+	trimmedManifest := bytes.TrimSpace(manifest)
+	if bytes.HasPrefix(trimmedManifest, []byte("{")) {
+		request := new(go_load.CreateDownloadTasksRequest)
+		if err := protojson.Unmarshal(trimmedManifest, request); err != nil {
+			return nil, err
+		}
+		return request.GetDownloadTaskList(), nil
+	}
+	if !bytes.HasPrefix(trimmedManifest, []byte("[")) {
+		return nil, errInvalidJSONDownloadTaskManifest
+	}
+	rawEntryList := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(trimmedManifest, &rawEntryList); err != nil {
+		return nil, err
+	}
+	requestList := make([]*go_load.CreateDownloadTaskRequest, 0, len(rawEntryList))
+	for _, rawEntry := range rawEntryList {
+		rawEntry = bytes.TrimSpace(rawEntry)
+		switch {
+		case bytes.HasPrefix(rawEntry, []byte(`"`)):
+			url := ""
+			if err := json.Unmarshal(rawEntry, &url); err != nil {
+				return nil, err
+			}
+			requestList = append(requestList, &go_load.CreateDownloadTaskRequest{Url: url})
+		case bytes.HasPrefix(rawEntry, []byte("{")):
+			request := new(go_load.CreateDownloadTaskRequest)
+			if err := protojson.Unmarshal(rawEntry, request); err != nil {
+				return nil, err
+			}
+			requestList = append(requestList, request)
+		default:
+			return nil, errInvalidJSONDownloadTaskListEntry
+		}
+	}
+	return requestList, nil
+}
@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errInvalidPageToken = status.Error(codes.InvalidArgument, "invalid page token")
+
+// downloadTaskListPageToken is the opaque cursor returned as next_page_token. It records the sort it was created
+// for, so that it cannot be reused with a different one, and the sort value and id of the last task of the page.
+type downloadTaskListPageToken struct {
+	SortField go_load.DownloadTaskListSortField `json:"f"`
+	SortOrder go_load.SortOrder                 `json:"o"`
+	SortValue string                            `json:"v"`
+	ID        uint64                            `json:"i"`
+}
+
+func getDownloadTaskListSortColumn(sortField go_load.DownloadTaskListSortField) (string, error) {
+	switch sortField {
+	case go_load.DownloadTaskListSortField_SortByCreateTime:
+		return database.ColNameDownloadTaskCreatedAt, nil
+	case go_load.DownloadTaskListSortField_SortByUpdateTime:
+		return database.ColNameDownloadTaskUpdatedAt, nil
+	case go_load.DownloadTaskListSortField_SortByFileSize:
+		return database.ColNameDownloadTaskFileSize, nil
+	case go_load.DownloadTaskListSortField_SortByDownloadStatus:
+		return database.ColNameDownloadTaskDownloadStatus, nil
+	default:
+		return "", status.Error(codes.InvalidArgument, "invalid sort field")
+	}
+}
+
+func encodeDownloadTaskListPageToken(
+	sortField go_load.DownloadTaskListSortField,
+	sortOrder go_load.SortOrder,
+	downloadTask database.DownloadTask,
+) (string, error) {
+	pageToken := downloadTaskListPageToken{
+		SortField: sortField,
+		SortOrder: sortOrder,
+		ID:        downloadTask.ID,
+	}
+	//nolint:exhaustive // Sort fields are validated before the list is queried
+	switch sortField {
+	case go_load.DownloadTaskListSortField_SortByCreateTime:
+		pageToken.SortValue = downloadTask.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case go_load.DownloadTaskListSortField_SortByUpdateTime:
+		pageToken.SortValue = downloadTask.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case go_load.DownloadTaskListSortField_SortByFileSize:
+		pageToken.SortValue = strconv.FormatUint(downloadTask.FileSize, 10)
+	case go_load.DownloadTaskListSortField_SortByDownloadStatus:
+		pageToken.SortValue = strconv.FormatInt(int64(downloadTask.DownloadStatus), 10)
+	}
+	pageTokenBytes, err := json.Marshal(pageToken)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(pageTokenBytes), nil
+}
+
+func decodeDownloadTaskListPageToken(
+	encodedPageToken string,
+	sortField go_load.DownloadTaskListSortField,
+	sortOrder go_load.SortOrder,
+) (*database.DownloadTaskListCursor, error) {
+	pageTokenBytes, err := base64.RawURLEncoding.DecodeString(encodedPageToken)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	pageToken := downloadTaskListPageToken{}
+	if err := json.Unmarshal(pageTokenBytes, &pageToken); err != nil {
+		return nil, errInvalidPageToken
+	}
+	if pageToken.SortField != sortField || pageToken.SortOrder != sortOrder {
+		return nil, status.Error(codes.InvalidArgument, "page token was created for a different sort")
+	}
+	cursor := &database.DownloadTaskListCursor{ID: pageToken.ID}
+	//nolint:exhaustive // Sort fields are validated before the list is queried
+	switch sortField {
+	case go_load.DownloadTaskListSortField_SortByCreateTime, go_load.DownloadTaskListSortField_SortByUpdateTime:
+		cursor.SortValue, err = time.Parse(time.RFC3339Nano, pageToken.SortValue)
+	case go_load.DownloadTaskListSortField_SortByFileSize:
+		cursor.SortValue, err = strconv.ParseUint(pageToken.SortValue, 10, 64)
+	case go_load.DownloadTaskListSortField_SortByDownloadStatus:
+		cursor.SortValue, err = strconv.ParseInt(pageToken.SortValue, 10, 32)
+	}
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	return cursor, nil
+}
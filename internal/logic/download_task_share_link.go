@@ -0,0 +1,356 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	shareLinkSigningKeyByteCount = 32
+	shareLinkTokenPartCount      = 3
+	shareLinkPathPrefix          = "/share/"
+)
+
+var (
+	errInvalidShareLinkToken      = status.Error(codes.PermissionDenied, "invalid share link token")
+	errShareLinkExpired           = status.Error(codes.PermissionDenied, "share link has expired")
+	errShareLinkNoLongerAvailable = status.Error(codes.PermissionDenied, "share link is revoked, expired or out of downloads")
+	errShareLinkExpiresInTooLong  = status.Error(codes.InvalidArgument, "share link expires_in_seconds exceeds the allowed maximum")
+	errMissingShareLinkSigningKey = errors.New(
+		"share link signing key is not configured, set generate_signing_key for local development")
+)
+
+type CreateDownloadTaskShareLinkParams struct {
+	Token            string
+	DownloadTaskID   uint64
+	ExpiresIn        time.Duration
+	MaxDownloadCount uint64
+}
+type CreateDownloadTaskShareLinkOutput struct {
+	DownloadTaskShareLink *go_load.DownloadTaskShareLink
+}
+type RevokeDownloadTaskShareLinkParams struct {
+	Token                   string
+	DownloadTaskShareLinkID uint64
+}
+type OpenDownloadTaskShareLinkParams struct {
+	ShareLinkToken string
+}
+type OpenDownloadTaskShareLinkOutput struct {
+	FileName     string
+	ContentType  string
+	Size         uint64
+	ModTime      time.Time
+	ETag         string
+	PresignedURL string
+}
+type CountDownloadTaskShareLinkDownloadParams struct {
+	ShareLinkToken string
+}
+type GetDownloadTaskShareLinkFileParams struct {
+	ShareLinkToken string
+	Offset         uint64
+}
+
+type DownloadTaskShareLink interface {
+	CreateDownloadTaskShareLink(context.Context, CreateDownloadTaskShareLinkParams) (CreateDownloadTaskShareLinkOutput, error)
+	RevokeDownloadTaskShareLink(context.Context, RevokeDownloadTaskShareLinkParams) error
+	// OpenDownloadTaskShareLink does not count a download against the max download count of the share link, the
+	// caller decides which responses count with CountDownloadTaskShareLinkDownload.
+	OpenDownloadTaskShareLink(context.Context, OpenDownloadTaskShareLinkParams) (OpenDownloadTaskShareLinkOutput, error)
+	// CountDownloadTaskShareLinkDownload fails once the share link is out of downloads.
+	CountDownloadTaskShareLinkDownload(context.Context, CountDownloadTaskShareLinkDownloadParams) error
+	GetDownloadTaskShareLinkFile(context.Context, GetDownloadTaskShareLinkFileParams) (io.ReadCloser, error)
+}
+type downloadTaskShareLink struct {
+	tokenLogic                        Token
+	authorizationLogic                Authorization
+	downloadTaskDataAccessor          database.DownloadTaskDataAccessor
+	downloadTaskShareLinkDataAccessor database.DownloadTaskShareLinkDataAccessor
+	fileClient                        file.Client
+	encryptedFileClient               file.EncryptedClient
+	shareLinkConfig                   configs.ShareLink
+	signingKey                        []byte
+	defaultExpiresIn                  time.Duration
+	maxExpiresIn                      time.Duration
+	presignedURLExpiresIn             time.Duration
+	logger                            *zap.Logger
+}
+
+func NewDownloadTaskShareLink(
+	tokenLogic Token,
+	authorizationLogic Authorization,
+	downloadTaskDataAccessor database.DownloadTaskDataAccessor,
+	downloadTaskShareLinkDataAccessor database.DownloadTaskShareLinkDataAccessor,
+	fileClient file.Client,
+	encryptedFileClient file.EncryptedClient,
+	shareLinkConfig configs.ShareLink,
+	logger *zap.Logger,
+) (DownloadTaskShareLink, error) {
+	defaultExpiresIn, err := shareLinkConfig.GetDefaultExpiresInDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse default_expires_in")
+		return nil, err
+	}
+	maxExpiresIn, err := shareLinkConfig.GetMaxExpiresInDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_expires_in")
+		return nil, err
+	}
+	presignedURLExpiresIn, err := shareLinkConfig.GetPresignedURLExpiresInDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse presigned_url_expires_in")
+		return nil, err
+	}
+	signingKey := []byte(shareLinkConfig.SigningKey)
+	if len(signingKey) == 0 {
+		if !shareLinkConfig.GenerateSigningKey {
+			logger.With(zap.Error(errMissingShareLinkSigningKey)).Error("failed to get signing_key")
+			return nil, errMissingShareLinkSigningKey
+		}
+		logger.Warn("share link signing key is not configured, generating a random one, share links will not be valid across restarts or replicas")
+		signingKey = make([]byte, shareLinkSigningKeyByteCount)
+		if _, err := rand.Read(signingKey); err != nil {
+			logger.With(zap.Error(err)).Error("failed to generate share link signing key")
+			return nil, err
+		}
+	}
+	return &downloadTaskShareLink{
+		tokenLogic:                        tokenLogic,
+		authorizationLogic:                authorizationLogic,
+		downloadTaskDataAccessor:          downloadTaskDataAccessor,
+		downloadTaskShareLinkDataAccessor: downloadTaskShareLinkDataAccessor,
+		fileClient:                        fileClient,
+		encryptedFileClient:               encryptedFileClient,
+		shareLinkConfig:                   shareLinkConfig,
+		signingKey:                        signingKey,
+		defaultExpiresIn:                  defaultExpiresIn,
+		maxExpiresIn:                      maxExpiresIn,
+		presignedURLExpiresIn:             presignedURLExpiresIn,
+		logger:                            logger,
+	}, nil
+}
+
+func (d downloadTaskShareLink) sign(payload string) string {
+	mac := hmac.New(sha256.New, d.signingKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// getShareLinkToken returns a token in the format of <share link id>.<expire time unix>.<signature>.
+func (d downloadTaskShareLink) getShareLinkToken(shareLink database.DownloadTaskShareLink) string {
+	payload := fmt.Sprintf("%d.%d", shareLink.ID, shareLink.ExpireTime.Unix())
+	return payload + "." + d.sign(payload)
+}
+
+func (d downloadTaskShareLink) parseShareLinkToken(shareLinkToken string) (uint64, error) {
+	parts := strings.Split(shareLinkToken, ".")
+	if len(parts) != shareLinkTokenPartCount {
+		return 0, errInvalidShareLinkToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(d.sign(payload)), []byte(parts[2])) {
+		return 0, errInvalidShareLinkToken
+	}
+	shareLinkID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, errInvalidShareLinkToken
+	}
+	expireTimeUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, errInvalidShareLinkToken
+	}
+	if time.Now().After(time.Unix(expireTimeUnix, 0)) {
+		return 0, errShareLinkExpired
+	}
+	return shareLinkID, nil
+}
+
+func (d downloadTaskShareLink) databaseShareLinkToProtoShareLink(shareLink database.DownloadTaskShareLink) *go_load.DownloadTaskShareLink {
+	return &go_load.DownloadTaskShareLink{
+		Id:               shareLink.ID,
+		OfDownloadTaskId: shareLink.OfDownloadTaskID,
+		Url:              strings.TrimSuffix(d.shareLinkConfig.BaseURL, "/") + shareLinkPathPrefix + d.getShareLinkToken(shareLink),
+		ExpireTime:       timestamppb.New(shareLink.ExpireTime),
+		MaxDownloadCount: shareLink.MaxDownloadCount,
+	}
+}
+
+func (d downloadTaskShareLink) getOwnedDownloadTask(ctx context.Context, token string, downloadTaskID uint64) (database.DownloadTask, error) {
+	accountID, _, err := d.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	if err != nil {
+		return database.DownloadTask{}, err
+	}
+	downloadTask, err := d.downloadTaskDataAccessor.GetDownloadTask(ctx, downloadTaskID)
+	if err != nil {
+		return database.DownloadTask{}, err
+	}
+	if err = d.authorizationLogic.AuthorizeDownloadTask(ctx, accountID, downloadTask, PermissionManageDownloadTask); err != nil {
+		return database.DownloadTask{}, err
+	}
+	return downloadTask, nil
+}
+
+func (d downloadTaskShareLink) CreateDownloadTaskShareLink(
+	ctx context.Context,
+	params CreateDownloadTaskShareLinkParams,
+) (CreateDownloadTaskShareLinkOutput, error) {
+	downloadTask, err := d.getOwnedDownloadTask(ctx, params.Token, params.DownloadTaskID)
+	if err != nil {
+		return CreateDownloadTaskShareLinkOutput{}, err
+	}
+	if _, err := getDownloadTaskFileName(downloadTask); err != nil {
+		return CreateDownloadTaskShareLinkOutput{}, err
+	}
+	expiresIn := params.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = d.defaultExpiresIn
+	}
+	if expiresIn > d.maxExpiresIn {
+		return CreateDownloadTaskShareLinkOutput{}, errShareLinkExpiresInTooLong
+	}
+	shareLink := database.DownloadTaskShareLink{
+		OfDownloadTaskID: downloadTask.ID,
+		ExpireTime:       time.Now().Add(expiresIn).Truncate(time.Second),
+		MaxDownloadCount: params.MaxDownloadCount,
+	}
+	shareLink.ID, err = d.downloadTaskShareLinkDataAccessor.CreateDownloadTaskShareLink(ctx, shareLink)
+	if err != nil {
+		return CreateDownloadTaskShareLinkOutput{}, err
+	}
+	return CreateDownloadTaskShareLinkOutput{
+		DownloadTaskShareLink: d.databaseShareLinkToProtoShareLink(shareLink),
+	}, nil
+}
+func (d downloadTaskShareLink) RevokeDownloadTaskShareLink(ctx context.Context, params RevokeDownloadTaskShareLinkParams) error {
+	shareLink, err := d.downloadTaskShareLinkDataAccessor.GetDownloadTaskShareLink(ctx, params.DownloadTaskShareLinkID)
+	if err != nil {
+		return err
+	}
+	if _, err := d.getOwnedDownloadTask(ctx, params.Token, shareLink.OfDownloadTaskID); err != nil {
+		return err
+	}
+	return d.downloadTaskShareLinkDataAccessor.RevokeDownloadTaskShareLink(ctx, shareLink.ID)
+}
+
+func (d downloadTaskShareLink) getShareLinkDownloadTaskWithFile(
+	ctx context.Context,
+	shareLinkToken string,
+) (database.DownloadTaskShareLink, database.DownloadTask, string, error) {
+	shareLinkID, err := d.parseShareLinkToken(shareLinkToken)
+	if err != nil {
+		return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", err
+	}
+	shareLink, err := d.downloadTaskShareLinkDataAccessor.GetDownloadTaskShareLink(ctx, shareLinkID)
+	if err != nil {
+		if errors.Is(err, database.ErrDownloadTaskShareLinkNotFound) {
+			return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", errInvalidShareLinkToken
+		}
+		return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", err
+	}
+	if shareLink.Revoked || time.Now().After(shareLink.ExpireTime) {
+		return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", errShareLinkNoLongerAvailable
+	}
+	downloadTask, err := d.downloadTaskDataAccessor.GetDownloadTask(ctx, shareLink.OfDownloadTaskID)
+	if err != nil {
+		return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", err
+	}
+	fileName, err := getDownloadTaskFileName(downloadTask)
+	if err != nil {
+		return database.DownloadTaskShareLink{}, database.DownloadTask{}, "", err
+	}
+	return shareLink, downloadTask, fileName, nil
+}
+
+func (d downloadTaskShareLink) OpenDownloadTaskShareLink(
+	ctx context.Context,
+	params OpenDownloadTaskShareLinkParams,
+) (OpenDownloadTaskShareLinkOutput, error) {
+	logger := utils.LoggerWithContext(ctx, d.logger)
+
+	shareLink, downloadTask, fileName, err := d.getShareLinkDownloadTaskWithFile(ctx, params.ShareLinkToken)
+	if err != nil {
+		return OpenDownloadTaskShareLinkOutput{}, err
+	}
+	output := OpenDownloadTaskShareLinkOutput{
+		FileName:    getDownloadTaskDisplayFileName(downloadTask, fileName),
+		ContentType: getDownloadTaskContentType(downloadTask),
+	}
+	fileEncryptionMetadata, err := getDownloadTaskFileEncryptionMetadata(downloadTask, fileName)
+	if err != nil {
+		return OpenDownloadTaskShareLinkOutput{}, err
+	}
+	// Encrypted files can only be decrypted on the way through the server. A presigned url can be downloaded from
+	// any number of times, so share links with a max download count are always streamed, and the url must not
+	// outlive the share link.
+	presignedURLExpiresIn := min(d.presignedURLExpiresIn, time.Until(shareLink.ExpireTime))
+	if d.shareLinkConfig.RedirectToPresignedURL && fileEncryptionMetadata == nil &&
+		shareLink.MaxDownloadCount == 0 && presignedURLExpiresIn >= time.Second {
+		presignedURL, presignErr := d.fileClient.GetPresignedURL(ctx, fileName, presignedURLExpiresIn)
+		if presignErr == nil {
+			output.PresignedURL = presignedURL
+			return output, nil
+		}
+		if !errors.Is(presignErr, file.ErrPresignedURLNotSupported) {
+			logger.With(zap.Error(presignErr)).Warn("failed to get presigned url, will fall back to streaming the file")
+		}
+	}
+	fileInfo, err := d.encryptedFileClient.Stat(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		return OpenDownloadTaskShareLinkOutput{}, err
+	}
+	output.Size = uint64(fileInfo.Size)
+	output.ModTime = fileInfo.ModTime
+	output.ETag = fileInfo.ETag
+	return output, nil
+}
+func (d downloadTaskShareLink) CountDownloadTaskShareLinkDownload(
+	ctx context.Context,
+	params CountDownloadTaskShareLinkDownloadParams,
+) error {
+	shareLink, _, _, err := d.getShareLinkDownloadTaskWithFile(ctx, params.ShareLinkToken)
+	if err != nil {
+		return err
+	}
+	increased, err := d.downloadTaskShareLinkDataAccessor.IncreaseDownloadTaskShareLinkDownloadCount(ctx, shareLink.ID)
+	if err != nil {
+		return err
+	}
+	if !increased {
+		return errShareLinkNoLongerAvailable
+	}
+	return nil
+}
+func (d downloadTaskShareLink) GetDownloadTaskShareLinkFile(
+	ctx context.Context,
+	params GetDownloadTaskShareLinkFileParams,
+) (io.ReadCloser, error) {
+	_, downloadTask, fileName, err := d.getShareLinkDownloadTaskWithFile(ctx, params.ShareLinkToken)
+	if err != nil {
+		return nil, err
+	}
+	fileEncryptionMetadata, err := getDownloadTaskFileEncryptionMetadata(downloadTask, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return d.encryptedFileClient.ReadRange(ctx, fileName, fileEncryptionMetadata, params.Offset, 0)
+}
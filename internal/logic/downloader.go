@@ -1,8 +1,11 @@
 package logic
 
 import (
+	"GoLoad/internal/generated/grpc/go_load"
 	"GoLoad/internal/utils"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
@@ -14,34 +17,68 @@ const (
 	HTTPMetadataKeyContentType    = "content-type"
 )
 
+var errHTTPDownloadStatusCode = errors.New("unexpected http response status code")
+
 type Downloader interface {
 	Download(ctx context.Context, writer io.Writer) (map[string]any, error)
 }
+
+// byteCountingWriter counts the bytes written through it. Downloads write sequentially, so it is not synchronized.
+type byteCountingWriter struct {
+	writer    io.Writer
+	byteCount uint64
+}
+
+func (b *byteCountingWriter) Write(p []byte) (int, error) {
+	n, err := b.writer.Write(p)
+	b.byteCount += uint64(n)
+	return n, err
+}
+
 type HTTPDownloader struct {
-	url    string
-	logger *zap.Logger
+	url                string
+	httpRequestOptions *go_load.HTTPRequestOptions
+	httpClient         *http.Client
+	logger             *zap.Logger
 }
 
-func NewHTTPDownloader(url string, logger *zap.Logger) Downloader {
+func NewHTTPDownloader(
+	url string,
+	httpRequestOptions *go_load.HTTPRequestOptions,
+	httpClient *http.Client,
+	logger *zap.Logger,
+) Downloader {
 	return &HTTPDownloader{
-		url:    url,
-		logger: logger,
+		url:                url,
+		httpRequestOptions: httpRequestOptions,
+		httpClient:         httpClient,
+		logger:             logger,
 	}
 }
 func (h HTTPDownloader) Download(ctx context.Context, writer io.Writer) (map[string]any, error) {
 	logger := utils.LoggerWithContext(ctx, h.logger)
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, http.NoBody)
+	ctx, err := contextWithHTTPRequestOptionsProxy(ctx, h.httpRequestOptions)
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to create http get request")
+		logger.With(zap.Error(err)).Error("failed to parse proxy url")
 		return nil, err
 	}
-	response, err := http.DefaultClient.Do(request)
+	request, err := newHTTPRequestWithOptions(ctx, h.url, h.httpRequestOptions)
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to make http get request")
+		logger.With(zap.Error(err)).Error("failed to create http request")
+		return nil, err
+	}
+	response, err := h.httpClient.Do(request)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to make http request")
 		return nil, err
 	}
 	defer response.Body.Close()
+	// The error page of a failed request, e.g. because of wrong credentials, is not the file.
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		logger.With(zap.Int("status_code", response.StatusCode)).Error("unexpected http response status code")
+		return nil, fmt.Errorf("%w: %d", errHTTPDownloadStatusCode, response.StatusCode)
+	}
 	_, err = io.Copy(writer, response.Body)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to read response and write to writer")
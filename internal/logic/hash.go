@@ -2,41 +2,193 @@ package logic
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 
 	"GoLoad/internal/configs"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+var (
+	errUnsupportedHashAlgorithm = errors.New("unsupported hash algorithm")
+	errInvalidHash              = errors.New("invalid hash")
+)
+
+// Hash hashes passwords into strings that name their algorithm and parameters, so that hashes made with older
+// settings can still be checked.
 type Hash interface {
 	Hash(ctx context.Context, data string) (string, error)
 	IsHashEqual(ctx context.Context, data string, hashed string) (bool, error)
+	// IsHashOutdated tells whether the hash was not made with the configured algorithm and parameters, in which case
+	// the data should be hashed again the next time it is known.
+	IsHashOutdated(ctx context.Context, hashed string) bool
+}
+
+// hasher is a hashing algorithm. Each one recognizes its own hashes by their prefix.
+type hasher interface {
+	isHashOfAlgorithm(hashed string) bool
+	hash(data string) (string, error)
+	isHashEqual(data string, hashed string) (bool, error)
+	isHashOutdated(hashed string) bool
 }
 type hash struct {
-	authConfig configs.Auth
+	// hasherList starts with the hasher of new hashes.
+	hasherList []hasher
 }
 
-func NewHash(authConfig configs.Auth) Hash {
-	return &hash{
-		authConfig: authConfig,
+func NewHash(authConfig configs.Auth) (Hash, error) {
+	bcryptHasher := bcryptHasher{cost: authConfig.Hash.Cost}
+	argon2idHasher := argon2idHasher{params: authConfig.Hash.Argon2id}
+	switch authConfig.Hash.Algorithm {
+	case configs.HashAlgorithmArgon2id:
+		return &hash{hasherList: []hasher{argon2idHasher, bcryptHasher}}, nil
+	case configs.HashAlgorithmBcrypt:
+		return &hash{hasherList: []hasher{bcryptHasher, argon2idHasher}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedHashAlgorithm, authConfig.Hash.Algorithm)
+	}
+}
+func (h hash) getHasherOfHash(hashed string) (hasher, bool) {
+	for _, hasher := range h.hasherList {
+		if hasher.isHashOfAlgorithm(hashed) {
+			return hasher, true
+		}
 	}
+	return nil, false
 }
 func (h hash) Hash(ctx context.Context, data string) (string, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(data), h.authConfig.Hash.Cost)
+	hashed, err := h.hasherList[0].hash(data)
 	if err != nil {
 		return "", status.Error(codes.Internal, "failed to hash data")
 	}
-	return string(hashed), nil
+	return hashed, nil
 }
 func (h hash) IsHashEqual(ctx context.Context, data string, hashed string) (bool, error) {
+	hasher, ok := h.getHasherOfHash(hashed)
+	if !ok {
+		return false, status.Error(codes.Internal, "failed to check if data equal hash")
+	}
+	isEqual, err := hasher.isHashEqual(data, hashed)
+	if err != nil {
+		return false, status.Error(codes.Internal, "failed to check if data equal hash")
+	}
+	return isEqual, nil
+}
+func (h hash) IsHashOutdated(ctx context.Context, hashed string) bool {
+	hasher, ok := h.getHasherOfHash(hashed)
+	if !ok {
+		return true
+	}
+	return hasher != h.hasherList[0] || hasher.isHashOutdated(hashed)
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func (b bcryptHasher) isHashOfAlgorithm(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+func (b bcryptHasher) hash(data string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(data), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+func (b bcryptHasher) isHashEqual(data string, hashed string) (bool, error) {
 	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(data)); err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return false, nil
 		}
-		return false, status.Error(codes.Internal, "failed to check if data equal hash")
+		return false, err
 	}
 	return true, nil
 }
+func (b bcryptHasher) isHashOutdated(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != b.cost
+}
+
+const argon2idHashPrefix = "$argon2id$"
+
+// argon2idHasher encodes its hashes in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<base64 salt>$<base64 key>.
+type argon2idHasher struct {
+	params configs.Argon2id
+}
+type argon2idHash struct {
+	version int
+	params  configs.Argon2id
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2idHash(hashed string) (argon2idHash, error) {
+	// The hash starts with a separator, so the first part is empty.
+	partList := strings.Split(hashed, "$")
+	if len(partList) != 6 || partList[1] != "argon2id" {
+		return argon2idHash{}, errInvalidHash
+	}
+	parsedHash := argon2idHash{}
+	if _, err := fmt.Sscanf(partList[2], "v=%d", &parsedHash.version); err != nil {
+		return argon2idHash{}, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(
+		partList[3], "m=%d,t=%d,p=%d",
+		&parsedHash.params.Memory, &parsedHash.params.Iterations, &parsedHash.params.Parallelism,
+	); err != nil {
+		return argon2idHash{}, errInvalidHash
+	}
+	var err error
+	if parsedHash.salt, err = base64.RawStdEncoding.DecodeString(partList[4]); err != nil {
+		return argon2idHash{}, errInvalidHash
+	}
+	if parsedHash.key, err = base64.RawStdEncoding.DecodeString(partList[5]); err != nil || len(parsedHash.key) == 0 {
+		return argon2idHash{}, errInvalidHash
+	}
+	parsedHash.params.SaltLength = uint32(len(parsedHash.salt))
+	parsedHash.params.KeyLength = uint32(len(parsedHash.key))
+	return parsedHash, nil
+}
+func (a argon2idHasher) isHashOfAlgorithm(hashed string) bool {
+	return strings.HasPrefix(hashed, argon2idHashPrefix)
+}
+func (a argon2idHasher) hash(data string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(data), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idHashPrefix, argon2.Version, a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+func (a argon2idHasher) isHashEqual(data string, hashed string) (bool, error) {
+	parsedHash, err := parseArgon2idHash(hashed)
+	if err != nil {
+		return false, err
+	}
+	if parsedHash.version != argon2.Version {
+		return false, errInvalidHash
+	}
+	key := argon2.IDKey(
+		[]byte(data), parsedHash.salt, parsedHash.params.Iterations, parsedHash.params.Memory,
+		parsedHash.params.Parallelism, parsedHash.params.KeyLength,
+	)
+	return subtle.ConstantTimeCompare(key, parsedHash.key) == 1, nil
+}
+func (a argon2idHasher) isHashOutdated(hashed string) bool {
+	parsedHash, err := parseArgon2idHash(hashed)
+	return err != nil || parsedHash.version != argon2.Version || parsedHash.params != a.params
+}
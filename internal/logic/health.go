@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/cache"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/dataaccess/mq/producer"
+	"GoLoad/internal/utils"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+
+	healthDependencyNameDatabase = "database"
+	healthDependencyNameCache    = "cache"
+	healthDependencyNameMQ       = "mq"
+	healthDependencyNameStorage  = "storage"
+	healthShuttingDownError      = "server is shutting down"
+)
+
+type HealthDependencyStatus struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+type CheckReadinessOutput struct {
+	Status       string                   `json:"status"`
+	Error        string                   `json:"error,omitempty"`
+	Dependencies []HealthDependencyStatus `json:"dependencies"`
+}
+
+func (c CheckReadinessOutput) IsReady() bool {
+	return c.Status == HealthStatusUp
+}
+
+type Health interface {
+	// CheckReadiness pings every dependency concurrently, each with its own timeout. The server is not ready if
+	// any of them is down or if it is shutting down.
+	CheckReadiness(ctx context.Context) CheckReadinessOutput
+	// StartShutdown makes the server report not ready from now on.
+	StartShutdown()
+	// ShutdownChannel is closed when StartShutdown is called.
+	ShutdownChannel() <-chan struct{}
+}
+type healthDependency struct {
+	name string
+	ping func(ctx context.Context) error
+}
+type health struct {
+	dependencyList  []healthDependency
+	isShuttingDown  atomic.Bool
+	shutdownOnce    sync.Once
+	shutdownChannel chan struct{}
+	healthConfig    configs.Health
+	logger          *zap.Logger
+}
+
+func NewHealth(
+	db *sql.DB,
+	cacheClient cache.Client,
+	producerClient producer.Client,
+	fileClient file.Client,
+	healthConfig configs.Health,
+	logger *zap.Logger,
+) Health {
+	return &health{
+		dependencyList: []healthDependency{
+			{name: healthDependencyNameDatabase, ping: db.PingContext},
+			{name: healthDependencyNameCache, ping: cacheClient.Ping},
+			{name: healthDependencyNameMQ, ping: producerClient.Ping},
+			{name: healthDependencyNameStorage, ping: fileClient.Ping},
+		},
+		shutdownChannel: make(chan struct{}),
+		healthConfig:    healthConfig,
+		logger:          logger,
+	}
+}
+func (h *health) checkDependency(
+	ctx context.Context,
+	dependency healthDependency,
+	checkTimeout time.Duration,
+) HealthDependencyStatus {
+	logger := utils.LoggerWithContext(ctx, h.logger).With(zap.String("dependency", dependency.name))
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	errChannel := make(chan error, 1)
+	// Not every client honors the context, so the ping runs in its own goroutine to enforce the timeout.
+	go func() {
+		errChannel <- dependency.ping(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errChannel:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	dependencyStatus := HealthDependencyStatus{
+		Name:       dependency.name,
+		Status:     HealthStatusUp,
+		DurationMS: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("dependency health check failed")
+		dependencyStatus.Status = HealthStatusDown
+		dependencyStatus.Error = err.Error()
+	}
+	return dependencyStatus
+}
+func (h *health) CheckReadiness(ctx context.Context) CheckReadinessOutput {
+	logger := utils.LoggerWithContext(ctx, h.logger)
+	output := CheckReadinessOutput{
+		Status:       HealthStatusUp,
+		Dependencies: make([]HealthDependencyStatus, len(h.dependencyList)),
+	}
+	checkTimeout, err := h.healthConfig.GetCheckTimeoutDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse health check timeout")
+		output.Status = HealthStatusDown
+		output.Error = err.Error()
+		return output
+	}
+	waitGroup := sync.WaitGroup{}
+	for i := range h.dependencyList {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			output.Dependencies[i] = h.checkDependency(ctx, h.dependencyList[i], checkTimeout)
+		}(i)
+	}
+	waitGroup.Wait()
+	for _, dependencyStatus := range output.Dependencies {
+		if dependencyStatus.Status != HealthStatusUp {
+			output.Status = HealthStatusDown
+		}
+	}
+	if h.isShuttingDown.Load() {
+		output.Status = HealthStatusDown
+		output.Error = healthShuttingDownError
+	}
+	return output
+}
+func (h *health) StartShutdown() {
+	h.shutdownOnce.Do(func() {
+		h.isShuttingDown.Store(true)
+		close(h.shutdownChannel)
+	})
+}
+func (h *health) ShutdownChannel() <-chan struct{} {
+	return h.shutdownChannel
+}
@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	httpRequestOptionsEncryptionKeyByteCount = 32
+)
+
+var (
+	errInvalidHTTPRequestOptionsEncryptionKey = fmt.Errorf(
+		"http request options encryption key must be %d base64 encoded bytes", httpRequestOptionsEncryptionKeyByteCount)
+	errInvalidEncryptedHTTPRequestOptions     = errors.New("invalid encrypted http request options")
+	errMissingHTTPRequestOptionsEncryptionKey = errors.New(
+		"http request options encryption key is not configured, set generate_http_request_options_encryption_key for local development")
+)
+
+// forbiddenHTTPRequestHeaderSet are the headers that are managed by the HTTP client or the downloaders, and cannot be
+// set by a download task.
+var forbiddenHTTPRequestHeaderSet = map[string]bool{
+	"Host":                 true,
+	"Content-Length":       true,
+	"Transfer-Encoding":    true,
+	"Connection":           true,
+	"Keep-Alive":           true,
+	"Upgrade":              true,
+	"Te":                   true,
+	"Trailer":              true,
+	"Proxy-Connection":     true,
+	"Proxy-Authorization":  true,
+	HTTPRequestHeaderRange: true,
+}
+
+// HTTPRequestOptions checks the http request options of download tasks, and encrypts them to be stored along with the
+// download task, since they may carry credentials.
+type HTTPRequestOptions interface {
+	// Validate returns InvalidArgument for options that cannot be sent. Nil options are valid. The limits declared in
+	// go_load.proto are not checked again.
+	Validate(ctx context.Context, options *go_load.HTTPRequestOptions) error
+	// Encrypt returns nil for nil options.
+	Encrypt(ctx context.Context, options *go_load.HTTPRequestOptions) ([]byte, error)
+	// Decrypt returns nil if encryptedOptions is empty.
+	Decrypt(ctx context.Context, encryptedOptions []byte) (*go_load.HTTPRequestOptions, error)
+}
+type httpRequestOptions struct {
+	urlPolicy     URLPolicy
+	encryptionKey []byte
+	logger        *zap.Logger
+}
+
+func NewHTTPRequestOptions(urlPolicy URLPolicy, downloadConfig configs.Download, logger *zap.Logger) (HTTPRequestOptions, error) {
+	encryptionKey, err := parseHTTPRequestOptionsEncryptionKey(downloadConfig.HTTPRequestOptionsEncryptionKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse http request options encryption key")
+		return nil, err
+	}
+	if encryptionKey == nil {
+		if !downloadConfig.GenerateHTTPRequestOptionsEncryptionKey {
+			logger.With(zap.Error(errMissingHTTPRequestOptionsEncryptionKey)).Error("failed to get http_request_options_encryption_key")
+			return nil, errMissingHTTPRequestOptionsEncryptionKey
+		}
+		logger.Warn("http request options encryption key is not configured, generating a random one, download tasks with http request options will not be executed across restarts or replicas")
+		encryptionKey = make([]byte, httpRequestOptionsEncryptionKeyByteCount)
+		if _, err := rand.Read(encryptionKey); err != nil {
+			logger.With(zap.Error(err)).Error("failed to generate http request options encryption key")
+			return nil, err
+		}
+	}
+	return &httpRequestOptions{
+		urlPolicy:     urlPolicy,
+		encryptionKey: encryptionKey,
+		logger:        logger,
+	}, nil
+}
+
+// parseHTTPRequestOptionsEncryptionKey returns nil if no encryption key is configured.
+func parseHTTPRequestOptionsEncryptionKey(encodedEncryptionKey string) ([]byte, error) {
+	if encodedEncryptionKey == "" {
+		return nil, nil
+	}
+	encryptionKey, err := base64.StdEncoding.DecodeString(encodedEncryptionKey)
+	if err != nil || len(encryptionKey) != httpRequestOptionsEncryptionKeyByteCount {
+		return nil, errInvalidHTTPRequestOptionsEncryptionKey
+	}
+	return encryptionKey, nil
+}
+func (h httpRequestOptions) newAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(h.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func validateHTTPHeaderValue(name string, value string) error {
+	if !httpguts.ValidHeaderFieldValue(value) {
+		return status.Errorf(codes.InvalidArgument, "invalid value of http header %s", name)
+	}
+	return nil
+}
+func (h httpRequestOptions) Validate(ctx context.Context, options *go_load.HTTPRequestOptions) error {
+	if options == nil {
+		return nil
+	}
+	switch options.GetMethod() {
+	case "", http.MethodGet:
+		if len(options.GetBody()) > 0 {
+			return status.Error(codes.InvalidArgument, "http request body is only sent with POST")
+		}
+	case http.MethodPost:
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported http request method %s", options.GetMethod())
+	}
+	for _, header := range options.GetHeaders() {
+		if !httpguts.ValidHeaderFieldName(header.GetName()) {
+			return status.Errorf(codes.InvalidArgument, "invalid http header name %q", header.GetName())
+		}
+		if forbiddenHTTPRequestHeaderSet[http.CanonicalHeaderKey(header.GetName())] {
+			return status.Errorf(codes.InvalidArgument, "http header %s cannot be set", header.GetName())
+		}
+		if err := validateHTTPHeaderValue(header.GetName(), header.GetValue()); err != nil {
+			return err
+		}
+	}
+	for _, cookie := range options.GetCookies() {
+		if err := (&http.Cookie{Name: cookie.GetName(), Value: cookie.GetValue()}).Valid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid http cookie %q", cookie.GetName())
+		}
+	}
+	if err := validateHTTPHeaderValue("User-Agent", options.GetUserAgent()); err != nil {
+		return err
+	}
+	if options.GetBasicAuth() != nil && options.GetBearerToken() != "" {
+		return status.Error(codes.InvalidArgument, "basic auth and bearer token cannot be used together")
+	}
+	if basicAuth := options.GetBasicAuth(); basicAuth != nil &&
+		(basicAuth.GetUsername() == "" || strings.Contains(basicAuth.GetUsername(), ":")) {
+		return status.Error(codes.InvalidArgument, "invalid basic auth username")
+	}
+	if err := validateHTTPHeaderValue("Authorization", options.GetBearerToken()); err != nil {
+		return err
+	}
+	if options.GetProxyUrl() != "" {
+		if err := h.urlPolicy.ValidateProxyURL(ctx, options.GetProxyUrl()); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid proxy url: %s", status.Convert(err).Message())
+		}
+	}
+	return nil
+}
+
+// Encrypt seals the protobuf encoding of the options with AES-GCM, prefixed by its nonce.
+func (h httpRequestOptions) Encrypt(ctx context.Context, options *go_load.HTTPRequestOptions) ([]byte, error) {
+	logger := utils.LoggerWithContext(ctx, h.logger)
+
+	if options == nil {
+		return nil, nil
+	}
+	optionsBytes, err := proto.Marshal(options)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to marshal http request options")
+		return nil, status.Error(codes.Internal, "failed to encrypt http request options")
+	}
+	aead, err := h.newAEAD()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to create http request options cipher")
+		return nil, status.Error(codes.Internal, "failed to encrypt http request options")
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate nonce")
+		return nil, status.Error(codes.Internal, "failed to encrypt http request options")
+	}
+	return aead.Seal(nonce, nonce, optionsBytes, nil), nil
+}
+func (h httpRequestOptions) Decrypt(_ context.Context, encryptedOptions []byte) (*go_load.HTTPRequestOptions, error) {
+	if len(encryptedOptions) == 0 {
+		return nil, nil
+	}
+	aead, err := h.newAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(encryptedOptions) < aead.NonceSize() {
+		return nil, errInvalidEncryptedHTTPRequestOptions
+	}
+	nonce, sealedOptions := encryptedOptions[:aead.NonceSize()], encryptedOptions[aead.NonceSize():]
+	optionsBytes, err := aead.Open(nil, nonce, sealedOptions, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidEncryptedHTTPRequestOptions, err)
+	}
+	options := new(go_load.HTTPRequestOptions)
+	if err := proto.Unmarshal(optionsBytes, options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// redactHTTPRequestOptions keeps the parts of the options that can be shown back, see HTTPRequestOptions in
+// go_load.proto.
+func redactHTTPRequestOptions(options *go_load.HTTPRequestOptions) *go_load.HTTPRequestOptions {
+	if options == nil {
+		return nil
+	}
+	redactedOptions := &go_load.HTTPRequestOptions{
+		UserAgent: options.GetUserAgent(),
+		Method:    options.GetMethod(),
+	}
+	for _, header := range options.GetHeaders() {
+		redactedOptions.Headers = append(redactedOptions.Headers, &go_load.HTTPHeader{Name: header.GetName()})
+	}
+	for _, cookie := range options.GetCookies() {
+		redactedOptions.Cookies = append(redactedOptions.Cookies, &go_load.HTTPCookie{Name: cookie.GetName()})
+	}
+	if basicAuth := options.GetBasicAuth(); basicAuth != nil {
+		redactedOptions.BasicAuth = &go_load.HTTPBasicAuth{Username: basicAuth.GetUsername()}
+	}
+	if proxyURL, err := url.Parse(options.GetProxyUrl()); err == nil && options.GetProxyUrl() != "" {
+		if proxyURL.User != nil {
+			proxyURL.User = url.User(proxyURL.User.Username())
+		}
+		redactedOptions.ProxyUrl = proxyURL.String()
+	}
+	return redactedOptions
+}
+
+// contextWithHTTPRequestOptionsProxy makes the requests made with the context go through the proxy of the options,
+// if any.
+func contextWithHTTPRequestOptionsProxy(ctx context.Context, options *go_load.HTTPRequestOptions) (context.Context, error) {
+	if options.GetProxyUrl() == "" {
+		return ctx, nil
+	}
+	proxyURL, err := url.Parse(options.GetProxyUrl())
+	if err != nil {
+		return nil, err
+	}
+	return contextWithProxyURL(ctx, proxyURL), nil
+}
+
+// setHTTPRequestOptionsHeaders sets the headers, user agent, credentials and cookies of the options on the request.
+func setHTTPRequestOptionsHeaders(request *http.Request, options *go_load.HTTPRequestOptions) {
+	for _, header := range options.GetHeaders() {
+		request.Header.Add(header.GetName(), header.GetValue())
+	}
+	if options.GetUserAgent() != "" {
+		request.Header.Set("User-Agent", options.GetUserAgent())
+	}
+	if basicAuth := options.GetBasicAuth(); basicAuth != nil {
+		request.SetBasicAuth(basicAuth.GetUsername(), basicAuth.GetPassword())
+	}
+	if options.GetBearerToken() != "" {
+		request.Header.Set("Authorization", "Bearer "+options.GetBearerToken())
+	}
+	for _, cookie := range options.GetCookies() {
+		request.AddCookie(&http.Cookie{Name: cookie.GetName(), Value: cookie.GetValue()})
+	}
+}
+
+// newHTTPRequestWithOptions creates the request for the url of a download task, with the method, body and headers of
+// the options.
+func newHTTPRequestWithOptions(ctx context.Context, rawURL string, options *go_load.HTTPRequestOptions) (*http.Request, error) {
+	method := http.MethodGet
+	if options.GetMethod() != "" {
+		method = options.GetMethod()
+	}
+	var body io.Reader = http.NoBody
+	if len(options.GetBody()) > 0 {
+		body = bytes.NewReader(options.GetBody())
+	}
+	request, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	setHTTPRequestOptionsHeaders(request, options)
+	return request, nil
+}
@@ -0,0 +1,228 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/utils"
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	downloadTaskMetadataFieldNameMalwareScanResult = "malware-scan-result"
+
+	malwareScannerNameClamd = "clamd"
+
+	clamdCommandInstream    = "zINSTREAM\x00"
+	clamdChunkLengthSize    = 4
+	clamdReplyStreamPrefix  = "stream: "
+	clamdReplyOK            = "OK"
+	clamdReplyFoundSuffix   = " FOUND"
+	clamdReplyErrorSuffix   = " ERROR"
+	maxClamdReplyByteCount  = 4096
+	maxClamdStreamChunkSize = 16 * 1024 * 1024
+)
+
+var (
+	errDownloadTaskQuarantined     = status.Error(codes.FailedPrecondition, "download task file is quarantined")
+	errMalwareScanFailed           = errors.New("malware scan failed")
+	errUnexpectedClamdReply        = errors.New("unexpected clamd reply")
+	errReadFileToScan              = errors.New("failed to read file to scan")
+	errInvalidClamdStreamChunkSize = fmt.Errorf("stream_chunk_size must be 1 to %d bytes", maxClamdStreamChunkSize)
+)
+
+type MalwareScanResult struct {
+	Scanner   string `json:"scanner"`
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type MalwareScanner interface {
+	// Scan scans a stored file, decrypting it with its encryption metadata if it is encrypted. It returns an error if
+	// no verdict could be reached, and the zero MalwareScanResult if the file was not scanned at all.
+	Scan(ctx context.Context, fileName string, fileEncryptionMetadata *file.EncryptionMetadata) (MalwareScanResult, error)
+}
+
+func NewMalwareScanner(
+	fileClient file.EncryptedClient,
+	downloadConfig configs.Download,
+	logger *zap.Logger,
+) (MalwareScanner, error) {
+	switch downloadConfig.MalwareScan.Scanner {
+	case "", configs.MalwareScannerTypeNoop:
+		return NewNoopMalwareScanner(), nil
+	case configs.MalwareScannerTypeClamd:
+		return NewClamdMalwareScanner(fileClient, downloadConfig, logger)
+	default:
+		return nil, fmt.Errorf("unsupported malware scanner: %s", downloadConfig.MalwareScan.Scanner)
+	}
+}
+
+type noopMalwareScanner struct{}
+
+func NewNoopMalwareScanner() MalwareScanner {
+	return &noopMalwareScanner{}
+}
+func (noopMalwareScanner) Scan(context.Context, string, *file.EncryptionMetadata) (MalwareScanResult, error) {
+	return MalwareScanResult{}, nil
+}
+
+// clamdMalwareScanner streams files to clamd with the INSTREAM command, as chunks prefixed by their length in
+// network byte order and terminated by an empty chunk.
+type clamdMalwareScanner struct {
+	fileClient      file.EncryptedClient
+	dialer          *net.Dialer
+	network         string
+	address         string
+	timeout         time.Duration
+	streamChunkSize uint64
+	logger          *zap.Logger
+}
+
+func NewClamdMalwareScanner(
+	fileClient file.EncryptedClient,
+	downloadConfig configs.Download,
+	logger *zap.Logger,
+) (MalwareScanner, error) {
+	malwareScanConfig := downloadConfig.MalwareScan
+	timeout, err := malwareScanConfig.GetTimeoutDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse malware scan timeout")
+		return nil, err
+	}
+	streamChunkSize, err := malwareScanConfig.GetStreamChunkSizeInBytes()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse stream_chunk_size")
+		return nil, err
+	}
+	if streamChunkSize == 0 || streamChunkSize > maxClamdStreamChunkSize {
+		return nil, errInvalidClamdStreamChunkSize
+	}
+	return &clamdMalwareScanner{
+		fileClient:      fileClient,
+		dialer:          &net.Dialer{},
+		network:         malwareScanConfig.Network,
+		address:         malwareScanConfig.Address,
+		timeout:         timeout,
+		streamChunkSize: streamChunkSize,
+		logger:          logger,
+	}, nil
+}
+
+// parseClamdReply reads the verdict from replies like "stream: OK", "stream: Eicar-Signature FOUND" or
+// "INSTREAM size limit exceeded. ERROR".
+func parseClamdReply(reply string) (MalwareScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimSuffix(reply, "\x00"))
+	verdict := strings.TrimPrefix(reply, clamdReplyStreamPrefix)
+	switch {
+	case verdict == clamdReplyOK:
+		return MalwareScanResult{Scanner: malwareScannerNameClamd}, nil
+	case strings.HasSuffix(verdict, clamdReplyFoundSuffix):
+		return MalwareScanResult{
+			Scanner:   malwareScannerNameClamd,
+			Infected:  true,
+			Signature: strings.TrimSuffix(verdict, clamdReplyFoundSuffix),
+		}, nil
+	case strings.HasSuffix(verdict, clamdReplyErrorSuffix):
+		return MalwareScanResult{}, fmt.Errorf("%w: %s", errMalwareScanFailed, verdict)
+	default:
+		return MalwareScanResult{}, fmt.Errorf("%w: %q", errUnexpectedClamdReply, reply)
+	}
+}
+func readClamdReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(io.LimitReader(conn, maxClamdReplyByteCount)).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+	return reply, nil
+}
+func (c clamdMalwareScanner) streamFile(conn net.Conn, reader io.Reader) error {
+	if _, err := io.WriteString(conn, clamdCommandInstream); err != nil {
+		return err
+	}
+	chunk := make([]byte, clamdChunkLengthSize+c.streamChunkSize)
+	for {
+		n, err := io.ReadFull(reader, chunk[clamdChunkLengthSize:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk[:clamdChunkLengthSize], uint32(n))
+			if _, writeErr := conn.Write(chunk[:clamdChunkLengthSize+n]); writeErr != nil {
+				return writeErr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", errReadFileToScan, err)
+		}
+	}
+	_, err := conn.Write(make([]byte, clamdChunkLengthSize))
+	return err
+}
+func (c clamdMalwareScanner) Scan(
+	ctx context.Context,
+	fileName string,
+	fileEncryptionMetadata *file.EncryptionMetadata,
+) (MalwareScanResult, error) {
+	logger := utils.LoggerWithContext(ctx, c.logger).With(zap.String("file_name", fileName))
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	reader, err := c.fileClient.Read(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to read file to scan")
+		return MalwareScanResult{}, err
+	}
+	defer reader.Close()
+	conn, err := c.dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to connect to clamd")
+		return MalwareScanResult{}, err
+	}
+	defer conn.Close()
+	// Unblocks the reads and writes of the connection once the context is done.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if streamErr := c.streamFile(conn, reader); streamErr != nil {
+		err = streamErr
+		// clamd replies before closing the connection when it rejects the stream, e.g. once it exceeds
+		// StreamMaxLength, which explains the failure better than the broken write.
+		if !errors.Is(streamErr, errReadFileToScan) {
+			if reply, replyErr := readClamdReply(conn); replyErr == nil {
+				if _, replyErr = parseClamdReply(reply); replyErr != nil {
+					err = replyErr
+				}
+			}
+		}
+		logger.With(zap.Error(err)).Error("failed to stream file to clamd")
+		return MalwareScanResult{}, err
+	}
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to read clamd reply")
+		return MalwareScanResult{}, err
+	}
+	result, err := parseClamdReply(reply)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("clamd failed to scan file")
+		return MalwareScanResult{}, err
+	}
+	return result, nil
+}
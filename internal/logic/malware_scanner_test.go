@@ -0,0 +1,333 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/doug-martin/goqu/v9"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+const (
+	testDownloadTaskID       uint64 = 100
+	testDownloadTaskFileName        = "download_file_100"
+	testClamdStreamChunkSize        = "4B"
+)
+
+var testDownloadedFileContent = []byte("content of the downloaded file")
+
+// fakeClamd replies to every INSTREAM command with reply, once it has received the whole stream.
+type fakeClamd struct {
+	listener net.Listener
+	reply    string
+	// streamChannel receives the content of each stream.
+	streamChannel chan []byte
+}
+
+func newFakeClamd(t *testing.T, reply string) *fakeClamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	clamd := &fakeClamd{listener: listener, reply: reply, streamChannel: make(chan []byte, 1)}
+	go clamd.serve()
+	return clamd
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		stream, err := readClamdStream(conn)
+		if err == nil {
+			f.streamChannel <- stream
+			_, _ = io.WriteString(conn, f.reply+"\x00")
+		}
+		_ = conn.Close()
+	}
+}
+
+func readClamdStream(conn net.Conn) ([]byte, error) {
+	command := make([]byte, len(clamdCommandInstream))
+	if _, err := io.ReadFull(conn, command); err != nil {
+		return nil, err
+	}
+	if string(command) != clamdCommandInstream {
+		return nil, errUnexpectedClamdReply
+	}
+	stream := new(bytes.Buffer)
+	chunkLength := make([]byte, clamdChunkLengthSize)
+	for {
+		if _, err := io.ReadFull(conn, chunkLength); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(chunkLength)
+		if length == 0 {
+			return stream.Bytes(), nil
+		}
+		if _, err := io.CopyN(stream, conn, int64(length)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *fakeClamd) malwareScanConfig() configs.MalwareScan {
+	return configs.MalwareScan{
+		Scanner:         configs.MalwareScannerTypeClamd,
+		Network:         "tcp",
+		Address:         f.listener.Addr().String(),
+		Timeout:         "5s",
+		StreamChunkSize: testClamdStreamChunkSize,
+	}
+}
+
+// fakeDownloadTaskDataAccessor holds a single download task.
+type fakeDownloadTaskDataAccessor struct {
+	database.DownloadTaskDataAccessor
+	downloadTask database.DownloadTask
+}
+
+func (f *fakeDownloadTaskDataAccessor) GetDownloadTaskWithXLock(_ context.Context, id uint64) (database.DownloadTask, error) {
+	if id != f.downloadTask.ID {
+		return database.DownloadTask{}, database.ErrDownloadTaskNotFound
+	}
+	return f.downloadTask, nil
+}
+
+func (f *fakeDownloadTaskDataAccessor) UpdateDownloadTask(_ context.Context, downloadTask database.DownloadTask) error {
+	f.downloadTask = downloadTask
+	return nil
+}
+
+func (f *fakeDownloadTaskDataAccessor) WithDatabase(database.Database) database.DownloadTaskDataAccessor {
+	return f
+}
+
+type fakeHTTPRequestOptions struct {
+	HTTPRequestOptions
+}
+
+func (fakeHTTPRequestOptions) Decrypt(context.Context, []byte) (*go_load.HTTPRequestOptions, error) {
+	return nil, nil
+}
+
+// fakeURLPolicy lets the download tasks reach the test server on the loopback address.
+type fakeURLPolicy struct {
+	URLPolicy
+}
+
+func (fakeURLPolicy) HTTPClient() *http.Client {
+	return http.DefaultClient
+}
+
+type fakeMetrics struct {
+	utils.Metrics
+}
+
+func (fakeMetrics) AddDownloadedBytes(string, uint64) {}
+
+func (fakeMetrics) ObserveDownload(string, uint64, time.Duration) {}
+
+func newTestEncryptedFileClient(t *testing.T, downloadDirectory string) file.EncryptedClient {
+	t.Helper()
+	downloadConfig := configs.Download{DownloadDirectory: downloadDirectory}
+	fileClient, err := file.NewLocalClient(downloadConfig, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create file client: %v", err)
+	}
+	encryptedFileClient, err := file.NewEncryptedClient(fileClient, nil, downloadConfig, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create encrypted file client: %v", err)
+	}
+	return encryptedFileClient
+}
+
+func newTestClamdMalwareScanner(t *testing.T, encryptedFileClient file.EncryptedClient, clamd *fakeClamd) MalwareScanner {
+	t.Helper()
+	malwareScanner, err := NewClamdMalwareScanner(
+		encryptedFileClient, configs.Download{MalwareScan: clamd.malwareScanConfig()}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create malware scanner: %v", err)
+	}
+	return malwareScanner
+}
+
+func TestClamdMalwareScannerScan(t *testing.T) {
+	testCases := []struct {
+		name           string
+		reply          string
+		expectedResult MalwareScanResult
+		expectedErr    error
+	}{
+		{
+			name:           "clean",
+			reply:          "stream: OK",
+			expectedResult: MalwareScanResult{Scanner: malwareScannerNameClamd},
+		},
+		{
+			name:  "infected",
+			reply: "stream: Eicar-Signature FOUND",
+			expectedResult: MalwareScanResult{
+				Scanner:   malwareScannerNameClamd,
+				Infected:  true,
+				Signature: "Eicar-Signature",
+			},
+		},
+		{
+			name:        "error",
+			reply:       "INSTREAM size limit exceeded. ERROR",
+			expectedErr: errMalwareScanFailed,
+		},
+		{
+			name:        "unexpected reply",
+			reply:       "UNKNOWN COMMAND",
+			expectedErr: errUnexpectedClamdReply,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			downloadDirectory := t.TempDir()
+			if err := os.WriteFile(
+				filepath.Join(downloadDirectory, testDownloadTaskFileName), testDownloadedFileContent, 0o600,
+			); err != nil {
+				t.Fatalf("failed to write file to scan: %v", err)
+			}
+			clamd := newFakeClamd(t, testCase.reply)
+			malwareScanner := newTestClamdMalwareScanner(t, newTestEncryptedFileClient(t, downloadDirectory), clamd)
+
+			result, err := malwareScanner.Scan(context.Background(), testDownloadTaskFileName, nil)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("expected %v, got %v", testCase.expectedErr, err)
+			}
+			if result != testCase.expectedResult {
+				t.Fatalf("expected %+v, got %+v", testCase.expectedResult, result)
+			}
+			// The file is streamed in several chunks of stream_chunk_size.
+			if stream := <-clamd.streamChannel; !bytes.Equal(stream, testDownloadedFileContent) {
+				t.Fatalf("expected clamd to receive the file, got %q", stream)
+			}
+		})
+	}
+}
+
+func TestExecuteDownloadTaskMalwareScan(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		reply                  string
+		expectedDownloadStatus go_load.DownloadStatus
+		expectedResult         *MalwareScanResult
+	}{
+		{
+			name:                   "clean",
+			reply:                  "stream: OK",
+			expectedDownloadStatus: go_load.DownloadStatus_Success,
+			expectedResult:         &MalwareScanResult{Scanner: malwareScannerNameClamd},
+		},
+		{
+			name:                   "infected",
+			reply:                  "stream: Eicar-Signature FOUND",
+			expectedDownloadStatus: go_load.DownloadStatus_Quarantined,
+			expectedResult: &MalwareScanResult{
+				Scanner:   malwareScannerNameClamd,
+				Infected:  true,
+				Signature: "Eicar-Signature",
+			},
+		},
+		{
+			name:                   "error",
+			reply:                  "INSTREAM size limit exceeded. ERROR",
+			expectedDownloadStatus: go_load.DownloadStatus_Failed,
+		},
+	}
+	fileServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(testDownloadedFileContent)
+	}))
+	defer fileServer.Close()
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sqlDB, sqlMock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create sql mock: %v", err)
+			}
+			defer sqlDB.Close()
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectCommit()
+
+			downloadDirectory := t.TempDir()
+			encryptedFileClient := newTestEncryptedFileClient(t, downloadDirectory)
+			downloadTaskDataAccessor := &fakeDownloadTaskDataAccessor{downloadTask: database.DownloadTask{
+				ID:             testDownloadTaskID,
+				OfAccountID:    testAccountID,
+				DownloadType:   go_load.DownloadType_HTTP,
+				URL:            fileServer.URL,
+				DownloadStatus: go_load.DownloadStatus_Pending,
+			}}
+			downloadTaskLogic := NewDownloadTask(
+				nil, nil, nil, downloadTaskDataAccessor, nil, goqu.New("mysql", sqlDB), nil, encryptedFileClient,
+				nil, newTestClamdMalwareScanner(t, encryptedFileClient, newFakeClamd(t, testCase.reply)),
+				fakeURLPolicy{}, fakeHTTPRequestOptions{}, configs.Download{}, configs.Cron{}, fakeMetrics{},
+				noop.NewTracerProvider(), zap.NewNop())
+
+			err = downloadTaskLogic.ExecuteDownloadTask(context.Background(), testDownloadTaskID)
+			if (err != nil) != (testCase.expectedResult == nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err := sqlMock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+			downloadTask := downloadTaskDataAccessor.downloadTask
+			if downloadTask.DownloadStatus != testCase.expectedDownloadStatus || downloadTask.FinishedAt == nil {
+				t.Fatalf("expected download status %s, got %s", testCase.expectedDownloadStatus, downloadTask.DownloadStatus)
+			}
+			if testCase.expectedResult != nil {
+				metadata, ok := downloadTask.Metadata.Data.(map[string]any)
+				if !ok || metadata[downloadTaskMetadataFieldNameMalwareScanResult] != *testCase.expectedResult {
+					t.Fatalf("expected malware scan result %+v in metadata %+v", testCase.expectedResult, metadata)
+				}
+			}
+
+			// The file is kept where it was downloaded to, whatever the verdict, but quarantined files are not served.
+			storedContent, err := os.ReadFile(filepath.Join(downloadDirectory, testDownloadTaskFileName))
+			if err != nil || !bytes.Equal(storedContent, testDownloadedFileContent) {
+				t.Fatalf("expected the downloaded file to be kept, got %q, %v", storedContent, err)
+			}
+			fileName, err := getDownloadTaskFileName(downloadTask)
+			switch testCase.expectedDownloadStatus {
+			case go_load.DownloadStatus_Success:
+				if err != nil || fileName != testDownloadTaskFileName {
+					t.Fatalf("expected file %s to be served, got %q, %v", testDownloadTaskFileName, fileName, err)
+				}
+			case go_load.DownloadStatus_Quarantined:
+				if !errors.Is(err, errDownloadTaskQuarantined) {
+					t.Fatalf("expected %v, got %v", errDownloadTaskQuarantined, err)
+				}
+			default:
+				if err == nil {
+					t.Fatal("expected the file of the failed download task not to be served")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"crypto/md5"  //nolint:gosec // Only used to verify checksums published in metalinks
+	"crypto/sha1" //nolint:gosec // Only used to verify checksums published in metalinks
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/xml"
+	"errors"
+	gohash "hash"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	metalinkHashTypeMD5    = "md5"
+	metalinkHashTypeSHA1   = "sha-1"
+	metalinkHashTypeSHA256 = "sha-256"
+	metalinkHashTypeSHA384 = "sha-384"
+	metalinkHashTypeSHA512 = "sha-512"
+	// metalinkMaxV3Preference is the highest preference a Metalink 3 url can have, used to turn preferences (higher
+	// is better) into Metalink 4 priorities (lower is better).
+	metalinkMaxV3Preference = 100
+)
+
+var (
+	errMetalinkWithoutFile      = errors.New("metalink does not have any file")
+	errMetalinkFileWithoutURL   = errors.New("metalink file does not have any url")
+	errMetalinkFileNotFound     = errors.New("metalink does not have a file with the requested name")
+	errMetalinkUnsupportedMedia = errors.New("metalink does not have any http url")
+
+	// metalinkHashTypeList lists the supported hash types from the strongest to the weakest.
+	metalinkHashTypeList = []string{
+		metalinkHashTypeSHA512,
+		metalinkHashTypeSHA384,
+		metalinkHashTypeSHA256,
+		metalinkHashTypeSHA1,
+		metalinkHashTypeMD5,
+	}
+	metalinkHashConstructorMap = map[string]func() gohash.Hash{
+		metalinkHashTypeMD5:    md5.New,
+		metalinkHashTypeSHA1:   sha1.New,
+		metalinkHashTypeSHA256: sha256.New,
+		metalinkHashTypeSHA384: sha512.New384,
+		metalinkHashTypeSHA512: sha512.New,
+	}
+)
+
+type metalinkXMLHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+type metalinkXMLURL struct {
+	Priority   int    `xml:"priority,attr"`
+	Preference int    `xml:"preference,attr"`
+	Type       string `xml:"type,attr"`
+	Location   string `xml:"location,attr"`
+	Value      string `xml:",chardata"`
+}
+type metalinkXMLFile struct {
+	Name   string            `xml:"name,attr"`
+	Size   uint64            `xml:"size"`
+	Hashes []metalinkXMLHash `xml:"hash"`
+	URLs   []metalinkXMLURL  `xml:"url"`
+	// Metalink 3 nests hashes inside a verification element and urls inside a resources element.
+	VerificationHashes []metalinkXMLHash `xml:"verification>hash"`
+	ResourceURLs       []metalinkXMLURL  `xml:"resources>url"`
+}
+type metalinkXML struct {
+	Files []metalinkXMLFile `xml:"file"`
+	// Metalink 3 nests the files inside a files element.
+	NestedFiles []metalinkXMLFile `xml:"files>file"`
+}
+
+type metalinkMirror struct {
+	URL      string
+	Priority int
+	Location string
+}
+type metalinkFile struct {
+	Name string
+	Size uint64
+	// Hashes maps Metalink 4 hash types to lowercase hex digests.
+	Hashes  map[string]string
+	Mirrors []metalinkMirror
+}
+
+// getStrongestHash returns the strongest supported hash of the file, or empty strings if there is none.
+func (m metalinkFile) getStrongestHash() (string, string) {
+	for _, hashType := range metalinkHashTypeList {
+		if digest, ok := m.Hashes[hashType]; ok {
+			return hashType, digest
+		}
+	}
+	return "", ""
+}
+
+// normalizeMetalinkHashType turns Metalink 3 hash types like sha256 into their Metalink 4 (IANA) names like sha-256.
+func normalizeMetalinkHashType(hashType string) string {
+	hashType = strings.ToLower(strings.TrimSpace(hashType))
+	if strings.HasPrefix(hashType, "sha") && !strings.HasPrefix(hashType, "sha-") {
+		return "sha-" + strings.TrimPrefix(hashType, "sha")
+	}
+	return hashType
+}
+
+func newMetalinkMirror(url metalinkXMLURL) (metalinkMirror, bool) {
+	urlValue := strings.TrimSpace(url.Value)
+	if urlValue == "" {
+		return metalinkMirror{}, false
+	}
+	// Metalink 3 types urls explicitly, only http and https ones can be downloaded.
+	if url.Type != "" && url.Type != "http" && url.Type != "https" {
+		return metalinkMirror{}, false
+	}
+	if !strings.HasPrefix(urlValue, "http://") && !strings.HasPrefix(urlValue, "https://") {
+		return metalinkMirror{}, false
+	}
+	priority := math.MaxInt32
+	switch {
+	case url.Priority > 0:
+		priority = url.Priority
+	case url.Preference > 0:
+		priority = metalinkMaxV3Preference - url.Preference + 1
+	}
+	return metalinkMirror{
+		URL:      urlValue,
+		Priority: priority,
+		Location: url.Location,
+	}, true
+}
+
+func newMetalinkFile(file metalinkXMLFile) (metalinkFile, error) {
+	result := metalinkFile{
+		Name:    file.Name,
+		Size:    file.Size,
+		Hashes:  make(map[string]string),
+		Mirrors: make([]metalinkMirror, 0),
+	}
+	for _, fileHash := range append(append([]metalinkXMLHash{}, file.Hashes...), file.VerificationHashes...) {
+		hashType := normalizeMetalinkHashType(fileHash.Type)
+		if _, ok := metalinkHashConstructorMap[hashType]; ok {
+			result.Hashes[hashType] = strings.ToLower(strings.TrimSpace(fileHash.Value))
+		}
+	}
+	urlList := append(append([]metalinkXMLURL{}, file.URLs...), file.ResourceURLs...)
+	if len(urlList) == 0 {
+		return metalinkFile{}, errMetalinkFileWithoutURL
+	}
+	for _, url := range urlList {
+		if mirror, ok := newMetalinkMirror(url); ok {
+			result.Mirrors = append(result.Mirrors, mirror)
+		}
+	}
+	if len(result.Mirrors) == 0 {
+		return metalinkFile{}, errMetalinkUnsupportedMedia
+	}
+	sort.SliceStable(result.Mirrors, func(i, j int) bool {
+		return result.Mirrors[i].Priority < result.Mirrors[j].Priority
+	})
+	return result, nil
+}
+
+// parseMetalink parses a Metalink 3 or Metalink 4 (RFC 5854) document. The mirrors of each file are sorted from the
+// most to the least preferred.
+func parseMetalink(document []byte) ([]metalinkFile, error) {
+	parsedMetalink := metalinkXML{}
+	if err := xml.Unmarshal(document, &parsedMetalink); err != nil {
+		return nil, err
+	}
+	xmlFileList := append(append([]metalinkXMLFile{}, parsedMetalink.Files...), parsedMetalink.NestedFiles...)
+	if len(xmlFileList) == 0 {
+		return nil, errMetalinkWithoutFile
+	}
+	fileList := make([]metalinkFile, 0, len(xmlFileList))
+	for _, xmlFile := range xmlFileList {
+		file, err := newMetalinkFile(xmlFile)
+		if err != nil {
+			return nil, err
+		}
+		fileList = append(fileList, file)
+	}
+	return fileList, nil
+}
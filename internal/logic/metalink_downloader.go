@@ -0,0 +1,464 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	gohash "hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	HTTPRequestHeaderRange         = "Range"
+	HTTPResponseHeaderContentRange = "Content-Range"
+
+	metalinkSegmentEncryptionKeyByteCount = 32
+
+	MetalinkMetadataKeyFileName = "metalink-file-name"
+	MetalinkMetadataKeyHashType = "metalink-hash-type"
+)
+
+var (
+	errMetalinkTooLarge             = errors.New("metalink size exceeds the configured limit")
+	errMetalinkMirrorStatusCode     = errors.New("unexpected mirror response status code")
+	errMetalinkMirrorIncomplete     = errors.New("mirror response ended before the end of the file")
+	errMetalinkMirrorContentRange   = errors.New("mirror response range does not match the requested range")
+	errMetalinkMirrorTooLong        = errors.New("mirror response is longer than the metalink size")
+	errMetalinkAllMirrorsFailed     = errors.New("all mirrors failed")
+	errMetalinkFileSizeMismatch     = errors.New("downloaded file size does not match the metalink")
+	errMetalinkFileChecksumMismatch = errors.New("downloaded file checksum does not match the metalink")
+)
+
+// MetalinkDownloader downloads the file described by a Metalink document, failing over between its mirrors in
+// priority order. Large files are split into segments that are downloaded from different mirrors concurrently. The
+// url may select a file of a multi-file metalink by its name in the fragment.
+//
+// The http request options are sent with the request of the metalink, and with the requests of the mirrors on the
+// same host, since the credentials are meant for that host. Every request goes through the proxy of the options.
+type MetalinkDownloader struct {
+	url                string
+	httpRequestOptions *go_load.HTTPRequestOptions
+	httpClient         *http.Client
+	metalinkConfig     configs.Metalink
+	logger             *zap.Logger
+}
+
+func NewMetalinkDownloader(
+	url string,
+	httpRequestOptions *go_load.HTTPRequestOptions,
+	httpClient *http.Client,
+	metalinkConfig configs.Metalink,
+	logger *zap.Logger,
+) Downloader {
+	return &MetalinkDownloader{
+		url:                url,
+		httpRequestOptions: httpRequestOptions,
+		httpClient:         httpClient,
+		metalinkConfig:     metalinkConfig,
+		logger:             logger,
+	}
+}
+
+func (m MetalinkDownloader) getMetalinkFile(ctx context.Context) (metalinkFile, error) {
+	maxMetalinkSize, err := m.metalinkConfig.GetMaxMetalinkSizeInBytes()
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	parsedURL, err := url.Parse(m.url)
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	request, err := newHTTPRequestWithOptions(ctx, m.url, m.httpRequestOptions)
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	response, err := m.httpClient.Do(request)
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return metalinkFile{}, fmt.Errorf("unexpected metalink response status code: %d", response.StatusCode)
+	}
+	document, err := io.ReadAll(io.LimitReader(response.Body, int64(maxMetalinkSize)+1))
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	if uint64(len(document)) > maxMetalinkSize {
+		return metalinkFile{}, errMetalinkTooLarge
+	}
+	fileList, err := parseMetalink(document)
+	if err != nil {
+		return metalinkFile{}, err
+	}
+	if parsedURL.Fragment == "" {
+		return fileList[0], nil
+	}
+	for _, file := range fileList {
+		if file.Name == parsedURL.Fragment {
+			return file, nil
+		}
+	}
+	return metalinkFile{}, errMetalinkFileNotFound
+}
+
+// isMetalinkOrigin tells whether the mirror has the scheme and host of the metalink, which the credentials of the http
+// request options are meant for.
+func (m MetalinkDownloader) isMetalinkOrigin(mirrorURL *url.URL) bool {
+	parsedURL, err := url.Parse(m.url)
+	return err == nil && strings.EqualFold(parsedURL.Scheme, mirrorURL.Scheme) && strings.EqualFold(parsedURL.Host, mirrorURL.Host)
+}
+
+// parseContentRange returns the first and last byte positions of a Content-Range header like "bytes 0-99/1000".
+func parseContentRange(contentRange string) (uint64, uint64, bool) {
+	byteRange, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	byteRange, _, ok = strings.Cut(byteRange, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	firstByte, lastByte, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	firstBytePosition, err := strconv.ParseUint(firstByte, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lastBytePosition, err := strconv.ParseUint(lastByte, 10, 64)
+	if err != nil || lastBytePosition < firstBytePosition {
+		return 0, 0, false
+	}
+	return firstBytePosition, lastBytePosition, true
+}
+
+// downloadRange writes the bytes of the mirror's file from offset up to end to the writer. An end of 0 means until
+// the end of the file, which is only allowed from the start of files whose size the metalink does not declare. The
+// range is requested unless it is the whole file, and the mirror must answer with exactly that range.
+func (m MetalinkDownloader) downloadRange(
+	ctx context.Context,
+	mirrorURL string,
+	fileSize uint64,
+	offset uint64,
+	end uint64,
+	writer io.Writer,
+) (uint64, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, mirrorURL, http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	if m.isMetalinkOrigin(request.URL) {
+		setHTTPRequestOptionsHeaders(request, m.httpRequestOptions)
+	}
+	isRangeRequest := offset > 0 || end < fileSize
+	if isRangeRequest {
+		rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+		if end > 0 {
+			rangeHeader += fmt.Sprint(end - 1)
+		}
+		request.Header.Set(HTTPRequestHeaderRange, rangeHeader)
+	}
+	response, err := m.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if (isRangeRequest && response.StatusCode != http.StatusPartialContent) ||
+		(!isRangeRequest && response.StatusCode != http.StatusOK) {
+		return 0, fmt.Errorf("%w: %d", errMetalinkMirrorStatusCode, response.StatusCode)
+	}
+	if isRangeRequest {
+		firstBytePosition, lastBytePosition, ok := parseContentRange(response.Header.Get(HTTPResponseHeaderContentRange))
+		if !ok || firstBytePosition != offset || (end > 0 && lastBytePosition != end-1) {
+			return 0, fmt.Errorf("%w: %q", errMetalinkMirrorContentRange, response.Header.Get(HTTPResponseHeaderContentRange))
+		}
+	}
+	if end == 0 {
+		writtenByteCount, err := io.Copy(writer, response.Body)
+		return uint64(writtenByteCount), err
+	}
+	writtenByteCount, err := io.CopyN(writer, response.Body, int64(end-offset))
+	if errors.Is(err, io.EOF) {
+		return uint64(writtenByteCount), errMetalinkMirrorIncomplete
+	}
+	if err != nil {
+		return uint64(writtenByteCount), err
+	}
+	if n, _ := io.ReadFull(response.Body, make([]byte, 1)); n > 0 {
+		return uint64(writtenByteCount), errMetalinkMirrorTooLong
+	}
+	return uint64(writtenByteCount), nil
+}
+
+// downloadWithFailover downloads the file from its mirrors in priority order. When a mirror fails midway, the
+// download resumes from the next mirror with a range request, if the metalink declares the size of the file so that
+// the resumed download can be bounded.
+func (m MetalinkDownloader) downloadWithFailover(ctx context.Context, file metalinkFile, writer io.Writer) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, m.logger)
+
+	var (
+		writtenByteCount uint64
+		lastErr          error
+	)
+	for _, mirror := range file.Mirrors {
+		// Without a declared size the rest of the file could not be bounded, and once the declared size is written
+		// there is nothing left to resume.
+		if writtenByteCount > 0 && writtenByteCount >= file.Size {
+			break
+		}
+		mirrorWrittenByteCount, err := m.downloadRange(ctx, mirror.URL, file.Size, writtenByteCount, file.Size, writer)
+		writtenByteCount += mirrorWrittenByteCount
+		if err == nil {
+			return writtenByteCount, nil
+		}
+		if ctx.Err() != nil {
+			return writtenByteCount, ctx.Err()
+		}
+		logger.
+			With(zap.String("mirror_url", mirror.URL)).
+			With(zap.Uint64("written_byte_count", writtenByteCount)).
+			With(zap.Error(err)).
+			Warn("mirror failed, trying the next one")
+		lastErr = err
+	}
+	return writtenByteCount, fmt.Errorf("%w: %w", errMetalinkAllMirrorsFailed, lastErr)
+}
+
+// metalinkSegmentFile is a temporary file holding a segment until it is written to the writer. The segment is
+// encrypted with a key only kept in memory, so that no plaintext of the file is left on the disk, even if the process
+// stops before removing it.
+type metalinkSegmentFile struct {
+	file  *os.File
+	block cipher.Block
+	iv    []byte
+}
+
+func newMetalinkSegmentFile() (*metalinkSegmentFile, error) {
+	key := make([]byte, metalinkSegmentEncryptionKeyByteCount)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "goload-metalink-segment-*")
+	if err != nil {
+		return nil, err
+	}
+	return &metalinkSegmentFile{
+		file:  file,
+		block: block,
+		iv:    make([]byte, aes.BlockSize),
+	}, nil
+}
+
+// reset empties the file for a new download of the segment, with a new iv so that the key stream is never reused.
+func (s *metalinkSegmentFile) reset() (io.Writer, error) {
+	if err := s.file.Truncate(0); err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(s.iv); err != nil {
+		return nil, err
+	}
+	return cipher.StreamWriter{S: cipher.NewCTR(s.block, s.iv), W: s.file}, nil
+}
+func (s *metalinkSegmentFile) newReader() (io.Reader, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return cipher.StreamReader{S: cipher.NewCTR(s.block, s.iv), R: s.file}, nil
+}
+func (s *metalinkSegmentFile) Close() error {
+	closeErr := s.file.Close()
+	if err := os.Remove(s.file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// downloadSegment downloads a segment into the file, starting from the mirror at the segment's index so that
+// concurrent segments are spread across mirrors, and failing over to the other mirrors in turn.
+func (m MetalinkDownloader) downloadSegment(
+	ctx context.Context,
+	file metalinkFile,
+	segmentIndex int,
+	offset uint64,
+	length uint64,
+	segmentFile *metalinkSegmentFile,
+) error {
+	logger := utils.LoggerWithContext(ctx, m.logger).
+		With(zap.Int("segment_index", segmentIndex)).
+		With(zap.Uint64("offset", offset)).
+		With(zap.Uint64("length", length))
+
+	var lastErr error
+	for i := range file.Mirrors {
+		mirror := file.Mirrors[(segmentIndex+i)%len(file.Mirrors)]
+		segmentWriter, err := segmentFile.reset()
+		if err != nil {
+			return err
+		}
+		_, err = m.downloadRange(ctx, mirror.URL, file.Size, offset, offset+length, segmentWriter)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.With(zap.String("mirror_url", mirror.URL)).With(zap.Error(err)).Warn("mirror failed, trying the next one")
+		lastErr = err
+	}
+	return fmt.Errorf("%w: %w", errMetalinkAllMirrorsFailed, lastErr)
+}
+
+// downloadSegments downloads the segments concurrently into temporary files, then writes them to the writer in
+// order, since the writer only supports sequential writes.
+func (m MetalinkDownloader) downloadSegments(
+	ctx context.Context,
+	file metalinkFile,
+	segmentCount int,
+	writer io.Writer,
+) (uint64, error) {
+	logger := utils.LoggerWithContext(ctx, m.logger)
+
+	segmentFileList := make([]*metalinkSegmentFile, segmentCount)
+	defer func() {
+		for _, segmentFile := range segmentFileList {
+			if segmentFile == nil {
+				continue
+			}
+			if err := segmentFile.Close(); err != nil {
+				logger.With(zap.Error(err)).Warn("failed to remove temporary segment file")
+			}
+		}
+	}()
+	for i := range segmentFileList {
+		segmentFile, err := newMetalinkSegmentFile()
+		if err != nil {
+			return 0, err
+		}
+		segmentFileList[i] = segmentFile
+	}
+
+	segmentSize := (file.Size + uint64(segmentCount) - 1) / uint64(segmentCount)
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, segmentFile := range segmentFileList {
+		offset := uint64(i) * segmentSize
+		length := min(segmentSize, file.Size-offset)
+		group.Go(func() error {
+			return m.downloadSegment(groupCtx, file, i, offset, length, segmentFile)
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return 0, err
+	}
+
+	var writtenByteCount uint64
+	for i, segmentFile := range segmentFileList {
+		segmentReader, err := segmentFile.newReader()
+		if err != nil {
+			return writtenByteCount, err
+		}
+		segmentLength := min(segmentSize, file.Size-uint64(i)*segmentSize)
+		segmentWrittenByteCount, err := io.CopyN(writer, segmentReader, int64(segmentLength))
+		writtenByteCount += uint64(segmentWrittenByteCount)
+		if err != nil {
+			return writtenByteCount, err
+		}
+	}
+	return writtenByteCount, nil
+}
+
+func (m MetalinkDownloader) getSegmentCount(file metalinkFile) (int, error) {
+	minSegmentSize, err := m.metalinkConfig.GetMinSegmentSizeInBytes()
+	if err != nil {
+		return 0, err
+	}
+	if file.Size == 0 || minSegmentSize == 0 {
+		return 1, nil
+	}
+	segmentCount := min(m.metalinkConfig.MaxConcurrentSegments, len(file.Mirrors))
+	if sizeSegmentCount := file.Size / minSegmentSize; sizeSegmentCount < uint64(segmentCount) {
+		segmentCount = int(sizeSegmentCount)
+	}
+	return max(segmentCount, 1), nil
+}
+
+func (m MetalinkDownloader) Download(ctx context.Context, writer io.Writer) (map[string]any, error) {
+	logger := utils.LoggerWithContext(ctx, m.logger).With(zap.String("url", m.url))
+
+	ctx, err := contextWithHTTPRequestOptionsProxy(ctx, m.httpRequestOptions)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse proxy url")
+		return nil, err
+	}
+	file, err := m.getMetalinkFile(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get metalink")
+		return nil, err
+	}
+	segmentCount, err := m.getSegmentCount(file)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse min_segment_size")
+		return nil, err
+	}
+	var (
+		hashType, expectedDigest = file.getStrongestHash()
+		hasher                   gohash.Hash
+	)
+	if hashType != "" {
+		hasher = metalinkHashConstructorMap[hashType]()
+		writer = io.MultiWriter(writer, hasher)
+	}
+
+	var writtenByteCount uint64
+	if segmentCount > 1 {
+		writtenByteCount, err = m.downloadSegments(ctx, file, segmentCount, writer)
+	} else {
+		writtenByteCount, err = m.downloadWithFailover(ctx, file, writer)
+	}
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to download metalink file")
+		return nil, err
+	}
+	if file.Size > 0 && writtenByteCount != file.Size {
+		logger.
+			With(zap.Uint64("expected_size", file.Size)).
+			With(zap.Uint64("size", writtenByteCount)).
+			Error("downloaded file size does not match the metalink")
+		return nil, errMetalinkFileSizeMismatch
+	}
+	if hasher != nil && hex.EncodeToString(hasher.Sum(nil)) != expectedDigest {
+		logger.With(zap.String("hash_type", hashType)).Error("downloaded file checksum does not match the metalink")
+		return nil, errMetalinkFileChecksumMismatch
+	}
+	if hasher == nil {
+		logger.Warn("metalink does not have a supported hash, file is not verified")
+	}
+	metadata := map[string]any{
+		MetalinkMetadataKeyFileName: file.Name,
+		MetalinkMetadataKeyHashType: hashType,
+	}
+	return metadata, nil
+}
@@ -0,0 +1,459 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/cache"
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	oidcStateByteCount = 32
+	oidcNonceByteCount = 32
+)
+
+var (
+	errOIDCDisabled                         = status.Error(codes.FailedPrecondition, "single sign-on is not enabled")
+	errInvalidOIDCLoginState                = status.Error(codes.Unauthenticated, "single sign-on login is invalid or has expired")
+	errOIDCLoginFailed                      = status.Error(codes.Unauthenticated, "failed to log in with the identity provider")
+	errOIDCIdentityNotLinked                = status.Error(codes.PermissionDenied, "identity is not linked to any account")
+	errOIDCEmailNotVerified                 = status.Error(codes.PermissionDenied, "identity provider did not return a verified email")
+	errOIDCIdentityLinkedToOtherAccount     = status.Error(codes.AlreadyExists, "identity is already linked to another account")
+	errOIDCAccountNameTaken                 = status.Error(codes.AlreadyExists, "an account named after the email already exists, log in to it and link the identity instead")
+	errAPIKeyCannotManageAccountIdentities  = status.Error(codes.PermissionDenied, "api keys cannot be used to manage account identities")
+	errCannotUnlinkLastAccountSignInMethod  = status.Error(codes.FailedPrecondition, "cannot unlink the only way to log in to the account")
+	errAccountIdentityNotOwnedByTheAccount  = status.Error(codes.PermissionDenied, "trying to unlink an identity the account does not own")
+	errOIDCProviderDiscoveryFailed          = status.Error(codes.Unavailable, "failed to reach the identity provider")
+	errOIDCAuthorizationCodeExchangeFailure = status.Error(codes.Unauthenticated, "failed to exchange the authorization code")
+)
+
+type StartOIDCLoginParams struct {
+	// LinkToken is the session token of the account to link the identity to, or empty to log in with the identity.
+	LinkToken string
+}
+type StartOIDCLoginOutput struct {
+	// State has to be sent back by the browser along with the authorization code.
+	State            string
+	AuthorizationURL string
+	ExpiresIn        time.Duration
+}
+type CompleteOIDCLoginParams struct {
+	State string
+	Code  string
+}
+type CompleteOIDCLoginOutput struct {
+	Account *go_load.Account
+	// Token is empty if the identity was linked to an account instead of logged in with.
+	Token string
+}
+type ListAccountIdentitiesParams struct {
+	Token string
+}
+type UnlinkAccountIdentityParams struct {
+	Token             string
+	AccountIdentityID uint64
+}
+
+type oidcIDTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func generateOIDCRandomString(byteCount int) (string, error) {
+	randomBytes := make([]byte, byteCount)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+func databaseAccountIdentityToProtoAccountIdentity(accountIdentity database.AccountIdentity) *go_load.AccountIdentity {
+	return &go_load.AccountIdentity{
+		Id:         accountIdentity.ID,
+		Issuer:     accountIdentity.Issuer,
+		Subject:    accountIdentity.Subject,
+		Email:      lo.FromPtr(accountIdentity.Email),
+		CreateTime: getProtoTimestamp(&accountIdentity.CreatedAt),
+	}
+}
+
+// OIDC logs accounts in with an OpenID Connect identity provider, using the authorization code flow with PKCE.
+// Identities are matched to accounts by their iss and sub claims, and an account can have several identities linked.
+type OIDC interface {
+	StartLogin(ctx context.Context, params StartOIDCLoginParams) (StartOIDCLoginOutput, error)
+	CompleteLogin(ctx context.Context, params CompleteOIDCLoginParams) (CompleteOIDCLoginOutput, error)
+	ListAccountIdentities(ctx context.Context, params ListAccountIdentitiesParams) ([]*go_load.AccountIdentity, error)
+	UnlinkAccountIdentity(ctx context.Context, params UnlinkAccountIdentityParams) error
+}
+
+// oidcProvider is discovered on first use, so that the server starts even if the identity provider is down.
+type oidcProvider struct {
+	oauth2Config    *oauth2.Config
+	idTokenVerifier *oidc.IDTokenVerifier
+}
+type openIDConnect struct {
+	goquDatabase                *goqu.Database
+	accountDataAccessor         database.AccountDataAccessor
+	accountPasswordDataAccessor database.AccountPasswordDataAccessor
+	accountIdentityDataAccessor database.AccountIdentityDataAccessor
+	oidcLoginStateCache         cache.OIDCLoginState
+	tokenLogic                  Token
+	authConfig                  configs.Auth
+	loginExpiresIn              time.Duration
+	providerMutex               *sync.Mutex
+	provider                    *oidcProvider
+	logger                      *zap.Logger
+}
+
+func NewOIDC(
+	goquDatabase *goqu.Database,
+	accountDataAccessor database.AccountDataAccessor,
+	accountPasswordDataAccessor database.AccountPasswordDataAccessor,
+	accountIdentityDataAccessor database.AccountIdentityDataAccessor,
+	oidcLoginStateCache cache.OIDCLoginState,
+	tokenLogic Token,
+	authConfig configs.Auth,
+	logger *zap.Logger,
+) (OIDC, error) {
+	var loginExpiresIn time.Duration
+	if authConfig.OIDC.Enabled {
+		var err error
+		loginExpiresIn, err = authConfig.OIDC.GetLoginExpiresInDuration()
+		if err != nil {
+			logger.With(zap.Error(err)).Error("failed to parse login_expires_in")
+			return nil, err
+		}
+	}
+	return &openIDConnect{
+		goquDatabase:                goquDatabase,
+		accountDataAccessor:         accountDataAccessor,
+		accountPasswordDataAccessor: accountPasswordDataAccessor,
+		accountIdentityDataAccessor: accountIdentityDataAccessor,
+		oidcLoginStateCache:         oidcLoginStateCache,
+		tokenLogic:                  tokenLogic,
+		authConfig:                  authConfig,
+		loginExpiresIn:              loginExpiresIn,
+		providerMutex:               new(sync.Mutex),
+		logger:                      logger,
+	}, nil
+}
+func (o *openIDConnect) getProvider(ctx context.Context) (*oidcProvider, error) {
+	if !o.authConfig.OIDC.Enabled {
+		return nil, errOIDCDisabled
+	}
+	o.providerMutex.Lock()
+	defer o.providerMutex.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	provider, err := oidc.NewProvider(ctx, o.authConfig.OIDC.IssuerURL)
+	if err != nil {
+		utils.LoggerWithContext(ctx, o.logger).With(zap.Error(err)).Error("failed to discover oidc provider")
+		return nil, errOIDCProviderDiscoveryFailed
+	}
+	scopes := o.authConfig.OIDC.Scopes
+	if !lo.Contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+	o.provider = &oidcProvider{
+		oauth2Config: &oauth2.Config{
+			ClientID:     o.authConfig.OIDC.ClientID,
+			ClientSecret: o.authConfig.OIDC.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  o.authConfig.OIDC.RedirectURL,
+			Scopes:       scopes,
+		},
+		idTokenVerifier: provider.Verifier(&oidc.Config{ClientID: o.authConfig.OIDC.ClientID}),
+	}
+	return o.provider, nil
+}
+
+// getSessionAccountID only accepts session tokens, so that a leaked API key cannot be used to link an identity.
+func (o *openIDConnect) getSessionAccountID(ctx context.Context, token string) (uint64, error) {
+	if isAPIKey(token) {
+		return 0, errAPIKeyCannotManageAccountIdentities
+	}
+	accountID, _, err := o.tokenLogic.GetAccountIDAndExpireTime(ctx, token)
+	return accountID, err
+}
+func (o *openIDConnect) StartLogin(ctx context.Context, params StartOIDCLoginParams) (StartOIDCLoginOutput, error) {
+	logger := utils.LoggerWithContext(ctx, o.logger)
+
+	provider, err := o.getProvider(ctx)
+	if err != nil {
+		return StartOIDCLoginOutput{}, err
+	}
+	loginState := cache.OIDCLoginStateData{
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}
+	if params.LinkToken != "" {
+		loginState.LinkAccountID, err = o.getSessionAccountID(ctx, params.LinkToken)
+		if err != nil {
+			return StartOIDCLoginOutput{}, err
+		}
+	}
+	state, err := generateOIDCRandomString(oidcStateByteCount)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate oidc state")
+		return StartOIDCLoginOutput{}, status.Error(codes.Internal, "failed to generate oidc state")
+	}
+	loginState.Nonce, err = generateOIDCRandomString(oidcNonceByteCount)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate oidc nonce")
+		return StartOIDCLoginOutput{}, status.Error(codes.Internal, "failed to generate oidc nonce")
+	}
+	if err := o.oidcLoginStateCache.Set(ctx, state, loginState, o.loginExpiresIn); err != nil {
+		return StartOIDCLoginOutput{}, status.Error(codes.Internal, "failed to save oidc login state")
+	}
+	return StartOIDCLoginOutput{
+		State: state,
+		AuthorizationURL: provider.oauth2Config.AuthCodeURL(
+			state,
+			oidc.Nonce(loginState.Nonce),
+			oauth2.S256ChallengeOption(loginState.CodeVerifier),
+		),
+		ExpiresIn: o.loginExpiresIn,
+	}, nil
+}
+
+// verifyAuthorizationCode exchanges the authorization code and returns the verified ID token it came with.
+func (o *openIDConnect) verifyAuthorizationCode(
+	ctx context.Context,
+	provider *oidcProvider,
+	loginState cache.OIDCLoginStateData,
+	code string,
+) (*oidc.IDToken, error) {
+	logger := utils.LoggerWithContext(ctx, o.logger)
+
+	oauth2Token, err := provider.oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(loginState.CodeVerifier))
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to exchange oidc authorization code")
+		return nil, errOIDCAuthorizationCodeExchangeFailure
+	}
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		logger.Warn("oidc token response has no id token")
+		return nil, errOIDCLoginFailed
+	}
+	idToken, err := provider.idTokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to verify oidc id token")
+		return nil, errOIDCLoginFailed
+	}
+	if idToken.Nonce != loginState.Nonce {
+		logger.Warn("oidc id token nonce does not match")
+		return nil, errOIDCLoginFailed
+	}
+	return idToken, nil
+}
+
+// createAccountWithIdentity provisions an account named after the verified email of the identity.
+func (o *openIDConnect) createAccountWithIdentity(
+	ctx context.Context,
+	accountIdentity database.AccountIdentity,
+) (database.Account, error) {
+	if accountIdentity.Email == nil {
+		return database.Account{}, errOIDCEmailNotVerified
+	}
+	accountName := *accountIdentity.Email
+	if _, err := o.accountDataAccessor.GetAccountByAccountName(ctx, accountName); err == nil {
+		return database.Account{}, errOIDCAccountNameTaken
+	} else if !errors.Is(err, database.ErrAccountNotFound) {
+		return database.Account{}, err
+	}
+	account := database.Account{
+		AccountName: accountName,
+		Role:        go_load.AccountRole_User,
+	}
+	if lo.Contains(o.authConfig.AdminAccountNameList, accountName) {
+		account.Role = go_load.AccountRole_Admin
+	}
+	txErr := o.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		var err error
+		account.ID, err = o.accountDataAccessor.WithDatabase(td).CreateAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		accountIdentity.OfAccountID = account.ID
+		_, err = o.accountIdentityDataAccessor.WithDatabase(td).CreateAccountIdentity(ctx, accountIdentity)
+		return err
+	})
+	if txErr != nil {
+		return database.Account{}, txErr
+	}
+	utils.LoggerWithContext(ctx, o.logger).
+		With(zap.Uint64("account_id", account.ID)).
+		With(zap.String("issuer", accountIdentity.Issuer)).
+		Info("created account on first single sign-on login")
+	return account, nil
+}
+
+// linkIdentity links the identity to the account, unless it is linked to another account already.
+func (o *openIDConnect) linkIdentity(
+	ctx context.Context,
+	accountID uint64,
+	accountIdentity database.AccountIdentity,
+) (database.Account, error) {
+	account, err := o.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return database.Account{}, err
+	}
+	if account.IsDisabled() {
+		return database.Account{}, errAccountDisabled
+	}
+	existingAccountIdentity, err := o.accountIdentityDataAccessor.GetAccountIdentityByIssuerAndSubject(
+		ctx, accountIdentity.Issuer, accountIdentity.Subject)
+	if err == nil {
+		if existingAccountIdentity.OfAccountID != account.ID {
+			return database.Account{}, errOIDCIdentityLinkedToOtherAccount
+		}
+		return account, nil
+	}
+	if !errors.Is(err, database.ErrAccountIdentityNotFound) {
+		return database.Account{}, err
+	}
+	accountIdentity.OfAccountID = account.ID
+	if _, err := o.accountIdentityDataAccessor.CreateAccountIdentity(ctx, accountIdentity); err != nil {
+		return database.Account{}, err
+	}
+	utils.LoggerWithContext(ctx, o.logger).
+		With(zap.Uint64("account_id", account.ID)).
+		With(zap.String("issuer", accountIdentity.Issuer)).
+		Info("linked identity to account")
+	return account, nil
+}
+
+// getAccountOfIdentity returns the account the identity is linked to, creating one if allowed.
+func (o *openIDConnect) getAccountOfIdentity(ctx context.Context, accountIdentity database.AccountIdentity) (database.Account, error) {
+	existingAccountIdentity, err := o.accountIdentityDataAccessor.GetAccountIdentityByIssuerAndSubject(
+		ctx, accountIdentity.Issuer, accountIdentity.Subject)
+	if err != nil {
+		if !errors.Is(err, database.ErrAccountIdentityNotFound) {
+			return database.Account{}, err
+		}
+		if !o.authConfig.OIDC.AllowAccountCreation {
+			return database.Account{}, errOIDCIdentityNotLinked
+		}
+		return o.createAccountWithIdentity(ctx, accountIdentity)
+	}
+	return o.accountDataAccessor.GetAccountByID(ctx, existingAccountIdentity.OfAccountID)
+}
+func (o *openIDConnect) CompleteLogin(ctx context.Context, params CompleteOIDCLoginParams) (CompleteOIDCLoginOutput, error) {
+	provider, err := o.getProvider(ctx)
+	if err != nil {
+		return CompleteOIDCLoginOutput{}, err
+	}
+	loginState, err := o.oidcLoginStateCache.Take(ctx, params.State)
+	if err != nil {
+		return CompleteOIDCLoginOutput{}, errInvalidOIDCLoginState
+	}
+	idToken, err := o.verifyAuthorizationCode(ctx, provider, loginState, params.Code)
+	if err != nil {
+		return CompleteOIDCLoginOutput{}, err
+	}
+	claims := oidcIDTokenClaims{}
+	if err := idToken.Claims(&claims); err != nil {
+		utils.LoggerWithContext(ctx, o.logger).With(zap.Error(err)).Warn("failed to parse oidc id token claims")
+		return CompleteOIDCLoginOutput{}, errOIDCLoginFailed
+	}
+	accountIdentity := database.AccountIdentity{
+		Issuer:    idToken.Issuer,
+		Subject:   idToken.Subject,
+		CreatedAt: time.Now(),
+	}
+	// Unverified emails are not kept, so that they cannot be used to claim an account name.
+	if claims.Email != "" && claims.EmailVerified {
+		accountIdentity.Email = &claims.Email
+	}
+	if loginState.LinkAccountID != 0 {
+		account, linkErr := o.linkIdentity(ctx, loginState.LinkAccountID, accountIdentity)
+		if linkErr != nil {
+			return CompleteOIDCLoginOutput{}, linkErr
+		}
+		return CompleteOIDCLoginOutput{Account: databaseAccountToProtoAccount(account)}, nil
+	}
+	account, err := o.getAccountOfIdentity(ctx, accountIdentity)
+	if err != nil {
+		return CompleteOIDCLoginOutput{}, err
+	}
+	if account.IsDisabled() {
+		return CompleteOIDCLoginOutput{}, errAccountDisabled
+	}
+	token, _, err := o.tokenLogic.GetToken(ctx, account.ID)
+	if err != nil {
+		return CompleteOIDCLoginOutput{}, err
+	}
+	return CompleteOIDCLoginOutput{
+		Account: databaseAccountToProtoAccount(account),
+		Token:   token,
+	}, nil
+}
+func (o *openIDConnect) ListAccountIdentities(
+	ctx context.Context,
+	params ListAccountIdentitiesParams,
+) ([]*go_load.AccountIdentity, error) {
+	accountID, err := o.getSessionAccountID(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	accountIdentityList, err := o.accountIdentityDataAccessor.GetAccountIdentityListOfAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(accountIdentityList, func(item database.AccountIdentity, _ int) *go_load.AccountIdentity {
+		return databaseAccountIdentityToProtoAccountIdentity(item)
+	}), nil
+}
+
+// hasPassword tells whether the account can log in with a password, accounts created by single sign-on cannot.
+func (o *openIDConnect) hasPassword(ctx context.Context, accountID uint64) (bool, error) {
+	if _, err := o.accountPasswordDataAccessor.GetAccountPassword(ctx, accountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+func (o *openIDConnect) UnlinkAccountIdentity(ctx context.Context, params UnlinkAccountIdentityParams) error {
+	accountID, err := o.getSessionAccountID(ctx, params.Token)
+	if err != nil {
+		return err
+	}
+	accountIdentity, err := o.accountIdentityDataAccessor.GetAccountIdentity(ctx, params.AccountIdentityID)
+	if err != nil {
+		return err
+	}
+	if accountIdentity.OfAccountID != accountID {
+		return errAccountIdentityNotOwnedByTheAccount
+	}
+	accountIdentityList, err := o.accountIdentityDataAccessor.GetAccountIdentityListOfAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if len(accountIdentityList) == 1 {
+		hasPassword, hasPasswordErr := o.hasPassword(ctx, accountID)
+		if hasPasswordErr != nil {
+			return hasPasswordErr
+		}
+		if !hasPassword {
+			return errCannotUnlinkLastAccountSignInMethod
+		}
+	}
+	return o.accountIdentityDataAccessor.DeleteAccountIdentity(ctx, accountIdentity.ID)
+}
@@ -0,0 +1,407 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/cache"
+	"GoLoad/internal/dataaccess/database"
+
+	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	testOIDCClientID     = "go-load"
+	testOIDCSigningKeyID = "test-key"
+	testOIDCSubject      = "subject"
+	testOIDCEmail        = "user@example.com"
+	testSessionToken     = "session-token"
+)
+
+// fakeIdentityProvider serves the discovery document, the JWKS and the token endpoint of an OIDC issuer. The
+// authorization endpoint is skipped: authorize plays the part of the browser and returns the code directly.
+type fakeIdentityProvider struct {
+	server        *httptest.Server
+	privateKey    *rsa.PrivateKey
+	emailVerified bool
+	// nonce replaces the nonce of the authorization request in the ID token if set.
+	nonce string
+
+	mutex            *sync.Mutex
+	authorizationMap map[string]url.Values
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	identityProvider := &fakeIdentityProvider{
+		privateKey:       privateKey,
+		emailVerified:    true,
+		mutex:            new(sync.Mutex),
+		authorizationMap: make(map[string]url.Values),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", identityProvider.serveDiscovery)
+	mux.HandleFunc("GET /jwks", identityProvider.serveJWKS)
+	mux.HandleFunc("POST /token", identityProvider.serveToken)
+	identityProvider.server = httptest.NewServer(mux)
+	t.Cleanup(identityProvider.server.Close)
+	return identityProvider
+}
+
+func (f *fakeIdentityProvider) issuer() string {
+	return f.server.URL
+}
+
+func (f *fakeIdentityProvider) authorize(t *testing.T, authorizationURL string) string {
+	t.Helper()
+	parsedURL, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("failed to parse authorization url: %v", err)
+	}
+	query := parsedURL.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authorizationURL)
+	}
+	code := "code-" + query.Get("state")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.authorizationMap[code] = query
+	return code
+}
+
+func (f *fakeIdentityProvider) serveDiscovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                f.issuer(),
+		"authorization_endpoint":                f.issuer() + "/authorize",
+		"token_endpoint":                        f.issuer() + "/token",
+		"jwks_uri":                              f.issuer() + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (f *fakeIdentityProvider) serveJWKS(w http.ResponseWriter, _ *http.Request) {
+	encoding := base64.RawURLEncoding
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": testOIDCSigningKeyID,
+			"n":   encoding.EncodeToString(f.privateKey.N.Bytes()),
+			"e":   encoding.EncodeToString(big.NewInt(int64(f.privateKey.E)).Bytes()),
+		}},
+	})
+}
+
+func (f *fakeIdentityProvider) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mutex.Lock()
+	authorization, ok := f.authorizationMap[r.PostForm.Get("code")]
+	delete(f.authorizationMap, r.PostForm.Get("code"))
+	f.mutex.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	codeChallenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(codeChallenge[:]) != authorization.Get("code_challenge") {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	nonce := authorization.Get("nonce")
+	if f.nonce != "" {
+		nonce = f.nonce
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            f.issuer(),
+		"sub":            testOIDCSubject,
+		"aud":            testOIDCClientID,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          testOIDCEmail,
+		"email_verified": f.emailVerified,
+	})
+	idToken.Header["kid"] = testOIDCSigningKeyID
+	signedIDToken, err := idToken.SignedString(f.privateKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signedIDToken,
+	})
+}
+
+type fakeOIDCLoginStateCache struct {
+	loginStateMap map[string]cache.OIDCLoginStateData
+}
+
+func (f *fakeOIDCLoginStateCache) Set(
+	_ context.Context,
+	state string,
+	loginState cache.OIDCLoginStateData,
+	_ time.Duration,
+) error {
+	f.loginStateMap[state] = loginState
+	return nil
+}
+
+func (f *fakeOIDCLoginStateCache) Take(_ context.Context, state string) (cache.OIDCLoginStateData, error) {
+	loginState, ok := f.loginStateMap[state]
+	if !ok {
+		return cache.OIDCLoginStateData{}, errors.New("oidc login state not found")
+	}
+	delete(f.loginStateMap, state)
+	return loginState, nil
+}
+
+// fakeAccountDataAccessor only implements the account lookups.
+type fakeAccountDataAccessor struct {
+	database.AccountDataAccessor
+	accountList []database.Account
+}
+
+func (f fakeAccountDataAccessor) GetAccountByID(_ context.Context, id uint64) (database.Account, error) {
+	for _, account := range f.accountList {
+		if account.ID == id {
+			return account, nil
+		}
+	}
+	return database.Account{}, database.ErrAccountNotFound
+}
+
+func (f fakeAccountDataAccessor) GetAccountByAccountName(_ context.Context, accountName string) (database.Account, error) {
+	for _, account := range f.accountList {
+		if account.AccountName == accountName {
+			return account, nil
+		}
+	}
+	return database.Account{}, database.ErrAccountNotFound
+}
+
+// fakeAccountIdentityDataAccessor only implements the identity lookup and creation.
+type fakeAccountIdentityDataAccessor struct {
+	database.AccountIdentityDataAccessor
+	accountIdentityList []database.AccountIdentity
+}
+
+func (f *fakeAccountIdentityDataAccessor) CreateAccountIdentity(
+	_ context.Context,
+	accountIdentity database.AccountIdentity,
+) (uint64, error) {
+	accountIdentity.ID = uint64(len(f.accountIdentityList) + 1)
+	f.accountIdentityList = append(f.accountIdentityList, accountIdentity)
+	return accountIdentity.ID, nil
+}
+
+func (f *fakeAccountIdentityDataAccessor) GetAccountIdentityByIssuerAndSubject(
+	_ context.Context,
+	issuer string,
+	subject string,
+) (database.AccountIdentity, error) {
+	for _, accountIdentity := range f.accountIdentityList {
+		if accountIdentity.Issuer == issuer && accountIdentity.Subject == subject {
+			return accountIdentity, nil
+		}
+	}
+	return database.AccountIdentity{}, database.ErrAccountIdentityNotFound
+}
+
+// fakeToken issues a fixed token and only accepts testSessionToken, as the session token of testAccountID.
+type fakeToken struct {
+	Token
+}
+
+func (fakeToken) GetToken(_ context.Context, _ uint64) (string, time.Time, error) {
+	return testSessionToken, time.Now().Add(time.Hour), nil
+}
+
+func (fakeToken) GetAccountIDAndExpireTime(_ context.Context, token string) (uint64, time.Time, error) {
+	if token != testSessionToken {
+		return 0, time.Time{}, errInvalidToken
+	}
+	return testAccountID, time.Now().Add(time.Hour), nil
+}
+
+type testOIDC struct {
+	oidc                        OIDC
+	identityProvider            *fakeIdentityProvider
+	accountIdentityDataAccessor *fakeAccountIdentityDataAccessor
+}
+
+func newTestOIDC(t *testing.T) testOIDC {
+	t.Helper()
+	identityProvider := newFakeIdentityProvider(t)
+	accountIdentityDataAccessor := &fakeAccountIdentityDataAccessor{}
+	authConfig := configs.Auth{
+		OIDC: configs.OIDC{
+			Enabled:              true,
+			IssuerURL:            identityProvider.issuer(),
+			ClientID:             testOIDCClientID,
+			ClientSecret:         "client-secret",
+			RedirectURL:          "http://localhost:8081/oidc/callback",
+			AllowAccountCreation: true,
+			LoginExpiresIn:       "5m",
+		},
+	}
+	oidc, err := NewOIDC(
+		nil,
+		fakeAccountDataAccessor{accountList: []database.Account{
+			{ID: testAccountID, AccountName: "account"},
+			{ID: testOtherAccountID, AccountName: testOIDCEmail},
+		}},
+		nil,
+		accountIdentityDataAccessor,
+		&fakeOIDCLoginStateCache{loginStateMap: make(map[string]cache.OIDCLoginStateData)},
+		fakeToken{},
+		authConfig,
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create oidc logic: %v", err)
+	}
+	return testOIDC{
+		oidc:                        oidc,
+		identityProvider:            identityProvider,
+		accountIdentityDataAccessor: accountIdentityDataAccessor,
+	}
+}
+
+func (o testOIDC) login(t *testing.T, linkToken string, state string) (CompleteOIDCLoginOutput, error) {
+	t.Helper()
+	ctx := context.Background()
+	startOutput, err := o.oidc.StartLogin(ctx, StartOIDCLoginParams{LinkToken: linkToken})
+	if err != nil {
+		t.Fatalf("failed to start login: %v", err)
+	}
+	code := o.identityProvider.authorize(t, startOutput.AuthorizationURL)
+	if state == "" {
+		state = startOutput.State
+	}
+	return o.oidc.CompleteLogin(ctx, CompleteOIDCLoginParams{State: state, Code: code})
+}
+
+func TestCompleteOIDCLoginRejected(t *testing.T) {
+	testCases := []struct {
+		name          string
+		state         string
+		nonce         string
+		emailVerified bool
+		expectedErr   error
+		expectedCode  codes.Code
+	}{
+		{
+			name:          "state mismatch",
+			state:         "other-state",
+			emailVerified: true,
+			expectedErr:   errInvalidOIDCLoginState,
+			expectedCode:  codes.Unauthenticated,
+		},
+		{
+			name:          "nonce mismatch",
+			nonce:         "other-nonce",
+			emailVerified: true,
+			expectedErr:   errOIDCLoginFailed,
+			expectedCode:  codes.Unauthenticated,
+		},
+		{
+			// The account named after the email must not be taken over with an email the provider did not verify.
+			name:          "unverified email of existing account",
+			emailVerified: false,
+			expectedErr:   errOIDCEmailNotVerified,
+			expectedCode:  codes.PermissionDenied,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			oidc := newTestOIDC(t)
+			oidc.identityProvider.nonce = testCase.nonce
+			oidc.identityProvider.emailVerified = testCase.emailVerified
+
+			output, err := oidc.login(t, "", testCase.state)
+			assertErrorCode(t, err, testCase.expectedCode)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("expected %v, got %v", testCase.expectedErr, err)
+			}
+			if output.Token != "" {
+				t.Fatal("expected no token")
+			}
+			if createdList := oidc.accountIdentityDataAccessor.accountIdentityList; len(createdList) != 0 {
+				t.Fatalf("expected no identity to be linked, got %+v", createdList)
+			}
+		})
+	}
+}
+
+func TestCompleteOIDCLoginLinkNewIdentity(t *testing.T) {
+	oidc := newTestOIDC(t)
+
+	output, err := oidc.login(t, testSessionToken, "")
+	assertErrorCode(t, err, codes.OK)
+	if output.Account.GetId() != testAccountID || output.Token != "" {
+		t.Fatalf("expected the identity to be linked to account %d without a token, got %+v", testAccountID, output)
+	}
+	createdList := oidc.accountIdentityDataAccessor.accountIdentityList
+	if len(createdList) != 1 {
+		t.Fatalf("expected one identity to be linked, got %+v", createdList)
+	}
+	accountIdentity := createdList[0]
+	if accountIdentity.OfAccountID != testAccountID ||
+		accountIdentity.Issuer != oidc.identityProvider.issuer() ||
+		accountIdentity.Subject != testOIDCSubject ||
+		accountIdentity.Email == nil || *accountIdentity.Email != testOIDCEmail {
+		t.Fatalf("unexpected linked identity %+v", accountIdentity)
+	}
+
+	// The linked identity logs in to the account it was linked to.
+	output, err = oidc.login(t, "", "")
+	assertErrorCode(t, err, codes.OK)
+	if output.Account.GetId() != testAccountID || output.Token != testSessionToken {
+		t.Fatalf("expected to log in to account %d, got %+v", testAccountID, output)
+	}
+}
+
+func TestCompleteOIDCLoginIdentityLinkedToOtherAccount(t *testing.T) {
+	oidc := newTestOIDC(t)
+	oidc.accountIdentityDataAccessor.accountIdentityList = []database.AccountIdentity{{
+		ID:          1,
+		OfAccountID: testOtherAccountID,
+		Issuer:      oidc.identityProvider.issuer(),
+		Subject:     testOIDCSubject,
+	}}
+
+	_, err := oidc.login(t, testSessionToken, "")
+	if !errors.Is(err, errOIDCIdentityLinkedToOtherAccount) {
+		t.Fatalf("expected %v, got %v", errOIDCIdentityLinkedToOtherAccount, err)
+	}
+	if accountIdentityList := oidc.accountIdentityDataAccessor.accountIdentityList; len(accountIdentityList) != 1 {
+		t.Fatalf("expected the identity to stay linked to the other account only, got %+v", accountIdentityList)
+	}
+}
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"GoLoad/internal/configs"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errPasswordContainsAccountName = status.Error(codes.InvalidArgument, "password must not contain the account name")
+
+// validatePassword checks a new password of the account against the password policy.
+func validatePassword(passwordPolicy configs.PasswordPolicy, accountName string, password string) error {
+	if password == "" {
+		return errPasswordIsEmpty
+	}
+	passwordLength := utf8.RuneCountInString(password)
+	if passwordLength < passwordPolicy.MinLength {
+		return status.Errorf(codes.InvalidArgument, "password must be at least %d characters long", passwordPolicy.MinLength)
+	}
+	if passwordPolicy.MaxLength > 0 && passwordLength > passwordPolicy.MaxLength {
+		return status.Errorf(codes.InvalidArgument, "password must be at most %d characters long", passwordPolicy.MaxLength)
+	}
+	var hasUppercase, hasLowercase, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUppercase = true
+		case unicode.IsLower(r):
+			hasLowercase = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if passwordPolicy.RequireUppercase && !hasUppercase {
+		return status.Error(codes.InvalidArgument, "password must contain an uppercase letter")
+	}
+	if passwordPolicy.RequireLowercase && !hasLowercase {
+		return status.Error(codes.InvalidArgument, "password must contain a lowercase letter")
+	}
+	if passwordPolicy.RequireDigit && !hasDigit {
+		return status.Error(codes.InvalidArgument, "password must contain a digit")
+	}
+	if passwordPolicy.RequireSymbol && !hasSymbol {
+		return status.Error(codes.InvalidArgument, "password must contain a symbol")
+	}
+	if passwordPolicy.DisallowAccountName && accountName != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(accountName)) {
+		return errPasswordContainsAccountName
+	}
+	return nil
+}
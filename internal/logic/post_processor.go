@@ -0,0 +1,596 @@
+package logic
+
+import (
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/generated/grpc/go_load"
+	"GoLoad/internal/utils"
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/openpgp" //nolint:staticcheck // Still the only OpenPGP implementation in x/crypto
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	downloadTaskMetadataFieldNamePostProcessingStepResults = "post-processing-step-results"
+
+	postProcessingStepNameExtractArchive  = "extract_archive"
+	postProcessingStepNameDecompress      = "decompress"
+	postProcessingStepNameVerifySignature = "verify_signature"
+
+	postProcessingFormatDetectionByteCount = 512
+	zipReadBlockSize                       = 1024 * 1024
+	tarMagicOffset                         = 257
+	pgpPublicKeyBlockHeader                = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	pgpSignatureHeader                     = "-----BEGIN PGP SIGNATURE-----"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}
+	tarMagic  = []byte("ustar")
+
+	errUnsupportedArchiveFormat     = errors.New("unsupported archive format")
+	errUnsupportedCompressionFormat = errors.New("unsupported compression format")
+	errUnsafeArchiveEntryName       = errors.New("archive entry name escapes the output prefix")
+	errExtractedSizeLimitExceeded   = errors.New("extracted size exceeds the configured limit")
+	errExtractedFileCountExceeded   = errors.New("extracted file count exceeds the configured limit")
+	errDecompressedSizeExceeded     = errors.New("decompressed size exceeds the configured limit")
+	errSignatureSizeExceeded        = errors.New("signature size exceeds the configured limit")
+	errUnsupportedPublicKey         = errors.New("unsupported public key")
+	errSignatureMismatch            = errors.New("signature does not match")
+)
+
+type PostProcessingStepResult struct {
+	Step        string   `json:"step"`
+	Success     bool     `json:"success"`
+	Message     string   `json:"message,omitempty"`
+	OutputFiles []string `json:"output_files,omitempty"`
+}
+
+type PostProcessor interface {
+	// Process runs the post-processing steps in order against the downloaded file and returns the result of every
+	// step that was run. Decompress steps replace the file the following steps operate on with the decompressed
+	// file. Processing stops at the first failed step. fileEncryptionMetadataMap holds the encryption metadata of the
+	// downloaded file, and receives the one of each output file.
+	Process(
+		ctx context.Context,
+		fileName string,
+		steps []*go_load.PostProcessingStep,
+		fileEncryptionMetadataMap map[string]*file.EncryptionMetadata,
+	) ([]PostProcessingStepResult, error)
+}
+type postProcessor struct {
+	fileClient            file.EncryptedClient
+	httpClient            *http.Client
+	maxExtractedSize      uint64
+	maxExtractedFileCount int
+	maxDecompressedSize   uint64
+	maxSignatureSize      uint64
+	logger                *zap.Logger
+}
+
+func NewPostProcessor(
+	fileClient file.EncryptedClient,
+	urlPolicy URLPolicy,
+	downloadConfig configs.Download,
+	logger *zap.Logger,
+) (PostProcessor, error) {
+	maxExtractedSize, err := downloadConfig.PostProcessing.GetMaxExtractedSizeInBytes()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_extracted_size")
+		return nil, err
+	}
+	maxDecompressedSize, err := downloadConfig.PostProcessing.GetMaxDecompressedSizeInBytes()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_decompressed_size")
+		return nil, err
+	}
+	maxSignatureSize, err := downloadConfig.PostProcessing.GetMaxSignatureSizeInBytes()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse max_signature_size")
+		return nil, err
+	}
+	return &postProcessor{
+		fileClient:            fileClient,
+		httpClient:            urlPolicy.HTTPClient(),
+		maxExtractedSize:      maxExtractedSize,
+		maxExtractedFileCount: downloadConfig.PostProcessing.MaxExtractedFileCount,
+		maxDecompressedSize:   maxDecompressedSize,
+		maxSignatureSize:      maxSignatureSize,
+		logger:                logger,
+	}, nil
+}
+
+// sanitizeRelativePath cleans a slash separated path, rejecting absolute paths and paths that escape their parent.
+func sanitizeRelativePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) {
+		return "", errUnsafeArchiveEntryName
+	}
+	cleanedName := path.Clean(name)
+	if cleanedName == "." || cleanedName == ".." || strings.HasPrefix(cleanedName, "../") {
+		return "", errUnsafeArchiveEntryName
+	}
+	return cleanedName, nil
+}
+
+// ValidatePostProcessingSteps checks the post-processing steps of a download task before it is created.
+func ValidatePostProcessingSteps(steps []*go_load.PostProcessingStep) error {
+	for _, step := range steps {
+		switch step := step.GetStep().(type) {
+		case *go_load.PostProcessingStep_ExtractArchive:
+			if outputPrefix := step.ExtractArchive.GetOutputPrefix(); outputPrefix != "" {
+				if _, err := sanitizeRelativePath(outputPrefix); err != nil {
+					return status.Error(codes.InvalidArgument, "invalid extract archive output prefix")
+				}
+			}
+		case *go_load.PostProcessingStep_Decompress:
+		case *go_load.PostProcessingStep_VerifySignature:
+			if step.VerifySignature.GetSignatureUrl() == "" || step.VerifySignature.GetPublicKey() == "" {
+				return status.Error(codes.InvalidArgument, "verify signature step requires signature url and public key")
+			}
+		default:
+			return status.Error(codes.InvalidArgument, "post-processing step is empty")
+		}
+	}
+	return nil
+}
+
+func (p postProcessor) Process(
+	ctx context.Context,
+	fileName string,
+	steps []*go_load.PostProcessingStep,
+	fileEncryptionMetadataMap map[string]*file.EncryptionMetadata,
+) ([]PostProcessingStepResult, error) {
+	logger := utils.LoggerWithContext(ctx, p.logger).With(zap.String("file_name", fileName))
+
+	var (
+		currentFileName = fileName
+		results         = make([]PostProcessingStepResult, 0, len(steps))
+	)
+	for i, step := range steps {
+		var (
+			result = PostProcessingStepResult{}
+			err    error
+		)
+		switch step := step.GetStep().(type) {
+		case *go_load.PostProcessingStep_ExtractArchive:
+			result.Step = postProcessingStepNameExtractArchive
+			// The output prefix is a directory of the download task's own, so that no task can write over the
+			// files of another.
+			outputPrefix := path.Join(fileName+"_extracted", step.ExtractArchive.GetOutputPrefix())
+			result.OutputFiles, err = p.extractArchive(ctx, currentFileName, outputPrefix, fileEncryptionMetadataMap)
+		case *go_load.PostProcessingStep_Decompress:
+			result.Step = postProcessingStepNameDecompress
+			decompressedFileName := fmt.Sprintf("%s_decompressed_%d", fileName, i)
+			err = p.decompress(ctx, currentFileName, decompressedFileName, fileEncryptionMetadataMap)
+			if err == nil {
+				result.OutputFiles = []string{decompressedFileName}
+				currentFileName = decompressedFileName
+			}
+		case *go_load.PostProcessingStep_VerifySignature:
+			result.Step = postProcessingStepNameVerifySignature
+			err = p.verifySignature(ctx, currentFileName, fileEncryptionMetadataMap[currentFileName], step.VerifySignature)
+		default:
+			err = errors.New("post-processing step is empty")
+		}
+		if err != nil {
+			logger.With(zap.Int("step_index", i)).With(zap.Error(err)).Error("post-processing step failed")
+			result.Message = err.Error()
+			results = append(results, result)
+			return results, err
+		}
+		result.Success = true
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// decompressReader wraps the reader in a decompressor if it starts with a known compression magic number. The
+// returned bool is false if the reader is not compressed.
+func (p postProcessor) decompressReader(reader *bufio.Reader) (io.Reader, bool, error) {
+	header, err := reader.Peek(len(xzMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false, err
+	}
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gzipReader, err := gzip.NewReader(reader)
+		return gzipReader, true, err
+	case bytes.HasPrefix(header, zstdMagic):
+		zstdDecoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, false, err
+		}
+		return zstdDecoder.IOReadCloser(), true, nil
+	case bytes.HasPrefix(header, xzMagic):
+		xzReader, err := xz.NewReader(reader)
+		return xzReader, true, err
+	default:
+		return reader, false, nil
+	}
+}
+
+func (p postProcessor) decompress(
+	ctx context.Context,
+	fileName string,
+	decompressedFileName string,
+	fileEncryptionMetadataMap map[string]*file.EncryptionMetadata,
+) error {
+	reader, err := p.fileClient.Read(ctx, fileName, fileEncryptionMetadataMap[fileName])
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	decompressedReader, isCompressed, err := p.decompressReader(bufio.NewReader(reader))
+	if err != nil {
+		return err
+	}
+	if !isCompressed {
+		return errUnsupportedCompressionFormat
+	}
+	writer, encryptionMetadata, err := p.fileClient.Write(ctx, decompressedFileName)
+	if err != nil {
+		return err
+	}
+	if encryptionMetadata != nil {
+		fileEncryptionMetadataMap[decompressedFileName] = encryptionMetadata
+	}
+	writtenByteCount, err := io.Copy(writer, io.LimitReader(decompressedReader, int64(p.maxDecompressedSize)+1))
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if uint64(writtenByteCount) > p.maxDecompressedSize {
+		return errDecompressedSizeExceeded
+	}
+	return nil
+}
+
+type archiveExtraction struct {
+	fileClient                file.EncryptedClient
+	fileEncryptionMetadataMap map[string]*file.EncryptionMetadata
+	outputPrefix              string
+	remainingSize             uint64
+	remainingFileCount        int
+	outputFiles               []string
+}
+
+func (a *archiveExtraction) writeEntry(ctx context.Context, entryName string, reader io.Reader) error {
+	sanitizedEntryName, err := sanitizeRelativePath(entryName)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, entryName)
+	}
+	if a.remainingFileCount <= 0 {
+		return errExtractedFileCountExceeded
+	}
+	a.remainingFileCount--
+	outputFileName := path.Join(a.outputPrefix, sanitizedEntryName)
+	writer, encryptionMetadata, err := a.fileClient.Write(ctx, outputFileName)
+	if err != nil {
+		return err
+	}
+	if encryptionMetadata != nil {
+		a.fileEncryptionMetadataMap[outputFileName] = encryptionMetadata
+	}
+	// The file is listed before it is written, so that it is deleted along with the download task even if the entry
+	// fails part way.
+	a.outputFiles = append(a.outputFiles, outputFileName)
+	writtenByteCount, err := io.Copy(writer, io.LimitReader(reader, int64(a.remainingSize)+1))
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if uint64(writtenByteCount) > a.remainingSize {
+		return errExtractedSizeLimitExceeded
+	}
+	a.remainingSize -= uint64(writtenByteCount)
+	return nil
+}
+
+func (p postProcessor) extractArchive(
+	ctx context.Context,
+	fileName string,
+	outputPrefix string,
+	fileEncryptionMetadataMap map[string]*file.EncryptionMetadata,
+) ([]string, error) {
+	sanitizedOutputPrefix, err := sanitizeRelativePath(outputPrefix)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := p.fileClient.Read(ctx, fileName, fileEncryptionMetadataMap[fileName])
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	extraction := &archiveExtraction{
+		fileClient:                p.fileClient,
+		fileEncryptionMetadataMap: fileEncryptionMetadataMap,
+		outputPrefix:              sanitizedOutputPrefix,
+		remainingSize:             p.maxExtractedSize,
+		remainingFileCount:        p.maxExtractedFileCount,
+		outputFiles:               make([]string, 0),
+	}
+	bufferedReader := bufio.NewReaderSize(reader, postProcessingFormatDetectionByteCount)
+	header, err := bufferedReader.Peek(len(zipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if bytes.HasPrefix(header, zipMagic) {
+		err = p.extractZip(ctx, fileName, fileEncryptionMetadataMap[fileName], extraction)
+		return extraction.outputFiles, err
+	}
+	decompressedReader, _, err := p.decompressReader(bufferedReader)
+	if err != nil {
+		return nil, err
+	}
+	bufferedDecompressedReader := bufio.NewReaderSize(decompressedReader, postProcessingFormatDetectionByteCount)
+	header, err = bufferedDecompressedReader.Peek(tarMagicOffset + len(tarMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(header) < tarMagicOffset+len(tarMagic) || !bytes.Equal(header[tarMagicOffset:], tarMagic) {
+		return nil, errUnsupportedArchiveFormat
+	}
+	err = p.extractTar(ctx, bufferedDecompressedReader, extraction)
+	return extraction.outputFiles, err
+}
+
+func (p postProcessor) extractTar(ctx context.Context, reader io.Reader, extraction *archiveExtraction) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		// Symbolic links, hard links and devices are skipped, so that no entry can point outside of the prefix.
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extraction.writeEntry(ctx, header.Name, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// storedFileReaderAt reads a stored file at random offsets, as the zip central directory requires, without copying
+// it out of the storage, where it may be encrypted. It reads a block at a time, so that sequential reads do not each
+// make a request to the storage. It is not safe for concurrent use.
+type storedFileReaderAt struct {
+	ctx                    context.Context
+	fileClient             file.EncryptedClient
+	fileName               string
+	fileEncryptionMetadata *file.EncryptionMetadata
+	size                   int64
+	blockOffset            int64
+	block                  []byte
+}
+
+func (s *storedFileReaderAt) loadBlock(offset int64) error {
+	blockOffset := offset - offset%zipReadBlockSize
+	reader, err := s.fileClient.ReadRange(
+		s.ctx, s.fileName, s.fileEncryptionMetadata, uint64(blockOffset), uint64(min(zipReadBlockSize, s.size-blockOffset)))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	block, err := io.ReadAll(io.LimitReader(reader, zipReadBlockSize))
+	if err != nil {
+		return err
+	}
+	if len(block) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	s.blockOffset = blockOffset
+	s.block = block
+	return nil
+}
+func (s *storedFileReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		currentOffset := offset + int64(n)
+		if currentOffset >= s.size {
+			return n, io.EOF
+		}
+		if s.block == nil || currentOffset < s.blockOffset || currentOffset >= s.blockOffset+int64(len(s.block)) {
+			if err := s.loadBlock(currentOffset); err != nil {
+				return n, err
+			}
+		}
+		n += copy(p[n:], s.block[currentOffset-s.blockOffset:])
+	}
+	return n, nil
+}
+
+func (p postProcessor) extractZip(
+	ctx context.Context,
+	fileName string,
+	fileEncryptionMetadata *file.EncryptionMetadata,
+	extraction *archiveExtraction,
+) error {
+	fileInfo, err := p.fileClient.Stat(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		return err
+	}
+	readerAt := &storedFileReaderAt{
+		ctx:                    ctx,
+		fileClient:             p.fileClient,
+		fileName:               fileName,
+		fileEncryptionMetadata: fileEncryptionMetadata,
+		size:                   fileInfo.Size,
+	}
+	zipReader, err := zip.NewReader(readerAt, fileInfo.Size)
+	if err != nil {
+		return err
+	}
+	for _, zipFile := range zipReader.File {
+		if !zipFile.Mode().IsRegular() {
+			continue
+		}
+		if zipFile.UncompressedSize64 > extraction.remainingSize {
+			return errExtractedSizeLimitExceeded
+		}
+		zipFileReader, err := zipFile.Open()
+		if err != nil {
+			return err
+		}
+		err = extraction.writeEntry(ctx, zipFile.Name, zipFileReader)
+		zipFileReader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p postProcessor) getSignature(ctx context.Context, signatureURL string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, signatureURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected signature response status code: %d", response.StatusCode)
+	}
+	signature, err := io.ReadAll(io.LimitReader(response.Body, int64(p.maxSignatureSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(signature)) > p.maxSignatureSize {
+		return nil, errSignatureSizeExceeded
+	}
+	return signature, nil
+}
+
+// decodeSignature accepts both raw and base64 encoded signatures.
+func (p postProcessor) decodeSignature(signature []byte) []byte {
+	decodedSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return signature
+	}
+	return decodedSignature
+}
+
+func (p postProcessor) verifySignature(
+	ctx context.Context,
+	fileName string,
+	fileEncryptionMetadata *file.EncryptionMetadata,
+	step *go_load.VerifySignaturePostProcessingStep,
+) error {
+	signature, err := p.getSignature(ctx, step.GetSignatureUrl())
+	if err != nil {
+		return err
+	}
+	reader, err := p.fileClient.Read(ctx, fileName, fileEncryptionMetadata)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if strings.Contains(step.GetPublicKey(), pgpPublicKeyBlockHeader) {
+		return p.verifyPGPSignature(step.GetPublicKey(), signature, reader)
+	}
+	pemBlock, _ := pem.Decode([]byte(step.GetPublicKey()))
+	if pemBlock == nil {
+		return errUnsupportedPublicKey
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(pemBlock.Bytes)
+	if err != nil {
+		return err
+	}
+	signature = p.decodeSignature(signature)
+	switch publicKey := publicKey.(type) {
+	case ed25519.PublicKey:
+		data, err := io.ReadAll(io.LimitReader(reader, int64(p.maxDecompressedSize)+1))
+		if err != nil {
+			return err
+		}
+		if uint64(len(data)) > p.maxDecompressedSize {
+			return errDecompressedSizeExceeded
+		}
+		if !ed25519.Verify(publicKey, data, signature) {
+			return errSignatureMismatch
+		}
+		return nil
+	case *rsa.PublicKey:
+		digest, err := p.sha256Digest(reader)
+		if err != nil {
+			return err
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, signature); err != nil {
+			return errSignatureMismatch
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		digest, err := p.sha256Digest(reader)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(publicKey, digest, signature) {
+			return errSignatureMismatch
+		}
+		return nil
+	default:
+		return errUnsupportedPublicKey
+	}
+}
+
+func (p postProcessor) sha256Digest(reader io.Reader) ([]byte, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+func (p postProcessor) verifyPGPSignature(armoredPublicKey string, signature []byte, reader io.Reader) error {
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPublicKey))
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(signature, []byte(pgpSignatureHeader)) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyRing, reader, bytes.NewReader(signature))
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyRing, reader, bytes.NewReader(signature))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", errSignatureMismatch, err)
+	}
+	return nil
+}
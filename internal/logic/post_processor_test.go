@@ -0,0 +1,262 @@
+package logic
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/file"
+	"GoLoad/internal/generated/grpc/go_load"
+
+	"go.uber.org/zap"
+)
+
+func TestSanitizeRelativePath(t *testing.T) {
+	testCases := []struct {
+		name         string
+		expectedName string
+		expectedErr  error
+	}{
+		{name: "a.txt", expectedName: "a.txt"},
+		{name: "a/b.txt", expectedName: "a/b.txt"},
+		{name: "a/./b/../c.txt", expectedName: "a/c.txt"},
+		{name: "a/", expectedName: "a"},
+		{name: `a\b.txt`, expectedName: "a/b.txt"},
+		{name: "a/../b.txt", expectedName: "b.txt"},
+		{name: "", expectedErr: errUnsafeArchiveEntryName},
+		{name: ".", expectedErr: errUnsafeArchiveEntryName},
+		{name: "..", expectedErr: errUnsafeArchiveEntryName},
+		{name: "../x", expectedErr: errUnsafeArchiveEntryName},
+		{name: "/abs", expectedErr: errUnsafeArchiveEntryName},
+		{name: "a/../../x", expectedErr: errUnsafeArchiveEntryName},
+		{name: `..\x`, expectedErr: errUnsafeArchiveEntryName},
+		{name: `\abs`, expectedErr: errUnsafeArchiveEntryName},
+		{name: `a\..\..\x`, expectedErr: errUnsafeArchiveEntryName},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sanitizedName, err := sanitizeRelativePath(testCase.name)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("expected %v, got %v", testCase.expectedErr, err)
+			}
+			if sanitizedName != testCase.expectedName {
+				t.Fatalf("expected %q, got %q", testCase.expectedName, sanitizedName)
+			}
+		})
+	}
+}
+
+type testArchiveEntry struct {
+	name    string
+	content string
+}
+
+type testArchiveFormat struct {
+	name  string
+	write func(t *testing.T, entryList []testArchiveEntry) []byte
+}
+
+func writeTestTar(t *testing.T, entryList []testArchiveEntry) []byte {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(buffer)
+	for _, entry := range entryList {
+		header := &tar.Header{Name: entry.name, Mode: 0o600, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func writeTestTarGzip(t *testing.T, entryList []testArchiveEntry) []byte {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buffer)
+	if _, err := gzipWriter.Write(writeTestTar(t, entryList)); err != nil {
+		t.Fatalf("failed to write gzip: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func writeTestZip(t *testing.T, entryList []testArchiveEntry) []byte {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buffer)
+	for _, entry := range entryList {
+		writer, err := zipWriter.Create(entry.name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+var testArchiveFormatList = []testArchiveFormat{
+	{name: "tar", write: writeTestTar},
+	{name: "tar.gz", write: writeTestTarGzip},
+	{name: "zip", write: writeTestZip},
+}
+
+// listStoredFiles returns the slash separated paths of the files under the directory.
+func listStoredFiles(t *testing.T, directory string) []string {
+	t.Helper()
+	fileList := make([]string, 0)
+	err := filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, filePath)
+		fileList = append(fileList, filepath.ToSlash(relativePath))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to list stored files: %v", err)
+	}
+	slices.Sort(fileList)
+	return fileList
+}
+
+func TestPostProcessorExtractArchive(t *testing.T) {
+	const extractedPrefix = testDownloadTaskFileName + "_extracted/"
+	testCases := []struct {
+		name                  string
+		entryList             []testArchiveEntry
+		maxExtractedSize      string
+		maxExtractedFileCount int
+		expectedErr           error
+		expectedOutputFiles   []string
+	}{
+		{
+			name:                "safe entries",
+			entryList:           []testArchiveEntry{{"a.txt", "a"}, {"dir/b.txt", "bb"}, {`dir\c.txt`, "ccc"}},
+			expectedOutputFiles: []string{extractedPrefix + "a.txt", extractedPrefix + "dir/b.txt", extractedPrefix + "dir/c.txt"},
+		},
+		{
+			name:        "parent directory",
+			entryList:   []testArchiveEntry{{"../x", "x"}},
+			expectedErr: errUnsafeArchiveEntryName,
+		},
+		{
+			name:        "absolute path",
+			entryList:   []testArchiveEntry{{"/abs", "x"}},
+			expectedErr: errUnsafeArchiveEntryName,
+		},
+		{
+			name:        "escaping path",
+			entryList:   []testArchiveEntry{{"a/../../x", "x"}},
+			expectedErr: errUnsafeArchiveEntryName,
+		},
+		{
+			name:        "backslash escaping path",
+			entryList:   []testArchiveEntry{{`..\..\x`, "x"}},
+			expectedErr: errUnsafeArchiveEntryName,
+		},
+		{
+			name:             "size bomb",
+			entryList:        []testArchiveEntry{{"a.txt", "0123456789"}, {"b.txt", "0123456789"}},
+			maxExtractedSize: "16B",
+			expectedErr:      errExtractedSizeLimitExceeded,
+		},
+		{
+			name:                  "file count bomb",
+			entryList:             []testArchiveEntry{{"a.txt", "a"}, {"b.txt", "b"}, {"c.txt", "c"}},
+			maxExtractedFileCount: 2,
+			expectedErr:           errExtractedFileCountExceeded,
+		},
+	}
+	for _, archiveFormat := range testArchiveFormatList {
+		for _, testCase := range testCases {
+			t.Run(archiveFormat.name+"/"+testCase.name, func(t *testing.T) {
+				// The download directory is nested, so that entries escaping it can be caught in its parent.
+				rootDirectory := t.TempDir()
+				downloadDirectory := filepath.Join(rootDirectory, "download")
+				encryptedFileClient := newTestEncryptedFileClient(t, downloadDirectory)
+				if err := os.WriteFile(
+					filepath.Join(downloadDirectory, testDownloadTaskFileName),
+					archiveFormat.write(t, testCase.entryList),
+					0o600,
+				); err != nil {
+					t.Fatalf("failed to write archive: %v", err)
+				}
+				postProcessingConfig := configs.PostProcessing{
+					MaxExtractedSize:      "1KB",
+					MaxExtractedFileCount: 10,
+					MaxDecompressedSize:   "1KB",
+					MaxSignatureSize:      "1KB",
+				}
+				if testCase.maxExtractedSize != "" {
+					postProcessingConfig.MaxExtractedSize = testCase.maxExtractedSize
+				}
+				if testCase.maxExtractedFileCount != 0 {
+					postProcessingConfig.MaxExtractedFileCount = testCase.maxExtractedFileCount
+				}
+				postProcessor, err := NewPostProcessor(
+					encryptedFileClient, fakeURLPolicy{}, configs.Download{PostProcessing: postProcessingConfig}, zap.NewNop())
+				if err != nil {
+					t.Fatalf("failed to create post processor: %v", err)
+				}
+
+				results, err := postProcessor.Process(
+					context.Background(),
+					testDownloadTaskFileName,
+					[]*go_load.PostProcessingStep{{Step: &go_load.PostProcessingStep_ExtractArchive{
+						ExtractArchive: &go_load.ExtractArchivePostProcessingStep{},
+					}}},
+					make(map[string]*file.EncryptionMetadata),
+				)
+				if !errors.Is(err, testCase.expectedErr) {
+					t.Fatalf("expected %v, got %v", testCase.expectedErr, err)
+				}
+				if len(results) != 1 || results[0].Success != (testCase.expectedErr == nil) {
+					t.Fatalf("unexpected results %+v", results)
+				}
+				// Whatever was extracted before a failure is listed, so that it is deleted along with the download task.
+				expectedStoredFileList := []string{"download/" + testDownloadTaskFileName}
+				for _, outputFile := range results[0].OutputFiles {
+					expectedStoredFileList = append(expectedStoredFileList, "download/"+outputFile)
+				}
+				slices.Sort(expectedStoredFileList)
+				if storedFileList := listStoredFiles(t, rootDirectory); !slices.Equal(storedFileList, expectedStoredFileList) {
+					t.Fatalf("expected only the archive and its output files to be stored, got %v", storedFileList)
+				}
+				if testCase.expectedErr != nil {
+					return
+				}
+				if !slices.Equal(results[0].OutputFiles, testCase.expectedOutputFiles) {
+					t.Fatalf("expected output files %v, got %v", testCase.expectedOutputFiles, results[0].OutputFiles)
+				}
+				for i, outputFile := range results[0].OutputFiles {
+					content, err := os.ReadFile(filepath.Join(downloadDirectory, outputFile))
+					if err != nil || string(content) != testCase.entryList[i].content {
+						t.Fatalf("unexpected content of %s: %q, %v", outputFile, content, err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/dataaccess/cache"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type CheckRateLimitParams struct {
+	Method string
+	// Token is used to limit the requests of its account or API key together, if it is valid.
+	Token string
+	// ClientIP limits the requests without a valid token.
+	ClientIP string
+}
+
+// RateLimit limits the requests to each method, in fixed windows.
+type RateLimit interface {
+	// CheckRateLimit counts the request and returns a ResourceExhausted error carrying errdetails.RetryInfo if the
+	// limit of the method is exceeded.
+	CheckRateLimit(ctx context.Context, params CheckRateLimitParams) error
+}
+type rateLimitRule struct {
+	limit  uint64
+	window time.Duration
+}
+type rateLimit struct {
+	rateLimitCache cache.RateLimit
+	tokenLogic     Token
+	enabled        bool
+	defaultRule    rateLimitRule
+	methodRuleMap  map[string]rateLimitRule
+	logger         *zap.Logger
+}
+
+func newRateLimitRule(rule configs.RateLimitRule) (rateLimitRule, error) {
+	if rule.Limit == 0 {
+		return rateLimitRule{}, nil
+	}
+	window, err := rule.GetWindowDuration()
+	if err != nil {
+		return rateLimitRule{}, err
+	}
+	if window <= 0 {
+		return rateLimitRule{}, fmt.Errorf("rate limit window must be positive: %s", rule.Window)
+	}
+	return rateLimitRule{
+		limit:  rule.Limit,
+		window: window,
+	}, nil
+}
+func NewRateLimit(rateLimitCache cache.RateLimit, tokenLogic Token, grpcConfig configs.GRPC, logger *zap.Logger) (RateLimit, error) {
+	defaultRule, err := newRateLimitRule(grpcConfig.RateLimit.Default)
+	if err != nil {
+		return nil, err
+	}
+	methodRuleMap := make(map[string]rateLimitRule, len(grpcConfig.RateLimit.Methods))
+	for method, rule := range grpcConfig.RateLimit.Methods {
+		if methodRuleMap[method], err = newRateLimitRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return &rateLimit{
+		rateLimitCache: rateLimitCache,
+		tokenLogic:     tokenLogic,
+		enabled:        grpcConfig.RateLimit.Enabled,
+		defaultRule:    defaultRule,
+		methodRuleMap:  methodRuleMap,
+		logger:         logger,
+	}, nil
+}
+
+// getSubject only trusts a token once it is verified, otherwise a client could spread its requests over made up
+// tokens. It verifies the token of every request before any other check does, the lookups this takes are accepted as
+// the cost of counting the requests of each account together.
+func (r rateLimit) getSubject(ctx context.Context, params CheckRateLimitParams) string {
+	if params.Token != "" {
+		if accountID, _, err := r.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token); err == nil {
+			if isAPIKey(params.Token) {
+				return fmt.Sprintf("api_key:%s", hashAPIKey(params.Token))
+			}
+			return fmt.Sprintf("account:%d", accountID)
+		}
+	}
+	return fmt.Sprintf("ip:%s", params.ClientIP)
+}
+func (r rateLimit) CheckRateLimit(ctx context.Context, params CheckRateLimitParams) error {
+	if !r.enabled {
+		return nil
+	}
+	rule, ok := r.methodRuleMap[params.Method]
+	if !ok {
+		rule = r.defaultRule
+	}
+	if rule.limit == 0 {
+		return nil
+	}
+	subject := r.getSubject(ctx, params)
+	logger := utils.LoggerWithContext(ctx, r.logger).
+		With(zap.String("method", params.Method)).
+		With(zap.String("subject", subject))
+
+	now := time.Now()
+	windowStart := now.Truncate(rule.window)
+	requestCount, err := r.rateLimitCache.IncreaseRequestCount(ctx, subject, params.Method, windowStart, rule.window)
+	if err != nil {
+		// An unreachable cache should not take the whole API down with it.
+		logger.With(zap.Error(err)).Warn("failed to count request, will not rate limit it")
+		return nil
+	}
+	if requestCount <= rule.limit {
+		return nil
+	}
+	retryDelay := windowStart.Add(rule.window).Sub(now)
+	rateLimitStatus, err := status.New(codes.ResourceExhausted, "rate limit exceeded, retry later").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	}
+	logger.With(zap.Uint64("request_count", requestCount)).Debug("request rate limited")
+	return rateLimitStatus.Err()
+}
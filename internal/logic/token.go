@@ -2,15 +2,15 @@ package logic
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
 	"database/sql"
-	"encoding/pem"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,100 +21,133 @@ import (
 	"GoLoad/internal/utils"
 )
 
-const (
-	rs512KeyPairBitCount = 2048
-)
-
 var (
 	errUnexpectedSigningMethod = status.Error(codes.Unauthenticated, "unexpected signing method")
 	errCannotGetTokensClaims   = status.Error(codes.Unauthenticated, "cannot get token's claims")
 	errCannotGetTokensKidClaim = status.Error(codes.Unauthenticated, "cannot get token's kid claim")
 	errCannotGetTokensSubClaim = status.Error(codes.Unauthenticated, "cannot get token's sub claim")
 	errCannotGetTokensExpClaim = status.Error(codes.Unauthenticated, "cannot get token's exp claim")
+	errCannotGetTokensJTIClaim = status.Error(codes.Unauthenticated, "cannot get token's jti claim")
+	errCannotGetTokensVerClaim = status.Error(codes.Unauthenticated, "cannot get token's ver claim")
+	errTokenRevoked            = status.Error(codes.Unauthenticated, "token has been revoked")
 	errTokenPublicKeyNotFound  = status.Error(codes.Unauthenticated, "token public key not found")
 	errInvalidToken            = status.Error(codes.Unauthenticated, "invalid token")
+	errTokenAccountNotFound    = status.Error(codes.Unauthenticated, "token's account not found")
 	errFailedToSignToken       = status.Error(codes.Internal, "failed to sign token")
 )
 
 type Token interface {
 	GetToken(ctx context.Context, accountID uint64) (string, time.Time, error)
+	// GetAccountIDAndExpireTime accepts both session tokens and API keys. The expire time of API keys that do not
+	// expire is the zero time.
 	GetAccountIDAndExpireTime(ctx context.Context, token string) (uint64, time.Time, error)
+	// RefreshToken returns a new session token if the token expires within the regenerate_token_before_expiry window,
+	// or an empty string otherwise. API keys are never refreshed.
+	RefreshToken(ctx context.Context, token string) (string, time.Time, error)
+	// RevokeToken invalidates a session token before it expires.
+	RevokeToken(ctx context.Context, token string) error
+	// RevokeAllTokensOfAccount invalidates every session token issued to the account so far.
+	RevokeAllTokensOfAccount(ctx context.Context, accountID uint64) error
+	// RotateSigningKey publishes the next signing key rotation_overlap before the current one stops signing, and
+	// deletes the keys whose tokens have all expired. Replicas running it at the same time may each create a key, the
+	// one starting last with the highest id is used.
+	RotateSigningKey(ctx context.Context) error
+	// GetJSONWebKeySet returns the public keys of every token that has not expired yet.
+	GetJSONWebKeySet(ctx context.Context) (JSONWebKeySet, error)
 	WithDatabase(database database.Database) Token
 }
 
-func generateRSAKeyPair(bits int) (*rsa.PrivateKey, error) {
-	privateKeyPair, err := rsa.GenerateKey(rand.Reader, bits)
-	if err != nil {
-		return nil, err
-	}
-	return privateKeyPair, nil
-}
-
-func pemEncodePublicKey(pubKey *rsa.PublicKey) ([]byte, error) {
-	pubBytes, err := x509.MarshalPKIXPublicKey(pubKey)
-	if err != nil {
-		return nil, err
-	}
-	block := &pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: pubBytes,
-	}
-	return pem.EncodeToMemory(block), nil
-}
-
 type token struct {
 	accountDataAccessor        database.AccountDataAccessor
+	apiKeyDataAccessor         database.APIKeyDataAccessor
 	tokenPublicKeyCache        cache.TokenPublicKey
 	tokenPublicKeyDataAccessor database.TokenPublicKeyDataAccessor
+	revokedTokenCache          cache.RevokedToken
 	expiresIn                  time.Duration
-	privateKey                 *rsa.PrivateKey
-	tokenPublicKeyID           uint64
+	regenerateBeforeExpiry     time.Duration
+	algorithm                  string
+	signingAlgorithm           tokenSigningAlgorithm
+	signingKeyEncryptionKey    []byte
+	rotationInterval           time.Duration
+	rotationOverlap            time.Duration
+	signingKeyHolder           *tokenSigningKeyHolder
 	authConfig                 configs.Auth
 	logger                     *zap.Logger
 }
 
-func NewToken(accountDataAccessor database.AccountDataAccessor, tokenPublicKeyCache cache.TokenPublicKey,
-	tokenPublicKeyDataAccessor database.TokenPublicKeyDataAccessor, authConfig configs.Auth, logger *zap.Logger) (Token, error) {
+func NewToken(accountDataAccessor database.AccountDataAccessor, apiKeyDataAccessor database.APIKeyDataAccessor, tokenPublicKeyCache cache.TokenPublicKey,
+	tokenPublicKeyDataAccessor database.TokenPublicKeyDataAccessor, revokedTokenCache cache.RevokedToken, authConfig configs.Auth,
+	logger *zap.Logger) (Token, error) {
 	expiresIn, err := authConfig.Token.GetExpiresInDuration()
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to parse expires_in")
 		return nil, err
 	}
-	rsaKeyPair, err := generateRSAKeyPair(rs512KeyPairBitCount)
+	regenerateBeforeExpiry, err := authConfig.Token.GetRegenerateTokenBeforeExpiryDuration()
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to generate rsa key pair")
+		logger.With(zap.Error(err)).Error("failed to parse regenerate_token_before_expiry")
 		return nil, err
 	}
-	publicKeyBytes, err := pemEncodePublicKey(&rsaKeyPair.PublicKey)
+	rotationInterval, err := authConfig.Token.GetRotationIntervalDuration()
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to encode public key in pem format")
+		logger.With(zap.Error(err)).Error("failed to parse rotation_interval")
 		return nil, err
 	}
-	tokenPublicKeyID, err := tokenPublicKeyDataAccessor.CreatePublicKey(
-		context.Background(),
-		database.TokenPublicKey{PublicKey: publicKeyBytes},
-	)
+	rotationOverlap, err := authConfig.Token.GetRotationOverlapDuration()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse rotation_overlap")
+		return nil, err
+	}
+	algorithm := authConfig.Token.Algorithm
+	if algorithm == "" {
+		algorithm = defaultTokenSigningAlgorithm
+	}
+	signingAlgorithm, err := getTokenSigningAlgorithm(algorithm)
 	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to create public key entry in database")
+		logger.With(zap.Error(err)).Error("failed to get token signing algorithm")
 		return nil, err
 	}
+	signingKeyEncryptionKey, err := parseTokenSigningKeyEncryptionKey(authConfig.Token.SigningKeyEncryptionKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse signing_key_encryption_key")
+		return nil, err
+	}
+	if signingKeyEncryptionKey == nil {
+		if !authConfig.Token.GenerateSigningKeyEncryptionKey {
+			logger.With(zap.Error(errMissingTokenSigningKeyEncryptionKey)).Error("failed to get signing_key_encryption_key")
+			return nil, errMissingTokenSigningKeyEncryptionKey
+		}
+		logger.Warn("token signing key encryption key is not configured, generating a random one, signing keys will not be shared across restarts or replicas")
+		signingKeyEncryptionKey = make([]byte, tokenSigningKeyEncryptionKeyByteCount)
+		if _, err := rand.Read(signingKeyEncryptionKey); err != nil {
+			logger.With(zap.Error(err)).Error("failed to generate token signing key encryption key")
+			return nil, err
+		}
+	}
 	return &token{
 		accountDataAccessor:        accountDataAccessor,
+		apiKeyDataAccessor:         apiKeyDataAccessor,
 		tokenPublicKeyCache:        tokenPublicKeyCache,
 		tokenPublicKeyDataAccessor: tokenPublicKeyDataAccessor,
+		revokedTokenCache:          revokedTokenCache,
 		expiresIn:                  expiresIn,
-		privateKey:                 rsaKeyPair,
-		tokenPublicKeyID:           tokenPublicKeyID,
+		regenerateBeforeExpiry:     regenerateBeforeExpiry,
+		algorithm:                  algorithm,
+		signingAlgorithm:           signingAlgorithm,
+		signingKeyEncryptionKey:    signingKeyEncryptionKey,
+		rotationInterval:           rotationInterval,
+		rotationOverlap:            rotationOverlap,
+		signingKeyHolder:           &tokenSigningKeyHolder{},
 		authConfig:                 authConfig,
 		logger:                     logger,
 	}, nil
 }
-func (t token) getJWTPublicKey(ctx context.Context, id uint64) (*rsa.PublicKey, error) {
+func (t token) getJWTPublicKey(ctx context.Context, id uint64) (crypto.PublicKey, error) {
 	logger := utils.LoggerWithContext(ctx, t.logger).With(zap.Uint64("id", id))
 
 	cachedPublicKeyBytes, err := t.tokenPublicKeyCache.Get(ctx, id)
 	if err == nil && cachedPublicKeyBytes != nil {
-		return jwt.ParseRSAPublicKeyFromPEM(cachedPublicKeyBytes)
+		return pemDecodeTokenPublicKey(cachedPublicKeyBytes)
 	}
 
 	logger.With(zap.Error(err)).Warn("failed to get cached public key bytes, will fail back to database")
@@ -132,17 +165,178 @@ func (t token) getJWTPublicKey(ctx context.Context, id uint64) (*rsa.PublicKey,
 	if err != nil {
 		logger.With(zap.Error(err)).Warn("failed to set public key bytes into cache")
 	}
-	return jwt.ParseRSAPublicKeyFromPEM(tokenPublicKey.PublicKey)
+	return pemDecodeTokenPublicKey(tokenPublicKey.PublicKey)
 }
 
-func (t token) GetAccountIDAndExpireTime(ctx context.Context, tokenString string) (uint64, time.Time, error) {
+// createSigningKey generates a signing key that signs from signingStartsAt for rotation_interval.
+func (t token) createSigningKey(ctx context.Context, signingStartsAt time.Time) (tokenSigningKey, error) {
+	logger := utils.LoggerWithContext(ctx, t.logger).With(zap.String("algorithm", t.algorithm))
+
+	privateKey, err := t.signingAlgorithm.generateKey()
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to generate signing key")
+		return tokenSigningKey{}, err
+	}
+	publicKeyBytes, err := pemEncodeTokenPublicKey(privateKey.Public())
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to encode public key in pem format")
+		return tokenSigningKey{}, err
+	}
+	encryptedPrivateKey, err := encryptTokenSigningKey(t.signingKeyEncryptionKey, privateKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to encrypt signing key")
+		return tokenSigningKey{}, err
+	}
+	tokenPublicKey := database.TokenPublicKey{
+		PublicKey:           publicKeyBytes,
+		Algorithm:           t.algorithm,
+		EncryptedPrivateKey: encryptedPrivateKey,
+		SigningStartsAt:     signingStartsAt,
+		SigningEndsAt:       signingStartsAt.Add(t.rotationInterval),
+		CreatedAt:           time.Now(),
+	}
+	tokenPublicKey.ID, err = t.tokenPublicKeyDataAccessor.CreatePublicKey(ctx, tokenPublicKey)
+	if err != nil {
+		return tokenSigningKey{}, err
+	}
+	logger.With(zap.Uint64("id", tokenPublicKey.ID)).
+		With(zap.Time("signing_starts_at", tokenPublicKey.SigningStartsAt)).
+		Info("created signing key")
+	return tokenSigningKey{
+		id:            tokenPublicKey.ID,
+		method:        t.signingAlgorithm.method,
+		privateKey:    privateKey,
+		signingEndsAt: tokenPublicKey.SigningEndsAt,
+	}, nil
+}
+
+// decryptSigningKey returns false if the key cannot sign, e.g. because it was created before signing keys were stored
+// or encrypted with another encryption key.
+func (t token) decryptSigningKey(ctx context.Context, tokenPublicKey database.TokenPublicKey) (tokenSigningKey, bool) {
+	logger := utils.LoggerWithContext(ctx, t.logger).With(zap.Uint64("id", tokenPublicKey.ID))
+
+	if tokenPublicKey.EncryptedPrivateKey == nil {
+		return tokenSigningKey{}, false
+	}
+	signingAlgorithm, err := getTokenSigningAlgorithm(tokenPublicKey.Algorithm)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("signing key has an unsupported algorithm")
+		return tokenSigningKey{}, false
+	}
+	privateKey, err := decryptTokenSigningKey(t.signingKeyEncryptionKey, tokenPublicKey.EncryptedPrivateKey)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("failed to decrypt signing key, it may be encrypted with another encryption key")
+		return tokenSigningKey{}, false
+	}
+	return tokenSigningKey{
+		id:            tokenPublicKey.ID,
+		method:        signingAlgorithm.method,
+		privateKey:    privateKey,
+		signingEndsAt: tokenPublicKey.SigningEndsAt,
+	}, true
+}
+
+// getSigningKey returns the key that started signing last among the ones still signing, creating one if there is
+// none.
+func (t token) getSigningKey(ctx context.Context) (tokenSigningKey, error) {
+	t.signingKeyHolder.mutex.Lock()
+	defer t.signingKeyHolder.mutex.Unlock()
+
+	now := time.Now()
+	if t.signingKeyHolder.signingKey != nil && now.Before(t.signingKeyHolder.reloadAt) {
+		return *t.signingKeyHolder.signingKey, nil
+	}
+	tokenPublicKeyList, err := t.tokenPublicKeyDataAccessor.GetPublicKeyListSigningAfter(ctx, now)
+	if err != nil {
+		return tokenSigningKey{}, err
+	}
+	var signingKey *tokenSigningKey
+	for i := len(tokenPublicKeyList) - 1; i >= 0 && signingKey == nil; i-- {
+		if tokenPublicKeyList[i].SigningStartsAt.After(now) {
+			continue
+		}
+		if decryptedSigningKey, ok := t.decryptSigningKey(ctx, tokenPublicKeyList[i]); ok {
+			signingKey = &decryptedSigningKey
+		}
+	}
+	if signingKey == nil {
+		createdSigningKey, createErr := t.createSigningKey(ctx, now)
+		if createErr != nil {
+			return tokenSigningKey{}, createErr
+		}
+		signingKey = &createdSigningKey
+	}
+	t.signingKeyHolder.signingKey = signingKey
+	t.signingKeyHolder.reloadAt = now.Add(tokenSigningKeyReloadInterval)
+	if signingKey.signingEndsAt.Before(t.signingKeyHolder.reloadAt) {
+		t.signingKeyHolder.reloadAt = signingKey.signingEndsAt
+	}
+	return *signingKey, nil
+}
+
+// getEnabledAccount checks that the account of a token exists and is enabled. Disabling an account takes effect on
+// the tokens issued before it was disabled as well.
+func (t token) getEnabledAccount(ctx context.Context, accountID uint64) (database.Account, error) {
+	account, err := t.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, database.ErrAccountNotFound) {
+			return database.Account{}, errTokenAccountNotFound
+		}
+		return database.Account{}, err
+	}
+	if account.IsDisabled() {
+		return database.Account{}, errAccountDisabled
+	}
+	utils.SetAccountIDOfContext(ctx, account.ID)
+	return account, nil
+}
+func (t token) getAPIKeyAccountIDAndExpireTime(ctx context.Context, key string) (uint64, time.Time, error) {
 	logger := utils.LoggerWithContext(ctx, t.logger)
 
-	parsedToken, err := jwt.Parse(tokenString, func(parsedToken *jwt.Token) (interface{}, error) {
-		if _, ok := parsedToken.Method.(*jwt.SigningMethodRSA); !ok {
-			logger.Error("unexpected signing method")
-			return nil, errUnexpectedSigningMethod
+	apiKey, err := t.apiKeyDataAccessor.GetAPIKeyByKeyHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			return 0, time.Time{}, errInvalidAPIKey
 		}
+		return 0, time.Time{}, err
+	}
+	logger = logger.With(zap.Uint64("api_key_id", apiKey.ID))
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		logger.Warn("expired api key used")
+		return 0, time.Time{}, errAPIKeyExpired
+	}
+	account, err := t.getEnabledAccount(ctx, apiKey.OfAccountID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	// The last used time is only kept up to apiKeyLastUsedAtUpdateInterval, to avoid a write on every request.
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedAtUpdateInterval {
+		if updateErr := t.apiKeyDataAccessor.UpdateAPIKeyLastUsedAt(ctx, apiKey.ID, now); updateErr != nil {
+			logger.With(zap.Error(updateErr)).Warn("failed to update api key last used at")
+		}
+	}
+	expireTime := time.Time{}
+	if apiKey.ExpiresAt != nil {
+		expireTime = *apiKey.ExpiresAt
+	}
+	return account.ID, expireTime, nil
+}
+
+// tokenClaims are the claims of a session token. TokenID is the jti claim, and Version is the token version of the
+// account when the token was issued.
+type tokenClaims struct {
+	AccountID  uint64
+	ExpireTime time.Time
+	TokenID    string
+	Version    uint64
+}
+
+// parseToken checks the signature and the expiry of a session token, but not whether it has been revoked.
+func (t token) parseToken(ctx context.Context, tokenString string) (tokenClaims, error) {
+	logger := utils.LoggerWithContext(ctx, t.logger)
+
+	parsedToken, err := jwt.Parse(tokenString, func(parsedToken *jwt.Token) (interface{}, error) {
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if !ok {
 			logger.Error("cannot get token's claims")
@@ -153,50 +347,200 @@ func (t token) GetAccountIDAndExpireTime(ctx context.Context, tokenString string
 			logger.Error("cannot get token's kid claim")
 			return nil, errCannotGetTokensKidClaim
 		}
-		return t.getJWTPublicKey(ctx, uint64(tokenPublicKeyID))
+		publicKey, err := t.getJWTPublicKey(ctx, uint64(tokenPublicKeyID))
+		if err != nil {
+			return nil, err
+		}
+		if !isSigningMethodOfPublicKey(parsedToken.Method, publicKey) {
+			logger.Error("unexpected signing method")
+			return nil, errUnexpectedSigningMethod
+		}
+		return publicKey, nil
 	})
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to parse token")
-		return 0, time.Time{}, errInvalidToken
+		return tokenClaims{}, errInvalidToken
 	}
 	if !parsedToken.Valid {
 		logger.Error("invalid token")
-		return 0, time.Time{}, errInvalidToken
+		return tokenClaims{}, errInvalidToken
 	}
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	if !ok {
 		logger.Error("cannot get token's claims")
-		return 0, time.Time{}, errCannotGetTokensClaims
+		return tokenClaims{}, errCannotGetTokensClaims
 	}
 	accountID, ok := claims["sub"].(float64)
 	if !ok {
 		logger.Error("cannot get token's sub claim")
-		return 0, time.Time{}, errCannotGetTokensSubClaim
+		return tokenClaims{}, errCannotGetTokensSubClaim
 	}
 	expireTimeUnix, ok := claims["exp"].(float64)
 	if !ok {
 		logger.Error("cannot get token's exp claim")
-		return 0, time.Time{}, errCannotGetTokensExpClaim
+		return tokenClaims{}, errCannotGetTokensExpClaim
 	}
-	return uint64(accountID), time.Unix(int64(expireTimeUnix), 0), nil
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		logger.Error("cannot get token's jti claim")
+		return tokenClaims{}, errCannotGetTokensJTIClaim
+	}
+	version, ok := claims["ver"].(float64)
+	if !ok {
+		logger.Error("cannot get token's ver claim")
+		return tokenClaims{}, errCannotGetTokensVerClaim
+	}
+	return tokenClaims{
+		AccountID:  uint64(accountID),
+		ExpireTime: time.Unix(int64(expireTimeUnix), 0),
+		TokenID:    tokenID,
+		Version:    uint64(version),
+	}, nil
+}
+func (t token) GetAccountIDAndExpireTime(ctx context.Context, tokenString string) (uint64, time.Time, error) {
+	if isAPIKey(tokenString) {
+		return t.getAPIKeyAccountIDAndExpireTime(ctx, tokenString)
+	}
+	claims, err := t.parseToken(ctx, tokenString)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	revoked, err := t.revokedTokenCache.Has(ctx, claims.TokenID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if revoked {
+		return 0, time.Time{}, errTokenRevoked
+	}
+	account, err := t.getEnabledAccount(ctx, claims.AccountID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if claims.Version != account.TokenVersion {
+		return 0, time.Time{}, errTokenRevoked
+	}
+	return account.ID, claims.ExpireTime, nil
+}
+func (t token) RefreshToken(ctx context.Context, tokenString string) (string, time.Time, error) {
+	if isAPIKey(tokenString) {
+		return "", time.Time{}, nil
+	}
+	// The expiry is checked first, so that the revocation checks are only done for the tokens to refresh.
+	claims, err := t.parseToken(ctx, tokenString)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if time.Until(claims.ExpireTime) > t.regenerateBeforeExpiry {
+		return "", time.Time{}, nil
+	}
+	accountID, _, err := t.GetAccountIDAndExpireTime(ctx, tokenString)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return t.GetToken(ctx, accountID)
+}
+func (t token) RevokeToken(ctx context.Context, tokenString string) error {
+	claims, err := t.parseToken(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+	return t.revokedTokenCache.Add(ctx, claims.TokenID, time.Until(claims.ExpireTime))
+}
+func (t token) RevokeAllTokensOfAccount(ctx context.Context, accountID uint64) error {
+	return t.accountDataAccessor.IncreaseAccountTokenVersion(ctx, accountID)
 }
 func (t token) GetToken(ctx context.Context, accountID uint64) (string, time.Time, error) {
 	logger := utils.LoggerWithContext(ctx, t.logger)
 
+	account, err := t.accountDataAccessor.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signingKey, err := t.getSigningKey(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to get signing key")
+		return "", time.Time{}, errFailedToSignToken
+	}
 	expireTime := time.Now().Add(t.expiresIn)
-	token := jwt.NewWithClaims(jwt.SigningMethodRS512, jwt.MapClaims{
+	token := jwt.NewWithClaims(signingKey.method, jwt.MapClaims{
 		"sub": accountID,
 		"exp": expireTime.Unix(),
-		"kid": t.tokenPublicKeyID,
+		"kid": signingKey.id,
+		"jti": uuid.NewString(),
+		"ver": account.TokenVersion,
 	})
-	tokenString, err := token.SignedString(t.privateKey)
+	// The kid header lets other services pick the key out of the JWKS.
+	token.Header["kid"] = strconv.FormatUint(signingKey.id, 10)
+	tokenString, err := token.SignedString(signingKey.privateKey)
 	if err != nil {
 		logger.With(zap.Error(err)).Error("failed to sign token")
 		return "", time.Time{}, errFailedToSignToken
 	}
 	return tokenString, expireTime, nil
 }
+func (t token) RotateSigningKey(ctx context.Context) error {
+	logger := utils.LoggerWithContext(ctx, t.logger)
+
+	now := time.Now()
+	tokenPublicKeyList, err := t.tokenPublicKeyDataAccessor.GetPublicKeyListSigningAfter(ctx, now)
+	if err != nil {
+		return err
+	}
+	var latestTokenPublicKey *database.TokenPublicKey
+	for i := len(tokenPublicKeyList) - 1; i >= 0 && latestTokenPublicKey == nil; i-- {
+		if _, ok := t.decryptSigningKey(ctx, tokenPublicKeyList[i]); ok {
+			latestTokenPublicKey = &tokenPublicKeyList[i]
+		}
+	}
+	switch {
+	case latestTokenPublicKey == nil:
+		if _, err := t.createSigningKey(ctx, now); err != nil {
+			return err
+		}
+	case latestTokenPublicKey.Algorithm != t.algorithm:
+		// The configured algorithm changed, switch to it without waiting for the current key to stop signing.
+		signingStartsAt := now.Add(t.rotationOverlap)
+		if latestTokenPublicKey.SigningEndsAt.Before(signingStartsAt) {
+			signingStartsAt = latestTokenPublicKey.SigningEndsAt
+		}
+		if _, err := t.createSigningKey(ctx, signingStartsAt); err != nil {
+			return err
+		}
+	case latestTokenPublicKey.SigningEndsAt.Sub(now) <= t.rotationOverlap:
+		if _, err := t.createSigningKey(ctx, latestTokenPublicKey.SigningEndsAt); err != nil {
+			return err
+		}
+	}
+	// A key retires once the last token it signed expires.
+	deletedCount, err := t.tokenPublicKeyDataAccessor.DeletePublicKeysSigningBefore(ctx, now.Add(-t.expiresIn))
+	if err != nil {
+		return err
+	}
+	if deletedCount > 0 {
+		logger.With(zap.Uint64("deleted_count", deletedCount)).Info("deleted retired signing keys")
+	}
+	return nil
+}
+func (t token) GetJSONWebKeySet(ctx context.Context) (JSONWebKeySet, error) {
+	logger := utils.LoggerWithContext(ctx, t.logger)
+
+	tokenPublicKeyList, err := t.tokenPublicKeyDataAccessor.GetPublicKeyListSigningAfter(ctx, time.Now().Add(-t.expiresIn))
+	if err != nil {
+		return JSONWebKeySet{}, err
+	}
+	jsonWebKeySet := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(tokenPublicKeyList))}
+	for _, tokenPublicKey := range tokenPublicKeyList {
+		jsonWebKey, err := newJSONWebKey(tokenPublicKey)
+		if err != nil {
+			logger.With(zap.Error(err)).With(zap.Uint64("id", tokenPublicKey.ID)).Warn("failed to convert public key to jwk")
+			continue
+		}
+		jsonWebKeySet.Keys = append(jsonWebKeySet.Keys, jsonWebKey)
+	}
+	return jsonWebKeySet, nil
+}
 func (t token) WithDatabase(database database.Database) Token {
 	t.accountDataAccessor = t.accountDataAccessor.WithDatabase(database)
+	t.apiKeyDataAccessor = t.apiKeyDataAccessor.WithDatabase(database)
 	return t
 }
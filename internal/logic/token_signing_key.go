@@ -0,0 +1,243 @@
+package logic
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"GoLoad/internal/dataaccess/database"
+)
+
+const (
+	defaultTokenSigningAlgorithm          = "RS256"
+	rsaTokenSigningKeyBitCount            = 2048
+	tokenSigningKeyEncryptionKeyByteCount = 32
+	// tokenSigningKeyReloadInterval bounds how long a replica keeps signing with a key after a newer one started
+	// signing, e.g. after the configured algorithm changed.
+	tokenSigningKeyReloadInterval = time.Minute
+	jsonWebKeyUseSignature        = "sig"
+)
+
+var (
+	errUnsupportedTokenSigningAlgorithm    = errors.New("unsupported token signing algorithm")
+	errInvalidTokenSigningKeyEncryptionKey = fmt.Errorf(
+		"token signing key encryption key must be %d base64 encoded bytes", tokenSigningKeyEncryptionKeyByteCount)
+	errMissingTokenSigningKeyEncryptionKey = errors.New(
+		"token signing key encryption key is not configured, set generate_signing_key_encryption_key for local development")
+	errInvalidEncryptedTokenSigningKey = errors.New("invalid encrypted token signing key")
+	errInvalidTokenPublicKey           = errors.New("invalid token public key")
+)
+
+type tokenSigningAlgorithm struct {
+	method      jwt.SigningMethod
+	generateKey func() (crypto.Signer, error)
+}
+
+var tokenSigningAlgorithmMap = map[string]tokenSigningAlgorithm{
+	"RS256": {
+		method: jwt.SigningMethodRS256,
+		generateKey: func() (crypto.Signer, error) {
+			return rsa.GenerateKey(rand.Reader, rsaTokenSigningKeyBitCount)
+		},
+	},
+	"RS512": {
+		method: jwt.SigningMethodRS512,
+		generateKey: func() (crypto.Signer, error) {
+			return rsa.GenerateKey(rand.Reader, rsaTokenSigningKeyBitCount)
+		},
+	},
+	"ES256": {
+		method: jwt.SigningMethodES256,
+		generateKey: func() (crypto.Signer, error) {
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		},
+	},
+	"EdDSA": {
+		method: jwt.SigningMethodEdDSA,
+		generateKey: func() (crypto.Signer, error) {
+			_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			return privateKey, err
+		},
+	},
+}
+
+func getTokenSigningAlgorithm(algorithm string) (tokenSigningAlgorithm, error) {
+	signingAlgorithm, ok := tokenSigningAlgorithmMap[algorithm]
+	if !ok {
+		return tokenSigningAlgorithm{}, fmt.Errorf("%w: %s", errUnsupportedTokenSigningAlgorithm, algorithm)
+	}
+	return signingAlgorithm, nil
+}
+
+// isSigningMethodOfPublicKey keeps a token from being verified with a key of another kind than the one it claims to
+// be signed with.
+func isSigningMethodOfPublicKey(method jwt.SigningMethod, publicKey crypto.PublicKey) bool {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodRSA)
+		return ok
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	default:
+		return false
+	}
+}
+
+func pemEncodeTokenPublicKey(publicKey crypto.PublicKey) ([]byte, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	}), nil
+}
+func pemDecodeTokenPublicKey(publicKeyBytes []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyBytes)
+	if block == nil {
+		return nil, errInvalidTokenPublicKey
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// parseTokenSigningKeyEncryptionKey returns nil if no encryption key is configured.
+func parseTokenSigningKeyEncryptionKey(encodedEncryptionKey string) ([]byte, error) {
+	if encodedEncryptionKey == "" {
+		return nil, nil
+	}
+	encryptionKey, err := base64.StdEncoding.DecodeString(encodedEncryptionKey)
+	if err != nil || len(encryptionKey) != tokenSigningKeyEncryptionKeyByteCount {
+		return nil, errInvalidTokenSigningKeyEncryptionKey
+	}
+	return encryptionKey, nil
+}
+func newTokenSigningKeyAEAD(encryptionKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptTokenSigningKey seals the PKCS #8 encoding of the private key with AES-GCM, prefixed by its nonce.
+func encryptTokenSigningKey(encryptionKey []byte, privateKey crypto.Signer) ([]byte, error) {
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newTokenSigningKeyAEAD(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, privateKeyBytes, nil), nil
+}
+func decryptTokenSigningKey(encryptionKey []byte, encryptedPrivateKey []byte) (crypto.Signer, error) {
+	aead, err := newTokenSigningKeyAEAD(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(encryptedPrivateKey) < aead.NonceSize() {
+		return nil, errInvalidEncryptedTokenSigningKey
+	}
+	nonce, sealedPrivateKey := encryptedPrivateKey[:aead.NonceSize()], encryptedPrivateKey[aead.NonceSize():]
+	privateKeyBytes, err := aead.Open(nil, nonce, sealedPrivateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errInvalidEncryptedTokenSigningKey
+	}
+	return signer, nil
+}
+
+// tokenSigningKey is the key new tokens are signed with.
+type tokenSigningKey struct {
+	id            uint64
+	method        jwt.SigningMethod
+	privateKey    crypto.Signer
+	signingEndsAt time.Time
+}
+
+// tokenSigningKeyHolder is shared by the copies of token made by WithDatabase.
+type tokenSigningKeyHolder struct {
+	mutex      sync.Mutex
+	signingKey *tokenSigningKey
+	reloadAt   time.Time
+}
+
+// JSONWebKey is the public part of a token signing key, as described in RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func newJSONWebKey(tokenPublicKey database.TokenPublicKey) (JSONWebKey, error) {
+	publicKey, err := pemDecodeTokenPublicKey(tokenPublicKey.PublicKey)
+	if err != nil {
+		return JSONWebKey{}, err
+	}
+	jsonWebKey := JSONWebKey{
+		KeyID:     strconv.FormatUint(tokenPublicKey.ID, 10),
+		Use:       jsonWebKeyUseSignature,
+		Algorithm: tokenPublicKey.Algorithm,
+	}
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		jsonWebKey.KeyType = "RSA"
+		jsonWebKey.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jsonWebKey.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		coordinateByteCount := (publicKey.Curve.Params().BitSize + 7) / 8
+		jsonWebKey.KeyType = "EC"
+		jsonWebKey.Curve = publicKey.Curve.Params().Name
+		jsonWebKey.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, coordinateByteCount)))
+		jsonWebKey.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, coordinateByteCount)))
+	case ed25519.PublicKey:
+		jsonWebKey.KeyType = "OKP"
+		jsonWebKey.Curve = "Ed25519"
+		jsonWebKey.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JSONWebKey{}, errInvalidTokenPublicKey
+	}
+	return jsonWebKey, nil
+}
@@ -0,0 +1,337 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"GoLoad/internal/configs"
+	"GoLoad/internal/utils"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errURLSchemeNotAllowed  = errors.New("url scheme is not allowed")
+	errURLHostNotAllowed    = errors.New("url host is not allowed")
+	errAddressNotAllowed    = errors.New("address is not allowed")
+	errTooManyURLRedirects  = errors.New("too many redirects")
+	errURLHostNotResolvable = status.Error(codes.InvalidArgument, "failed to resolve url host")
+)
+
+// nonPublicNetworkList are the networks not covered by the netip.Addr predicates that should not be reachable from a
+// download task either.
+var nonPublicNetworkList = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// URLPolicy keeps download tasks from reaching the hosts and addresses they should not, such as the internal
+// services next to the server.
+type URLPolicy interface {
+	// ValidateURL checks the scheme and host of the url and the addresses the host resolves to.
+	ValidateURL(ctx context.Context, rawURL string) error
+	// ValidateProxyURL checks a proxy the same way, against the allowed proxy schemes.
+	ValidateProxyURL(ctx context.Context, rawURL string) error
+	// HTTPClient checks every request and redirect against the policy, and the address of every connection, since
+	// the host may resolve to another address by the time the download starts. Requests go through the proxy set
+	// with contextWithProxyURL, if any, in which case the proxy resolves the host instead.
+	HTTPClient() *http.Client
+}
+type urlPolicy struct {
+	allowedSchemeSet      map[string]bool
+	allowedProxySchemeSet map[string]bool
+	allowedHostList       []string
+	deniedHostList        []string
+	allowPrivateNetworks  bool
+	allowedNetworkList    []netip.Prefix
+	deniedNetworkList     []netip.Prefix
+	httpClient            *http.Client
+	logger                *zap.Logger
+}
+
+func parseNetworkList(networkList []string) ([]netip.Prefix, error) {
+	prefixList := make([]netip.Prefix, 0, len(networkList))
+	for _, network := range networkList {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %s: %w", network, err)
+		}
+		prefixList = append(prefixList, prefix.Masked())
+	}
+	return prefixList, nil
+}
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+func NewURLPolicy(downloadConfig configs.Download, logger *zap.Logger) (URLPolicy, error) {
+	policyConfig := downloadConfig.URLPolicy
+	allowedNetworkList, err := parseNetworkList(policyConfig.AllowedNetworks)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse allowed_networks")
+		return nil, err
+	}
+	deniedNetworkList, err := parseNetworkList(policyConfig.DeniedNetworks)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("failed to parse denied_networks")
+		return nil, err
+	}
+	policy := &urlPolicy{
+		allowedSchemeSet:      make(map[string]bool, len(policyConfig.AllowedSchemes)),
+		allowedProxySchemeSet: make(map[string]bool, len(policyConfig.AllowedProxySchemes)),
+		allowedHostList:       make([]string, 0, len(policyConfig.AllowedHosts)),
+		deniedHostList:        make([]string, 0, len(policyConfig.DeniedHosts)),
+		allowPrivateNetworks:  policyConfig.AllowPrivateNetworks,
+		allowedNetworkList:    allowedNetworkList,
+		deniedNetworkList:     deniedNetworkList,
+		logger:                logger,
+	}
+	for _, scheme := range policyConfig.AllowedSchemes {
+		policy.allowedSchemeSet[strings.ToLower(scheme)] = true
+	}
+	for _, scheme := range policyConfig.AllowedProxySchemes {
+		policy.allowedProxySchemeSet[strings.ToLower(scheme)] = true
+	}
+	for _, host := range policyConfig.AllowedHosts {
+		policy.allowedHostList = append(policy.allowedHostList, normalizeHost(host))
+	}
+	for _, host := range policyConfig.DeniedHosts {
+		policy.deniedHostList = append(policy.deniedHostList, normalizeHost(host))
+	}
+	if policy.httpClient, err = policy.newHTTPClient(downloadConfig.HTTPClient); err != nil {
+		logger.With(zap.Error(err)).Error("failed to create download http client")
+		return nil, err
+	}
+	return policy, nil
+}
+
+func isHostInList(hostList []string, host string) bool {
+	hostAddr, hostAddrErr := netip.ParseAddr(host)
+	for _, pattern := range hostList {
+		switch {
+		case strings.HasPrefix(pattern, "."):
+			if host == pattern[1:] || strings.HasSuffix(host, pattern) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if prefix, err := netip.ParsePrefix(pattern); err == nil && hostAddrErr == nil && prefix.Contains(hostAddr.Unmap()) {
+				return true
+			}
+		case host == pattern:
+			return true
+		}
+	}
+	return false
+}
+func isNetworkInList(networkList []netip.Prefix, addr netip.Addr) bool {
+	for _, network := range networkList {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+func isNonPublicAddress(addr netip.Addr) bool {
+	return addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() ||
+		isNetworkInList(nonPublicNetworkList, addr)
+}
+func validateScheme(allowedSchemeSet map[string]bool, scheme string) error {
+	if !allowedSchemeSet[strings.ToLower(scheme)] {
+		return fmt.Errorf("%w: %s", errURLSchemeNotAllowed, scheme)
+	}
+	return nil
+}
+func (u urlPolicy) validateHost(host string) error {
+	host = normalizeHost(host)
+	if host == "" || isHostInList(u.deniedHostList, host) {
+		return errURLHostNotAllowed
+	}
+	if len(u.allowedHostList) > 0 && !isHostInList(u.allowedHostList, host) {
+		return errURLHostNotAllowed
+	}
+	return nil
+}
+func (u urlPolicy) validateAddress(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if isNetworkInList(u.deniedNetworkList, addr) {
+		return fmt.Errorf("%w: %s", errAddressNotAllowed, addr)
+	}
+	if isNetworkInList(u.allowedNetworkList, addr) || u.allowPrivateNetworks {
+		return nil
+	}
+	if isNonPublicAddress(addr) {
+		return fmt.Errorf("%w: %s", errAddressNotAllowed, addr)
+	}
+	return nil
+}
+func (u urlPolicy) validateRequestURL(requestURL *url.URL) error {
+	if err := validateScheme(u.allowedSchemeSet, requestURL.Scheme); err != nil {
+		return err
+	}
+	return u.validateHost(requestURL.Hostname())
+}
+func (u urlPolicy) validateURL(ctx context.Context, rawURL string, allowedSchemeSet map[string]bool) error {
+	logger := utils.LoggerWithContext(ctx, u.logger).With(zap.String("url", rawURL))
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || !parsedURL.IsAbs() {
+		return status.Error(codes.InvalidArgument, "invalid url")
+	}
+	if err = validateScheme(allowedSchemeSet, parsedURL.Scheme); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if parsedURL.Host == "" {
+		return status.Error(codes.InvalidArgument, "invalid url")
+	}
+	if err = u.validateHost(parsedURL.Hostname()); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	host := parsedURL.Hostname()
+	addrList := make([]netip.Addr, 0)
+	if addr, parseErr := netip.ParseAddr(host); parseErr == nil {
+		addrList = append(addrList, addr)
+	} else {
+		addrList, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil || len(addrList) == 0 {
+			logger.With(zap.Error(err)).Warn("failed to resolve url host")
+			return errURLHostNotResolvable
+		}
+	}
+	for _, addr := range addrList {
+		if err = u.validateAddress(addr); err != nil {
+			return status.Error(codes.InvalidArgument, "url host resolves to an address that is not allowed")
+		}
+	}
+	return nil
+}
+func (u urlPolicy) ValidateURL(ctx context.Context, rawURL string) error {
+	return u.validateURL(ctx, rawURL, u.allowedSchemeSet)
+}
+func (u urlPolicy) ValidateProxyURL(ctx context.Context, rawURL string) error {
+	return u.validateURL(ctx, rawURL, u.allowedProxySchemeSet)
+}
+
+// controlDial runs right before connecting, once the address is resolved, so it also catches hosts that resolve to
+// a different address than when they were validated.
+func (u urlPolicy) controlDial(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errAddressNotAllowed, address)
+	}
+	return u.validateAddress(addrPort.Addr())
+}
+
+// urlPolicyRoundTripper checks the url of each request, including the ones made to follow redirects.
+type urlPolicyRoundTripper struct {
+	policy    urlPolicy
+	transport http.RoundTripper
+}
+
+func (u urlPolicyRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := u.policy.validateRequestURL(request.URL); err != nil {
+		return nil, err
+	}
+	return u.transport.RoundTrip(request)
+}
+
+type proxyURLContextKey struct{}
+
+// contextWithProxyURL makes the requests of the HTTP client of URLPolicy go through the proxy. The proxy is expected
+// to be checked with ValidateProxyURL first, its address is checked again on connect.
+func contextWithProxyURL(ctx context.Context, proxyURL *url.URL) context.Context {
+	return context.WithValue(ctx, proxyURLContextKey{}, proxyURL)
+}
+
+// getRequestProxyURL ignores the proxies of the environment, only the proxy of the request is used.
+func getRequestProxyURL(request *http.Request) (*url.URL, error) {
+	proxyURL, _ := request.Context().Value(proxyURLContextKey{}).(*url.URL)
+	return proxyURL, nil
+}
+
+// readTimeoutConn fails a read once nothing has been received for readTimeout, so that a stalled download does not
+// hold on to its worker.
+type readTimeoutConn struct {
+	net.Conn
+	readTimeout time.Duration
+}
+
+func (r readTimeoutConn) Read(p []byte) (int, error) {
+	if err := r.Conn.SetReadDeadline(time.Now().Add(r.readTimeout)); err != nil {
+		return 0, err
+	}
+	return r.Conn.Read(p)
+}
+
+func (u urlPolicy) newHTTPClient(httpClientConfig configs.HTTPClient) (*http.Client, error) {
+	connectTimeout, err := httpClientConfig.GetConnectTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	tlsHandshakeTimeout, err := httpClientConfig.GetTLSHandshakeTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	responseHeaderTimeout, err := httpClientConfig.GetResponseHeaderTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	readTimeout, err := httpClientConfig.GetReadTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	idleConnTimeout, err := httpClientConfig.GetIdleConnTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   u.controlDial,
+	}
+	transport, _ := http.DefaultTransport.(*http.Transport)
+	transport = transport.Clone()
+	transport.Proxy = getRequestProxyURL
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil || readTimeout <= 0 {
+			return conn, err
+		}
+		return readTimeoutConn{Conn: conn, readTimeout: readTimeout}, nil
+	}
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.MaxIdleConnsPerHost = httpClientConfig.MaxIdleConnsPerHost
+	return &http.Client{
+		Transport: urlPolicyRoundTripper{
+			policy:    u,
+			transport: transport,
+		},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= httpClientConfig.MaxRedirects {
+				return errTooManyURLRedirects
+			}
+			return u.validateRequestURL(request.URL)
+		},
+	}, nil
+}
+func (u urlPolicy) HTTPClient() *http.Client {
+	return u.httpClient
+}
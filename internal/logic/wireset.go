@@ -9,4 +9,16 @@ var WireSet = wire.NewSet(
 	NewHash,
 	NewToken,
 	NewDownloadTask,
+	NewDownloadTaskShareLink,
+	NewPostProcessor,
+	NewMalwareScanner,
+	NewHealth,
+	NewAdmin,
+	NewAuthorization,
+	NewWorkspace,
+	NewAPIKey,
+	NewOIDC,
+	NewRateLimit,
+	NewURLPolicy,
+	NewHTTPRequestOptions,
 )
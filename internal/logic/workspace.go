@@ -0,0 +1,335 @@
+package logic
+
+import (
+	"GoLoad/internal/dataaccess/database"
+	"GoLoad/internal/generated/grpc/go_load"
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxWorkspaceNameLength = 256
+)
+
+var (
+	errInvalidWorkspaceName         = status.Error(codes.InvalidArgument, "workspace name must be between 1 and 256 characters")
+	errInvalidWorkspaceRole         = status.Error(codes.InvalidArgument, "invalid workspace role")
+	errWorkspaceMemberAlreadyExists = status.Error(codes.AlreadyExists, "account is already a member of the workspace")
+	errWorkspaceLastOwner           = status.Error(codes.FailedPrecondition, "the last owner of a workspace cannot be removed or demoted")
+)
+
+type CreateWorkspaceParams struct {
+	Token         string
+	WorkspaceName string
+}
+type GetWorkspaceListParams struct {
+	Token string
+}
+type GetWorkspaceMemberListParams struct {
+	Token       string
+	WorkspaceID uint64
+}
+type AddWorkspaceMemberParams struct {
+	Token       string
+	WorkspaceID uint64
+	AccountName string
+	Role        go_load.WorkspaceRole
+}
+type UpdateWorkspaceMemberParams struct {
+	Token       string
+	WorkspaceID uint64
+	AccountID   uint64
+	Role        go_load.WorkspaceRole
+}
+type RemoveWorkspaceMemberParams struct {
+	Token       string
+	WorkspaceID uint64
+	AccountID   uint64
+}
+
+// Workspace manages workspaces, which share their download tasks between their members. Every workspace keeps at
+// least one owner.
+type Workspace interface {
+	// CreateWorkspace creates a workspace whose only member is the account of the token, as an owner.
+	CreateWorkspace(ctx context.Context, params CreateWorkspaceParams) (*go_load.Workspace, error)
+	GetWorkspaceList(ctx context.Context, params GetWorkspaceListParams) ([]*go_load.Workspace, error)
+	GetWorkspaceMemberList(ctx context.Context, params GetWorkspaceMemberListParams) ([]*go_load.WorkspaceMember, error)
+	AddWorkspaceMember(ctx context.Context, params AddWorkspaceMemberParams) (*go_load.WorkspaceMember, error)
+	UpdateWorkspaceMember(ctx context.Context, params UpdateWorkspaceMemberParams) (*go_load.WorkspaceMember, error)
+	// RemoveWorkspaceMember removes a member of the workspace. Members can always remove themselves.
+	RemoveWorkspaceMember(ctx context.Context, params RemoveWorkspaceMemberParams) error
+}
+type workspace struct {
+	goquDatabase                *goqu.Database
+	accountDataAccessor         database.AccountDataAccessor
+	workspaceDataAccessor       database.WorkspaceDataAccessor
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor
+	tokenLogic                  Token
+	authorizationLogic          Authorization
+	logger                      *zap.Logger
+}
+
+func NewWorkspace(
+	goquDatabase *goqu.Database,
+	accountDataAccessor database.AccountDataAccessor,
+	workspaceDataAccessor database.WorkspaceDataAccessor,
+	workspaceMemberDataAccessor database.WorkspaceMemberDataAccessor,
+	tokenLogic Token,
+	authorizationLogic Authorization,
+	logger *zap.Logger,
+) Workspace {
+	return &workspace{
+		goquDatabase:                goquDatabase,
+		accountDataAccessor:         accountDataAccessor,
+		workspaceDataAccessor:       workspaceDataAccessor,
+		workspaceMemberDataAccessor: workspaceMemberDataAccessor,
+		tokenLogic:                  tokenLogic,
+		authorizationLogic:          authorizationLogic,
+		logger:                      logger,
+	}
+}
+
+func databaseWorkspaceToProtoWorkspace(workspace database.Workspace, role go_load.WorkspaceRole) *go_load.Workspace {
+	return &go_load.Workspace{
+		Id:            workspace.ID,
+		WorkspaceName: workspace.WorkspaceName,
+		Role:          role,
+		CreateTime:    getProtoTimestamp(&workspace.CreatedAt),
+	}
+}
+func databaseWorkspaceMemberToProtoWorkspaceMember(
+	member database.WorkspaceMember,
+	account database.Account,
+) *go_load.WorkspaceMember {
+	return &go_load.WorkspaceMember{
+		Account: databaseAccountToProtoAccount(account),
+		Role:    member.Role,
+	}
+}
+func validateWorkspaceRole(role go_load.WorkspaceRole) error {
+	if role != go_load.WorkspaceRole_Viewer &&
+		role != go_load.WorkspaceRole_Downloader &&
+		role != go_load.WorkspaceRole_Owner {
+		return errInvalidWorkspaceRole
+	}
+	return nil
+}
+
+func (w workspace) CreateWorkspace(ctx context.Context, params CreateWorkspaceParams) (*go_load.Workspace, error) {
+	nameLength := utf8.RuneCountInString(params.WorkspaceName)
+	if nameLength == 0 || nameLength > maxWorkspaceNameLength {
+		return nil, errInvalidWorkspaceName
+	}
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	workspace := database.Workspace{
+		WorkspaceName: params.WorkspaceName,
+		CreatedAt:     time.Now(),
+	}
+	txErr := w.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		workspaceID, createWorkspaceErr := w.workspaceDataAccessor.WithDatabase(td).CreateWorkspace(ctx, workspace)
+		if createWorkspaceErr != nil {
+			return createWorkspaceErr
+		}
+		workspace.ID = workspaceID
+		return w.workspaceMemberDataAccessor.WithDatabase(td).CreateWorkspaceMember(ctx, database.WorkspaceMember{
+			OfWorkspaceID: workspaceID,
+			OfAccountID:   accountID,
+			Role:          go_load.WorkspaceRole_Owner,
+		})
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	return databaseWorkspaceToProtoWorkspace(workspace, go_load.WorkspaceRole_Owner), nil
+}
+func (w workspace) GetWorkspaceList(ctx context.Context, params GetWorkspaceListParams) ([]*go_load.Workspace, error) {
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	workspaceList, err := w.workspaceDataAccessor.GetWorkspaceListOfAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(workspaceList, func(item database.WorkspaceOfAccount, _ int) *go_load.Workspace {
+		return databaseWorkspaceToProtoWorkspace(item.Workspace, item.Role)
+	}), nil
+}
+func (w workspace) GetWorkspaceMemberList(
+	ctx context.Context,
+	params GetWorkspaceMemberListParams,
+) ([]*go_load.WorkspaceMember, error) {
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.authorizationLogic.AuthorizeWorkspace(ctx, accountID, params.WorkspaceID, PermissionViewDownloadTask); err != nil {
+		return nil, err
+	}
+	memberList, err := w.workspaceMemberDataAccessor.GetWorkspaceMemberList(ctx, params.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	protoMemberList := make([]*go_load.WorkspaceMember, 0, len(memberList))
+	for _, member := range memberList {
+		account, getAccountErr := w.accountDataAccessor.GetAccountByID(ctx, member.OfAccountID)
+		if getAccountErr != nil {
+			return nil, getAccountErr
+		}
+		protoMemberList = append(protoMemberList, databaseWorkspaceMemberToProtoWorkspaceMember(member, account))
+	}
+	return protoMemberList, nil
+}
+
+// lockWorkspace locks the workspace for the rest of the transaction, then checks that the account has the
+// permission on it. Changes to the members of a workspace are serialized this way, so that its last owner cannot be
+// removed by concurrent requests.
+func (w workspace) lockWorkspace(
+	ctx context.Context,
+	td *goqu.TxDatabase,
+	accountID uint64,
+	workspaceID uint64,
+	permission Permission,
+) error {
+	if _, err := w.workspaceDataAccessor.WithDatabase(td).GetWorkspaceWithXLock(ctx, workspaceID); err != nil {
+		if errors.Is(err, database.ErrWorkspaceNotFound) {
+			return newPermissionDeniedError(permission)
+		}
+		return err
+	}
+	_, err := w.authorizationLogic.AuthorizeWorkspace(ctx, accountID, workspaceID, permission)
+	return err
+}
+
+// isLastWorkspaceOwner tells whether the member is the only owner of the workspace.
+func (w workspace) isLastWorkspaceOwner(ctx context.Context, td *goqu.TxDatabase, member database.WorkspaceMember) (bool, error) {
+	if member.Role != go_load.WorkspaceRole_Owner {
+		return false, nil
+	}
+	memberList, err := w.workspaceMemberDataAccessor.WithDatabase(td).GetWorkspaceMemberList(ctx, member.OfWorkspaceID)
+	if err != nil {
+		return false, err
+	}
+	ownerCount := lo.CountBy(memberList, func(item database.WorkspaceMember) bool {
+		return item.Role == go_load.WorkspaceRole_Owner
+	})
+	return ownerCount <= 1, nil
+}
+func (w workspace) AddWorkspaceMember(ctx context.Context, params AddWorkspaceMemberParams) (*go_load.WorkspaceMember, error) {
+	if err := validateWorkspaceRole(params.Role); err != nil {
+		return nil, err
+	}
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	member := database.WorkspaceMember{
+		OfWorkspaceID: params.WorkspaceID,
+		Role:          params.Role,
+	}
+	var account database.Account
+	txErr := w.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		if lockErr := w.lockWorkspace(ctx, td, accountID, params.WorkspaceID, PermissionManageWorkspaceMember); lockErr != nil {
+			return lockErr
+		}
+		var getAccountErr error
+		account, getAccountErr = w.accountDataAccessor.WithDatabase(td).GetAccountByAccountName(ctx, params.AccountName)
+		if getAccountErr != nil {
+			return getAccountErr
+		}
+		member.OfAccountID = account.ID
+		_, getMemberErr := w.workspaceMemberDataAccessor.WithDatabase(td).GetWorkspaceMember(ctx, params.WorkspaceID, account.ID)
+		if getMemberErr == nil {
+			return errWorkspaceMemberAlreadyExists
+		}
+		if !errors.Is(getMemberErr, database.ErrWorkspaceMemberNotFound) {
+			return getMemberErr
+		}
+		return w.workspaceMemberDataAccessor.WithDatabase(td).CreateWorkspaceMember(ctx, member)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	return databaseWorkspaceMemberToProtoWorkspaceMember(member, account), nil
+}
+func (w workspace) UpdateWorkspaceMember(
+	ctx context.Context,
+	params UpdateWorkspaceMemberParams,
+) (*go_load.WorkspaceMember, error) {
+	if err := validateWorkspaceRole(params.Role); err != nil {
+		return nil, err
+	}
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return nil, err
+	}
+	var member database.WorkspaceMember
+	txErr := w.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		if lockErr := w.lockWorkspace(ctx, td, accountID, params.WorkspaceID, PermissionManageWorkspaceMember); lockErr != nil {
+			return lockErr
+		}
+		var getMemberErr error
+		member, getMemberErr = w.workspaceMemberDataAccessor.WithDatabase(td).GetWorkspaceMember(ctx, params.WorkspaceID, params.AccountID)
+		if getMemberErr != nil {
+			return getMemberErr
+		}
+		if params.Role != go_load.WorkspaceRole_Owner {
+			isLastOwner, isLastOwnerErr := w.isLastWorkspaceOwner(ctx, td, member)
+			if isLastOwnerErr != nil {
+				return isLastOwnerErr
+			}
+			if isLastOwner {
+				return errWorkspaceLastOwner
+			}
+		}
+		member.Role = params.Role
+		return w.workspaceMemberDataAccessor.WithDatabase(td).UpdateWorkspaceMember(ctx, member)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	account, err := w.accountDataAccessor.GetAccountByID(ctx, member.OfAccountID)
+	if err != nil {
+		return nil, err
+	}
+	return databaseWorkspaceMemberToProtoWorkspaceMember(member, account), nil
+}
+func (w workspace) RemoveWorkspaceMember(ctx context.Context, params RemoveWorkspaceMemberParams) error {
+	accountID, _, err := w.tokenLogic.GetAccountIDAndExpireTime(ctx, params.Token)
+	if err != nil {
+		return err
+	}
+	// Members only need to be able to see the workspace to leave it.
+	permission := PermissionManageWorkspaceMember
+	if params.AccountID == accountID {
+		permission = PermissionViewDownloadTask
+	}
+	return w.goquDatabase.WithTx(func(td *goqu.TxDatabase) error {
+		if lockErr := w.lockWorkspace(ctx, td, accountID, params.WorkspaceID, permission); lockErr != nil {
+			return lockErr
+		}
+		member, getMemberErr := w.workspaceMemberDataAccessor.WithDatabase(td).GetWorkspaceMember(ctx, params.WorkspaceID, params.AccountID)
+		if getMemberErr != nil {
+			return getMemberErr
+		}
+		isLastOwner, isLastOwnerErr := w.isLastWorkspaceOwner(ctx, td, member)
+		if isLastOwnerErr != nil {
+			return isLastOwnerErr
+		}
+		if isLastOwner {
+			return errWorkspaceLastOwner
+		}
+		return w.workspaceMemberDataAccessor.WithDatabase(td).DeleteWorkspaceMember(ctx, params.WorkspaceID, params.AccountID)
+	})
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeaderName is the HTTP header, gRPC metadata and Kafka message header carrying the request id.
+	RequestIDHeaderName = "X-Request-ID"
+	// maxRequestIDLength bounds request ids received from clients, longer ones are replaced.
+	maxRequestIDLength = 128
+)
+
+type contextKey int
+
+const (
+	contextKeyRequestID contextKey = iota
+	contextKeyAccountID
+	contextKeyDownloadTaskID
+)
+
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// IsValidRequestID reports whether a request id received from a client can be used as is. Request ids are logged,
+// so they are limited to printable ASCII characters.
+func IsValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithRequestID returns a context carrying the request id. The context also gets room for the id of the
+// account making the request, which is only known once its token is verified, see SetAccountIDOfContext.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, contextKeyRequestID, requestID)
+	return context.WithValue(ctx, contextKeyAccountID, new(atomic.Uint64))
+}
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKeyRequestID).(string)
+	return requestID
+}
+
+// SetAccountIDOfContext records the account making the request, so that it is logged by every logger created from
+// the request's context afterwards. It does nothing if the context was not created by ContextWithRequestID.
+func SetAccountIDOfContext(ctx context.Context, accountID uint64) {
+	if accountIDHolder, ok := ctx.Value(contextKeyAccountID).(*atomic.Uint64); ok {
+		accountIDHolder.Store(accountID)
+	}
+}
+func AccountIDFromContext(ctx context.Context) uint64 {
+	if accountIDHolder, ok := ctx.Value(contextKeyAccountID).(*atomic.Uint64); ok {
+		return accountIDHolder.Load()
+	}
+	return 0
+}
+func ContextWithDownloadTaskID(ctx context.Context, downloadTaskID uint64) context.Context {
+	return context.WithValue(ctx, contextKeyDownloadTaskID, downloadTaskID)
+}
+func DownloadTaskIDFromContext(ctx context.Context) uint64 {
+	downloadTaskID, _ := ctx.Value(contextKeyDownloadTaskID).(uint64)
+	return downloadTaskID
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"GoLoad/internal/configs"
@@ -42,7 +43,27 @@ func InitializeLogger(logConfig configs.Log) (*zap.Logger, func(), error) {
 	return logger, cleanup, err
 }
 
-func LoggerWithContext(_ context.Context, logger *zap.Logger) *zap.Logger {
-	//TODO: Add request ID to context
-	return logger
+// LoggerWithContext adds the request id, account id, download task id and trace id found in the context to the
+// logger.
+func LoggerWithContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if ctx == nil {
+		return logger
+	}
+	fieldList := make([]zap.Field, 0, 4)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fieldList = append(fieldList, zap.String("request_id", requestID))
+	}
+	if accountID := AccountIDFromContext(ctx); accountID != 0 {
+		fieldList = append(fieldList, zap.Uint64("account_id", accountID))
+	}
+	if downloadTaskID := DownloadTaskIDFromContext(ctx); downloadTaskID != 0 {
+		fieldList = append(fieldList, zap.Uint64("download_task_id", downloadTaskID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fieldList = append(fieldList, zap.String("trace_id", spanContext.TraceID().String()))
+	}
+	if len(fieldList) == 0 {
+		return logger
+	}
+	return logger.With(fieldList...)
 }
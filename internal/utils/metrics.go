@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+
+	"GoLoad/internal/configs"
+)
+
+const (
+	CacheNameTokenPublicKey   = "token_public_key"
+	CacheNameTakenAccountName = "taken_account_name"
+	CacheNameRevokedToken     = "revoked_token"
+	CacheNameOIDCLoginState   = "oidc_login_state"
+	CacheNameLoginAttempt     = "login_attempt"
+
+	metricsCacheResultHit  = "hit"
+	metricsCacheResultMiss = "miss"
+)
+
+type Metrics interface {
+	// Handler serves the metrics in the Prometheus exposition format.
+	Handler() http.Handler
+	ObserveGRPCRequest(method string, code codes.Code, duration time.Duration)
+	SetDownloadTaskCount(downloadStatus string, count uint64)
+	AddDownloadedBytes(downloadType string, byteCount uint64)
+	ObserveDownload(downloadType string, byteCount uint64, duration time.Duration)
+	SetExecutorConcurrencyLimit(concurrencyLimit int)
+	AddExecutorBusyWorkerCount(delta int)
+	SetExecutorWaitingTaskCount(waitingTaskCount int)
+	SetMQConsumerLag(queueName string, partition int32, lag int64)
+	IncMQConsumerHandlerErrorCount(queueName string)
+	ObserveStorageWrite(backend string, operation string, duration time.Duration)
+	IncCacheRequestCount(cacheName string, hit bool)
+}
+type metrics struct {
+	registry                      *prometheus.Registry
+	grpcRequestDurationSeconds    *prometheus.HistogramVec
+	grpcRequestErrorsTotal        *prometheus.CounterVec
+	downloadTasks                 *prometheus.GaugeVec
+	downloadedBytesTotal          *prometheus.CounterVec
+	downloadDurationSeconds       *prometheus.HistogramVec
+	downloadThroughputBytesPerSec *prometheus.HistogramVec
+	executorConcurrencyLimit      prometheus.Gauge
+	executorBusyWorkers           prometheus.Gauge
+	executorWaitingTasks          prometheus.Gauge
+	mqConsumerLag                 *prometheus.GaugeVec
+	mqConsumerHandlerErrorsTotal  *prometheus.CounterVec
+	storageWriteDurationSeconds   *prometheus.HistogramVec
+	cacheRequestsTotal            *prometheus.CounterVec
+}
+
+// NewMetrics creates the application metrics on a dedicated registry, along with the Go runtime and process
+// collectors. The metrics are always recorded, metricsConfig.Enabled only controls whether they are served.
+func NewMetrics(metricsConfig configs.Metrics) (Metrics, error) {
+	durationBuckets := metricsConfig.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+	throughputBuckets := metricsConfig.ThroughputBuckets
+	if len(throughputBuckets) == 0 {
+		throughputBuckets = prometheus.ExponentialBuckets(64*1024, 4, 8)
+	}
+	namespace := metricsConfig.Namespace
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		grpcRequestDurationSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of gRPC requests by method and status code.",
+			Buckets:   durationBuckets,
+		}, []string{"method", "code"}),
+		grpcRequestErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_errors_total",
+			Help:      "Number of gRPC requests that returned an error, by method and status code.",
+		}, []string{"method", "code"}),
+		downloadTasks: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "download_tasks",
+			Help:      "Number of download tasks by download status.",
+		}, []string{"download_status"}),
+		downloadedBytesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "download",
+			Name:      "bytes_total",
+			Help:      "Number of bytes downloaded by download type, including those of failed downloads.",
+		}, []string{"download_type"}),
+		downloadDurationSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "download",
+			Name:      "duration_seconds",
+			Help:      "Duration of successful downloads by download type.",
+			Buckets:   durationBuckets,
+		}, []string{"download_type"}),
+		downloadThroughputBytesPerSec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "download",
+			Name:      "throughput_bytes_per_second",
+			Help:      "Average throughput of successful downloads by download type.",
+			Buckets:   throughputBuckets,
+		}, []string{"download_type"}),
+		executorConcurrencyLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "executor",
+			Name:      "concurrency_limit",
+			Help:      "Maximum number of download tasks the executor runs concurrently.",
+		}),
+		executorBusyWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "executor",
+			Name:      "busy_workers",
+			Help:      "Number of executor workers currently running a download task.",
+		}),
+		executorWaitingTasks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "executor",
+			Name:      "waiting_tasks",
+			Help:      "Number of download tasks waiting for a free executor worker.",
+		}),
+		mqConsumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "mq_consumer",
+			Name:      "lag",
+			Help:      "Number of messages not consumed yet by queue and partition.",
+		}, []string{"queue", "partition"}),
+		mqConsumerHandlerErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mq_consumer",
+			Name:      "handler_errors_total",
+			Help:      "Number of messages the handler failed to process by queue.",
+		}, []string{"queue"}),
+		storageWriteDurationSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "write_duration_seconds",
+			Help:      "Duration of file writes by storage backend and operation.",
+			Buckets:   durationBuckets,
+		}, []string{"backend", "operation"}),
+		cacheRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Number of cache lookups by cache and result.",
+		}, []string{"cache", "result"}),
+	}
+	if err := registerCollectors(
+		m.registry,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.grpcRequestDurationSeconds,
+		m.grpcRequestErrorsTotal,
+		m.downloadTasks,
+		m.downloadedBytesTotal,
+		m.downloadDurationSeconds,
+		m.downloadThroughputBytesPerSec,
+		m.executorConcurrencyLimit,
+		m.executorBusyWorkers,
+		m.executorWaitingTasks,
+		m.mqConsumerLag,
+		m.mqConsumerHandlerErrorsTotal,
+		m.storageWriteDurationSeconds,
+		m.cacheRequestsTotal,
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func registerCollectors(registry *prometheus.Registry, collectorList ...prometheus.Collector) error {
+	for _, collector := range collectorList {
+		if err := registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+func (m metrics) ObserveGRPCRequest(method string, code codes.Code, duration time.Duration) {
+	m.grpcRequestDurationSeconds.WithLabelValues(method, code.String()).Observe(duration.Seconds())
+	if code != codes.OK {
+		m.grpcRequestErrorsTotal.WithLabelValues(method, code.String()).Inc()
+	}
+}
+func (m metrics) SetDownloadTaskCount(downloadStatus string, count uint64) {
+	m.downloadTasks.WithLabelValues(downloadStatus).Set(float64(count))
+}
+func (m metrics) AddDownloadedBytes(downloadType string, byteCount uint64) {
+	m.downloadedBytesTotal.WithLabelValues(downloadType).Add(float64(byteCount))
+}
+func (m metrics) ObserveDownload(downloadType string, byteCount uint64, duration time.Duration) {
+	m.downloadDurationSeconds.WithLabelValues(downloadType).Observe(duration.Seconds())
+	if duration > 0 {
+		m.downloadThroughputBytesPerSec.WithLabelValues(downloadType).Observe(float64(byteCount) / duration.Seconds())
+	}
+}
+func (m metrics) SetExecutorConcurrencyLimit(concurrencyLimit int) {
+	m.executorConcurrencyLimit.Set(float64(concurrencyLimit))
+}
+func (m metrics) AddExecutorBusyWorkerCount(delta int) {
+	m.executorBusyWorkers.Add(float64(delta))
+}
+func (m metrics) SetExecutorWaitingTaskCount(waitingTaskCount int) {
+	m.executorWaitingTasks.Set(float64(waitingTaskCount))
+}
+func (m metrics) SetMQConsumerLag(queueName string, partition int32, lag int64) {
+	m.mqConsumerLag.WithLabelValues(queueName, strconv.FormatInt(int64(partition), 10)).Set(float64(lag))
+}
+func (m metrics) IncMQConsumerHandlerErrorCount(queueName string) {
+	m.mqConsumerHandlerErrorsTotal.WithLabelValues(queueName).Inc()
+}
+func (m metrics) ObserveStorageWrite(backend string, operation string, duration time.Duration) {
+	m.storageWriteDurationSeconds.WithLabelValues(backend, operation).Observe(duration.Seconds())
+}
+func (m metrics) IncCacheRequestCount(cacheName string, hit bool) {
+	result := metricsCacheResultMiss
+	if hit {
+		result = metricsCacheResultHit
+	}
+	m.cacheRequestsTotal.WithLabelValues(cacheName, result).Inc()
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfterHeaderName is both the gRPC metadata and the HTTP header telling rejected clients how many seconds to
+// wait before retrying.
+const RetryAfterHeaderName = "retry-after"
+
+// GetRetryAfter returns the retry delay of the errdetails.RetryInfo of the error, in whole seconds rounded up.
+func GetRetryAfter(err error) (string, bool) {
+	for _, detail := range status.Convert(err).Details() {
+		retryInfo, ok := detail.(*errdetails.RetryInfo)
+		if !ok || retryInfo.GetRetryDelay() == nil {
+			continue
+		}
+		retryDelay := retryInfo.GetRetryDelay().AsDuration()
+		retryAfterSeconds := int64((retryDelay + time.Second - 1) / time.Second)
+		return strconv.FormatInt(max(retryAfterSeconds, 1), 10), true
+	}
+	return "", false
+}
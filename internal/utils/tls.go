@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"GoLoad/internal/configs"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errTLSCertificateMissing = errors.New("tls certificate and key files are required")
+	errNoCertificateInCAFile = errors.New("no certificate found in ca file")
+)
+
+// certificateReloader holds a certificate and its key, and loads them again once their files change. A failed reload
+// keeps the previous certificate until the next check, since the files may be caught halfway through being replaced.
+type certificateReloader struct {
+	certFile       string
+	keyFile        string
+	reloadInterval time.Duration
+	mutex          sync.Mutex
+	certificate    *tls.Certificate
+	certModTime    time.Time
+	keyModTime     time.Time
+	lastCheckTime  time.Time
+	logger         *zap.Logger
+}
+
+func newCertificateReloader(
+	certFile string,
+	keyFile string,
+	reloadInterval time.Duration,
+	logger *zap.Logger,
+) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile:       certFile,
+		keyFile:        keyFile,
+		reloadInterval: reloadInterval,
+		lastCheckTime:  time.Now(),
+		logger:         logger.With(zap.String("cert_file", certFile)).With(zap.String("key_file", keyFile)),
+	}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+func getFileModTime(name string) (time.Time, error) {
+	fileInfo, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fileInfo.ModTime(), nil
+}
+func (c *certificateReloader) load() error {
+	certModTime, err := getFileModTime(c.certFile)
+	if err != nil {
+		return err
+	}
+	keyModTime, err := getFileModTime(c.keyFile)
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+	c.certificate = &certificate
+	c.certModTime = certModTime
+	c.keyModTime = keyModTime
+	return nil
+}
+func (c *certificateReloader) getCertificate() *tls.Certificate {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if c.reloadInterval <= 0 || now.Sub(c.lastCheckTime) < c.reloadInterval {
+		return c.certificate
+	}
+	c.lastCheckTime = now
+	certModTime, certErr := getFileModTime(c.certFile)
+	keyModTime, keyErr := getFileModTime(c.keyFile)
+	if certErr != nil || keyErr != nil || (certModTime.Equal(c.certModTime) && keyModTime.Equal(c.keyModTime)) {
+		return c.certificate
+	}
+	if err := c.load(); err != nil {
+		c.logger.With(zap.Error(err)).Error("failed to reload tls certificate")
+		return c.certificate
+	}
+	c.logger.Info("reloaded tls certificate")
+	return c.certificate
+}
+
+func getTLSMinVersion(version configs.TLSVersion) (uint16, error) {
+	switch version {
+	case "", configs.TLSVersion12:
+		return tls.VersionTLS12, nil
+	case configs.TLSVersion13:
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version: %s", version)
+	}
+}
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	caBytes, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("%w: %s", errNoCertificateInCAFile, caFile)
+	}
+	return certPool, nil
+}
+
+// getReloadInterval returns zero, which disables reloading, if reloadInterval is not set.
+func getReloadInterval(reloadInterval string, getDuration func() (time.Duration, error)) (time.Duration, error) {
+	if reloadInterval == "" {
+		return 0, nil
+	}
+	return getDuration()
+}
+
+// NewServerTLSConfig returns nil if TLS is not enabled.
+func NewServerTLSConfig(tlsConfig configs.TLS, logger *zap.Logger) (*tls.Config, error) {
+	if !tlsConfig.Enabled {
+		return nil, nil
+	}
+	if tlsConfig.CertFile == "" || tlsConfig.KeyFile == "" {
+		return nil, errTLSCertificateMissing
+	}
+	minVersion, err := getTLSMinVersion(tlsConfig.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	reloadInterval, err := getReloadInterval(tlsConfig.ReloadInterval, tlsConfig.GetReloadIntervalDuration)
+	if err != nil {
+		return nil, err
+	}
+	reloader, err := newCertificateReloader(tlsConfig.CertFile, tlsConfig.KeyFile, reloadInterval, logger)
+	if err != nil {
+		return nil, err
+	}
+	serverTLSConfig := &tls.Config{
+		MinVersion: minVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return reloader.getCertificate(), nil
+		},
+	}
+	if tlsConfig.ClientCAFile != "" {
+		if serverTLSConfig.ClientCAs, err = loadCertPool(tlsConfig.ClientCAFile); err != nil {
+			return nil, err
+		}
+		serverTLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return serverTLSConfig, nil
+}
+
+// NewClientTLSConfig returns nil if TLS is not enabled.
+func NewClientTLSConfig(clientTLSConfig configs.ClientTLS, logger *zap.Logger) (*tls.Config, error) {
+	if !clientTLSConfig.Enabled {
+		return nil, nil
+	}
+	minVersion, err := getTLSMinVersion(clientTLSConfig.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: minVersion,
+		ServerName: clientTLSConfig.ServerName,
+		//nolint:gosec // Only skipped when explicitly configured, e.g. for self-signed certificates in development
+		InsecureSkipVerify: clientTLSConfig.InsecureSkipVerify,
+	}
+	if clientTLSConfig.CAFile != "" {
+		if tlsConfig.RootCAs, err = loadCertPool(clientTLSConfig.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if clientTLSConfig.CertFile != "" || clientTLSConfig.KeyFile != "" {
+		if clientTLSConfig.CertFile == "" || clientTLSConfig.KeyFile == "" {
+			return nil, errTLSCertificateMissing
+		}
+		reloadInterval, err := getReloadInterval(clientTLSConfig.ReloadInterval, clientTLSConfig.GetReloadIntervalDuration)
+		if err != nil {
+			return nil, err
+		}
+		reloader, err := newCertificateReloader(clientTLSConfig.CertFile, clientTLSConfig.KeyFile, reloadInterval, logger)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.getCertificate(), nil
+		}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"GoLoad/internal/configs"
+)
+
+const (
+	TracerName = "GoLoad"
+
+	tracerProviderShutdownTimeout = 5 * time.Second
+)
+
+func newSpanExporter(tracingConfig configs.Tracing) (sdktrace.SpanExporter, error) {
+	switch tracingConfig.Exporter {
+	case configs.TracingExporterOTLPGRPC:
+		options := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(tracingConfig.Endpoint),
+			otlptracegrpc.WithHeaders(tracingConfig.Headers),
+		}
+		if tracingConfig.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(context.Background(), options...)
+	case configs.TracingExporterOTLPHTTP:
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(tracingConfig.Endpoint),
+			otlptracehttp.WithHeaders(tracingConfig.Headers),
+		}
+		if tracingConfig.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	case configs.TracingExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %s", tracingConfig.Exporter)
+	}
+}
+
+// InitializeTracerProvider creates the tracer provider and sets it, along with the W3C trace context and baggage
+// propagators, as the global ones. When tracing is disabled, the provider does not record anything but the trace
+// context received from clients is still propagated.
+func InitializeTracerProvider(tracingConfig configs.Tracing, logger *zap.Logger) (trace.TracerProvider, func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !tracingConfig.Enabled {
+		tracerProvider := noop.NewTracerProvider()
+		otel.SetTracerProvider(tracerProvider)
+		return tracerProvider, func() {}, nil
+	}
+
+	spanExporter, err := newSpanExporter(tracingConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracingResource, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(tracingConfig.ServiceName)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(tracingResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConfig.SampleRatio))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracerProviderShutdownTimeout)
+		defer cancel()
+		if shutdownErr := tracerProvider.Shutdown(ctx); shutdownErr != nil {
+			logger.With(zap.Error(shutdownErr)).Error("failed to shutdown tracer provider")
+		}
+	}
+	return tracerProvider, cleanup, nil
+}
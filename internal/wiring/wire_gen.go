@@ -33,64 +33,176 @@ func InitializeStandaloneServer(configFilePath configs.ConfigFilePath) (*app.Sta
 		return nil, nil, err
 	}
 	configsDatabase := config.Database
+	tracing := config.Tracing
 	log := config.Log
 	logger, cleanup, err := utils.InitializeLogger(log)
 	if err != nil {
 		return nil, nil, err
 	}
-	db, cleanup2, err := database.InitializeAndMigrateUpDB(configsDatabase, logger)
+	tracerProvider, cleanup2, err := utils.InitializeTracerProvider(tracing, logger)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
+	db, cleanup3, err := database.InitializeAndMigrateUpDB(configsDatabase, tracerProvider, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
 	goquDatabase := database.InitializeGoquDB(db)
 	configsCache := config.Cache
-	client := cache.NewRedisClient(configsCache, logger)
-	takenAccountName := cache.NewTakenAccountName(client, logger)
+	client, err := cache.NewRedisClient(configsCache, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	metrics := config.Metrics
+	utilsMetrics, err := utils.NewMetrics(metrics)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	takenAccountName := cache.NewTakenAccountName(client, utilsMetrics, logger)
+	loginAttempt := cache.NewLoginAttempt(client, utilsMetrics, logger)
 	accountDataAccessor := database.NewAccountDataAccessor(goquDatabase, logger)
 	accountPasswordDataAccessor := database.NewAccountPasswordDataAccessor(goquDatabase, logger)
+	workspaceDataAccessor := database.NewWorkspaceDataAccessor(goquDatabase, logger)
+	workspaceMemberDataAccessor := database.NewWorkspaceMemberDataAccessor(goquDatabase, logger)
 	auth := config.Auth
-	hash := logic.NewHash(auth)
-	tokenPublicKey := cache.NewTokenPublicKey(client, logger)
+	hash, err := logic.NewHash(auth)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	apiKeyDataAccessor := database.NewAPIKeyDataAccessor(goquDatabase, logger)
+	tokenPublicKey := cache.NewTokenPublicKey(client, utilsMetrics, logger)
 	tokenPublicKeyDataAccessor := database.NewTokenPublicKeyDataAccessor(goquDatabase, logger)
-	token, err := logic.NewToken(accountDataAccessor, tokenPublicKey, tokenPublicKeyDataAccessor, auth, logger)
+	revokedToken := cache.NewRevokedToken(client, utilsMetrics, logger)
+	token, err := logic.NewToken(accountDataAccessor, apiKeyDataAccessor, tokenPublicKey, tokenPublicKeyDataAccessor, revokedToken, auth, logger)
 	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
-	account := logic.NewAccount(goquDatabase, takenAccountName, accountDataAccessor, accountPasswordDataAccessor, hash, token, logger)
+	authorization := logic.NewAuthorization(workspaceMemberDataAccessor, logger)
 	downloadTaskDataAccessor := database.NewDownloadTaskDataAccessor(goquDatabase, logger)
 	mq := config.MQ
-	producerClient, err := producer.NewClient(mq, logger)
+	producerClient, err := producer.NewClient(mq, tracerProvider, logger)
 	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
 	downloadTaskCreatedProducer := producer.NewDownloadTaskCreatedProducer(producerClient, logger)
 	download := config.Download
-	fileClient, err := file.NewClient(download, logger)
+	fileClient, err := file.NewClient(download, utilsMetrics, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	kms, err := file.NewKMS(download, logger)
 	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	encryptedClient, err := file.NewEncryptedClient(fileClient, kms, download, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	urlPolicy, err := logic.NewURLPolicy(download, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	postProcessor, err := logic.NewPostProcessor(encryptedClient, urlPolicy, download, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	malwareScanner, err := logic.NewMalwareScanner(encryptedClient, download, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	httpRequestOptions, err := logic.NewHTTPRequestOptions(urlPolicy, download, logger)
+	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
 	cron := config.Cron
-	downloadTask := logic.NewDownloadTask(token, accountDataAccessor, downloadTaskDataAccessor, downloadTaskCreatedProducer, goquDatabase, fileClient, cron, logger)
+	downloadTask := logic.NewDownloadTask(token, authorization, accountDataAccessor, downloadTaskDataAccessor, downloadTaskCreatedProducer, goquDatabase, fileClient, encryptedClient, postProcessor, malwareScanner, urlPolicy, httpRequestOptions, download, cron, utilsMetrics, tracerProvider, logger)
+	account := logic.NewAccount(goquDatabase, takenAccountName, loginAttempt, accountDataAccessor, accountPasswordDataAccessor, workspaceDataAccessor, workspaceMemberDataAccessor, hash, token, downloadTask, auth, logger)
+	downloadTaskShareLinkDataAccessor := database.NewDownloadTaskShareLinkDataAccessor(goquDatabase, logger)
+	shareLink := config.ShareLink
+	downloadTaskShareLink, err := logic.NewDownloadTaskShareLink(token, authorization, downloadTaskDataAccessor, downloadTaskShareLinkDataAccessor, fileClient, encryptedClient, shareLink, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	workspace := logic.NewWorkspace(goquDatabase, accountDataAccessor, workspaceDataAccessor, workspaceMemberDataAccessor, token, authorization, logger)
+	apiKey := logic.NewAPIKey(apiKeyDataAccessor, token, logger)
+	accountIdentityDataAccessor := database.NewAccountIdentityDataAccessor(goquDatabase, logger)
+	oidcLoginState := cache.NewOIDCLoginState(client, utilsMetrics, logger)
+	oidc, err := logic.NewOIDC(goquDatabase, accountDataAccessor, accountPasswordDataAccessor, accountIdentityDataAccessor, oidcLoginState, token, auth, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
 	configsGRPC := config.GRPC
-	goLoadServiceServer, err := grpc.NewHandler(account, downloadTask, configsGRPC)
+	goLoadServiceServer, err := grpc.NewHandler(account, downloadTask, downloadTaskShareLink, workspace, apiKey, oidc, configsGRPC)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	admin := logic.NewAdmin(goquDatabase, accountDataAccessor, accountPasswordDataAccessor, downloadTaskDataAccessor, downloadTaskCreatedProducer, hash, token, auth, logger)
+	goLoadAdminServiceServer := grpc.NewAdminHandler(admin)
+	rateLimit := cache.NewRateLimit(client, logger)
+	logicRateLimit, err := logic.NewRateLimit(rateLimit, token, configsGRPC, logger)
 	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
-	server := grpc.NewServer(goLoadServiceServer, configsGRPC, logger)
+	health := config.Health
+	logicHealth := logic.NewHealth(db, client, producerClient, fileClient, health, logger)
+	server := grpc.NewServer(goLoadServiceServer, goLoadAdminServiceServer, admin, apiKey, token, logicRateLimit, configsGRPC, health, logicHealth, utilsMetrics, tracerProvider, logger)
 	configsHTTP := config.HTTP
-	httpServer := http.NewServer(configsGRPC, configsHTTP, auth, logger)
+	httpServer := http.NewServer(configsGRPC, configsHTTP, auth, download, metrics, downloadTask, downloadTaskShareLink, apiKey, token, oidc, logicRateLimit, logicHealth, utilsMetrics, tracerProvider, logger)
 	downloadTaskCreated := consumers.NewDownloadTaskCreated(downloadTask, logger)
-	consumerConsumer, err := consumer.NewConsumer(mq, logger)
+	consumerConsumer, err := consumer.NewConsumer(mq, utilsMetrics, tracerProvider, logger)
 	if err != nil {
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
@@ -98,8 +210,12 @@ func InitializeStandaloneServer(configFilePath configs.ConfigFilePath) (*app.Sta
 	root := consumers.NewRoot(downloadTaskCreated, consumerConsumer, logger)
 	executeAllPendingDownloadTask := jobs.NewExecuteAllPendingDownloadTask(downloadTask)
 	updateDownloadingAndFailedDownloadTaskStatusToPending := jobs.NewUpdateDownloadingAndFailedDownloadTaskStatusToPending(downloadTask)
-	standaloneServer := app.NewStandaloneServer(server, httpServer, root, executeAllPendingDownloadTask, updateDownloadingAndFailedDownloadTaskStatusToPending, cron, logger)
+	deleteExpiredDownloadTask := jobs.NewDeleteExpiredDownloadTask(downloadTask)
+	updateDownloadTaskCountMetrics := jobs.NewUpdateDownloadTaskCountMetrics(downloadTask)
+	rotateTokenSigningKey := jobs.NewRotateTokenSigningKey(token)
+	standaloneServer := app.NewStandaloneServer(server, httpServer, root, executeAllPendingDownloadTask, updateDownloadingAndFailedDownloadTaskStatusToPending, deleteExpiredDownloadTask, updateDownloadTaskCountMetrics, rotateTokenSigningKey, cron, metrics, health, logicHealth, logger)
 	return standaloneServer, func() {
+		cleanup3()
 		cleanup2()
 		cleanup()
 	}, nil